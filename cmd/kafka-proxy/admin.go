@@ -0,0 +1,173 @@
+package server
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/grepplabs/kafka-proxy/config"
+	"github.com/grepplabs/kafka-proxy/proxy"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+type adminConnections interface {
+	Connections() []proxy.ConnectionInfo
+	CloseConnection(connID uint64) bool
+	ClosePrincipalConnections(principal string) int
+}
+
+type adminListeners interface {
+	Mappings() []proxy.ListenerMapping
+}
+
+type adminHandler struct {
+	token       []byte
+	connections adminConnections
+	listeners   adminListeners
+}
+
+func readAdminToken(filename string) (string, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read admin token file")
+	}
+	token := strings.TrimSpace(string(content))
+	if token == "" {
+		return "", errors.Errorf("admin token file %s is empty", filename)
+	}
+	return token, nil
+}
+
+// listenAdmin opens the admin API listener. The listener should be bound to the loopback interface when TLS is
+// disabled, the bearer token is sent in clear text.
+func listenAdmin(c *config.Config) (net.Listener, error) {
+	address := c.Http.Admin.ListenAddress
+	if !c.Http.Admin.TLS.Enable {
+		if !isLoopbackAddress(address) {
+			logrus.Warnf("Admin API on %s is served without TLS, the bearer token is sent in clear text", address)
+		}
+		return net.Listen("tcp", address)
+	}
+	cert, err := tls.LoadX509KeyPair(c.Http.Admin.TLS.CertFile, c.Http.Admin.TLS.KeyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load admin API certificate")
+	}
+	return tls.Listen("tcp", address, &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12})
+}
+
+func isLoopbackAddress(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// newAdminHandler serves the admin API under the path
+//
+//	GET    <path>/connections[?principal=<principal>][&broker=<broker address>]
+//	DELETE <path>/connections?id=<connection id>
+//	DELETE <path>/connections?principal=<principal>
+//	GET    <path>/listeners
+func newAdminHandler(path string, token string, connections adminConnections, listeners adminListeners) http.Handler {
+	h := &adminHandler{token: []byte(token), connections: connections, listeners: listeners}
+	path = strings.TrimSuffix(path, "/")
+
+	m := http.NewServeMux()
+	m.Handle(path+"/connections", h.authenticated(h.handleConnections))
+	m.Handle(path+"/listeners", h.authenticated(h.handleListeners))
+	return m
+}
+
+func (h *adminHandler) authenticated(handler http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization := r.Header.Get("Authorization")
+		if !strings.HasPrefix(authorization, "Bearer ") ||
+			subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(authorization, "Bearer ")), h.token) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeAdminError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		handler(w, r)
+	})
+}
+
+func (h *adminHandler) handleConnections(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		principal := r.URL.Query().Get("principal")
+		broker := r.URL.Query().Get("broker")
+		connections := make([]proxy.ConnectionInfo, 0)
+		for _, conn := range h.connections.Connections() {
+			if principal != "" && conn.Principal != principal {
+				continue
+			}
+			if broker != "" && conn.BrokerAddress != broker {
+				continue
+			}
+			connections = append(connections, conn)
+		}
+		writeAdminJSON(w, http.StatusOK, connections)
+	case http.MethodDelete:
+		h.closeConnections(w, r)
+	default:
+		w.Header().Set("Allow", "GET, DELETE")
+		writeAdminError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (h *adminHandler) closeConnections(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	switch {
+	case query.Get("id") != "":
+		connID, err := strconv.ParseUint(query.Get("id"), 10, 64)
+		if err != nil {
+			writeAdminError(w, http.StatusBadRequest, "invalid connection id")
+			return
+		}
+		if !h.connections.CloseConnection(connID) {
+			writeAdminError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		logrus.Infof("Admin API closed connection %d from %s", connID, r.RemoteAddr)
+		writeAdminJSON(w, http.StatusOK, map[string]int{"closed": 1})
+	case query.Get("principal") != "":
+		principal := query.Get("principal")
+		closed := h.connections.ClosePrincipalConnections(principal)
+		logrus.Infof("Admin API closed %d connection(s) of principal %s from %s", closed, principal, r.RemoteAddr)
+		writeAdminJSON(w, http.StatusOK, map[string]int{"closed": closed})
+	default:
+		writeAdminError(w, http.StatusBadRequest, "id or principal query parameter is required")
+	}
+}
+
+func (h *adminHandler) handleListeners(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		writeAdminError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeAdminJSON(w, http.StatusOK, h.listeners.Mappings())
+}
+
+func writeAdminError(w http.ResponseWriter, status int, message string) {
+	writeAdminJSON(w, status, map[string]string{"error": message})
+}
+
+func writeAdminJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		logrus.Warnf("Admin API response write failed: %v", err)
+	}
+}
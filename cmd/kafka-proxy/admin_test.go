@@ -0,0 +1,207 @@
+package server
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grepplabs/kafka-proxy/config"
+	"github.com/grepplabs/kafka-proxy/proxy"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAdminConnections struct {
+	connections []proxy.ConnectionInfo
+	closedIDs   []uint64
+	principals  []string
+}
+
+func (f *fakeAdminConnections) Connections() []proxy.ConnectionInfo {
+	return f.connections
+}
+
+func (f *fakeAdminConnections) CloseConnection(connID uint64) bool {
+	for _, conn := range f.connections {
+		if conn.ID == connID {
+			f.closedIDs = append(f.closedIDs, connID)
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakeAdminConnections) ClosePrincipalConnections(principal string) int {
+	f.principals = append(f.principals, principal)
+	closed := 0
+	for _, conn := range f.connections {
+		if conn.Principal == principal {
+			closed++
+		}
+	}
+	return closed
+}
+
+type fakeAdminListeners []proxy.ListenerMapping
+
+func (f fakeAdminListeners) Mappings() []proxy.ListenerMapping {
+	return f
+}
+
+func newTestAdminHandler() (http.Handler, *fakeAdminConnections) {
+	connections := &fakeAdminConnections{connections: []proxy.ConnectionInfo{
+		{ID: 1, BrokerAddress: "kafka-0:9092", ClientAddress: "10.0.0.1:50000", Principal: "alice", RequestBytes: 10, ResponseBytes: 20},
+		{ID: 2, BrokerAddress: "kafka-1:9092", ClientAddress: "10.0.0.2:50000", Principal: "bob"},
+		{ID: 3, BrokerAddress: "kafka-1:9092", ClientAddress: "10.0.0.1:50001", Principal: "alice"},
+	}}
+	listeners := fakeAdminListeners{
+		{BrokerAddress: "kafka-0:9092", ListenerAddress: "0.0.0.0:32400", AdvertisedAddress: "proxy:32400"},
+		{BrokerAddress: "kafka-1:9092", ListenerAddress: "0.0.0.0:32401", AdvertisedAddress: "proxy:32401", Dynamic: true},
+	}
+	return newAdminHandler("/admin", "secret", connections, listeners), connections
+}
+
+func serveAdmin(handler http.Handler, method, target, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdminHandlerRequiresToken(t *testing.T) {
+	a := assert.New(t)
+	handler, connections := newTestAdminHandler()
+
+	for _, token := range []string{"", "wrong"} {
+		rec := serveAdmin(handler, http.MethodGet, "/admin/connections", token)
+		a.Equal(http.StatusUnauthorized, rec.Code)
+		a.Equal("Bearer", rec.Header().Get("WWW-Authenticate"))
+
+		rec = serveAdmin(handler, http.MethodDelete, "/admin/connections?principal=alice", token)
+		a.Equal(http.StatusUnauthorized, rec.Code)
+	}
+	a.Empty(connections.principals)
+
+	rec := serveAdmin(handler, http.MethodGet, "/metrics", "secret")
+	a.Equal(http.StatusNotFound, rec.Code)
+}
+
+func TestAdminHandlerListConnections(t *testing.T) {
+	a := assert.New(t)
+	handler, _ := newTestAdminHandler()
+
+	rec := serveAdmin(handler, http.MethodGet, "/admin/connections", "secret")
+	a.Equal(http.StatusOK, rec.Code)
+	a.Equal("application/json", rec.Header().Get("Content-Type"))
+	var connections []proxy.ConnectionInfo
+	a.Nil(json.Unmarshal(rec.Body.Bytes(), &connections))
+	a.Len(connections, 3)
+	a.Equal("10.0.0.1:50000", connections[0].ClientAddress)
+	a.Equal(int64(10), connections[0].RequestBytes)
+	a.Equal(int64(20), connections[0].ResponseBytes)
+
+	rec = serveAdmin(handler, http.MethodGet, "/admin/connections?principal=alice&broker=kafka-1:9092", "secret")
+	a.Equal(http.StatusOK, rec.Code)
+	connections = nil
+	a.Nil(json.Unmarshal(rec.Body.Bytes(), &connections))
+	a.Len(connections, 1)
+	a.Equal(uint64(3), connections[0].ID)
+
+	rec = serveAdmin(handler, http.MethodGet, "/admin/connections?principal=carol", "secret")
+	a.Equal(http.StatusOK, rec.Code)
+	a.Equal("[]\n", rec.Body.String())
+}
+
+func TestAdminHandlerCloseConnections(t *testing.T) {
+	a := assert.New(t)
+	handler, connections := newTestAdminHandler()
+
+	rec := serveAdmin(handler, http.MethodDelete, "/admin/connections?id=2", "secret")
+	a.Equal(http.StatusOK, rec.Code)
+	a.Equal(`{"closed":1}`+"\n", rec.Body.String())
+	a.Equal([]uint64{2}, connections.closedIDs)
+
+	rec = serveAdmin(handler, http.MethodDelete, "/admin/connections?id=4", "secret")
+	a.Equal(http.StatusNotFound, rec.Code)
+
+	rec = serveAdmin(handler, http.MethodDelete, "/admin/connections?id=x", "secret")
+	a.Equal(http.StatusBadRequest, rec.Code)
+
+	rec = serveAdmin(handler, http.MethodDelete, "/admin/connections?principal=alice", "secret")
+	a.Equal(http.StatusOK, rec.Code)
+	a.Equal(`{"closed":2}`+"\n", rec.Body.String())
+	a.Equal([]string{"alice"}, connections.principals)
+
+	rec = serveAdmin(handler, http.MethodDelete, "/admin/connections", "secret")
+	a.Equal(http.StatusBadRequest, rec.Code)
+
+	rec = serveAdmin(handler, http.MethodPost, "/admin/connections", "secret")
+	a.Equal(http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestAdminHandlerListListeners(t *testing.T) {
+	a := assert.New(t)
+	handler, _ := newTestAdminHandler()
+
+	rec := serveAdmin(handler, http.MethodGet, "/admin/listeners", "secret")
+	a.Equal(http.StatusOK, rec.Code)
+	var mappings []proxy.ListenerMapping
+	a.Nil(json.Unmarshal(rec.Body.Bytes(), &mappings))
+	a.Equal([]proxy.ListenerMapping(fakeAdminListeners{
+		{BrokerAddress: "kafka-0:9092", ListenerAddress: "0.0.0.0:32400", AdvertisedAddress: "proxy:32400"},
+		{BrokerAddress: "kafka-1:9092", ListenerAddress: "0.0.0.0:32401", AdvertisedAddress: "proxy:32401", Dynamic: true},
+	}), mappings)
+
+	rec = serveAdmin(handler, http.MethodDelete, "/admin/listeners", "secret")
+	a.Equal(http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestReadAdminToken(t *testing.T) {
+	a := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "admin-token")
+	a.Nil(err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "token")
+	a.Nil(ioutil.WriteFile(filename, []byte("secret\n"), 0600))
+	token, err := readAdminToken(filename)
+	a.Nil(err)
+	a.Equal("secret", token)
+
+	a.Nil(ioutil.WriteFile(filename, []byte(" \n"), 0600))
+	_, err = readAdminToken(filename)
+	a.NotNil(err)
+
+	_, err = readAdminToken(filepath.Join(dir, "missing"))
+	a.NotNil(err)
+}
+
+func TestListenAdmin(t *testing.T) {
+	a := assert.New(t)
+
+	c := config.NewConfig()
+	c.Http.Admin.ListenAddress = "127.0.0.1:0"
+	listener, err := listenAdmin(c)
+	a.Nil(err)
+	listener.Close()
+
+	c.Http.Admin.TLS.Enable = true
+	c.Http.Admin.TLS.CertFile = "missing.crt"
+	c.Http.Admin.TLS.KeyFile = "missing.key"
+	_, err = listenAdmin(c)
+	a.NotNil(err)
+
+	a.True(isLoopbackAddress("127.0.0.1:9081"))
+	a.True(isLoopbackAddress("[::1]:9081"))
+	a.True(isLoopbackAddress("localhost:9081"))
+	a.False(isLoopbackAddress("0.0.0.0:9081"))
+	a.False(isLoopbackAddress(":9081"))
+	a.False(isLoopbackAddress("10.0.0.1:9081"))
+}
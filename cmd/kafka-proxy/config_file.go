@@ -0,0 +1,61 @@
+package server
+
+import (
+	"reflect"
+
+	"github.com/grepplabs/kafka-proxy/config"
+	"github.com/spf13/pflag"
+)
+
+// loadConfigFile sets the configuration from the file, the flags set on the command line take precedence. The cfg
+// must have the values of the flags.
+func loadConfigFile(flags *pflag.FlagSet, cfg *config.Config, filename string) error {
+	flagValues := *cfg
+	if err := cfg.LoadFile(filename); err != nil {
+		return err
+	}
+	// the flags are bound to the fields of c, the cfg can be a copy
+	base := reflect.ValueOf(c).Pointer()
+	size := reflect.TypeOf(*c).Size()
+	changed := make(map[uintptr]bool)
+	flags.Visit(func(flag *pflag.Flag) {
+		if target := flagTarget(flag.Value); target >= base && target < base+size {
+			changed[target-base] = true
+		}
+	})
+	restoreFields(reflect.ValueOf(cfg).Elem(), reflect.ValueOf(&flagValues).Elem(), reflect.ValueOf(cfg).Pointer(), changed)
+	return nil
+}
+
+// flagTarget returns the address of the variable which is bound to the flag
+func flagTarget(value pflag.Value) uintptr {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Ptr {
+		return 0
+	}
+	if v.Elem().Kind() != reflect.Struct {
+		// e.g. stringValue is a *string
+		return v.Pointer()
+	}
+	// slice values e.g. stringArrayValue keep the pointer to the variable
+	for i := 0; i < v.Elem().NumField(); i++ {
+		if field := v.Elem().Field(i); field.Kind() == reflect.Ptr {
+			return field.Pointer()
+		}
+	}
+	return 0
+}
+
+func restoreFields(current reflect.Value, saved reflect.Value, base uintptr, changed map[uintptr]bool) {
+	for i := 0; i < current.NumField(); i++ {
+		field := current.Field(i)
+		// the nested struct has the same address as its first field
+		if field.Kind() == reflect.Struct {
+			restoreFields(field, saved.Field(i), base, changed)
+			continue
+		}
+		if changed[field.UnsafeAddr()-base] {
+			field.Set(saved.Field(i))
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/grepplabs/kafka-proxy/config"
+	"github.com/grepplabs/kafka-proxy/pkg/libs/util"
+	"github.com/sirupsen/logrus"
+)
+
+// fields which are applied to the running proxy, the changes of other fields require a restart
+var reloadableFields = map[string]bool{
+	"Log.Level":                 true,
+	"Proxy.BootstrapServers":    true,
+	"Proxy.ExternalServers":     true,
+	"Proxy.DialAddressMappings": true,
+	"Kafka.DialTimeout":         true,
+	"Kafka.ReadTimeout":         true,
+	"Kafka.WriteTimeout":        true,
+	"Kafka.ForbiddenApiKeys":    true,
+}
+
+type reloadable interface {
+	Reload(cfg *config.Config) error
+}
+
+// configReloader rebuilds the configuration on SIGHUP or a change of the configuration file. New connections use the
+// reloaded configuration, the established connections are kept.
+type configReloader struct {
+	current   *config.Config
+	listeners reloadable
+	client    reloadable
+	buildFn   func(cfg *config.Config) error
+
+	lock sync.Mutex
+}
+
+func newConfigReloader(cfg *config.Config, listeners reloadable, client reloadable) *configReloader {
+	current := *cfg
+	return &configReloader{current: &current, listeners: listeners, client: client, buildFn: buildConfig}
+}
+
+func (r *configReloader) run(filename string, cancel <-chan struct{}) error {
+	reloads := make(chan struct{}, 1)
+	trigger := func() {
+		select {
+		case reloads <- struct{}{}:
+		default:
+		}
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	if filename != "" {
+		done := make(chan bool, 1)
+		defer close(done)
+		if err := util.WatchForUpdates(filename, done, trigger); err != nil {
+			logrus.Errorf("Configuration file %s is not watched, use SIGHUP to reload: %v", filename, err)
+		}
+	}
+	for {
+		select {
+		case <-signals:
+			logrus.Info("Reloading configuration on SIGHUP")
+			r.reload()
+		case <-reloads:
+			logrus.Infof("Reloading configuration after the change of %s", filename)
+			r.reload()
+		case <-cancel:
+			return nil
+		}
+	}
+}
+
+// reload applies the reloadable fields, the whole reload is rejected if the configuration is invalid
+func (r *configReloader) reload() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	cfg := new(config.Config)
+	*cfg = flagValues
+	if err := r.buildFn(cfg); err != nil {
+		logrus.Errorf("Configuration reload rejected: %v", err)
+		return
+	}
+	reloaded := false
+	for _, field := range config.ChangedFields(r.current, cfg) {
+		if !reloadableFields[field] {
+			logrus.Warnf("Configuration field %s was changed, the change requires a restart and is ignored", field)
+			continue
+		}
+		logrus.Infof("Configuration field %s was changed", field)
+		reloaded = true
+	}
+	if !reloaded {
+		logrus.Info("Configuration reload has no changes to apply")
+		return
+	}
+
+	next := *r.current
+	next.Log.Level = cfg.Log.Level
+	next.Proxy.BootstrapServers = cfg.Proxy.BootstrapServers
+	next.Proxy.ExternalServers = cfg.Proxy.ExternalServers
+	next.Proxy.DialAddressMappings = cfg.Proxy.DialAddressMappings
+	next.Kafka.DialTimeout = cfg.Kafka.DialTimeout
+	next.Kafka.ReadTimeout = cfg.Kafka.ReadTimeout
+	next.Kafka.WriteTimeout = cfg.Kafka.WriteTimeout
+	next.Kafka.ForbiddenApiKeys = cfg.Kafka.ForbiddenApiKeys
+
+	if err := r.client.Reload(&next); err != nil {
+		logrus.Errorf("Configuration reload rejected: %v", err)
+		return
+	}
+	if err := r.listeners.Reload(&next); err != nil {
+		logrus.Errorf("Configuration reload rejected: %v", err)
+		if err = r.client.Reload(r.current); err != nil {
+			logrus.Errorf("Configuration rollback failed: %v", err)
+		}
+		return
+	}
+	setLogLevel(next.Log.Level)
+	r.current = &next
+	logrus.Info("Configuration reloaded")
+}
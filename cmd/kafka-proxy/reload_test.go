@@ -0,0 +1,119 @@
+package server
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grepplabs/kafka-proxy/config"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeReloadable struct {
+	reloads []*config.Config
+	err     error
+}
+
+func (f *fakeReloadable) Reload(cfg *config.Config) error {
+	f.reloads = append(f.reloads, cfg)
+	return f.err
+}
+
+func newTestConfigReloader(buildFn func(cfg *config.Config) error) (*configReloader, *fakeReloadable, *fakeReloadable) {
+	flagValues = *config.NewConfig()
+	listeners, client := &fakeReloadable{}, &fakeReloadable{}
+	reloader := newConfigReloader(config.NewConfig(), listeners, client)
+	reloader.buildFn = buildFn
+	return reloader, listeners, client
+}
+
+func TestConfigReloaderAppliesReloadableFields(t *testing.T) {
+	a := assert.New(t)
+
+	reloader, listeners, client := newTestConfigReloader(func(cfg *config.Config) error {
+		cfg.Kafka.ReadTimeout = time.Minute
+		cfg.Kafka.ForbiddenApiKeys = []int{32}
+		cfg.Proxy.DialAddressMappings = []config.DialAddressMapping{{SourceAddress: "kafka-0:9092", DestinationAddress: "10.0.0.1:9092"}}
+		// requires a restart
+		cfg.Kafka.MaxOpenRequests = 1
+		return nil
+	})
+	reloader.reload()
+
+	a.Len(client.reloads, 1)
+	a.Len(listeners.reloads, 1)
+	a.Equal(time.Minute, reloader.current.Kafka.ReadTimeout)
+	a.Equal([]int{32}, reloader.current.Kafka.ForbiddenApiKeys)
+	a.Len(reloader.current.Proxy.DialAddressMappings, 1)
+	a.Equal(256, reloader.current.Kafka.MaxOpenRequests)
+	a.Equal(reloader.current, client.reloads[0])
+
+	// nothing to apply
+	reloader.reload()
+	a.Len(client.reloads, 1)
+}
+
+func TestConfigReloaderRejectsInvalidConfig(t *testing.T) {
+	a := assert.New(t)
+
+	reloader, listeners, client := newTestConfigReloader(func(cfg *config.Config) error {
+		cfg.Kafka.ReadTimeout = time.Minute
+		return errors.New("invalid")
+	})
+	reloader.reload()
+
+	a.Empty(client.reloads)
+	a.Empty(listeners.reloads)
+	a.Equal(30*time.Second, reloader.current.Kafka.ReadTimeout)
+}
+
+func TestConfigReloaderRollsBackClient(t *testing.T) {
+	a := assert.New(t)
+
+	reloader, listeners, client := newTestConfigReloader(func(cfg *config.Config) error {
+		cfg.Kafka.ReadTimeout = time.Minute
+		return nil
+	})
+	listeners.err = errors.New("bootstrap server mapping configured twice")
+	previous := reloader.current
+	reloader.reload()
+
+	a.Len(client.reloads, 2)
+	a.Equal(previous, client.reloads[1])
+	a.Equal(previous, reloader.current)
+}
+
+func TestConfigReloaderReadsConfigFile(t *testing.T) {
+	setupBootstrapServersMappingTest()
+	a := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "config-file")
+	a.Nil(err)
+	defer os.RemoveAll(dir)
+	configFile := filepath.Join(dir, "proxy.yaml")
+	a.Nil(ioutil.WriteFile(configFile, []byte("kafka:\n  readTimeout: 1m\n  writeTimeout: 1m\n"), 0600))
+
+	args := []string{"cobra.test",
+		"--config", configFile,
+		"--bootstrap-server-mapping", "kafka-0.example.com:9092,0.0.0.0:32400",
+		"--kafka-write-timeout", "5s",
+	}
+	_ = Server.ParseFlags(args)
+	a.Nil(Server.PreRunE(nil, args))
+
+	listeners, client := &fakeReloadable{}, &fakeReloadable{}
+	reloader := newConfigReloader(c, listeners, client)
+	a.Nil(ioutil.WriteFile(configFile, []byte("kafka:\n  readTimeout: 2m\n  writeTimeout: 2m\n"), 0600))
+	reloader.reload()
+
+	a.Len(client.reloads, 1)
+	a.Equal(2*time.Minute, reloader.current.Kafka.ReadTimeout)
+	// flags take precedence
+	a.Equal(5*time.Second, reloader.current.Kafka.WriteTimeout)
+	a.Equal("0.0.0.0:32400", reloader.current.Proxy.BootstrapServers[0].ListenerAddress)
+	// the configuration of the running proxy is not changed
+	a.Equal(time.Minute, c.Kafka.ReadTimeout)
+}
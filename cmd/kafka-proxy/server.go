@@ -10,6 +10,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
 	"net"
 	"net/http"
@@ -25,8 +26,10 @@ import (
 
 	"github.com/grepplabs/kafka-proxy/pkg/apis"
 	localauth "github.com/grepplabs/kafka-proxy/plugin/local-auth/shared"
+	scramstore "github.com/grepplabs/kafka-proxy/plugin/scram-credential-store/shared"
 	tokeninfo "github.com/grepplabs/kafka-proxy/plugin/token-info/shared"
 	tokenprovider "github.com/grepplabs/kafka-proxy/plugin/token-provider/shared"
+	usercredentials "github.com/grepplabs/kafka-proxy/plugin/user-credentials/shared"
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-plugin"
 
@@ -34,55 +37,102 @@ import (
 	// built-in plugins
 	_ "github.com/grepplabs/kafka-proxy/pkg/libs/googleid-info"
 	_ "github.com/grepplabs/kafka-proxy/pkg/libs/googleid-provider"
+	_ "github.com/grepplabs/kafka-proxy/pkg/libs/htpasswd"
+	_ "github.com/grepplabs/kafka-proxy/pkg/libs/oidc-info"
+	_ "github.com/grepplabs/kafka-proxy/pkg/libs/scram-file-store"
+	_ "github.com/grepplabs/kafka-proxy/pkg/libs/token-exchange"
+	_ "github.com/grepplabs/kafka-proxy/pkg/libs/user-credentials-file"
 	"github.com/spf13/viper"
 )
 
 var (
 	c = new(config.Config)
 
+	configFile string
+	// flags of the Server command, PreRunE cannot refer to the Server
+	serverFlags *pflag.FlagSet
+	// configuration from the flags only, the reload applies the configuration file to it again
+	flagValues config.Config
+
 	bootstrapServersMapping = make([]string, 0)
 	externalServersMapping  = make([]string, 0)
 	dialAddressMapping      = make([]string, 0)
+
+	namespacePrincipalMapping = make([]string, 0)
+	namespaceListenerMapping  = make([]string, 0)
+
+	metadataTopicFilter = make([]string, 0)
 )
 
 var Server = &cobra.Command{
 	Use:   "server",
 	Short: "Run the kafka-proxy server",
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		SetLogger()
-
-		if err := c.InitSASLCredentials(); err != nil {
-			return err
-		}
-		if err := c.InitBootstrapServers(getOrEnvStringSlice(bootstrapServersMapping, "BOOTSTRAP_SERVER_MAPPING")); err != nil {
-			return err
-		}
-		if err := c.InitExternalServers(getOrEnvStringSlice(externalServersMapping, "EXTERNAL_SERVER_MAPPING")); err != nil {
-			return err
-		}
-		if err := c.InitDialAddressMappings(getOrEnvStringSlice(dialAddressMapping, "DIAL_ADDRESS_MAPPING")); err != nil {
-			return err
-		}
-		if err := c.Validate(); err != nil {
+		flagValues = *c
+		if err := buildConfig(c); err != nil {
 			return err
 		}
+		SetLogger()
 		return nil
 	},
 	Run: Run,
 }
 
+// buildConfig applies the configuration file, the mappings from the flags or the environment and validates the result.
+// The cfg must have the values of the flags.
+func buildConfig(cfg *config.Config) error {
+	if configFile != "" {
+		if err := loadConfigFile(serverFlags, cfg, configFile); err != nil {
+			return err
+		}
+	}
+	if err := cfg.InitSASLCredentials(); err != nil {
+		return err
+	}
+	if err := initMappings(getOrEnvStringSlice(bootstrapServersMapping, "BOOTSTRAP_SERVER_MAPPING"), cfg.InitBootstrapServers); err != nil {
+		return err
+	}
+	if err := initMappings(getOrEnvStringSlice(externalServersMapping, "EXTERNAL_SERVER_MAPPING"), cfg.InitExternalServers); err != nil {
+		return err
+	}
+	if err := initMappings(getOrEnvStringSlice(dialAddressMapping, "DIAL_ADDRESS_MAPPING"), cfg.InitDialAddressMappings); err != nil {
+		return err
+	}
+	if err := initMappings(getOrEnvStringSlice(namespacePrincipalMapping, "NAMESPACE_PRINCIPAL_MAPPING"), cfg.InitNamespacePrincipalMappings); err != nil {
+		return err
+	}
+	if err := initMappings(getOrEnvStringSlice(namespaceListenerMapping, "NAMESPACE_LISTENER_MAPPING"), cfg.InitNamespaceListenerMappings); err != nil {
+		return err
+	}
+	if err := initMappings(getOrEnvStringSlice(metadataTopicFilter, "METADATA_TOPIC_FILTER"), cfg.InitMetadataTopicFilter); err != nil {
+		return err
+	}
+	return cfg.Validate()
+}
+
 func getOrEnvStringSlice(value []string, envKey string) []string {
-	if len(bootstrapServersMapping) != 0 {
+	if len(value) != 0 {
 		return value
 	}
 	return strings.Fields(os.Getenv(envKey))
 }
 
+// initMappings sets the mappings from the flags or the environment, otherwise the mappings of the config file are kept
+func initMappings(mappings []string, initFn func([]string) error) error {
+	if len(mappings) == 0 && configFile != "" {
+		return nil
+	}
+	return initFn(mappings)
+}
+
 func init() {
 	initFlags()
 }
 
 func initFlags() {
+	serverFlags = Server.Flags()
+	Server.Flags().StringVar(&configFile, "config", "", "Path to the YAML or JSON configuration file. Flags and environment variables take precedence")
+
 	// proxy
 	Server.Flags().StringVar(&c.Proxy.DefaultListenerIP, "default-listener-ip", "127.0.0.1", "Default listener IP")
 	Server.Flags().StringVar(&c.Proxy.DynamicAdvertisedListener, "dynamic-advertised-listener", "", "Advertised address for dynamic listeners. If empty, default-listener-ip is used")
@@ -108,14 +158,30 @@ func initFlags() {
 	Server.Flags().StringSliceVar(&c.Proxy.TLS.ListenerCurvePreferences, "proxy-listener-curve-preferences", []string{}, "List of curve preferences")
 
 	Server.Flags().StringSliceVar(&c.Proxy.TLS.ClientCert.Subjects, "proxy-listener-tls-required-client-subject", []string{}, "Required client certificate subject common name; example; s:/CN=[value]/C=[state]/C=[DE,PL] or r:/CN=[^val.{2}$]/C=[state]/C=[DE,PL]; check manual for more details")
+	Server.Flags().StringArrayVar(&c.Proxy.TLS.ClientCert.PrincipalMappingRules, "proxy-listener-tls-principal-mapping-rule", []string{}, "Rule mapping the client certificate to the principal, the first matching rule wins: DEFAULT, RULE:pattern/replacement/[L|U] or RULE:SAN:<DNS|URI|EMAIL|IP>:pattern/replacement/[L|U]")
 
 	// local authentication plugin
 	Server.Flags().BoolVar(&c.Auth.Local.Enable, "auth-local-enable", false, "Enable local SASL/PLAIN authentication performed by listener - SASL handshake will not be passed to kafka brokers")
-	Server.Flags().StringVar(&c.Auth.Local.Command, "auth-local-command", "", "Path to authentication plugin binary")
-	Server.Flags().StringVar(&c.Auth.Local.Mechanism, "auth-local-mechanism", "PLAIN", "SASL mechanism used for local authentication: PLAIN or OAUTHBEARER")
+	Server.Flags().StringVar(&c.Auth.Local.Command, "auth-local-command", "", "Path to authentication plugin binary or name of the built-in plugin (htpasswd, oidc-info, scram-file-store)")
+	Server.Flags().StringVar(&c.Auth.Local.Mechanism, "auth-local-mechanism", "PLAIN", "SASL mechanism used for local authentication: PLAIN, OAUTHBEARER, SCRAM-SHA-256 or SCRAM-SHA-512")
 	Server.Flags().StringArrayVar(&c.Auth.Local.Parameters, "auth-local-param", []string{}, "Authentication plugin parameter")
 	Server.Flags().StringVar(&c.Auth.Local.LogLevel, "auth-local-log-level", "trace", "Log level of the auth plugin")
 	Server.Flags().DurationVar(&c.Auth.Local.Timeout, "auth-local-timeout", 10*time.Second, "Authentication timeout")
+	Server.Flags().StringVar(&c.Auth.Local.Plain.Command, "auth-local-plain-command", "", "Path to PasswordAuthenticator plugin binary or name of the built-in plugin enabling PLAIN together with auth-local-mechanism")
+	Server.Flags().StringArrayVar(&c.Auth.Local.Plain.Parameters, "auth-local-plain-param", []string{}, "PLAIN authentication plugin parameter")
+	Server.Flags().StringVar(&c.Auth.Local.OAuthBearer.Command, "auth-local-oauthbearer-command", "", "Path to TokenInfo plugin binary or name of the built-in plugin enabling OAUTHBEARER together with auth-local-mechanism")
+	Server.Flags().StringArrayVar(&c.Auth.Local.OAuthBearer.Parameters, "auth-local-oauthbearer-param", []string{}, "OAUTHBEARER authentication plugin parameter")
+	Server.Flags().BoolVar(&c.Auth.Local.Cache.Enable, "auth-local-cache-enable", false, "Cache the results of PLAIN and OAUTHBEARER local authentication")
+	Server.Flags().DurationVar(&c.Auth.Local.Cache.SuccessTTL, "auth-local-cache-success-ttl", 5*time.Minute, "Time to live of successful authentication results")
+	Server.Flags().DurationVar(&c.Auth.Local.Cache.FailureTTL, "auth-local-cache-failure-ttl", 10*time.Second, "Time to live of failed authentication results. 0 disables caching of failures")
+	Server.Flags().IntVar(&c.Auth.Local.Cache.MaxSize, "auth-local-cache-max-size", 10000, "Maximum number of cached authentication results")
+	Server.Flags().BoolVar(&c.Auth.Local.BruteForce.Enable, "auth-local-brute-force-enable", false, "Lock out usernames and source IPs after failed local authentications")
+	Server.Flags().IntVar(&c.Auth.Local.BruteForce.UsernameMaxFailures, "auth-local-brute-force-username-max-failures", 5, "Failed authentications of a username until the lockout. 0 disables the username lockout")
+	Server.Flags().IntVar(&c.Auth.Local.BruteForce.IPMaxFailures, "auth-local-brute-force-ip-max-failures", 20, "Failed authentications from a source IP until the lockout. 0 disables the IP lockout")
+	Server.Flags().DurationVar(&c.Auth.Local.BruteForce.FailureWindow, "auth-local-brute-force-failure-window", 10*time.Minute, "Failures are reset when there was no failure and lockout within the window")
+	Server.Flags().DurationVar(&c.Auth.Local.BruteForce.Lockout, "auth-local-brute-force-lockout", time.Minute, "First lockout duration, it is doubled for every further failure")
+	Server.Flags().DurationVar(&c.Auth.Local.BruteForce.MaxLockout, "auth-local-brute-force-max-lockout", time.Hour, "Maximum lockout duration")
+	Server.Flags().IntVar(&c.Auth.Local.BruteForce.GlobalMaxFailuresPerSecond, "auth-local-brute-force-global-max-failures-per-second", 0, "Local authentications from source IPs without a success within the failure window are rejected while the failures exceed the rate. 0 disables the global limit")
 
 	Server.Flags().BoolVar(&c.Auth.Gateway.Client.Enable, "auth-gateway-client-enable", false, "Enable gateway client authentication")
 	Server.Flags().StringVar(&c.Auth.Gateway.Client.Command, "auth-gateway-client-command", "", "Path to authentication plugin binary")
@@ -132,6 +198,10 @@ func initFlags() {
 	Server.Flags().StringVar(&c.Auth.Gateway.Server.Method, "auth-gateway-server-method", "", "Authentication method")
 	Server.Flags().Uint64Var(&c.Auth.Gateway.Server.Magic, "auth-gateway-server-magic", 0, "Magic bytes sent in the handshake")
 	Server.Flags().DurationVar(&c.Auth.Gateway.Server.Timeout, "auth-gateway-server-timeout", 10*time.Second, "Authentication timeout")
+	Server.Flags().BoolVar(&c.Auth.Gateway.Server.Cache.Enable, "auth-gateway-server-cache-enable", false, "Cache the results of gateway token verification")
+	Server.Flags().DurationVar(&c.Auth.Gateway.Server.Cache.SuccessTTL, "auth-gateway-server-cache-success-ttl", 5*time.Minute, "Time to live of successful verification results")
+	Server.Flags().DurationVar(&c.Auth.Gateway.Server.Cache.FailureTTL, "auth-gateway-server-cache-failure-ttl", 10*time.Second, "Time to live of failed verification results. 0 disables caching of failures")
+	Server.Flags().IntVar(&c.Auth.Gateway.Server.Cache.MaxSize, "auth-gateway-server-cache-max-size", 10000, "Maximum number of cached verification results")
 
 	// kafka
 	Server.Flags().StringVar(&c.Kafka.ClientID, "kafka-client-id", "kafka-proxy", "An optional identifier to track the source of requests")
@@ -148,6 +218,17 @@ func initFlags() {
 
 	Server.Flags().BoolVar(&c.Kafka.Producer.Acks0Disabled, "producer-acks-0-disabled", false, "Assume fire-and-forget is never sent by the producer. Enabling this parameter will increase performance")
 
+	// topic ACL
+	Server.Flags().BoolVar(&c.ACL.Enable, "acl-enable", false, "Enable per principal topic authorization of Produce, Fetch, ListOffsets, Metadata, OffsetCommit and OffsetFetch requests. Other requests with topics e.g. DeleteTopics are rejected")
+	Server.Flags().StringVar(&c.ACL.RulesFile, "acl-rules-file", "", "File with ACL rules, one rule per line: <allow|deny> <principal|*> <read,write,describe|all> <topic pattern>")
+
+	// virtual namespaces
+	Server.Flags().StringArrayVar(&namespacePrincipalMapping, "namespace-principal-mapping", []string{}, "Mapping of principal to the prefix of its topics, consumer groups and transactional ids (principal,prefix)")
+	Server.Flags().StringArrayVar(&namespaceListenerMapping, "namespace-listener-mapping", []string{}, "Mapping of listener address to the prefix of topics, consumer groups and transactional ids (host:port,prefix). The principal mapping takes precedence")
+
+	// metadata topic filter
+	Server.Flags().StringArrayVar(&metadataTopicFilter, "metadata-topic-filter", []string{}, "Filter of topics returned in Metadata responses (<allow|deny>,<*|principal:name|listener:host:port>,<glob|re:regex>). Deny takes precedence, if any allow rule applies only matching topics are returned")
+
 	// TLS
 	Server.Flags().BoolVar(&c.Kafka.TLS.Enable, "tls-enable", false, "Whether or not to use TLS when connecting to the broker")
 	Server.Flags().BoolVar(&c.Kafka.TLS.InsecureSkipVerify, "tls-insecure-skip-verify", false, "It controls whether a client verifies the server's certificate chain and host name")
@@ -168,17 +249,31 @@ func initFlags() {
 
 	// SASL by Proxy plugin
 	Server.Flags().BoolVar(&c.Kafka.SASL.Plugin.Enable, "sasl-plugin-enable", false, "Use plugin for SASL authentication")
-	Server.Flags().StringVar(&c.Kafka.SASL.Plugin.Command, "sasl-plugin-command", "", "Path to authentication plugin binary")
+	Server.Flags().StringVar(&c.Kafka.SASL.Plugin.Command, "sasl-plugin-command", "", "Path to authentication plugin binary or name of the built-in provider (token-passthrough, token-exchange)")
 	Server.Flags().StringVar(&c.Kafka.SASL.Plugin.Mechanism, "sasl-plugin-mechanism", "OAUTHBEARER", "SASL mechanism used for proxy authentication: PLAIN or OAUTHBEARER")
 	Server.Flags().StringArrayVar(&c.Kafka.SASL.Plugin.Parameters, "sasl-plugin-param", []string{}, "Authentication plugin parameter")
 	Server.Flags().StringVar(&c.Kafka.SASL.Plugin.LogLevel, "sasl-plugin-log-level", "trace", "Log level of the auth plugin")
 	Server.Flags().DurationVar(&c.Kafka.SASL.Plugin.Timeout, "sasl-plugin-timeout", 10*time.Second, "Authentication timeout")
+	Server.Flags().BoolVar(&c.Kafka.SASL.Plugin.ClientTokenEnable, "sasl-plugin-client-token-enable", false, "Pass the token of the client authenticated by local OAUTHBEARER to the token provider e.g. token-passthrough or token-exchange. Requires auth-local-enable")
+
+	// SASL by Proxy with the credentials of the principal authenticated locally
+	Server.Flags().BoolVar(&c.Kafka.SASL.UserCredentials.Enable, "sasl-user-credentials-enable", false, "Use upstream SASL credentials of the principal authenticated by local SASL. Requires auth-local-enable")
+	Server.Flags().StringVar(&c.Kafka.SASL.UserCredentials.Command, "sasl-user-credentials-command", "", "Path to user credentials plugin binary or name of the built-in provider (user-credentials-file)")
+	Server.Flags().StringArrayVar(&c.Kafka.SASL.UserCredentials.Parameters, "sasl-user-credentials-param", []string{}, "User credentials plugin parameter")
+	Server.Flags().StringVar(&c.Kafka.SASL.UserCredentials.LogLevel, "sasl-user-credentials-log-level", "trace", "Log level of the user credentials plugin")
 
 	// Web
 	Server.Flags().BoolVar(&c.Http.Disable, "http-disable", false, "Disable HTTP endpoints")
 	Server.Flags().StringVar(&c.Http.ListenAddress, "http-listen-address", "0.0.0.0:9080", "Address that kafka-proxy is listening on")
 	Server.Flags().StringVar(&c.Http.MetricsPath, "http-metrics-path", "/metrics", "Path on which to expose metrics")
 	Server.Flags().StringVar(&c.Http.HealthPath, "http-health-path", "/health", "Path on which to health endpoint")
+	Server.Flags().BoolVar(&c.Http.Admin.Enable, "http-admin-enable", false, "Enable admin API to list and close the connections")
+	Server.Flags().StringVar(&c.Http.Admin.ListenAddress, "http-admin-listen-address", "127.0.0.1:9081", "Address that the admin API is listening on. Without TLS the bearer token is sent in clear text")
+	Server.Flags().BoolVar(&c.Http.Admin.TLS.Enable, "http-admin-tls-enable", false, "Serve the admin API over TLS")
+	Server.Flags().StringVar(&c.Http.Admin.TLS.CertFile, "http-admin-tls-cert-file", "", "PEM encoded file with the server certificate of the admin API")
+	Server.Flags().StringVar(&c.Http.Admin.TLS.KeyFile, "http-admin-tls-key-file", "", "PEM encoded file with the private key of the admin API")
+	Server.Flags().StringVar(&c.Http.Admin.Path, "http-admin-path", "/admin", "Path prefix of the admin API")
+	Server.Flags().StringVar(&c.Http.Admin.TokenFile, "http-admin-token-file", "", "Path to the file with the bearer token required by the admin API")
 
 	// Debug
 	Server.Flags().BoolVar(&c.Debug.Enabled, "debug-enable", false, "Enable Debug endpoint")
@@ -203,63 +298,109 @@ func Run(_ *cobra.Command, _ []string) {
 
 	var localPasswordAuthenticator apis.PasswordAuthenticator
 	var localTokenAuthenticator apis.TokenInfo
+	var localScramCredentialStore apis.ScramCredentialStore
 	if c.Auth.Local.Enable {
-		switch c.Auth.Local.Mechanism {
-		case "PLAIN":
-			var err error
-			factory, ok := registry.GetComponent(new(apis.PasswordAuthenticatorFactory), c.Auth.Local.Command).(apis.PasswordAuthenticatorFactory)
-			if ok {
-				logrus.Infof("Using built-in '%s' PasswordAuthenticator for local PasswordAuthenticator", c.Auth.Local.Command)
-				localPasswordAuthenticator, err = factory.New(c.Auth.Local.Parameters)
-				if err != nil {
-					logrus.Fatal(err)
+		for _, localMechanism := range c.LocalMechanisms() {
+			switch localMechanism.Mechanism {
+			case "PLAIN":
+				var err error
+				factory, ok := registry.GetComponent(new(apis.PasswordAuthenticatorFactory), localMechanism.Command).(apis.PasswordAuthenticatorFactory)
+				if ok {
+					logrus.Infof("Using built-in '%s' PasswordAuthenticator for local PasswordAuthenticator", localMechanism.Command)
+					localPasswordAuthenticator, err = factory.New(localMechanism.Parameters)
+					if err != nil {
+						logrus.Fatal(err)
+					}
+				} else {
+					client := NewPluginClient(localauth.Handshake, localauth.PluginMap, c.Auth.Local.LogLevel, localMechanism.Command, localMechanism.Parameters)
+					defer client.Kill()
+
+					rpcClient, err := client.Client()
+					if err != nil {
+						logrus.Fatal(err)
+					}
+					raw, err := rpcClient.Dispense("passwordAuthenticator")
+					if err != nil {
+						logrus.Fatal(err)
+					}
+					localPasswordAuthenticator, ok = raw.(apis.PasswordAuthenticator)
+					if !ok {
+						logrus.Fatal(errors.New("unsupported PasswordAuthenticator plugin type"))
+					}
 				}
-			} else {
-				client := NewPluginClient(localauth.Handshake, localauth.PluginMap, c.Auth.Local.LogLevel, c.Auth.Local.Command, c.Auth.Local.Parameters)
-				defer client.Kill()
-
-				rpcClient, err := client.Client()
-				if err != nil {
-					logrus.Fatal(err)
+			case "OAUTHBEARER":
+				var err error
+				factory, ok := registry.GetComponent(new(apis.TokenInfoFactory), localMechanism.Command).(apis.TokenInfoFactory)
+				if ok {
+					logrus.Infof("Using built-in '%s' TokenInfo for local TokenAuthenticator", localMechanism.Command)
+
+					localTokenAuthenticator, err = factory.New(localMechanism.Parameters)
+					if err != nil {
+						logrus.Fatal(err)
+					}
+				} else {
+					client := NewPluginClient(tokeninfo.Handshake, tokeninfo.PluginMap, c.Auth.Local.LogLevel, localMechanism.Command, localMechanism.Parameters)
+					defer client.Kill()
+
+					rpcClient, err := client.Client()
+					if err != nil {
+						logrus.Fatal(err)
+					}
+					raw, err := rpcClient.Dispense("tokenInfo")
+					if err != nil {
+						logrus.Fatal(err)
+					}
+					localTokenAuthenticator, ok = raw.(apis.TokenInfo)
+					if !ok {
+						logrus.Fatal(errors.New("unsupported TokenInfo plugin type"))
+					}
 				}
-				raw, err := rpcClient.Dispense("passwordAuthenticator")
-				if err != nil {
-					logrus.Fatal(err)
-				}
-				localPasswordAuthenticator, ok = raw.(apis.PasswordAuthenticator)
-				if !ok {
-					logrus.Fatal(errors.New("unsupported PasswordAuthenticator plugin type"))
+			case "SCRAM-SHA-256", "SCRAM-SHA-512":
+				var err error
+				factory, ok := registry.GetComponent(new(apis.ScramCredentialStoreFactory), localMechanism.Command).(apis.ScramCredentialStoreFactory)
+				if ok {
+					logrus.Infof("Using built-in '%s' ScramCredentialStore for local SCRAM authentication", localMechanism.Command)
+
+					localScramCredentialStore, err = factory.New(localMechanism.Parameters)
+					if err != nil {
+						logrus.Fatal(err)
+					}
+				} else {
+					client := NewPluginClient(scramstore.Handshake, scramstore.PluginMap, c.Auth.Local.LogLevel, localMechanism.Command, localMechanism.Parameters)
+					defer client.Kill()
+
+					rpcClient, err := client.Client()
+					if err != nil {
+						logrus.Fatal(err)
+					}
+					raw, err := rpcClient.Dispense("scramCredentialStore")
+					if err != nil {
+						logrus.Fatal(err)
+					}
+					localScramCredentialStore, ok = raw.(apis.ScramCredentialStore)
+					if !ok {
+						logrus.Fatal(errors.New("unsupported ScramCredentialStore plugin type"))
+					}
 				}
+			default:
+				logrus.Fatal(errors.New("unsupported local auth mechanism"))
 			}
-		case "OAUTHBEARER":
+		}
+		if c.Auth.Local.Cache.Enable {
+			options := proxy.AuthCacheOptions{SuccessTTL: c.Auth.Local.Cache.SuccessTTL, FailureTTL: c.Auth.Local.Cache.FailureTTL, MaxSize: c.Auth.Local.Cache.MaxSize}
 			var err error
-			factory, ok := registry.GetComponent(new(apis.TokenInfoFactory), c.Auth.Local.Command).(apis.TokenInfoFactory)
-			if ok {
-				logrus.Infof("Using built-in '%s' TokenInfo for local TokenAuthenticator", c.Auth.Local.Command)
-
-				localTokenAuthenticator, err = factory.New(c.Auth.Local.Parameters)
+			if localPasswordAuthenticator != nil {
+				localPasswordAuthenticator, err = proxy.NewCachingPasswordAuthenticator(proxy.AuthCacheLocalPlain, localPasswordAuthenticator, options)
 				if err != nil {
 					logrus.Fatal(err)
 				}
-			} else {
-				client := NewPluginClient(tokeninfo.Handshake, tokeninfo.PluginMap, c.Auth.Local.LogLevel, c.Auth.Local.Command, c.Auth.Local.Parameters)
-				defer client.Kill()
-
-				rpcClient, err := client.Client()
-				if err != nil {
-					logrus.Fatal(err)
-				}
-				raw, err := rpcClient.Dispense("tokenInfo")
+			}
+			if localTokenAuthenticator != nil {
+				localTokenAuthenticator, err = proxy.NewCachingTokenInfo(proxy.AuthCacheLocalOauthBearer, localTokenAuthenticator, options)
 				if err != nil {
 					logrus.Fatal(err)
 				}
-				localTokenAuthenticator, ok = raw.(apis.TokenInfo)
-				if !ok {
-					logrus.Fatal(errors.New("unsupported TokenInfo plugin type"))
-				}
 			}
-		default:
-			logrus.Fatal(errors.New("unsupported local auth mechanism"))
 		}
 	}
 
@@ -298,6 +439,35 @@ func Run(_ *cobra.Command, _ []string) {
 		}
 	}
 
+	var userCredentialsProvider apis.UserCredentialsProvider
+	if c.Kafka.SASL.UserCredentials.Enable {
+		var err error
+		factory, ok := registry.GetComponent(new(apis.UserCredentialsProviderFactory), c.Kafka.SASL.UserCredentials.Command).(apis.UserCredentialsProviderFactory)
+		if ok {
+			logrus.Infof("Using built-in '%s' UserCredentialsProvider for sasl authentication", c.Kafka.SASL.UserCredentials.Command)
+			userCredentialsProvider, err = factory.New(c.Kafka.SASL.UserCredentials.Parameters)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+		} else {
+			client := NewPluginClient(usercredentials.Handshake, usercredentials.PluginMap, c.Kafka.SASL.UserCredentials.LogLevel, c.Kafka.SASL.UserCredentials.Command, c.Kafka.SASL.UserCredentials.Parameters)
+			defer client.Kill()
+
+			rpcClient, err := client.Client()
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			raw, err := rpcClient.Dispense("userCredentialsProvider")
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			userCredentialsProvider, ok = raw.(apis.UserCredentialsProvider)
+			if !ok {
+				logrus.Fatal(errors.New("unsupported UserCredentialsProvider plugin type"))
+			}
+		}
+	}
+
 	var gatewayTokenProvider apis.TokenProvider
 	if c.Auth.Gateway.Client.Enable {
 		var err error
@@ -355,8 +525,17 @@ func Run(_ *cobra.Command, _ []string) {
 				logrus.Fatal(errors.New("unsupported TokenInfo plugin type"))
 			}
 		}
+		if c.Auth.Gateway.Server.Cache.Enable {
+			options := proxy.AuthCacheOptions{SuccessTTL: c.Auth.Gateway.Server.Cache.SuccessTTL, FailureTTL: c.Auth.Gateway.Server.Cache.FailureTTL, MaxSize: c.Auth.Gateway.Server.Cache.MaxSize}
+			gatewayTokenInfo, err = proxy.NewCachingTokenInfo(proxy.AuthCacheGatewayServer, gatewayTokenInfo, options)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+		}
 	}
 
+	httpHandler := NewHTTPHandler()
+
 	var g run.Group
 	{
 		// All active connections are stored in this variable.
@@ -370,7 +549,7 @@ func Run(_ *cobra.Command, _ []string) {
 		if err != nil {
 			logrus.Fatal(err)
 		}
-		proxyClient, err := proxy.NewClient(connset, c, listeners.GetNetAddressMapping, localPasswordAuthenticator, localTokenAuthenticator, saslTokenProvider, gatewayTokenProvider, gatewayTokenInfo)
+		proxyClient, err := proxy.NewClient(connset, c, listeners.GetNetAddressMapping, localPasswordAuthenticator, localTokenAuthenticator, localScramCredentialStore, saslTokenProvider, userCredentialsProvider, gatewayTokenProvider, gatewayTokenInfo)
 		if err != nil {
 			logrus.Fatal(err)
 		}
@@ -380,6 +559,31 @@ func Run(_ *cobra.Command, _ []string) {
 		}, func(error) {
 			proxyClient.Close()
 		})
+
+		if c.Http.Admin.Enable {
+			token, err := readAdminToken(c.Http.Admin.TokenFile)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			adminListener, err := listenAdmin(c)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			adminHandler := newAdminHandler(c.Http.Admin.Path, token, connset, listeners)
+			g.Add(func() error {
+				return http.Serve(adminListener, adminHandler)
+			}, func(error) {
+				adminListener.Close()
+			})
+		}
+
+		reloader := newConfigReloader(c, listeners, proxyClient)
+		cancelReload := make(chan struct{})
+		g.Add(func() error {
+			return reloader.run(configFile, cancelReload)
+		}, func(error) {
+			close(cancelReload)
+		})
 	}
 	{
 		cancelInterrupt := make(chan struct{})
@@ -402,7 +606,7 @@ func Run(_ *cobra.Command, _ []string) {
 			logrus.Fatal(err)
 		}
 		g.Add(func() error {
-			return http.Serve(httpListener, NewHTTPHandler())
+			return http.Serve(httpListener, httpHandler)
 		}, func(error) {
 			httpListener.Close()
 		})
@@ -461,9 +665,13 @@ func SetLogger() {
 	} else {
 		logrus.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
 	}
-	level, err := logrus.ParseLevel(c.Log.Level)
+	setLogLevel(c.Log.Level)
+}
+
+func setLogLevel(logLevel string) {
+	level, err := logrus.ParseLevel(logLevel)
 	if err != nil {
-		logrus.Errorf("Couldn't parse log level: %s", c.Log.Level)
+		logrus.Errorf("Couldn't parse log level: %s", logLevel)
 		level = logrus.InfoLevel
 	}
 	logrus.SetLevel(level)
@@ -3,8 +3,11 @@ package server
 import (
 	"github.com/grepplabs/kafka-proxy/config"
 	"github.com/stretchr/testify/assert"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 func setupBootstrapServersMappingTest() {
@@ -237,3 +240,68 @@ func serverPreRunFailure(t *testing.T, cmdLineFlags []string, expectedErrorMsg s
 
 	a.Equal(err.Error(), expectedErrorMsg)
 }
+
+func TestConfigFilePrecedence(t *testing.T) {
+	setupBootstrapServersMappingTest()
+	a := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "config-file")
+	a.Nil(err)
+	defer os.RemoveAll(dir)
+	configFile := filepath.Join(dir, "proxy.yaml")
+	a.Nil(ioutil.WriteFile(configFile, []byte(`
+proxy:
+  defaultListenerIP: 0.0.0.0
+  bootstrapServers:
+    - brokerAddress: kafka-0.example.com:9092
+      listenerAddress: 0.0.0.0:32400
+  dialAddressMappings:
+    - sourceAddress: kafka-0.example.com:9092
+      destinationAddress: 10.0.0.1:9092
+kafka:
+  dialTimeout: 1m
+  readTimeout: 1m
+http:
+  listenAddress: 0.0.0.0:9999
+  metricsPath: /prometheus
+`), 0600))
+	_ = os.Setenv("DIAL_ADDRESS_MAPPING", "kafka-0.example.com:9092,10.0.0.2:9092")
+
+	args := []string{"cobra.test",
+		"--config", configFile,
+		"--kafka-dial-timeout", "5s",
+		"--http-listen-address", "127.0.0.1:9080",
+	}
+	_ = Server.ParseFlags(args)
+	err = Server.PreRunE(nil, args)
+	a.Nil(err)
+
+	// flags take precedence
+	a.Equal(5*time.Second, c.Kafka.DialTimeout)
+	a.Equal("127.0.0.1:9080", c.Http.ListenAddress)
+	// environment takes precedence
+	a.Equal("10.0.0.2:9092", c.Proxy.DialAddressMappings[0].DestinationAddress)
+	// file takes precedence over the defaults
+	a.Equal(time.Minute, c.Kafka.ReadTimeout)
+	a.Equal("/prometheus", c.Http.MetricsPath)
+	a.Equal("0.0.0.0", c.Proxy.DefaultListenerIP)
+	a.Equal("0.0.0.0:32400", c.Proxy.BootstrapServers[0].ListenerAddress)
+	// defaults are kept
+	a.Equal(30*time.Second, c.Kafka.WriteTimeout)
+}
+
+func TestConfigFileError(t *testing.T) {
+	setupBootstrapServersMappingTest()
+	a := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "config-file")
+	a.Nil(err)
+	defer os.RemoveAll(dir)
+	configFile := filepath.Join(dir, "proxy.yaml")
+	a.Nil(ioutil.WriteFile(configFile, []byte("kafka:\n  dialTimeOut: 1m\n  unknown: 1\n"), 0600))
+
+	args := []string{"cobra.test", "--config", configFile}
+	_ = Server.ParseFlags(args)
+	err = Server.PreRunE(nil, args)
+	a.EqualError(err, "config file "+configFile+": 'Kafka' has invalid keys: unknown")
+}
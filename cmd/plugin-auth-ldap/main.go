@@ -6,6 +6,7 @@ import (
 	"flag"
 	"fmt"
 	"github.com/go-ldap/ldap/v3"
+	"github.com/grepplabs/kafka-proxy/pkg/libs/util"
 	"github.com/grepplabs/kafka-proxy/plugin/local-auth/shared"
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/go-plugin"
@@ -18,7 +19,22 @@ import (
 	"strings"
 )
 
-const UsernamePlaceholder = "%u"
+const (
+	UsernamePlaceholder = "%u"
+	UserDNPlaceholder   = "%d"
+)
+
+const (
+	StatusOK                 = 0
+	StatusDialError          = 1
+	StatusBindError          = 2
+	StatusInvalidCredentials = 3
+	StatusUserSearchError    = 4
+	StatusUserNotFound       = 5
+	StatusUserNotUnique      = 6
+	StatusGroupSearchError   = 7
+	StatusNotGroupMember     = 8
+)
 
 type LdapAuthenticator struct {
 	Urls      []string
@@ -34,83 +50,306 @@ type LdapAuthenticator struct {
 	BindPassword   string
 	UserSearchBase string
 	UserFilter     string
+
+	RequiredGroups  []string
+	GroupAttr       string
+	GroupSearchBase string
+	GroupFilter     string
+	GroupNameAttr   string
+
+	pool *connPool
+}
+
+// statusError is an authentication failure reported with a distinct status code
+type statusError struct {
+	status int32
+	err    error
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func newStatusError(status int32, err error) error {
+	return &statusError{status: status, err: err}
 }
 
 func (pa LdapAuthenticator) Authenticate(username, password string) (bool, int32, error) {
-	// logrus.Printf("Authenticate request for %s:%s,expected %s:%s ", username, password, pa.username, pa.password)
-	l, err := pa.DialLDAP()
+	var err error
+	if password == "" {
+		err = newStatusError(StatusInvalidCredentials, errors.New("empty password"))
+	} else if pa.SearchLDAP {
+		err = pa.authenticateWithSearch(username, password)
+	} else {
+		err = pa.authenticateWithBind(username, password)
+	}
 	if err != nil {
-		logrus.Errorf("user %s ldap dial error %v", username, err)
-		return false, 1, nil
+		status := int32(StatusBindError)
+		if se, ok := err.(*statusError); ok {
+			status = se.status
+		}
+		logrus.Errorf("user %s authentication failed with status %d: %v", username, status, err)
+		return false, status, nil
+	}
+	return true, StatusOK, nil
+}
+
+// authenticateWithSearch searches the user with a pooled service connection and verifies the password with a bind as the user.
+// The connection is bound as the service again before it is reused.
+func (pa LdapAuthenticator) authenticateWithSearch(username, password string) error {
+	conn, err := pa.pool.get()
+	if err != nil {
+		return newStatusError(StatusDialError, err)
+	}
+	reusable := false
+	defer func() {
+		if reusable {
+			pa.pool.put(conn)
+		} else {
+			conn.Close()
+		}
+	}()
+
+	entry, err := pa.searchUser(conn, username)
+	if isNetworkError(err) || (err != nil && conn.IsClosing()) {
+		// idle connection was closed by the server, a read error is not reported as a network error
+		conn.Close()
+		if conn, err = pa.pool.dial(); err != nil {
+			return newStatusError(StatusDialError, err)
+		}
+		entry, err = pa.searchUser(conn, username)
+	}
+	if err != nil {
+		reusable = true
+		return err
+	}
+	bindErr := pa.bindUser(conn, entry.DN, password)
+	serviceErr := pa.serviceBind(conn)
+	if serviceErr != nil {
+		logrus.Errorf("LDAP bind (service) failed: %v", serviceErr)
+	}
+	reusable = serviceErr == nil
+	if bindErr != nil {
+		return bindErr
 	}
-	if l == nil {
-		logrus.Errorf("ldap connection is nil")
-		return false, 1, nil
+	if serviceErr != nil && len(pa.RequiredGroups) != 0 && pa.GroupSearchBase != "" {
+		return newStatusError(StatusGroupSearchError, errors.Wrap(serviceErr, "LDAP bind (service) failed"))
 	}
-	defer l.Close()
+	return pa.checkGroups(conn, username, entry)
+}
+
+func isNetworkError(err error) bool {
+	if se, ok := err.(*statusError); ok {
+		err = se.err
+	}
+	return err != nil && ldap.IsErrorWithCode(errors.Cause(err), ldap.ErrorNetwork)
+}
 
-	bindDN, err := pa.getUserBindDN(l, username)
+// authenticateWithBind binds as the user with the DN built from the username
+func (pa LdapAuthenticator) authenticateWithBind(username, password string) error {
+	conn, err := pa.DialLDAP()
 	if err != nil {
-		logrus.Errorf("user %s ldap get user bindDN error %v", username, err)
-		return false, 1, nil
+		return newStatusError(StatusDialError, err)
+	}
+	if conn == nil {
+		return newStatusError(StatusDialError, errors.New("ldap connection is nil"))
+	}
+	defer conn.Close()
+
+	bindDN := pa.getUserBindDN(username)
+	if err = pa.bindUser(conn, bindDN, password); err != nil {
+		return err
 	}
-	err = l.Bind(bindDN, password)
+	if len(pa.RequiredGroups) == 0 {
+		return nil
+	}
+	// the user entry is read with the permissions of the user
+	var entry *ldap.Entry
+	if pa.GroupSearchBase == "" {
+		entry, err = pa.readEntry(conn, bindDN, pa.GroupAttr)
+		if err != nil {
+			return newStatusError(StatusGroupSearchError, err)
+		}
+	} else {
+		entry = ldap.NewEntry(bindDN, nil)
+	}
+	return pa.checkGroups(conn, username, entry)
+}
+
+func (pa LdapAuthenticator) bindUser(conn *ldap.Conn, bindDN string, password string) error {
+	err := conn.Bind(bindDN, password)
 	if err != nil {
 		if ldapErr, ok := err.(*ldap.Error); ok && ldapErr.ResultCode == ldap.LDAPResultInvalidCredentials {
-			logrus.Errorf("user %s credentials are invalid", username)
-			return false, 0, nil
+			return newStatusError(StatusInvalidCredentials, errors.New("credentials are invalid"))
 		}
-		logrus.Errorf("user %s ldap bind error %v", username, err)
-		return false, 2, nil
+		return newStatusError(StatusBindError, errors.Wrap(err, "ldap bind error"))
 	}
-	return true, 0, nil
+	return nil
 }
 
-func (pa LdapAuthenticator) getUserBindDN(conn *ldap.Conn, username string) (string, error) {
-	bindDN := ""
-	if pa.SearchLDAP {
+func (pa LdapAuthenticator) serviceBind(conn *ldap.Conn) error {
+	if pa.BindDN == "" {
+		return conn.UnauthenticatedBind("")
+	}
+	if pa.BindPassword == "" {
+		return conn.UnauthenticatedBind(pa.BindDN)
+	}
+	return conn.Bind(pa.BindDN, pa.BindPassword)
+}
+
+// dialService dials a new connection for the pool and binds as the service
+func (pa LdapAuthenticator) dialService() (*ldap.Conn, error) {
+	conn, err := pa.DialLDAP()
+	if err != nil {
+		return nil, err
+	}
+	if conn == nil {
+		return nil, errors.New("ldap connection is nil")
+	}
+	if err = pa.serviceBind(conn); err != nil {
+		conn.Close()
+		return nil, errors.Wrapf(err, "LDAP bind (service) failed")
+	}
+	return conn, nil
+}
+
+func (pa LdapAuthenticator) searchUser(conn *ldap.Conn, username string) (*ldap.Entry, error) {
+	attributes := []string{"dn"}
+	if len(pa.RequiredGroups) != 0 && pa.GroupSearchBase == "" {
+		attributes = append(attributes, pa.GroupAttr)
+	}
+	filter := strings.ReplaceAll(pa.UserFilter, UsernamePlaceholder, ldap.EscapeFilter(username))
+	searchRequest := ldap.NewSearchRequest(
+		pa.UserSearchBase,
+		ldap.ScopeWholeSubtree,
+		ldap.NeverDerefAliases,
+		0,
+		0,
+		false,
+		filter,
+		attributes,
+		nil,
+	)
+	sr, err := conn.Search(searchRequest)
+	if err != nil {
+		return nil, newStatusError(StatusUserSearchError, errors.Wrapf(err, "base DN %s, filter %s", pa.UserSearchBase, filter))
+	}
+	if len(sr.Entries) < 1 {
+		return nil, newStatusError(StatusUserNotFound, errors.Errorf("LDAP user search with base DN %s and filter %s returned empty result", pa.UserSearchBase, filter))
+	}
+	if len(sr.Entries) > 1 {
+		return nil, newStatusError(StatusUserNotUnique, errors.Errorf("LDAP user search with base DN %s and filter %s not unique result", pa.UserSearchBase, filter))
+	}
+	return sr.Entries[0], nil
+}
+
+func (pa LdapAuthenticator) readEntry(conn *ldap.Conn, dn string, attributes ...string) (*ldap.Entry, error) {
+	searchRequest := ldap.NewSearchRequest(
+		dn,
+		ldap.ScopeBaseObject,
+		ldap.NeverDerefAliases,
+		0,
+		0,
+		false,
+		"(objectClass=*)",
+		attributes,
+		nil,
+	)
+	sr, err := conn.Search(searchRequest)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading entry %s", dn)
+	}
+	if len(sr.Entries) != 1 {
+		return nil, errors.Errorf("entry %s not found", dn)
+	}
+	return sr.Entries[0], nil
+}
+
+// checkGroups verifies that the user is member of one of the required groups.
+// The groups are taken from the group attribute (memberOf) of the user entry or searched with the group filter.
+func (pa LdapAuthenticator) checkGroups(conn *ldap.Conn, username string, entry *ldap.Entry) error {
+	if len(pa.RequiredGroups) == 0 {
+		return nil
+	}
+	var groups []string
+	if pa.GroupSearchBase != "" {
 		var err error
-		if pa.BindDN != "" {
-			if pa.BindPassword != "" {
-				err = conn.Bind(pa.BindDN, pa.BindPassword)
-			} else {
-				err = conn.UnauthenticatedBind(pa.BindDN)
-			}
-			if err != nil {
-				return "", errors.Wrapf(err, "LDAP bind (service) failed")
-			}
-		}
-		filter := strings.ReplaceAll(pa.UserFilter, UsernamePlaceholder, username)
-		searchRequest := ldap.NewSearchRequest(
-			pa.UserSearchBase,
-			ldap.ScopeWholeSubtree,
-			ldap.NeverDerefAliases,
-			0,
-			0,
-			false,
-			filter,
-			[]string{"dn"},
-			nil,
-		)
-		sr, err := conn.Search(searchRequest)
+		groups, err = pa.searchGroups(conn, username, entry.DN)
 		if err != nil {
-			return "", errors.Wrapf(err, "base DN %s, filter %s", pa.UserSearchBase, filter)
+			return newStatusError(StatusGroupSearchError, err)
 		}
-		if len(sr.Entries) < 1 {
-			return "", errors.Errorf("LDAP user search with base DN %s and filter %s returned empty result", pa.UserSearchBase, filter)
-		}
-		if len(sr.Entries) > 1 {
-			return "", errors.Errorf("LDAP user search with base DN %s and filter %s not unique result", pa.UserSearchBase, filter)
-		}
-		bindDN = sr.Entries[0].DN
 	} else {
-		if pa.UPNDomain != "" {
-			bindDN = fmt.Sprintf("%s@%s", escapeLDAPValue(username), pa.UPNDomain)
-		} else {
-			bindDN = fmt.Sprintf("%s=%s,%s", pa.UserAttr, escapeLDAPValue(username), pa.UserDN)
+		groups = entry.GetEqualFoldAttributeValues(pa.GroupAttr)
+	}
+	if !isGroupMember(groups, pa.RequiredGroups) {
+		return newStatusError(StatusNotGroupMember, errors.Errorf("user is not member of any of the groups %v", pa.RequiredGroups))
+	}
+	return nil
+}
+
+func (pa LdapAuthenticator) searchGroups(conn *ldap.Conn, username string, userDN string) ([]string, error) {
+	filter := strings.ReplaceAll(pa.GroupFilter, UsernamePlaceholder, ldap.EscapeFilter(username))
+	filter = strings.ReplaceAll(filter, UserDNPlaceholder, ldap.EscapeFilter(userDN))
+	searchRequest := ldap.NewSearchRequest(
+		pa.GroupSearchBase,
+		ldap.ScopeWholeSubtree,
+		ldap.NeverDerefAliases,
+		0,
+		0,
+		false,
+		filter,
+		[]string{pa.GroupNameAttr},
+		nil,
+	)
+	sr, err := conn.Search(searchRequest)
+	if err != nil {
+		return nil, errors.Wrapf(err, "base DN %s, filter %s", pa.GroupSearchBase, filter)
+	}
+	groups := make([]string, 0)
+	for _, entry := range sr.Entries {
+		groups = append(groups, entry.DN)
+		groups = append(groups, entry.GetEqualFoldAttributeValues(pa.GroupNameAttr)...)
+	}
+	return groups, nil
+}
+
+// isGroupMember checks if one of the groups matches a required group by the DN or by the value of the first RDN e.g. cn
+func isGroupMember(groups []string, requiredGroups []string) bool {
+	for _, group := range groups {
+		names := []string{group}
+		if dn, err := ldap.ParseDN(group); err == nil && len(dn.RDNs) != 0 {
+			for _, attr := range dn.RDNs[0].Attributes {
+				names = append(names, attr.Value)
+			}
+		}
+		for _, required := range requiredGroups {
+			for _, name := range names {
+				if strings.EqualFold(name, required) || equalDN(name, required) {
+					return true
+				}
+			}
 		}
 	}
-	return bindDN, nil
+	return false
+}
+
+func equalDN(a, b string) bool {
+	dnA, err := ldap.ParseDN(a)
+	if err != nil || len(dnA.RDNs) == 0 {
+		return false
+	}
+	dnB, err := ldap.ParseDN(b)
+	if err != nil || len(dnB.RDNs) == 0 {
+		return false
+	}
+	return dnA.Equal(dnB)
+}
+
+func (pa LdapAuthenticator) getUserBindDN(username string) string {
+	if pa.UPNDomain != "" {
+		return fmt.Sprintf("%s@%s", escapeLDAPValue(username), pa.UPNDomain)
+	}
+	return fmt.Sprintf("%s=%s,%s", pa.UserAttr, escapeLDAPValue(username), pa.UserDN)
 }
 
 func escapeLDAPValue(input string) string {
@@ -173,7 +412,7 @@ func (pa LdapAuthenticator) DialLDAP() (*ldap.Conn, error) {
 				break
 			}
 			if pa.StartTLS {
-				err = conn.StartTLS(&tls.Config{InsecureSkipVerify: true})
+				err = conn.StartTLS(&tls.Config{InsecureSkipVerify: true, Certificates: pa.TlsConfig.Certificates})
 			}
 		case "ldaps":
 			if port == "" {
@@ -196,6 +435,8 @@ func (pa LdapAuthenticator) DialLDAP() (*ldap.Conn, error) {
 type pluginMeta struct {
 	url                string
 	caCertFile         string
+	clientCertFile     string
+	clientKeyFile      string
 	insecureSkipVerify bool
 	startTLS           bool
 	upnDomain          string
@@ -207,6 +448,14 @@ type pluginMeta struct {
 	bindPassword   string
 	userSearchBase string
 	userFilter     string
+
+	requiredGroups  util.ArrayFlags
+	groupAttr       string
+	groupSearchBase string
+	groupFilter     string
+	groupNameAttr   string
+
+	poolSize int
 }
 
 func (f *pluginMeta) flagSet() *flag.FlagSet {
@@ -214,6 +463,8 @@ func (f *pluginMeta) flagSet() *flag.FlagSet {
 
 	fs.StringVar(&f.url, "url", "", "LDAP URL to connect to (eg: ldaps://127.0.0.1:636). Multiple URLs can be specified by concatenating them with commas.")
 	fs.StringVar(&f.caCertFile, "ldap-ca-cert-file", "", "X509 CA certificate (PEM) to verify peer against")
+	fs.StringVar(&f.clientCertFile, "ldap-client-cert-file", "", "X509 client certificate (PEM) presented to the LDAP server")
+	fs.StringVar(&f.clientKeyFile, "ldap-client-key-file", "", "Private key (PEM) of the client certificate")
 	fs.BoolVar(&f.insecureSkipVerify, "ldap-insecure-skip-verify", false, "It controls whether a client verifies the server's certificate chain and host name")
 	fs.BoolVar(&f.startTLS, "start-tls", true, "Issue a StartTLS command after establishing unencrypted connection (optional)")
 	fs.StringVar(&f.upnDomain, "upn-domain", "", "Enables userPrincipalDomain login with [username]@UPNDomain (optional)")
@@ -226,6 +477,14 @@ func (f *pluginMeta) flagSet() *flag.FlagSet {
 	fs.StringVar(&f.userSearchBase, "user-search-base", "", "The search base as the starting point for the user search e.g. ou=people,dc=example,dc=org")
 	fs.StringVar(&f.userFilter, "user-filter", "", fmt.Sprintf("The user search filter. It must contain '%s' placeholder for the username e.g. (&(objectClass=person)(uid=%s)(memberOf=cn=kafka-users,ou=realm-roles,dc=example,dc=org))", UsernamePlaceholder, UsernamePlaceholder))
 
+	fs.Var(&f.requiredGroups, "required-group", "Group DN or name the user must be member of. If set more than once, one of the groups is required")
+	fs.StringVar(&f.groupAttr, "group-attr", "memberOf", "Attribute of the user entry containing the groups of the user")
+	fs.StringVar(&f.groupSearchBase, "group-search-base", "", "The search base for the group search e.g. ou=groups,dc=example,dc=org. If not set, the groups are read from --group-attr of the user entry")
+	fs.StringVar(&f.groupFilter, "group-filter", "", fmt.Sprintf("The group search filter. The '%s' placeholder is replaced by the username and '%s' by the user DN e.g. (&(objectClass=groupOfUniqueNames)(uniqueMember=%s))", UsernamePlaceholder, UserDNPlaceholder, UserDNPlaceholder))
+	fs.StringVar(&f.groupNameAttr, "group-name-attr", "cn", "Attribute of the group entry containing the group name")
+
+	fs.IntVar(&f.poolSize, "pool-size", 10, "Maximum number of idle service bound connections used for user searches. 0 disables the pooling")
+
 	return fs
 }
 
@@ -284,38 +543,67 @@ func main() {
 		logrus.Errorf("parameters user-dn or bind-dn are required")
 		os.Exit(1)
 	}
+	searchLDAP := pluginMeta.searchLDAP || pluginMeta.bindDN != ""
+	if len(pluginMeta.requiredGroups) != 0 {
+		logrus.Infof("required-group=%v", pluginMeta.requiredGroups)
 
-	tlsConfig, err := getTlsConfig(pluginMeta.caCertFile, pluginMeta.insecureSkipVerify)
+		if pluginMeta.groupSearchBase != "" {
+			if pluginMeta.groupFilter == "" {
+				logrus.Errorf("parameter group-filter is required when group-search-base is set")
+				os.Exit(1)
+			}
+		} else {
+			if pluginMeta.groupAttr == "" {
+				logrus.Errorf("parameter group-attr or group-search-base is required")
+				os.Exit(1)
+			}
+			if !searchLDAP && pluginMeta.upnDomain != "" {
+				logrus.Errorf("parameter group-search-base, bind-dn or search-ldap is required to check groups with upn-domain")
+				os.Exit(1)
+			}
+		}
+	}
+
+	tlsConfig, err := getTlsConfig(pluginMeta.caCertFile, pluginMeta.clientCertFile, pluginMeta.clientKeyFile, pluginMeta.insecureSkipVerify)
 	if err != nil {
 		logrus.Errorf("error %v getting TLS config", err)
 		os.Exit(1)
 	}
 
+	authenticator := &LdapAuthenticator{
+		Urls:            urls,
+		TlsConfig:       tlsConfig,
+		StartTLS:        pluginMeta.startTLS,
+		UPNDomain:       pluginMeta.upnDomain,
+		UserDN:          pluginMeta.userDN,
+		UserAttr:        pluginMeta.userAttr,
+		SearchLDAP:      searchLDAP,
+		BindDN:          pluginMeta.bindDN,
+		BindPassword:    pluginMeta.bindPassword,
+		UserSearchBase:  pluginMeta.userSearchBase,
+		UserFilter:      pluginMeta.userFilter,
+		RequiredGroups:  pluginMeta.requiredGroups,
+		GroupAttr:       pluginMeta.groupAttr,
+		GroupSearchBase: pluginMeta.groupSearchBase,
+		GroupFilter:     pluginMeta.groupFilter,
+		GroupNameAttr:   pluginMeta.groupNameAttr,
+	}
+	authenticator.pool = newConnPool(pluginMeta.poolSize, authenticator.dialService)
+
 	plugin.Serve(&plugin.ServeConfig{
 		HandshakeConfig: shared.Handshake,
 		Plugins: map[string]plugin.Plugin{
-			"passwordAuthenticator": &shared.PasswordAuthenticatorPlugin{Impl: &LdapAuthenticator{
-				Urls:           urls,
-				TlsConfig:      tlsConfig,
-				StartTLS:       pluginMeta.startTLS,
-				UPNDomain:      pluginMeta.upnDomain,
-				UserDN:         pluginMeta.userDN,
-				UserAttr:       pluginMeta.userAttr,
-				SearchLDAP:     pluginMeta.searchLDAP || pluginMeta.bindDN != "",
-				BindDN:         pluginMeta.bindDN,
-				BindPassword:   pluginMeta.bindPassword,
-				UserSearchBase: pluginMeta.userSearchBase,
-				UserFilter:     pluginMeta.userFilter,
-			}},
+			"passwordAuthenticator": &shared.PasswordAuthenticatorPlugin{Impl: authenticator},
 		},
 		// A non-nil value here enables gRPC serving for this plugin...
 		GRPCServer: plugin.DefaultGRPCServer,
 	})
 }
 
-func getTlsConfig(caCertFile string, insecureSkipVerify bool) (*tls.Config, error) {
+func getTlsConfig(caCertFile string, clientCertFile string, clientKeyFile string, insecureSkipVerify bool) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
 	if caCertFile == "" {
-		return &tls.Config{InsecureSkipVerify: insecureSkipVerify}, nil
+		tlsConfig.InsecureSkipVerify = insecureSkipVerify
 	} else {
 		certData, err := ioutil.ReadFile(caCertFile)
 		if err != nil {
@@ -325,6 +613,17 @@ func getTlsConfig(caCertFile string, insecureSkipVerify bool) (*tls.Config, erro
 		if ok := certPool.AppendCertsFromPEM(certData); !ok {
 			return nil, errors.Errorf("could not parse certificate(s) in file %s", caCertFile)
 		}
-		return &tls.Config{RootCAs: certPool}, nil
+		tlsConfig.RootCAs = certPool
+	}
+	if clientCertFile != "" || clientKeyFile != "" {
+		if clientCertFile == "" || clientKeyFile == "" {
+			return nil, errors.New("both ldap-client-cert-file and ldap-client-key-file are required")
+		}
+		cert, err := tls.LoadX509KeyPair(clientCertFile, clientKeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "loading client certificate")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
 	}
+	return tlsConfig, nil
 }
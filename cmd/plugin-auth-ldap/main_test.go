@@ -0,0 +1,360 @@
+package main
+
+import (
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestIsGroupMember(t *testing.T) {
+	groups := []string{"cn=kafka-users,ou=realm-roles,dc=example,dc=org", "ldap-users"}
+	tests := []struct {
+		required []string
+		expected bool
+	}{
+		{required: []string{"kafka-users"}, expected: true},
+		{required: []string{"Kafka-Users"}, expected: true},
+		{required: []string{"cn=kafka-users,ou=realm-roles,dc=example,dc=org"}, expected: true},
+		{required: []string{"CN=kafka-users, OU=realm-roles, DC=example, DC=org"}, expected: true},
+		{required: []string{"ldap-users"}, expected: true},
+		{required: []string{"superadmin", "kafka-users"}, expected: true},
+		{required: []string{"superadmin"}, expected: false},
+		{required: []string{"cn=kafka-users,ou=admin-roles,dc=example,dc=org"}, expected: false},
+		{required: []string{"realm-roles"}, expected: false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, isGroupMember(groups, tt.required), "%v", tt.required)
+	}
+	assert.False(t, isGroupMember(nil, []string{"kafka-users"}))
+}
+
+func TestGetTlsConfig(t *testing.T) {
+	a := assert.New(t)
+
+	tlsConfig, err := getTlsConfig("", "", "", true)
+	a.Nil(err)
+	a.True(tlsConfig.InsecureSkipVerify)
+	a.Empty(tlsConfig.Certificates)
+
+	_, err = getTlsConfig("", "client-cert.pem", "", false)
+	a.EqualError(err, "both ldap-client-cert-file and ldap-client-key-file are required")
+
+	_, err = getTlsConfig("", "/not/existing/cert.pem", "/not/existing/key.pem", false)
+	a.NotNil(err)
+}
+
+func TestConnPool(t *testing.T) {
+	a := assert.New(t)
+
+	server := newFakeLdapServer()
+	pool := newConnPool(1, server.dial)
+
+	conn, err := pool.get()
+	a.Nil(err)
+	a.Equal(int32(1), server.dialCount())
+
+	pool.put(conn)
+	pooled, err := pool.get()
+	a.Nil(err)
+	a.True(conn == pooled)
+	a.Equal(int32(1), server.dialCount())
+
+	// the pool is full, the other connection is closed
+	other, err := pool.dial()
+	a.Nil(err)
+	pool.put(conn)
+	pool.put(other)
+	a.True(other.IsClosing())
+	a.False(conn.IsClosing())
+
+	// connections closed while idle are not returned
+	conn.Close()
+	fresh, err := pool.get()
+	a.Nil(err)
+	a.False(fresh == conn)
+	a.Equal(int32(3), server.dialCount())
+
+	// closed connections are not pooled
+	fresh.Close()
+	pool.put(fresh)
+	a.Len(pool.conns, 0)
+}
+
+func TestConnPoolDisabled(t *testing.T) {
+	a := assert.New(t)
+
+	server := newFakeLdapServer()
+	pool := newConnPool(0, server.dial)
+	conn, err := pool.get()
+	a.Nil(err)
+	pool.put(conn)
+	a.True(conn.IsClosing())
+}
+
+func TestAuthenticateWithSearchRedialsClosedConnection(t *testing.T) {
+	a := assert.New(t)
+
+	server := newFakeLdapServer()
+	authenticator := newTestSearchAuthenticator(server)
+	conn, err := authenticator.pool.get()
+	a.Nil(err)
+	authenticator.pool.put(conn)
+
+	// the server drops the idle connection on the next search
+	atomic.StoreInt32(&server.dropSearches, 1)
+	ok, status, err := authenticator.Authenticate("alice", "alice-secret")
+	a.Nil(err)
+	a.True(ok)
+	a.Equal(int32(StatusOK), status)
+	a.Equal(int32(2), server.dialCount())
+	a.True(conn.IsClosing())
+
+	// the new connection is pooled
+	if a.Len(authenticator.pool.conns, 1) {
+		pooled := <-authenticator.pool.conns
+		a.False(pooled == conn)
+		a.False(pooled.IsClosing())
+	}
+}
+
+func TestAuthenticateStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		password string
+		modify   func(pa *LdapAuthenticator)
+		status   int32
+	}{
+		{name: "success", username: "alice", password: "alice-secret", status: StatusOK},
+		{name: "empty password", username: "alice", password: "", status: StatusInvalidCredentials},
+		{name: "invalid credentials", username: "alice", password: "wrong-secret", status: StatusInvalidCredentials},
+		{name: "user not found", username: "mallory", password: "secret", modify: func(pa *LdapAuthenticator) {
+			pa.UserSearchBase = "ou=empty,dc=example,dc=org"
+		}, status: StatusUserNotFound},
+		{name: "user not unique", username: "alice", password: "alice-secret", modify: func(pa *LdapAuthenticator) {
+			pa.UserSearchBase = "ou=duplicates,dc=example,dc=org"
+		}, status: StatusUserNotUnique},
+		{name: "user search error", username: "alice", password: "alice-secret", modify: func(pa *LdapAuthenticator) {
+			pa.UserSearchBase = "ou=missing,dc=example,dc=org"
+		}, status: StatusUserSearchError},
+		{name: "dial error", username: "alice", password: "alice-secret", modify: func(pa *LdapAuthenticator) {
+			pa.pool = newConnPool(1, func() (*ldap.Conn, error) { return nil, errors.New("connection refused") })
+		}, status: StatusDialError},
+		{name: "bind dial error", username: "alice", password: "alice-secret", modify: func(pa *LdapAuthenticator) {
+			pa.SearchLDAP = false
+			pa.Urls = []string{}
+		}, status: StatusDialError},
+		{name: "not group member", username: "alice", password: "alice-secret", modify: func(pa *LdapAuthenticator) {
+			pa.RequiredGroups = []string{"kafka-admins"}
+		}, status: StatusNotGroupMember},
+		{name: "group search error", username: "alice", password: "alice-secret", modify: func(pa *LdapAuthenticator) {
+			pa.RequiredGroups = []string{"kafka-users"}
+			pa.GroupSearchBase = "ou=missing,dc=example,dc=org"
+			pa.GroupFilter = "(member=%d)"
+		}, status: StatusGroupSearchError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+
+			authenticator := newTestSearchAuthenticator(newFakeLdapServer())
+			if tt.modify != nil {
+				tt.modify(authenticator)
+			}
+			ok, status, err := authenticator.Authenticate(tt.username, tt.password)
+			a.Nil(err)
+			a.Equal(tt.status == StatusOK, ok)
+			a.Equal(tt.status, status)
+		})
+	}
+}
+
+func TestCheckGroups(t *testing.T) {
+	a := assert.New(t)
+
+	server := newFakeLdapServer()
+	authenticator := newTestSearchAuthenticator(server)
+	userDN := "uid=alice,ou=people,dc=example,dc=org"
+
+	// groups of the memberOf attribute, the connection is not used
+	authenticator.RequiredGroups = []string{"kafka-users"}
+	entry := ldap.NewEntry(userDN, map[string][]string{"memberOf": {"cn=kafka-users,ou=groups,dc=example,dc=org"}})
+	a.Nil(authenticator.checkGroups(nil, "alice", entry))
+	err := authenticator.checkGroups(nil, "alice", ldap.NewEntry(userDN, nil))
+	a.Equal(int32(StatusNotGroupMember), err.(*statusError).status)
+
+	// groups searched with the group filter
+	conn, err := server.dial()
+	a.Nil(err)
+	defer conn.Close()
+	authenticator.GroupSearchBase = "ou=groups,dc=example,dc=org"
+	authenticator.GroupFilter = "(&(objectClass=groupOfNames)(member=%d))"
+	a.Nil(authenticator.checkGroups(conn, "alice", ldap.NewEntry(userDN, nil)))
+	a.Equal("(&(objectClass=groupOfNames)(member=uid=alice,ou=people,dc=example,dc=org))", server.lastFilter())
+
+	authenticator.RequiredGroups = []string{"kafka-admins"}
+	err = authenticator.checkGroups(conn, "alice", entry)
+	a.Equal(int32(StatusNotGroupMember), err.(*statusError).status)
+}
+
+func newTestSearchAuthenticator(server *fakeLdapServer) *LdapAuthenticator {
+	authenticator := &LdapAuthenticator{
+		SearchLDAP:     true,
+		BindDN:         "cn=admin,dc=example,dc=org",
+		BindPassword:   "admin-secret",
+		UserSearchBase: "ou=people,dc=example,dc=org",
+		UserFilter:     "(uid=%u)",
+		GroupAttr:      "memberOf",
+		GroupNameAttr:  "cn",
+	}
+	authenticator.pool = newConnPool(1, server.dial)
+	return authenticator
+}
+
+type fakeLdapEntry struct {
+	dn         string
+	attributes map[string][]string
+}
+
+// fakeLdapServer answers simple binds and searches on in-memory connections
+type fakeLdapServer struct {
+	// bind DN to password
+	passwords map[string]string
+	// search base to result entries, searches of other bases fail with noSuchObject
+	entries map[string][]fakeLdapEntry
+	// number of connections dropped instead of answering a search
+	dropSearches int32
+	dials        int32
+
+	filters []string
+	l       sync.Mutex
+}
+
+func newFakeLdapServer() *fakeLdapServer {
+	return &fakeLdapServer{
+		passwords: map[string]string{
+			"cn=admin,dc=example,dc=org":            "admin-secret",
+			"uid=alice,ou=people,dc=example,dc=org": "alice-secret",
+		},
+		entries: map[string][]fakeLdapEntry{
+			"ou=people,dc=example,dc=org": {{dn: "uid=alice,ou=people,dc=example,dc=org",
+				attributes: map[string][]string{"memberOf": {"cn=kafka-users,ou=groups,dc=example,dc=org"}}}},
+			"ou=empty,dc=example,dc=org": {},
+			"ou=duplicates,dc=example,dc=org": {{dn: "uid=alice,ou=people,dc=example,dc=org"},
+				{dn: "uid=alice,ou=admins,dc=example,dc=org"}},
+			"ou=groups,dc=example,dc=org": {{dn: "cn=kafka-users,ou=groups,dc=example,dc=org",
+				attributes: map[string][]string{"cn": {"kafka-users"}}}},
+		},
+	}
+}
+
+func (s *fakeLdapServer) dial() (*ldap.Conn, error) {
+	atomic.AddInt32(&s.dials, 1)
+	client, server := net.Pipe()
+	go s.serve(server)
+	conn := ldap.NewConn(client, false)
+	conn.Start()
+	return conn, nil
+}
+
+func (s *fakeLdapServer) dialCount() int32 {
+	return atomic.LoadInt32(&s.dials)
+}
+
+func (s *fakeLdapServer) lastFilter() string {
+	s.l.Lock()
+	defer s.l.Unlock()
+	if len(s.filters) == 0 {
+		return ""
+	}
+	return s.filters[len(s.filters)-1]
+}
+
+func (s *fakeLdapServer) serve(conn net.Conn) {
+	defer conn.Close()
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+		messageID := packet.Children[0].Value.(int64)
+		request := packet.Children[1]
+		switch request.Tag {
+		case ldap.ApplicationBindRequest:
+			resultCode := uint16(ldap.LDAPResultInvalidCredentials)
+			if password, ok := s.passwords[request.Children[1].Value.(string)]; ok && password == request.Children[2].Data.String() {
+				resultCode = ldap.LDAPResultSuccess
+			}
+			if !s.write(conn, messageID, ldapResult(ldap.ApplicationBindResponse, resultCode)) {
+				return
+			}
+		case ldap.ApplicationSearchRequest:
+			if atomic.AddInt32(&s.dropSearches, -1) >= 0 {
+				return
+			}
+			filter, err := ldap.DecompileFilter(request.Children[6])
+			if err != nil {
+				return
+			}
+			s.l.Lock()
+			s.filters = append(s.filters, filter)
+			s.l.Unlock()
+
+			entries, ok := s.entries[request.Children[0].Value.(string)]
+			for _, entry := range entries {
+				if !s.write(conn, messageID, searchResultEntry(entry)) {
+					return
+				}
+			}
+			resultCode := uint16(ldap.LDAPResultSuccess)
+			if !ok {
+				resultCode = ldap.LDAPResultNoSuchObject
+			}
+			if !s.write(conn, messageID, ldapResult(ldap.ApplicationSearchResultDone, resultCode)) {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (s *fakeLdapServer) write(conn net.Conn, messageID int64, response *ber.Packet) bool {
+	envelope := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	envelope.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, "MessageID"))
+	envelope.AppendChild(response)
+	_, err := conn.Write(envelope.Bytes())
+	return err == nil
+}
+
+func ldapResult(tag ber.Tag, resultCode uint16) *ber.Packet {
+	result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Result")
+	result.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(resultCode), "resultCode"))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "matchedDN"))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, strings.ToLower(ldap.LDAPResultCodeMap[resultCode]), "diagnosticMessage"))
+	return result
+}
+
+func searchResultEntry(entry fakeLdapEntry) *ber.Packet {
+	result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "Search Result Entry")
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.dn, "objectName"))
+	attributes := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attributes")
+	for name, values := range entry.attributes {
+		attribute := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attribute")
+		attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "type"))
+		vals := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "vals")
+		for _, value := range values {
+			vals.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "value"))
+		}
+		attribute.AppendChild(vals)
+		attributes.AppendChild(attribute)
+	}
+	result.AppendChild(attributes)
+	return result
+}
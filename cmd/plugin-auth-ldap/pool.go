@@ -0,0 +1,49 @@
+package main
+
+import (
+	"github.com/go-ldap/ldap/v3"
+)
+
+// connPool keeps idle service bound connections used for user and group searches
+type connPool struct {
+	dial  func() (*ldap.Conn, error)
+	conns chan *ldap.Conn
+}
+
+func newConnPool(size int, dial func() (*ldap.Conn, error)) *connPool {
+	if size < 0 {
+		size = 0
+	}
+	return &connPool{
+		dial:  dial,
+		conns: make(chan *ldap.Conn, size),
+	}
+}
+
+// get returns an idle connection or dials a new one
+func (p *connPool) get() (*ldap.Conn, error) {
+	for {
+		select {
+		case conn := <-p.conns:
+			if !conn.IsClosing() {
+				return conn, nil
+			}
+			conn.Close()
+		default:
+			return p.dial()
+		}
+	}
+}
+
+// put returns the service bound connection to the pool, the connection is closed when the pool is full
+func (p *connPool) put(conn *ldap.Conn) {
+	if conn.IsClosing() {
+		conn.Close()
+		return
+	}
+	select {
+	case p.conns <- conn:
+	default:
+		conn.Close()
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"github.com/grepplabs/kafka-proxy/pkg/libs/scram-file-store"
+	"github.com/grepplabs/kafka-proxy/plugin/scram-credential-store/shared"
+	"github.com/hashicorp/go-plugin"
+	"github.com/sirupsen/logrus"
+	"os"
+)
+
+func main() {
+	credentialStore, err := new(scramfilestore.Factory).New(os.Args[1:])
+	if err != nil {
+		logrus.Errorf("cannot initialize scram-file-store: %v", err)
+		os.Exit(1)
+	}
+
+	plugin.Serve(&plugin.ServeConfig{
+		HandshakeConfig: shared.Handshake,
+		Plugins: map[string]plugin.Plugin{
+			"scramCredentialStore": &shared.ScramCredentialStorePlugin{Impl: credentialStore},
+		},
+		// A non-nil value here enables gRPC serving for this plugin...
+		GRPCServer: plugin.DefaultGRPCServer,
+	})
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"github.com/grepplabs/kafka-proxy/pkg/libs/user-credentials-file"
+	"github.com/grepplabs/kafka-proxy/plugin/user-credentials/shared"
+	"github.com/hashicorp/go-plugin"
+	"github.com/sirupsen/logrus"
+	"os"
+)
+
+func main() {
+	credentialsProvider, err := new(usercredentialsfile.Factory).New(os.Args[1:])
+	if err != nil {
+		logrus.Errorf("cannot initialize user-credentials-file: %v", err)
+		os.Exit(1)
+	}
+
+	plugin.Serve(&plugin.ServeConfig{
+		HandshakeConfig: shared.Handshake,
+		Plugins: map[string]plugin.Plugin{
+			"userCredentialsProvider": &shared.UserCredentialsProviderPlugin{Impl: credentialsProvider},
+		},
+		// A non-nil value here enables gRPC serving for this plugin...
+		GRPCServer: plugin.DefaultGRPCServer,
+	})
+}
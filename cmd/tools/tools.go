@@ -1,9 +1,12 @@
 package tools
 
 import (
+	"errors"
+	"fmt"
 	"github.com/armon/go-socks5"
 	"github.com/elazarl/goproxy"
 	"github.com/elazarl/goproxy/ext/auth"
+	"github.com/grepplabs/kafka-proxy/pkg/libs/scram-file-store"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"net/http"
@@ -26,9 +29,16 @@ var socks5Proxy = &cobra.Command{
 	RunE:  socks5ProxyServer,
 }
 
+var scramCredentials = &cobra.Command{
+	Use:   "scram-credentials",
+	Short: "Print SCRAM credentials entry of the scram-file-store",
+	RunE:  printScramCredentials,
+}
+
 func init() {
 	Tools.AddCommand(httpProxy)
 	Tools.AddCommand(socks5Proxy)
+	Tools.AddCommand(scramCredentials)
 
 	Tools.PersistentFlags().String("username", "", `username for proxy authentication`)
 	Tools.PersistentFlags().String("password", "", "password for proxy authentication")
@@ -37,6 +47,9 @@ func init() {
 	httpProxy.Flags().Bool("verbose", false, "should every proxy request be logged to stdout")
 
 	socks5Proxy.Flags().String("addr", ":1080", "proxy listen address")
+
+	scramCredentials.Flags().String("mechanism", scramfilestore.MechanismSHA512, "SCRAM mechanism: SCRAM-SHA-256 or SCRAM-SHA-512")
+	scramCredentials.Flags().Int("iterations", scramfilestore.MinIterations, "iteration count")
 }
 
 func httpProxyServer(cmd *cobra.Command, _ []string) error {
@@ -91,3 +104,20 @@ type socks5ProxyCredentials struct {
 func (s socks5ProxyCredentials) Valid(username, password string) bool {
 	return s.username == username && s.password == password
 }
+
+func printScramCredentials(cmd *cobra.Command, _ []string) error {
+	username, _ := cmd.Flags().GetString("username")
+	password, _ := cmd.Flags().GetString("password")
+	mechanism, _ := cmd.Flags().GetString("mechanism")
+	iterations, _ := cmd.Flags().GetInt("iterations")
+
+	if username == "" || password == "" {
+		return errors.New("username and password are required")
+	}
+	credentials, err := scramfilestore.NewCredentials(mechanism, username, password, nil, iterations)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s %s %s\n", username, mechanism, scramfilestore.FormatCredentials(credentials))
+	return nil
+}
@@ -0,0 +1,181 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/grepplabs/kafka-proxy/pkg/libs/util"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// LoadFile sets the configuration from the YAML or JSON file. The keys are the field names of the Config matched
+// case-insensitively, the durations are strings e.g.
+//
+//	proxy:
+//	  bootstrapServers:
+//	    - brokerAddress: kafka-0:9092
+//	      listenerAddress: 0.0.0.0:32400
+//	kafka:
+//	  dialTimeout: 15s
+//
+// Fields which are not present in the file keep their values.
+func (c *Config) LoadFile(filename string) error {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	if err = c.load(content); err != nil {
+		return errors.Wrapf(err, "config file %s", filename)
+	}
+	return nil
+}
+
+func (c *Config) load(content []byte) error {
+	var raw interface{}
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return err
+	}
+	if raw == nil {
+		return nil
+	}
+	values, err := stringKeys("", raw)
+	if err != nil {
+		return err
+	}
+	if _, ok := values.(map[string]interface{}); !ok {
+		return errors.New("top level must be a mapping")
+	}
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:  mapstructure.StringToTimeDurationHookFunc(),
+		ErrorUnused: true,
+		// slices and maps are replaced, they must not share the backing arrays with the previous values
+		ZeroFields: true,
+		Result:     c,
+	})
+	if err != nil {
+		return err
+	}
+	if err = decoder.Decode(values); err != nil {
+		if decodeErr, ok := err.(*mapstructure.Error); ok {
+			return errors.New(strings.Join(decodeErr.Errors, ", "))
+		}
+		return err
+	}
+	return c.normalizeFileMappings()
+}
+
+// stringKeys converts the YAML mappings to maps with string keys
+func stringKeys(key string, value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			name, ok := k.(string)
+			if !ok {
+				return nil, errors.Errorf("'%s' has a non-string key %v", key, k)
+			}
+			converted, err := stringKeys(joinKey(key, name), item)
+			if err != nil {
+				return nil, err
+			}
+			result[name] = converted
+		}
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			converted, err := stringKeys(fmt.Sprintf("%s[%d]", key, i), item)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = converted
+		}
+		return result, nil
+	}
+	return value, nil
+}
+
+func joinKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// normalizeFileMappings validates the structured mappings and sets the defaults as the parsing of the flags does
+func (c *Config) normalizeFileMappings() (err error) {
+	if c.Proxy.BootstrapServers, err = normalizeListenerConfigs("Proxy.BootstrapServers", c.Proxy.BootstrapServers); err != nil {
+		return err
+	}
+	if c.Proxy.ExternalServers, err = normalizeListenerConfigs("Proxy.ExternalServers", c.Proxy.ExternalServers); err != nil {
+		return err
+	}
+	for i, mapping := range c.Proxy.DialAddressMappings {
+		mappings, err := getDialAddressMappings([]string{mapping.SourceAddress + "," + mapping.DestinationAddress})
+		if err != nil {
+			return errors.Wrapf(err, "'Proxy.DialAddressMappings[%d]'", i)
+		}
+		c.Proxy.DialAddressMappings[i] = mappings[0]
+	}
+	for i, mapping := range c.Namespace.PrincipalMappings {
+		if mapping.Name == "" || mapping.Prefix == "" {
+			return errors.Errorf("'Namespace.PrincipalMappings[%d]' Name and Prefix are required", i)
+		}
+	}
+	for i, mapping := range c.Namespace.ListenerMappings {
+		mappings, err := getNamespaceMappings([]string{mapping.Name + "," + mapping.Prefix}, true)
+		if err != nil {
+			return errors.Wrapf(err, "'Namespace.ListenerMappings[%d]'", i)
+		}
+		c.Namespace.ListenerMappings[i] = mappings[0]
+	}
+	for i := range c.MetadataTopicFilter.Rules {
+		if err = normalizeTopicFilterRule(&c.MetadataTopicFilter.Rules[i]); err != nil {
+			return errors.Wrapf(err, "'MetadataTopicFilter.Rules[%d]'", i)
+		}
+	}
+	return nil
+}
+
+func normalizeListenerConfigs(key string, listenerConfigs []ListenerConfig) ([]ListenerConfig, error) {
+	result := make([]ListenerConfig, 0, len(listenerConfigs))
+	for i, listenerConfig := range listenerConfigs {
+		mapping := listenerConfig.BrokerAddress + "," + listenerConfig.ListenerAddress
+		if listenerConfig.AdvertisedAddress != "" {
+			mapping += "," + listenerConfig.AdvertisedAddress
+		}
+		normalized, err := getListenerConfigs([]string{mapping})
+		if err != nil {
+			return nil, errors.Wrapf(err, "'%s[%d]'", key, i)
+		}
+		result = append(result, normalized[0])
+	}
+	return result, nil
+}
+
+func normalizeTopicFilterRule(rule *TopicFilterRule) error {
+	if rule.Pattern == "" {
+		return errors.New("Pattern is required")
+	}
+	if rule.Listener != "" {
+		host, port, err := util.SplitHostPort(rule.Listener)
+		if err != nil {
+			return err
+		}
+		rule.Listener = net.JoinHostPort(host, fmt.Sprint(port))
+	}
+	if rule.Regex {
+		_, err := regexp.Compile(rule.Pattern)
+		return err
+	}
+	if _, err := path.Match(rule.Pattern, ""); err != nil {
+		return errors.Wrap(err, "invalid glob pattern")
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfigYAML(t *testing.T) {
+	a := assert.New(t)
+
+	c := NewConfig()
+	c.Proxy.DefaultListenerIP = "0.0.0.0"
+	err := c.load([]byte(`
+proxy:
+  bootstrapServers:
+    - brokerAddress: kafka-0.example.com:9092
+      listenerAddress: 0.0.0.0:32400
+    - brokerAddress: kafka-1.example.com:9092
+      listenerAddress: 0.0.0.0:32401
+      advertisedAddress: proxy.example.com:32401
+  dialAddressMappings:
+    - sourceAddress: kafka-0.example.com:9092
+      destinationAddress: 10.0.0.1:9092
+auth:
+  local:
+    enable: true
+    command: htpasswd
+    parameters:
+      - --file=users.htpasswd
+    timeout: 5s
+kafka:
+  dialTimeout: 1m
+  forbiddenApiKeys: [32, 33]
+metadataTopicFilter:
+  rules:
+    - allow: true
+      principal: alice
+      pattern: orders-*
+`))
+	a.Nil(err)
+	a.Equal([]ListenerConfig{
+		{BrokerAddress: "kafka-0.example.com:9092", ListenerAddress: "0.0.0.0:32400", AdvertisedAddress: "0.0.0.0:32400"},
+		{BrokerAddress: "kafka-1.example.com:9092", ListenerAddress: "0.0.0.0:32401", AdvertisedAddress: "proxy.example.com:32401"},
+	}, c.Proxy.BootstrapServers)
+	a.Equal([]DialAddressMapping{{SourceAddress: "kafka-0.example.com:9092", DestinationAddress: "10.0.0.1:9092"}}, c.Proxy.DialAddressMappings)
+	a.True(c.Auth.Local.Enable)
+	a.Equal("htpasswd", c.Auth.Local.Command)
+	a.Equal([]string{"--file=users.htpasswd"}, c.Auth.Local.Parameters)
+	a.Equal(5*time.Second, c.Auth.Local.Timeout)
+	a.Equal(time.Minute, c.Kafka.DialTimeout)
+	a.Equal([]int{32, 33}, c.Kafka.ForbiddenApiKeys)
+	a.Equal([]TopicFilterRule{{Allow: true, Principal: "alice", Pattern: "orders-*"}}, c.MetadataTopicFilter.Rules)
+
+	// values which are not in the file are kept
+	a.Equal("0.0.0.0", c.Proxy.DefaultListenerIP)
+	a.Equal(30*time.Second, c.Kafka.ReadTimeout)
+}
+
+func TestLoadConfigJSON(t *testing.T) {
+	a := assert.New(t)
+
+	c := NewConfig()
+	err := c.load([]byte(`{"proxy": {"bootstrapServers": [{"brokerAddress": "kafka-0:9092", "listenerAddress": "127.0.0.1:32400"}]}, "kafka": {"maxOpenRequests": 16}}`))
+	a.Nil(err)
+	a.Equal("127.0.0.1:32400", c.Proxy.BootstrapServers[0].ListenerAddress)
+	a.Equal(16, c.Kafka.MaxOpenRequests)
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		content string
+		err     string
+	}{
+		{content: "proxy:\n  bootstrapServer: []\n", err: "'Proxy' has invalid keys: bootstrapServer"},
+		{content: "kafka:\n  dialTimeout: 5 minutes\n", err: "error decoding 'Kafka.DialTimeout': time: "},
+		{content: "kafka:\n  maxOpenRequests: many\n", err: "'Kafka.MaxOpenRequests' expected type 'int', got unconvertible type 'string'"},
+		{content: "proxy:\n  bootstrapServers:\n    - brokerAddress: kafka-0:9092\n", err: "'Proxy.BootstrapServers[0]'"},
+		{content: "proxy:\n  dialAddressMappings:\n    - sourceAddress: kafka-0:9092\n      destinationAddress: kafka-0\n", err: "'Proxy.DialAddressMappings[0]'"},
+		{content: "metadataTopicFilter:\n  rules:\n    - pattern: '['\n      regex: true\n", err: "'MetadataTopicFilter.Rules[0]': error parsing regexp"},
+		{content: "auth:\n  1: true\n", err: "'auth' has a non-string key 1"},
+		{content: "- a\n- b\n", err: "top level must be a mapping"},
+	}
+	for _, tt := range tests {
+		err := NewConfig().load([]byte(tt.content))
+		assert.NotNil(t, err, tt.content)
+		if err != nil {
+			assert.Contains(t, err.Error(), tt.err)
+		}
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	a := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "config-file")
+	a.Nil(err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "proxy.yaml")
+	a.Nil(ioutil.WriteFile(filename, []byte("log:\n  level: debug\n  format: jsn: x\n"), 0600))
+
+	err = NewConfig().LoadFile(filename)
+	a.NotNil(err)
+	a.Contains(err.Error(), "config file "+filename)
+
+	a.Nil(ioutil.WriteFile(filename, []byte("log:\n  level: debug\n"), 0600))
+	c := NewConfig()
+	a.Nil(c.LoadFile(filename))
+	a.Equal("debug", c.Log.Level)
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"reflect"
+)
+
+// ChangedFields returns the names of the fields which differ between the configurations e.g. Kafka.ReadTimeout
+func ChangedFields(previous, current *Config) []string {
+	return changedFields("", reflect.ValueOf(previous).Elem(), reflect.ValueOf(current).Elem())
+}
+
+func changedFields(prefix string, previous reflect.Value, current reflect.Value) []string {
+	changed := make([]string, 0)
+	for i := 0; i < previous.NumField(); i++ {
+		name := joinKey(prefix, previous.Type().Field(i).Name)
+		previousField, currentField := previous.Field(i), current.Field(i)
+		if previousField.Kind() == reflect.Struct {
+			changed = append(changed, changedFields(name, previousField, currentField)...)
+			continue
+		}
+		// nil and empty slices are equal
+		if previousField.Kind() == reflect.Slice && previousField.Len() == 0 && currentField.Len() == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(previousField.Interface(), currentField.Interface()) {
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangedFields(t *testing.T) {
+	a := assert.New(t)
+
+	previous := NewConfig()
+	current := NewConfig()
+	a.Empty(ChangedFields(previous, current))
+
+	current.Kafka.ForbiddenApiKeys = nil
+	a.Empty(ChangedFields(previous, current))
+
+	current.Kafka.ReadTimeout = time.Minute
+	current.Log.Level = "debug"
+	current.Proxy.BootstrapServers = []ListenerConfig{{BrokerAddress: "kafka-0:9092", ListenerAddress: "0.0.0.0:32400", AdvertisedAddress: "0.0.0.0:32400"}}
+	current.Auth.Local.Parameters = []string{"--file=users.htpasswd"}
+	a.Equal([]string{"Log.Level", "Proxy.BootstrapServers", "Auth.Local.Parameters", "Kafka.ReadTimeout"}, ChangedFields(previous, current))
+}
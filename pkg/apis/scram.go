@@ -0,0 +1,18 @@
+package apis
+
+// ScramCredentials are the salted credentials of a SCRAM user (RFC 5802), the password itself is not stored
+type ScramCredentials struct {
+	Salt       []byte
+	Iterations int32
+	StoredKey  []byte
+	ServerKey  []byte
+}
+
+type ScramCredentialStore interface {
+	// GetCredentials returns the credentials of the user for the mechanism SCRAM-SHA-256 or SCRAM-SHA-512. found is false for unknown users
+	GetCredentials(mechanism, username string) (credentials ScramCredentials, found bool, err error)
+}
+
+type ScramCredentialStoreFactory interface {
+	New(params []string) (ScramCredentialStore, error)
+}
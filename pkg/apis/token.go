@@ -6,6 +6,8 @@ import (
 
 type TokenRequest struct {
 	Params []string
+	// ClientToken is the OAUTHBEARER token of the client authenticated by the proxy, it is set when the token is passed to the broker
+	ClientToken string
 }
 type TokenResponse struct {
 	Success bool
@@ -0,0 +1,15 @@
+package apis
+
+type UserCredentials struct {
+	Username string
+	Password string
+}
+
+type UserCredentialsProvider interface {
+	// GetUserCredentials returns the upstream SASL credentials of the locally authenticated principal. found is false for unknown principals
+	GetUserCredentials(principal string) (credentials UserCredentials, found bool, err error)
+}
+
+type UserCredentialsProviderFactory interface {
+	New(params []string) (UserCredentialsProvider, error)
+}
@@ -0,0 +1,45 @@
+package htpasswd
+
+import (
+	"errors"
+	"flag"
+	"github.com/grepplabs/kafka-proxy/pkg/apis"
+	"github.com/grepplabs/kafka-proxy/pkg/libs/util"
+	"github.com/grepplabs/kafka-proxy/pkg/registry"
+)
+
+func init() {
+	registry.NewComponentInterface(new(apis.PasswordAuthenticatorFactory))
+	registry.Register(new(Factory), "htpasswd")
+}
+
+func (f *pluginMeta) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("htpasswd settings", flag.ContinueOnError)
+	return fs
+}
+
+type pluginMeta struct {
+	file   string
+	watch  bool
+	groups util.ArrayFlags
+}
+
+type Factory struct {
+}
+
+// New implements apis.PasswordAuthenticatorFactory
+func (t *Factory) New(params []string) (apis.PasswordAuthenticator, error) {
+	pluginMeta := &pluginMeta{}
+	fs := pluginMeta.flagSet()
+	fs.StringVar(&pluginMeta.file, "file", "", "Path to the htpasswd file with bcrypt, argon2id or SHA-512-crypt hashes")
+	fs.BoolVar(&pluginMeta.watch, "watch", true, "Watch the htpasswd file for reload")
+	fs.Var(&pluginMeta.groups, "group", "Group the user must be member of. If set more than once, one of the groups is required")
+
+	if err := fs.Parse(params); err != nil {
+		return nil, err
+	}
+	if pluginMeta.file == "" {
+		return nil, errors.New("parameter file is required")
+	}
+	return NewPasswordAuthenticator(pluginMeta.file, pluginMeta.groups, pluginMeta.watch)
+}
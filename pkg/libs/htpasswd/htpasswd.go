@@ -0,0 +1,296 @@
+package htpasswd
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/grepplabs/kafka-proxy/pkg/libs/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	StatusOK          = 0
+	StatusUnknownUser = 1
+	StatusWrongPass   = 2
+	StatusWrongGroup  = 3
+	StatusHashError   = 4
+)
+
+const argon2idPrefix = "$argon2id$"
+
+// upper bounds of the argon2id parameters, a single entry with higher values could exhaust the memory on each login attempt
+const (
+	argon2idMaxMemory      = 256 * 1024 // KiB
+	argon2idMaxIterations  = 16
+	argon2idMaxParallelism = 16
+	argon2idMaxKeyLength   = 128
+)
+
+var bcryptPrefixes = []string{"$2a$", "$2b$", "$2y$"}
+
+type user struct {
+	hash   string
+	groups []string
+}
+
+// PasswordAuthenticator verifies the passwords against a htpasswd file with bcrypt, argon2id or SHA-512-crypt hashes.
+// Every non-empty line, which does not start with #, contains the username, the hash and optionally comma separated groups e.g.
+//
+//	alice:$2y$10$...:admins,developers
+//
+// The file is reloaded on change e.g. when a mounted Kubernetes secret is updated.
+type PasswordAuthenticator struct {
+	requiredGroups map[string]struct{}
+
+	users map[string]user
+	// hash of the first user, it is verified for unknown users to keep the response time of existing and unknown users the same
+	dummyHash string
+	l         sync.RWMutex
+}
+
+// NewPasswordAuthenticator creates the authenticator. When required groups are set, the user must be in one of them
+func NewPasswordAuthenticator(filename string, requiredGroups []string, watch bool) (*PasswordAuthenticator, error) {
+	groups := make(map[string]struct{})
+	for _, group := range requiredGroups {
+		groups[group] = struct{}{}
+	}
+	authenticator := &PasswordAuthenticator{requiredGroups: groups}
+	if err := authenticator.load(filename); err != nil {
+		return nil, err
+	}
+	if watch {
+		action := func() {
+			logrus.Infof("reloading htpasswd file %s", filename)
+			if err := authenticator.load(filename); err != nil {
+				logrus.Errorf("error while reloading htpasswd file: %s", err)
+			}
+		}
+		if err := util.WatchForUpdates(filename, make(chan bool, 1), action); err != nil {
+			return nil, errors.Wrap(err, "cannot watch htpasswd file")
+		}
+	}
+	return authenticator, nil
+}
+
+func (a *PasswordAuthenticator) load(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	users, dummyHash, err := readUsers(file)
+	if err != nil {
+		return errors.Wrapf(err, "reading htpasswd file %s", filename)
+	}
+	a.l.Lock()
+	defer a.l.Unlock()
+	a.users = users
+	a.dummyHash = dummyHash
+	return nil
+}
+
+func (a *PasswordAuthenticator) getUser(username string) (user, bool) {
+	u, ok, _ := a.getUserOrDummyHash(username)
+	return u, ok
+}
+
+func (a *PasswordAuthenticator) getUserOrDummyHash(username string) (user, bool, string) {
+	a.l.RLock()
+	defer a.l.RUnlock()
+	u, ok := a.users[username]
+	return u, ok, a.dummyHash
+}
+
+// Authenticate implements apis.PasswordAuthenticator
+func (a *PasswordAuthenticator) Authenticate(username, password string) (bool, int32, error) {
+	u, ok, dummyHash := a.getUserOrDummyHash(username)
+	if !ok {
+		if dummyHash != "" {
+			_, _ = verifyPassword(dummyHash, password)
+		}
+		return false, StatusUnknownUser, nil
+	}
+	ok, err := verifyPassword(u.hash, password)
+	if err != nil {
+		logrus.Errorf("verifying password of user %s failed: %v", username, err)
+		return false, StatusHashError, nil
+	}
+	if !ok {
+		return false, StatusWrongPass, nil
+	}
+	if !a.inRequiredGroup(u.groups) {
+		return false, StatusWrongGroup, nil
+	}
+	return true, StatusOK, nil
+}
+
+// Groups returns the groups of the user
+func (a *PasswordAuthenticator) Groups(username string) []string {
+	u, _ := a.getUser(username)
+	return u.groups
+}
+
+func (a *PasswordAuthenticator) inRequiredGroup(groups []string) bool {
+	if len(a.requiredGroups) == 0 {
+		return true
+	}
+	for _, group := range groups {
+		if _, ok := a.requiredGroups[group]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// readUsers returns the users and the hash of the first user
+func readUsers(reader io.Reader) (map[string]user, string, error) {
+	result := make(map[string]user)
+	firstHash := ""
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, ":")
+		if len(fields) != 2 && len(fields) != 3 {
+			return nil, "", fmt.Errorf("line %d: expected username:hash[:groups]", lineNumber)
+		}
+		username, hash := fields[0], fields[1]
+		if username == "" {
+			return nil, "", fmt.Errorf("line %d: username must not be empty", lineNumber)
+		}
+		if !isSupportedHash(hash) {
+			return nil, "", fmt.Errorf("line %d: hash of user %s must be bcrypt, argon2id or SHA-512-crypt", lineNumber, username)
+		}
+		if strings.HasPrefix(hash, argon2idPrefix) {
+			if _, err := parseArgon2id(hash); err != nil {
+				return nil, "", fmt.Errorf("line %d: hash of user %s: %v", lineNumber, username, err)
+			}
+		}
+		if strings.HasPrefix(hash, sha512CryptPrefix) {
+			rounds, _, _, err := sha512CryptRounds(hash)
+			if err != nil {
+				return nil, "", fmt.Errorf("line %d: hash of user %s: %v", lineNumber, username, err)
+			}
+			if rounds > sha512CryptMaxAcceptedRounds {
+				return nil, "", fmt.Errorf("line %d: hash of user %s: SHA-512-crypt rounds exceed the limit %d", lineNumber, username, sha512CryptMaxAcceptedRounds)
+			}
+		}
+		if _, ok := result[username]; ok {
+			return nil, "", fmt.Errorf("line %d: user %s configured twice", lineNumber, username)
+		}
+		u := user{hash: hash}
+		if len(fields) == 3 && fields[2] != "" {
+			for _, group := range strings.Split(fields[2], ",") {
+				if group = strings.TrimSpace(group); group != "" {
+					u.groups = append(u.groups, group)
+				}
+			}
+		}
+		result[username] = u
+		if firstHash == "" {
+			firstHash = hash
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, "", err
+	}
+	return result, firstHash, nil
+}
+
+func isBcrypt(hash string) bool {
+	for _, prefix := range bcryptPrefixes {
+		if strings.HasPrefix(hash, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSupportedHash(hash string) bool {
+	return isBcrypt(hash) || strings.HasPrefix(hash, argon2idPrefix) || strings.HasPrefix(hash, sha512CryptPrefix)
+}
+
+func verifyPassword(hash, password string) (bool, error) {
+	switch {
+	case isBcrypt(hash):
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			return false, nil
+		}
+		return err == nil, err
+	case strings.HasPrefix(hash, argon2idPrefix):
+		return verifyArgon2id(hash, password)
+	case strings.HasPrefix(hash, sha512CryptPrefix):
+		expected, err := sha512Crypt(password, hash)
+		if err != nil {
+			return false, err
+		}
+		return subtle.ConstantTimeCompare([]byte(expected), []byte(hash)) == 1, nil
+	default:
+		return false, errors.New("unsupported hash")
+	}
+}
+
+type argon2idParams struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+// parseArgon2id parses the PHC string format e.g. $argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>
+func parseArgon2id(hash string) (argon2idParams, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return argon2idParams{}, errors.New("invalid argon2id hash format")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return argon2idParams{}, errors.Wrap(err, "invalid argon2id version")
+	}
+	if version != argon2.Version {
+		return argon2idParams{}, fmt.Errorf("unsupported argon2id version %d", version)
+	}
+	var params argon2idParams
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism); err != nil {
+		return argon2idParams{}, errors.Wrap(err, "invalid argon2id parameters")
+	}
+	var err error
+	if params.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return argon2idParams{}, errors.Wrap(err, "invalid argon2id salt")
+	}
+	if params.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return argon2idParams{}, errors.Wrap(err, "invalid argon2id key")
+	}
+	if params.iterations == 0 || params.parallelism == 0 || len(params.key) == 0 {
+		return argon2idParams{}, errors.New("invalid argon2id parameters")
+	}
+	if params.memory > argon2idMaxMemory || params.iterations > argon2idMaxIterations || params.parallelism > argon2idMaxParallelism || len(params.key) > argon2idMaxKeyLength {
+		return argon2idParams{}, fmt.Errorf("argon2id parameters exceed the limits m=%d,t=%d,p=%d and key length %d", argon2idMaxMemory, argon2idMaxIterations, argon2idMaxParallelism, argon2idMaxKeyLength)
+	}
+	return params, nil
+}
+
+func verifyArgon2id(hash, password string) (bool, error) {
+	params, err := parseArgon2id(hash)
+	if err != nil {
+		return false, err
+	}
+	actual := argon2.IDKey([]byte(password), params.salt, params.iterations, params.memory, params.parallelism, uint32(len(params.key)))
+	return subtle.ConstantTimeCompare(actual, params.key) == 1, nil
+}
@@ -0,0 +1,206 @@
+package htpasswd
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func argon2idHash(password string, salt []byte) string {
+	key := argon2.IDKey([]byte(password), salt, 1, 1024, 1, 32)
+	return fmt.Sprintf("$argon2id$v=%d$m=1024,t=1,p=1$%s$%s", argon2.Version,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+func TestSha512Crypt(t *testing.T) {
+	tests := []struct {
+		setting  string
+		password string
+		expected string
+	}{
+		{setting: "$6$saltstring", password: "Hello world!", expected: "$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1"},
+		{setting: "$6$rounds=10000$saltstringsaltstring", password: "Hello world!", expected: "$6$rounds=10000$saltstringsaltst$OW1/O6BYHV6BcXZu8QVeXbDWra3Oeqh0sbHbbMCVNSnCM/UrjmM0Dp8vOuZeHBy/YTBmSK6H9qs/y3RnOaw5v."},
+		{setting: "$6$rounds=10$roundstoolow", password: "the minimum number is still observed", expected: "$6$rounds=1000$roundstoolow$kUMsbe306n21p9R.FRkW3IGn.S9NPN0x50YhH1xhLsPuWGsUSklZt58jaTfF4ZEQpyUNGc0dqbpBYYBaHHrsX."},
+	}
+	for _, tt := range tests {
+		actual, err := sha512Crypt(tt.password, tt.setting)
+		assert.Nil(t, err)
+		assert.Equal(t, tt.expected, actual)
+	}
+}
+
+func TestReadUsers(t *testing.T) {
+	a := assert.New(t)
+
+	content := "# users\n\nalice:$2y$05$abc\n  bob:$6$salt$hash:admins, developers \ncarol:$argon2id$v=19$m=1024,t=1,p=1$c2FsdA$a2V5:\n"
+	users, firstHash, err := readUsers(strings.NewReader(content))
+	a.Nil(err)
+	a.Equal("$2y$05$abc", firstHash)
+	a.Len(users, 3)
+	a.Equal(user{hash: "$2y$05$abc"}, users["alice"])
+	a.Equal(user{hash: "$6$salt$hash", groups: []string{"admins", "developers"}}, users["bob"])
+	a.Equal(user{hash: "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA$a2V5"}, users["carol"])
+}
+
+func TestReadUsersErrors(t *testing.T) {
+	tests := []struct {
+		content string
+		err     string
+	}{
+		{content: "alice", err: "line 1: expected username:hash[:groups]"},
+		{content: "alice:$6$salt$hash:admins:extra", err: "line 1: expected username:hash[:groups]"},
+		{content: ":$6$salt$hash", err: "line 1: username must not be empty"},
+		{content: "# comment\nalice:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=", err: "line 2: hash of user alice must be bcrypt, argon2id or SHA-512-crypt"},
+		{content: "alice:$1$salt$hash", err: "line 1: hash of user alice must be bcrypt, argon2id or SHA-512-crypt"},
+		{content: "alice:$6$salt$hash\nalice:$6$salt$hash", err: "line 2: user alice configured twice"},
+		{content: "alice:$argon2id$v=19$m=4194304,t=1,p=1$c2FsdA$a2V5", err: "line 1: hash of user alice: argon2id parameters exceed the limits m=262144,t=16,p=16 and key length 128"},
+		{content: "alice:$argon2id$v=19$m=1024,t=1,p=255$c2FsdA$a2V5", err: "line 1: hash of user alice: argon2id parameters exceed the limits m=262144,t=16,p=16 and key length 128"},
+		{content: "alice:$argon2id$v=19$m=1024,t=0,p=1$c2FsdA$a2V5", err: "line 1: hash of user alice: invalid argon2id parameters"},
+		{content: "alice:$6$rounds=1000001$salt$hash", err: "line 1: hash of user alice: SHA-512-crypt rounds exceed the limit 1000000"},
+		{content: "alice:$6$rounds=abc$salt$hash", err: "line 1: hash of user alice: invalid SHA-512-crypt rounds: strconv.ParseUint: parsing \"abc\": invalid syntax"},
+	}
+	for _, tt := range tests {
+		_, _, err := readUsers(strings.NewReader(tt.content))
+		assert.NotNil(t, err)
+		if err != nil {
+			assert.Equal(t, tt.err, err.Error())
+		}
+	}
+}
+
+func TestVerifyPassword(t *testing.T) {
+	a := assert.New(t)
+
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("alice-secret"), bcrypt.MinCost)
+	a.Nil(err)
+	sha512CryptHash, err := sha512Crypt("bob-secret", "$6$bobsalt")
+	a.Nil(err)
+	argon2Hash := argon2idHash("carol-secret", []byte("carol-salt"))
+
+	tests := []struct {
+		hash     string
+		password string
+		ok       bool
+	}{
+		{hash: string(bcryptHash), password: "alice-secret", ok: true},
+		{hash: string(bcryptHash), password: "bob-secret", ok: false},
+		{hash: sha512CryptHash, password: "bob-secret", ok: true},
+		{hash: sha512CryptHash, password: "alice-secret", ok: false},
+		{hash: argon2Hash, password: "carol-secret", ok: true},
+		{hash: argon2Hash, password: "alice-secret", ok: false},
+	}
+	for _, tt := range tests {
+		ok, err := verifyPassword(tt.hash, tt.password)
+		a.Nil(err)
+		a.Equal(tt.ok, ok, tt.hash)
+	}
+
+	_, err = verifyPassword("$argon2id$v=16$m=1024,t=1,p=1$c2FsdA$a2V5", "secret")
+	a.EqualError(err, "unsupported argon2id version 16")
+	_, err = verifyPassword("$argon2id$v=19$m=1024,t=0,p=1$c2FsdA$a2V5", "secret")
+	a.EqualError(err, "invalid argon2id parameters")
+	_, err = verifyPassword("$argon2id$v=19$m=4194304,t=1,p=1$c2FsdA$a2V5", "secret")
+	a.NotNil(err)
+	_, err = verifyPassword("$2y$05$abc", "secret")
+	a.NotNil(err)
+}
+
+func TestPasswordAuthenticator(t *testing.T) {
+	a := assert.New(t)
+
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("alice-secret"), bcrypt.MinCost)
+	a.Nil(err)
+	sha512CryptHash, err := sha512Crypt("bob-secret", "$6$bobsalt")
+	a.Nil(err)
+
+	file, err := ioutil.TempFile("", "htpasswd")
+	a.Nil(err)
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(fmt.Sprintf("alice:%s:admins,developers\nbob:%s\ncarol:$2y$05$abc:admins\n", bcryptHash, sha512CryptHash))
+	a.Nil(err)
+	a.Nil(file.Close())
+
+	authenticator, err := NewPasswordAuthenticator(file.Name(), nil, false)
+	a.Nil(err)
+	// unknown users are verified against the hash of the first user
+	a.Equal(string(bcryptHash), authenticator.dummyHash)
+
+	tests := []struct {
+		username string
+		password string
+		ok       bool
+		status   int32
+	}{
+		{username: "alice", password: "alice-secret", ok: true, status: StatusOK},
+		{username: "bob", password: "bob-secret", ok: true, status: StatusOK},
+		{username: "alice", password: "bob-secret", ok: false, status: StatusWrongPass},
+		{username: "dave", password: "alice-secret", ok: false, status: StatusUnknownUser},
+		{username: "carol", password: "carol-secret", ok: false, status: StatusHashError},
+	}
+	for _, tt := range tests {
+		ok, status, err := authenticator.Authenticate(tt.username, tt.password)
+		a.Nil(err)
+		a.Equal(tt.ok, ok, tt.username)
+		a.Equal(tt.status, status, tt.username)
+	}
+	a.Equal([]string{"admins", "developers"}, authenticator.Groups("alice"))
+	a.Empty(authenticator.Groups("bob"))
+	a.Empty(authenticator.Groups("dave"))
+
+	authenticator, err = NewPasswordAuthenticator(file.Name(), []string{"developers", "operators"}, false)
+	a.Nil(err)
+	ok, status, err := authenticator.Authenticate("alice", "alice-secret")
+	a.Nil(err)
+	a.True(ok)
+	a.Equal(int32(StatusOK), status)
+	ok, status, err = authenticator.Authenticate("bob", "bob-secret")
+	a.Nil(err)
+	a.False(ok)
+	a.Equal(int32(StatusWrongGroup), status)
+}
+
+func TestPasswordAuthenticatorReload(t *testing.T) {
+	a := assert.New(t)
+
+	file, err := ioutil.TempFile("", "htpasswd")
+	a.Nil(err)
+	defer os.Remove(file.Name())
+	sha512CryptHash, err := sha512Crypt("alice-secret", "$6$alicesalt")
+	a.Nil(err)
+	a.Nil(ioutil.WriteFile(file.Name(), []byte("alice:"+sha512CryptHash), 0600))
+
+	authenticator, err := NewPasswordAuthenticator(file.Name(), nil, false)
+	a.Nil(err)
+	ok, _, _ := authenticator.Authenticate("alice", "alice-secret")
+	a.True(ok)
+
+	rotatedHash, err := sha512Crypt("alice-rotated", "$6$alicesalt")
+	a.Nil(err)
+	a.Nil(ioutil.WriteFile(file.Name(), []byte("alice:"+rotatedHash), 0600))
+	a.Nil(authenticator.load(file.Name()))
+	ok, _, _ = authenticator.Authenticate("alice", "alice-secret")
+	a.False(ok)
+	ok, _, _ = authenticator.Authenticate("alice", "alice-rotated")
+	a.True(ok)
+
+	// invalid content keeps the previous users
+	a.Nil(ioutil.WriteFile(file.Name(), []byte("alice"), 0600))
+	a.NotNil(authenticator.load(file.Name()))
+	ok, _, _ = authenticator.Authenticate("alice", "alice-rotated")
+	a.True(ok)
+}
+
+func TestFactory(t *testing.T) {
+	a := assert.New(t)
+
+	_, err := new(Factory).New([]string{})
+	a.EqualError(err, "parameter file is required")
+	_, err = new(Factory).New([]string{"--file", "/not/existing/htpasswd", "--watch=false"})
+	a.NotNil(err)
+}
@@ -0,0 +1,165 @@
+package htpasswd
+
+import (
+	"crypto/sha512"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SHA-512-crypt as specified in https://www.akkadia.org/drepper/SHA-crypt.txt
+
+const (
+	sha512CryptPrefix        = "$6$"
+	sha512CryptRoundsPrefix  = "rounds="
+	sha512CryptDefaultRounds = 5000
+	sha512CryptMinRounds     = 1000
+	sha512CryptMaxRounds     = 999999999
+	// hashes with more rounds are rejected, every login would take seconds
+	sha512CryptMaxAcceptedRounds = 1000000
+	sha512CryptMaxSaltLength     = 16
+
+	cryptAlphabet = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+// sha512CryptPermutation is the order in which the bytes of the final digest are encoded
+var sha512CryptPermutation = [][3]int{
+	{0, 21, 42}, {22, 43, 1}, {44, 2, 23}, {3, 24, 45}, {25, 46, 4}, {47, 5, 26}, {6, 27, 48},
+	{28, 49, 7}, {50, 8, 29}, {9, 30, 51}, {31, 52, 10}, {53, 11, 32}, {12, 33, 54}, {34, 55, 13},
+	{56, 14, 35}, {15, 36, 57}, {37, 58, 16}, {59, 17, 38}, {18, 39, 60}, {40, 61, 19}, {62, 20, 41},
+}
+
+// sha512CryptRounds returns the rounds of the setting or hash and the rest after the rounds. The rounds are
+// adjusted to the range allowed by the specification.
+func sha512CryptRounds(setting string) (rounds int, customRounds bool, rest string, err error) {
+	rest = setting[len(sha512CryptPrefix):]
+	if !strings.HasPrefix(rest, sha512CryptRoundsPrefix) {
+		return sha512CryptDefaultRounds, false, rest, nil
+	}
+	end := strings.IndexByte(rest, '$')
+	if end == -1 {
+		return 0, false, "", fmt.Errorf("invalid SHA-512-crypt rounds")
+	}
+	value, err := strconv.ParseUint(rest[len(sha512CryptRoundsPrefix):end], 10, 32)
+	if err != nil {
+		return 0, false, "", fmt.Errorf("invalid SHA-512-crypt rounds: %v", err)
+	}
+	rounds = int(value)
+	if rounds < sha512CryptMinRounds {
+		rounds = sha512CryptMinRounds
+	}
+	if rounds > sha512CryptMaxRounds {
+		rounds = sha512CryptMaxRounds
+	}
+	return rounds, true, rest[end+1:], nil
+}
+
+// sha512Crypt hashes the password with the salt and rounds of the setting e.g. $6$rounds=10000$salt or a complete hash
+func sha512Crypt(password, setting string) (string, error) {
+	if !strings.HasPrefix(setting, sha512CryptPrefix) {
+		return "", fmt.Errorf("SHA-512-crypt hash must start with %s", sha512CryptPrefix)
+	}
+	rounds, customRounds, rest, err := sha512CryptRounds(setting)
+	if err != nil {
+		return "", err
+	}
+	salt := rest
+	if end := strings.IndexByte(salt, '$'); end != -1 {
+		salt = salt[:end]
+	}
+	if len(salt) > sha512CryptMaxSaltLength {
+		salt = salt[:sha512CryptMaxSaltLength]
+	}
+	digest := sha512CryptDigest([]byte(password), []byte(salt), rounds)
+
+	var result strings.Builder
+	result.WriteString(sha512CryptPrefix)
+	if customRounds {
+		result.WriteString(sha512CryptRoundsPrefix)
+		result.WriteString(strconv.Itoa(rounds))
+		result.WriteByte('$')
+	}
+	result.WriteString(salt)
+	result.WriteByte('$')
+	for _, p := range sha512CryptPermutation {
+		encode24Bit(&result, digest[p[0]], digest[p[1]], digest[p[2]], 4)
+	}
+	encode24Bit(&result, 0, 0, digest[63], 2)
+	return result.String(), nil
+}
+
+func sha512CryptDigest(password, salt []byte, rounds int) []byte {
+	b := sha512.New()
+	b.Write(password)
+	b.Write(salt)
+	b.Write(password)
+	digestB := b.Sum(nil)
+
+	a := sha512.New()
+	a.Write(password)
+	a.Write(salt)
+	i := len(password)
+	for ; i > 64; i -= 64 {
+		a.Write(digestB)
+	}
+	a.Write(digestB[:i])
+	for i = len(password); i > 0; i >>= 1 {
+		if i&1 != 0 {
+			a.Write(digestB)
+		} else {
+			a.Write(password)
+		}
+	}
+	digestA := a.Sum(nil)
+
+	dp := sha512.New()
+	for i = 0; i < len(password); i++ {
+		dp.Write(password)
+	}
+	p := repeatDigest(dp.Sum(nil), len(password))
+
+	ds := sha512.New()
+	for i = 0; i < 16+int(digestA[0]); i++ {
+		ds.Write(salt)
+	}
+	s := repeatDigest(ds.Sum(nil), len(salt))
+
+	c := digestA
+	for i = 0; i < rounds; i++ {
+		h := sha512.New()
+		if i&1 != 0 {
+			h.Write(p)
+		} else {
+			h.Write(c)
+		}
+		if i%3 != 0 {
+			h.Write(s)
+		}
+		if i%7 != 0 {
+			h.Write(p)
+		}
+		if i&1 != 0 {
+			h.Write(c)
+		} else {
+			h.Write(p)
+		}
+		c = h.Sum(nil)
+	}
+	return c
+}
+
+func repeatDigest(digest []byte, length int) []byte {
+	result := make([]byte, 0, length)
+	for len(result)+len(digest) <= length {
+		result = append(result, digest...)
+	}
+	return append(result, digest[:length-len(result)]...)
+}
+
+func encode24Bit(sb *strings.Builder, b2, b1, b0 byte, n int) {
+	w := uint(b2)<<16 | uint(b1)<<8 | uint(b0)
+	for ; n > 0; n-- {
+		sb.WriteByte(cryptAlphabet[w&0x3f])
+		w >>= 6
+	}
+}
@@ -0,0 +1,66 @@
+package oidcinfo
+
+import (
+	"flag"
+	"github.com/grepplabs/kafka-proxy/pkg/apis"
+	"github.com/grepplabs/kafka-proxy/pkg/libs/util"
+	"github.com/grepplabs/kafka-proxy/pkg/registry"
+)
+
+func init() {
+	registry.NewComponentInterface(new(apis.TokenInfoFactory))
+	registry.Register(new(Factory), "oidc-info")
+}
+
+func (f *pluginMeta) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("oidc info settings", flag.ContinueOnError)
+	return fs
+}
+
+type pluginMeta struct {
+	timeout                int
+	issuer                 string
+	skipIssuerCheck        bool
+	jwksURL                string
+	jwksFile               string
+	jwksRefreshInterval    int
+	jwksMinRefreshInterval int
+	audience               util.ArrayFlags
+	requiredClaims         util.ArrayFlags
+}
+
+type Factory struct {
+}
+
+// New implements apis.TokenInfoFactory
+func (t *Factory) New(params []string) (apis.TokenInfo, error) {
+	pluginMeta := &pluginMeta{}
+	fs := pluginMeta.flagSet()
+	fs.IntVar(&pluginMeta.timeout, "timeout", 10, "Request timeout in seconds")
+	fs.StringVar(&pluginMeta.issuer, "issuer", "", "Issuer URL. The jwks_uri is discovered from its openid-configuration, if jwks-url and jwks-file are not set")
+	fs.BoolVar(&pluginMeta.skipIssuerCheck, "skip-issuer-check", false, "Accept tokens of any issuer signed by the jwks-url or jwks-file keys. Keys shared by other applications or tenants are accepted")
+	fs.StringVar(&pluginMeta.jwksURL, "jwks-url", "", "URL of the JSON Web Key Set")
+	fs.StringVar(&pluginMeta.jwksFile, "jwks-file", "", "Location of the static JSON Web Key Set file, it is reloaded on change")
+	fs.IntVar(&pluginMeta.jwksRefreshInterval, "jwks-refresh-interval", 60*60, "JSON Web Key Set refresh interval in seconds")
+	fs.IntVar(&pluginMeta.jwksMinRefreshInterval, "jwks-min-refresh-interval", 60, "Minimum interval in seconds between refreshes triggered by an unknown key id")
+	fs.Var(&pluginMeta.audience, "audience", "The audience of a token, at least one audience is required")
+	fs.Var(&pluginMeta.requiredClaims, "required-claim", "Claim required in a token in form 'name' or 'name=value'. Array claims e.g. groups must contain the value")
+
+	if err := fs.Parse(params); err != nil {
+		return nil, err
+	}
+
+	opts := TokenInfoOptions{
+		Timeout:                pluginMeta.timeout,
+		Issuer:                 pluginMeta.issuer,
+		SkipIssuerCheck:        pluginMeta.skipIssuerCheck,
+		JWKSURL:                pluginMeta.jwksURL,
+		JWKSFile:               pluginMeta.jwksFile,
+		JWKSRefreshInterval:    pluginMeta.jwksRefreshInterval,
+		JWKSMinRefreshInterval: pluginMeta.jwksMinRefreshInterval,
+		Audience:               pluginMeta.audience,
+		RequiredClaims:         pluginMeta.requiredClaims,
+	}
+
+	return NewTokenInfo(opts)
+}
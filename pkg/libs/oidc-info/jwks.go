@@ -0,0 +1,176 @@
+package oidcinfo
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/context/ctxhttp"
+)
+
+const wellKnownConfiguration = "/.well-known/openid-configuration"
+
+// JSONWebKeySet https://tools.ietf.org/html/rfc7517#section-5
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JSONWebKey https://tools.ietf.org/html/rfc7517#section-4 with RSA, EC (https://tools.ietf.org/html/rfc7518#section-6)
+// and OKP (https://tools.ietf.org/html/rfc8037#section-2) public key parameters
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type publicKey struct {
+	alg string
+	key crypto.PublicKey
+}
+
+// GetPublicKey returns *rsa.PublicKey, *ecdsa.PublicKey or ed25519.PublicKey
+func (k *JSONWebKey) GetPublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid RSA exponent of key %s", k.Kid)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported EC curve %s of key %s", k.Crv, k.Kid)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("invalid EC point of key %s", k.Kid)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported OKP curve %s of key %s", k.Crv, k.Kid)
+		}
+		x, err := decodeBase64(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key %s", k.Kid)
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s of key %s", k.Kty, k.Kid)
+	}
+}
+
+// ParseJSONWebKeySet returns the signature verification keys by key id. Encryption keys and keys of unsupported types are skipped.
+func ParseJSONWebKeySet(data []byte) (map[string]publicKey, error) {
+	keySet := &JSONWebKeySet{}
+	if err := json.Unmarshal(data, keySet); err != nil {
+		return nil, err
+	}
+	publicKeys := make(map[string]publicKey)
+	for _, key := range keySet.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		pub, err := key.GetPublicKey()
+		if err != nil {
+			continue
+		}
+		publicKeys[key.Kid] = publicKey{alg: key.Alg, key: pub}
+	}
+	if len(publicKeys) == 0 {
+		return nil, fmt.Errorf("no signature verification keys found")
+	}
+	return publicKeys, nil
+}
+
+// discoverJWKSURI returns jwks_uri of the OpenID Provider Configuration
+func discoverJWKSURI(ctx context.Context, client *http.Client, issuer string) (string, error) {
+	body, err := httpGet(ctx, client, strings.TrimSuffix(issuer, "/")+wellKnownConfiguration)
+	if err != nil {
+		return "", err
+	}
+	configuration := struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}{}
+	if err = json.Unmarshal(body, &configuration); err != nil {
+		return "", err
+	}
+	if configuration.Issuer != issuer {
+		return "", fmt.Errorf("issuer %s of the openid configuration does not match %s", configuration.Issuer, issuer)
+	}
+	if configuration.JWKSURI == "" {
+		return "", fmt.Errorf("jwks_uri is missing in the openid configuration of %s", issuer)
+	}
+	return configuration.JWKSURI, nil
+}
+
+func httpGet(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	resp, err := ctxhttp.Get(ctx, client, url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if c := resp.StatusCode; c < 200 || c > 299 {
+		return nil, fmt.Errorf("cannot fetch %s: %v\nResponse: %s", url, resp.Status, body)
+	}
+	return body, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := decodeBase64(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func decodeBase64(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
@@ -0,0 +1,182 @@
+package oidcinfo
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+)
+
+// Header represents JOSE header
+type Header struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid,omitempty"`
+	Typ       string `json:"typ,omitempty"`
+}
+
+// Audience is a single string or an array of strings https://tools.ietf.org/html/rfc7519#section-4.1.3
+type Audience []string
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*a = multiple
+	return nil
+}
+
+// ClaimSet represents registered claims, other claims are available in Claims
+type ClaimSet struct {
+	Iss string   `json:"iss"`
+	Sub string   `json:"sub,omitempty"`
+	Aud Audience `json:"aud,omitempty"`
+	Exp int64    `json:"exp"`
+	Nbf int64    `json:"nbf,omitempty"`
+	Iat int64    `json:"iat,omitempty"`
+}
+
+type Token struct {
+	Raw      string
+	Header   *Header
+	ClaimSet *ClaimSet
+	Claims   map[string]interface{}
+
+	signingInput string
+	signature    []byte
+}
+
+func ParseJWT(token string) (*Token, error) {
+	args := strings.Split(token, ".")
+	if len(args) != 3 {
+		return nil, ErrInvalidToken
+	}
+	decodedHeader, err := decodeBase64(args[0])
+	if err != nil {
+		return nil, err
+	}
+	decodedPayload, err := decodeBase64(args[1])
+	if err != nil {
+		return nil, err
+	}
+	signature, err := decodeBase64(args[2])
+	if err != nil {
+		return nil, err
+	}
+
+	header := &Header{}
+	if err = json.Unmarshal(decodedHeader, header); err != nil {
+		return nil, err
+	}
+	claimSet := &ClaimSet{}
+	if err = json.Unmarshal(decodedPayload, claimSet); err != nil {
+		return nil, err
+	}
+	claims := make(map[string]interface{})
+	decoder := json.NewDecoder(bytes.NewBuffer(decodedPayload))
+	decoder.UseNumber()
+	if err = decoder.Decode(&claims); err != nil {
+		return nil, err
+	}
+	return &Token{
+		Raw:          token,
+		Header:       header,
+		ClaimSet:     claimSet,
+		Claims:       claims,
+		signingInput: args[0] + "." + args[1],
+		signature:    signature,
+	}, nil
+}
+
+// signatureAlgorithms are the supported asymmetric algorithms https://tools.ietf.org/html/rfc7518#section-3.1 and https://tools.ietf.org/html/rfc8037#section-3.1
+var signatureAlgorithms = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"PS256": crypto.SHA256,
+	"PS384": crypto.SHA384,
+	"PS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+	"ES512": crypto.SHA512,
+	"EdDSA": 0,
+}
+
+// ecdsaCurveBits are the curve sizes of the ECDSA algorithms
+var ecdsaCurveBits = map[string]int{
+	"ES256": 256,
+	"ES384": 384,
+	"ES512": 521,
+}
+
+func isSupportedAlgorithm(alg string) bool {
+	_, ok := signatureAlgorithms[alg]
+	return ok
+}
+
+// VerifySignature verifies the token signature with the public key
+func (t *Token) VerifySignature(key crypto.PublicKey) error {
+	alg := t.Header.Algorithm
+	hash, ok := signatureAlgorithms[alg]
+	if !ok {
+		return fmt.Errorf("unsupported algorithm %s", alg)
+	}
+	var digest []byte
+	if hash != 0 {
+		h := hash.New()
+		h.Write([]byte(t.signingInput))
+		digest = h.Sum(nil)
+	}
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		switch alg[:2] {
+		case "RS":
+			return rsa.VerifyPKCS1v15(pub, hash, digest, t.signature)
+		case "PS":
+			return rsa.VerifyPSS(pub, hash, digest, t.signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
+	case *ecdsa.PublicKey:
+		if alg[:2] != "ES" {
+			break
+		}
+		if pub.Curve.Params().BitSize != ecdsaCurveBits[alg] {
+			return fmt.Errorf("algorithm %s does not match the curve %s", alg, pub.Curve.Params().Name)
+		}
+		keySize := (pub.Curve.Params().BitSize + 7) / 8
+		if len(t.signature) != 2*keySize {
+			return errors.New("invalid signature length")
+		}
+		r := new(big.Int).SetBytes(t.signature[:keySize])
+		s := new(big.Int).SetBytes(t.signature[keySize:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	case ed25519.PublicKey:
+		if alg != "EdDSA" {
+			break
+		}
+		if !ed25519.Verify(pub, []byte(t.signingInput), t.signature) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("algorithm %s does not match the key type %T", alg, key)
+}
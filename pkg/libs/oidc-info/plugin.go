@@ -0,0 +1,347 @@
+package oidcinfo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/grepplabs/kafka-proxy/pkg/apis"
+	"github.com/grepplabs/kafka-proxy/pkg/libs/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	StatusOK                      = 0
+	StatusEmptyToken              = 1
+	StatusParseJWTFailed          = 2
+	StatusUnsupportedAlgorithm    = 3
+	StatusPublicKeyNotFound       = 4
+	StatusWrongSignature          = 5
+	StatusWrongIssuer             = 6
+	StatusNoExpirationTimeInToken = 7
+	StatusTokenExpired            = 8
+	StatusTokenTooEarly           = 9
+	StatusWrongAudience           = 10
+	StatusMissingClaim            = 11
+	StatusWrongClaim              = 12
+)
+
+var (
+	clockSkew = 1 * time.Minute
+	nowFn     = time.Now
+)
+
+type TokenInfoOptions struct {
+	Timeout int
+
+	Issuer string
+	// accept tokens of any issuer signed by the jwks-url or jwks-file keys
+	SkipIssuerCheck        bool
+	JWKSURL                string
+	JWKSFile               string
+	JWKSRefreshInterval    int
+	JWKSMinRefreshInterval int
+
+	Audience       []string
+	RequiredClaims []string
+}
+
+// requiredClaim is a claim name with an optional expected value
+type requiredClaim struct {
+	name  string
+	value *string
+}
+
+type TokenInfo struct {
+	timeout            time.Duration
+	issuer             string
+	jwksURL            string
+	jwksFile           string
+	minRefreshInterval time.Duration
+	audience           map[string]struct{}
+	requiredClaims     []requiredClaim
+	httpClient         *http.Client
+
+	publicKeys  map[string]publicKey
+	lastRefresh time.Time
+	l           sync.RWMutex
+	refreshLock sync.Mutex
+}
+
+func NewTokenInfo(options TokenInfoOptions) (*TokenInfo, error) {
+	if options.Issuer == "" && options.JWKSURL == "" && options.JWKSFile == "" {
+		return nil, errors.New("parameter issuer, jwks-url or jwks-file is required")
+	}
+	if options.JWKSURL != "" && options.JWKSFile != "" {
+		return nil, errors.New("parameters jwks-url and jwks-file are mutually exclusive")
+	}
+	// signing keys can be shared by other applications or tenants of the provider e.g. Azure AD
+	if options.Issuer == "" && !options.SkipIssuerCheck {
+		return nil, errors.New("parameter issuer is required, skip-issuer-check accepts tokens of any issuer")
+	}
+	if len(options.Audience) == 0 {
+		return nil, errors.New("parameter audience is required")
+	}
+	requiredClaims := make([]requiredClaim, 0)
+	for _, claim := range options.RequiredClaims {
+		pair := strings.SplitN(claim, "=", 2)
+		if pair[0] == "" {
+			return nil, fmt.Errorf("required claim must be in form 'name' or 'name=value', got '%s'", claim)
+		}
+		if len(pair) == 2 {
+			requiredClaims = append(requiredClaims, requiredClaim{name: pair[0], value: &pair[1]})
+		} else {
+			requiredClaims = append(requiredClaims, requiredClaim{name: pair[0]})
+		}
+	}
+	logrus.Infof("JWT issuer: %s", options.Issuer)
+	logrus.Infof("JWT target audience: %v", options.Audience)
+	logrus.Infof("JWT required claims: %v", options.RequiredClaims)
+
+	audience := make(map[string]struct{})
+	for _, elem := range options.Audience {
+		audience[elem] = struct{}{}
+	}
+
+	timeout := time.Duration(options.Timeout) * time.Second
+	tokenInfo := &TokenInfo{
+		timeout:            timeout,
+		issuer:             options.Issuer,
+		jwksURL:            options.JWKSURL,
+		jwksFile:           options.JWKSFile,
+		minRefreshInterval: time.Duration(options.JWKSMinRefreshInterval) * time.Second,
+		audience:           audience,
+		requiredClaims:     requiredClaims,
+		httpClient:         &http.Client{Timeout: timeout},
+	}
+
+	if options.JWKSFile != "" {
+		if err := tokenInfo.refreshKeys(); err != nil {
+			return nil, errors.Wrapf(err, "loading of jwks file %s failed", options.JWKSFile)
+		}
+		action := func() {
+			logrus.Infof("reloading jwks file %s", options.JWKSFile)
+			if err := tokenInfo.refreshKeys(); err != nil {
+				logrus.Errorf("error while reloading jwks file: %s", err)
+			}
+		}
+		if err := util.WatchForUpdates(options.JWKSFile, make(chan bool, 1), action); err != nil {
+			return nil, errors.Wrap(err, "cannot watch jwks file")
+		}
+		return tokenInfo, nil
+	}
+
+	op := func() error {
+		return tokenInfo.refreshKeys()
+	}
+	err := backoff.Retry(op, backoff.WithMaxTries(backoff.NewConstantBackOff(1*time.Second), 3))
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting of jwks failed")
+	}
+	keysRefresher := newKeysRefresher(tokenInfo, make(chan struct{}, 1), time.Duration(options.JWKSRefreshInterval)*time.Second)
+	go keysRefresher.refreshLoop()
+	return tokenInfo, nil
+}
+
+func (p *TokenInfo) getPublicKey(kid string) (publicKey, bool) {
+	p.l.RLock()
+	defer p.l.RUnlock()
+
+	key, ok := p.publicKeys[kid]
+	return key, ok
+}
+
+func (p *TokenInfo) getPublicKeyIDs() []string {
+	p.l.RLock()
+	defer p.l.RUnlock()
+	kids := make([]string, 0)
+	for kid := range p.publicKeys {
+		kids = append(kids, kid)
+	}
+	return kids
+}
+
+func (p *TokenInfo) setPublicKeys(publicKeys map[string]publicKey) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	p.publicKeys = publicKeys
+}
+
+func (p *TokenInfo) getLastRefresh() time.Time {
+	p.l.RLock()
+	defer p.l.RUnlock()
+
+	return p.lastRefresh
+}
+
+func (p *TokenInfo) setLastRefresh(lastRefresh time.Time) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	p.lastRefresh = lastRefresh
+}
+
+func (p *TokenInfo) refreshKeys() error {
+	p.refreshLock.Lock()
+	defer p.refreshLock.Unlock()
+
+	return p.loadKeys()
+}
+
+func (p *TokenInfo) loadKeys() error {
+	// also failed refreshes are throttled
+	p.setLastRefresh(nowFn())
+
+	var data []byte
+	var err error
+	if p.jwksFile != "" {
+		data, err = ioutil.ReadFile(p.jwksFile)
+	} else {
+		data, err = p.fetchKeys()
+	}
+	if err != nil {
+		return err
+	}
+	publicKeys, err := ParseJSONWebKeySet(data)
+	if err != nil {
+		return err
+	}
+	p.setPublicKeys(publicKeys)
+	return nil
+}
+
+func (p *TokenInfo) fetchKeys() ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	if p.jwksURL == "" {
+		jwksURL, err := discoverJWKSURI(ctx, p.httpClient, p.issuer)
+		if err != nil {
+			return nil, err
+		}
+		logrus.Infof("Discovered jwks_uri %s of issuer %s", jwksURL, p.issuer)
+		p.jwksURL = jwksURL
+	}
+	return httpGet(ctx, p.httpClient, p.jwksURL)
+}
+
+// refreshKeysOnMiss refreshes keys when the key id is not known e.g. the signing keys were rotated.
+// Refreshes are throttled by the minimum refresh interval.
+func (p *TokenInfo) refreshKeysOnMiss(kid string) {
+	if p.jwksFile != "" {
+		return
+	}
+	p.refreshLock.Lock()
+	defer p.refreshLock.Unlock()
+
+	// concurrent misses wait for the refresh of the first one
+	if nowFn().Sub(p.getLastRefresh()) < p.minRefreshInterval {
+		return
+	}
+	logrus.Infof("Refreshing jwks, key id %s not found", kid)
+	if err := p.loadKeys(); err != nil {
+		logrus.Errorf("refreshing of jwks failed: %v", err)
+	}
+}
+
+func (p *TokenInfo) lookupPublicKey(token *Token) (publicKey, bool) {
+	key, ok := p.getPublicKey(token.Header.KeyID)
+	if !ok {
+		p.refreshKeysOnMiss(token.Header.KeyID)
+		key, ok = p.getPublicKey(token.Header.KeyID)
+	}
+	if ok && key.alg != "" && key.alg != token.Header.Algorithm {
+		return publicKey{}, false
+	}
+	return key, ok
+}
+
+// verify token implements apis.TokenInfo VerifyToken method
+func (p *TokenInfo) VerifyToken(parent context.Context, request apis.VerifyRequest) (apis.VerifyResponse, error) {
+	if request.Token == "" {
+		return getVerifyResponseResponse(StatusEmptyToken)
+	}
+
+	token, err := ParseJWT(request.Token)
+	if err != nil {
+		return getVerifyResponseResponse(StatusParseJWTFailed)
+	}
+	if !isSupportedAlgorithm(token.Header.Algorithm) {
+		return getVerifyResponseResponse(StatusUnsupportedAlgorithm)
+	}
+	publicKey, ok := p.lookupPublicKey(token)
+	if !ok {
+		return getVerifyResponseResponse(StatusPublicKeyNotFound)
+	}
+	if err = token.VerifySignature(publicKey.key); err != nil {
+		return getVerifyResponseResponse(StatusWrongSignature)
+	}
+
+	if p.issuer != "" && token.ClaimSet.Iss != p.issuer {
+		return getVerifyResponseResponse(StatusWrongIssuer)
+	}
+	if token.ClaimSet.Exp < 1 {
+		return getVerifyResponseResponse(StatusNoExpirationTimeInToken)
+	}
+	unix := nowFn().Unix()
+	if unix > token.ClaimSet.Exp+int64(clockSkew.Seconds()) {
+		return getVerifyResponseResponse(StatusTokenExpired)
+	}
+	if token.ClaimSet.Nbf != 0 && unix < token.ClaimSet.Nbf-int64(clockSkew.Seconds()) {
+		return getVerifyResponseResponse(StatusTokenTooEarly)
+	}
+	if !p.checkAudience(token.ClaimSet.Aud) {
+		return getVerifyResponseResponse(StatusWrongAudience)
+	}
+	for _, claim := range p.requiredClaims {
+		value, ok := token.Claims[claim.name]
+		if !ok || value == nil {
+			return getVerifyResponseResponse(StatusMissingClaim)
+		}
+		if claim.value != nil && !claimContains(value, *claim.value) {
+			return getVerifyResponseResponse(StatusWrongClaim)
+		}
+	}
+	return apis.VerifyResponse{Success: true}, nil
+}
+
+func (p *TokenInfo) checkAudience(audience Audience) bool {
+	for _, aud := range audience {
+		if _, ok := p.audience[aud]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// claimContains checks a string, number or bool claim value, or if an array claim e.g. groups contains the value
+func claimContains(claim interface{}, value string) bool {
+	switch v := claim.(type) {
+	case string:
+		return v == value
+	case json.Number:
+		return v.String() == value
+	case bool:
+		return fmt.Sprint(v) == value
+	case []interface{}:
+		for _, elem := range v {
+			if claimContains(elem, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func getVerifyResponseResponse(status int) (apis.VerifyResponse, error) {
+	success := status == StatusOK
+	return apis.VerifyResponse{Success: success, Status: int32(status)}, nil
+}
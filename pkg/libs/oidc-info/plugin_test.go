@@ -0,0 +1,339 @@
+package oidcinfo
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grepplabs/kafka-proxy/pkg/apis"
+	"github.com/stretchr/testify/assert"
+)
+
+type testSigner struct {
+	kid string
+	alg string
+	key crypto.Signer
+}
+
+func (s *testSigner) jwk() JSONWebKey {
+	switch pub := s.key.Public().(type) {
+	case *rsa.PublicKey:
+		return JSONWebKey{Kty: "RSA", Alg: s.alg, Use: "sig", Kid: s.kid,
+			N: base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())}
+	case *ecdsa.PublicKey:
+		return JSONWebKey{Kty: "EC", Alg: s.alg, Use: "sig", Kid: s.kid, Crv: pub.Curve.Params().Name,
+			X: base64.RawURLEncoding.EncodeToString(pub.X.Bytes()),
+			Y: base64.RawURLEncoding.EncodeToString(pub.Y.Bytes())}
+	case ed25519.PublicKey:
+		return JSONWebKey{Kty: "OKP", Alg: s.alg, Use: "sig", Kid: s.kid, Crv: "Ed25519",
+			X: base64.RawURLEncoding.EncodeToString(pub)}
+	}
+	panic("unsupported key")
+}
+
+func (s *testSigner) sign(claims map[string]interface{}) (string, error) {
+	header, err := json.Marshal(Header{Algorithm: s.alg, KeyID: s.kid, Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	var signature []byte
+	hash := signatureAlgorithms[s.alg]
+	switch key := s.key.(type) {
+	case ed25519.PrivateKey:
+		signature = ed25519.Sign(key, []byte(signingInput))
+	case *ecdsa.PrivateKey:
+		h := hash.New()
+		h.Write([]byte(signingInput))
+		r, ss, err := ecdsa.Sign(rand.Reader, key, h.Sum(nil))
+		if err != nil {
+			return "", err
+		}
+		keySize := (key.Curve.Params().BitSize + 7) / 8
+		signature = make([]byte, 2*keySize)
+		r.FillBytes(signature[:keySize])
+		ss.FillBytes(signature[keySize:])
+	case *rsa.PrivateKey:
+		h := hash.New()
+		h.Write([]byte(signingInput))
+		if s.alg[:2] == "PS" {
+			signature, err = rsa.SignPSS(rand.Reader, key, hash, h.Sum(nil), &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		} else {
+			signature, err = rsa.SignPKCS1v15(rand.Reader, key, hash, h.Sum(nil))
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+type testIssuer struct {
+	server    *httptest.Server
+	signers   []*testSigner
+	jwksCalls int
+	l         sync.Mutex
+}
+
+func newTestIssuer(signers ...*testSigner) *testIssuer {
+	issuer := &testIssuer{signers: signers}
+	issuer.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case wellKnownConfiguration:
+			_ = json.NewEncoder(w).Encode(map[string]string{"issuer": issuer.server.URL, "jwks_uri": issuer.server.URL + "/keys"})
+		case "/keys":
+			_ = json.NewEncoder(w).Encode(issuer.jwks())
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return issuer
+}
+
+func (i *testIssuer) jwks() JSONWebKeySet {
+	i.l.Lock()
+	defer i.l.Unlock()
+	i.jwksCalls++
+	keySet := JSONWebKeySet{}
+	for _, signer := range i.signers {
+		keySet.Keys = append(keySet.Keys, signer.jwk())
+	}
+	return keySet
+}
+
+func (i *testIssuer) setSigners(signers ...*testSigner) {
+	i.l.Lock()
+	defer i.l.Unlock()
+	i.signers = signers
+}
+
+func (i *testIssuer) getJwksCalls() int {
+	i.l.Lock()
+	defer i.l.Unlock()
+	return i.jwksCalls
+}
+
+func newTestSigners(t *testing.T) []*testSigner {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	return []*testSigner{
+		{kid: "rsa", alg: "RS256", key: rsaKey},
+		{kid: "rsa-pss", alg: "PS256", key: rsaKey},
+		{kid: "ec", alg: "ES256", key: ecKey},
+		{kid: "ed", alg: "EdDSA", key: edKey},
+	}
+}
+
+func verify(tokenInfo apis.TokenInfo, token string) int32 {
+	resp, _ := tokenInfo.VerifyToken(context.Background(), apis.VerifyRequest{Token: token})
+	return resp.Status
+}
+
+func TestVerifyTokenAlgorithms(t *testing.T) {
+	a := assert.New(t)
+
+	signers := newTestSigners(t)
+	issuer := newTestIssuer(signers...)
+	defer issuer.server.Close()
+
+	tokenInfo, err := new(Factory).New([]string{"--issuer", issuer.server.URL, "--audience", "kafka"})
+	a.Nil(err)
+
+	for _, signer := range signers {
+		token, err := signer.sign(map[string]interface{}{"iss": issuer.server.URL, "sub": "alice", "aud": "kafka", "exp": time.Now().Add(time.Hour).Unix()})
+		a.Nil(err)
+		resp, err := tokenInfo.VerifyToken(context.Background(), apis.VerifyRequest{Token: token})
+		a.Nil(err)
+		a.Equal(apis.VerifyResponse{Success: true, Status: StatusOK}, resp, signer.alg)
+	}
+}
+
+func TestVerifyTokenClaims(t *testing.T) {
+	a := assert.New(t)
+
+	signers := newTestSigners(t)
+	issuer := newTestIssuer(signers...)
+	defer issuer.server.Close()
+
+	tokenInfo, err := new(Factory).New([]string{"--issuer", issuer.server.URL, "--audience", "kafka", "--required-claim", "email", "--required-claim", "groups=admins"})
+	a.Nil(err)
+
+	now := time.Now()
+	claims := func(modify func(claims map[string]interface{})) map[string]interface{} {
+		claims := map[string]interface{}{"iss": issuer.server.URL, "sub": "alice", "aud": []string{"other", "kafka"}, "exp": now.Add(time.Hour).Unix(),
+			"email": "alice@example.com", "groups": []string{"users", "admins"}}
+		modify(claims)
+		return claims
+	}
+	tests := []struct {
+		name   string
+		claims map[string]interface{}
+		status int32
+	}{
+		{"valid", claims(func(map[string]interface{}) {}), StatusOK},
+		{"wrong issuer", claims(func(c map[string]interface{}) { c["iss"] = "https://other.example.com" }), StatusWrongIssuer},
+		{"no expiration", claims(func(c map[string]interface{}) { delete(c, "exp") }), StatusNoExpirationTimeInToken},
+		{"expired", claims(func(c map[string]interface{}) { c["exp"] = now.Add(-2 * time.Minute).Unix() }), StatusTokenExpired},
+		{"not before", claims(func(c map[string]interface{}) { c["nbf"] = now.Add(2 * time.Minute).Unix() }), StatusTokenTooEarly},
+		{"wrong audience", claims(func(c map[string]interface{}) { c["aud"] = "other" }), StatusWrongAudience},
+		{"missing claim", claims(func(c map[string]interface{}) { delete(c, "email") }), StatusMissingClaim},
+		{"wrong claim", claims(func(c map[string]interface{}) { c["groups"] = []string{"users"} }), StatusWrongClaim},
+	}
+	for _, tc := range tests {
+		token, err := signers[0].sign(tc.claims)
+		a.Nil(err)
+		a.Equal(tc.status, verify(tokenInfo, token), tc.name)
+	}
+
+	a.Equal(int32(StatusEmptyToken), verify(tokenInfo, ""))
+	a.Equal(int32(StatusParseJWTFailed), verify(tokenInfo, "not-a-token"))
+
+	token, err := signers[0].sign(claims(func(map[string]interface{}) {}))
+	a.Nil(err)
+	// signed by the other key
+	other, err := (&testSigner{kid: "rsa", alg: "RS256", key: signers[2].key}).sign(claims(func(map[string]interface{}) {}))
+	a.Nil(err)
+	a.Equal(int32(StatusWrongSignature), verify(tokenInfo, other))
+	// alg none
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + token[strings.Index(token, "."):]
+	a.Equal(int32(StatusUnsupportedAlgorithm), verify(tokenInfo, none))
+	// alg does not match the key
+	pss, err := (&testSigner{kid: "rsa", alg: "PS256", key: signers[0].key}).sign(claims(func(map[string]interface{}) {}))
+	a.Nil(err)
+	a.Equal(int32(StatusPublicKeyNotFound), verify(tokenInfo, pss))
+}
+
+func TestVerifyTokenKeyRotation(t *testing.T) {
+	a := assert.New(t)
+
+	signers := newTestSigners(t)
+	issuer := newTestIssuer(signers[0])
+	defer issuer.server.Close()
+
+	tokenInfo, err := new(Factory).New([]string{"--issuer", issuer.server.URL, "--audience", "kafka", "--jwks-min-refresh-interval", "0"})
+	a.Nil(err)
+	a.Equal(1, issuer.getJwksCalls())
+
+	claims := map[string]interface{}{"iss": issuer.server.URL, "sub": "alice", "aud": "kafka", "exp": time.Now().Add(time.Hour).Unix()}
+	token, err := signers[2].sign(claims)
+	a.Nil(err)
+	a.Equal(int32(StatusPublicKeyNotFound), verify(tokenInfo, token))
+	a.Equal(2, issuer.getJwksCalls())
+
+	issuer.setSigners(signers[0], signers[2])
+	a.Equal(int32(StatusOK), verify(tokenInfo, token))
+	a.Equal(3, issuer.getJwksCalls())
+	a.Equal(int32(StatusOK), verify(tokenInfo, token))
+	a.Equal(3, issuer.getJwksCalls())
+
+	// refreshes on kid miss are throttled
+	tokenInfo.(*TokenInfo).minRefreshInterval = time.Hour
+	token, err = signers[3].sign(claims)
+	a.Nil(err)
+	a.Equal(int32(StatusPublicKeyNotFound), verify(tokenInfo, token))
+	a.Equal(3, issuer.getJwksCalls())
+}
+
+func TestVerifyTokenJWKSFile(t *testing.T) {
+	a := assert.New(t)
+
+	signers := newTestSigners(t)
+	keySet := JSONWebKeySet{Keys: []JSONWebKey{signers[3].jwk(), {Kty: "RSA", Use: "enc", Kid: "enc"}}}
+	data, err := json.Marshal(keySet)
+	a.Nil(err)
+
+	file, err := ioutil.TempFile("", "jwks")
+	a.Nil(err)
+	defer os.Remove(file.Name())
+	_, err = file.Write(data)
+	a.Nil(err)
+	a.Nil(file.Close())
+
+	tokenInfo, err := new(Factory).New([]string{"--jwks-file", file.Name(), "--issuer", "https://idp.example.com", "--audience", "kafka"})
+	a.Nil(err)
+
+	token, err := signers[3].sign(map[string]interface{}{"iss": "https://idp.example.com", "sub": "alice", "aud": "kafka", "exp": time.Now().Add(time.Hour).Unix()})
+	a.Nil(err)
+	a.Equal(int32(StatusOK), verify(tokenInfo, token))
+}
+
+func TestNewTokenInfoParameters(t *testing.T) {
+	a := assert.New(t)
+
+	_, err := new(Factory).New([]string{})
+	a.EqualError(err, "parameter issuer, jwks-url or jwks-file is required")
+
+	_, err = new(Factory).New([]string{"--jwks-url", "https://idp.example.com/keys", "--jwks-file", "keys.json"})
+	a.EqualError(err, "parameters jwks-url and jwks-file are mutually exclusive")
+
+	_, err = new(Factory).New([]string{"--jwks-url", "https://idp.example.com/keys", "--audience", "kafka"})
+	a.EqualError(err, "parameter issuer is required, skip-issuer-check accepts tokens of any issuer")
+
+	_, err = new(Factory).New([]string{"--jwks-url", "https://idp.example.com/keys", "--issuer", "https://idp.example.com"})
+	a.EqualError(err, "parameter audience is required")
+
+	_, err = new(Factory).New([]string{"--jwks-file", "keys.json", "--issuer", "https://idp.example.com", "--audience", "kafka", "--required-claim", "=value"})
+	a.EqualError(err, "required claim must be in form 'name' or 'name=value', got '=value'")
+}
+
+func TestVerifyTokenJWKSURL(t *testing.T) {
+	a := assert.New(t)
+
+	signers := newTestSigners(t)
+	issuer := newTestIssuer(signers[0])
+	defer issuer.server.Close()
+
+	claims := func(iss, aud string) map[string]interface{} {
+		return map[string]interface{}{"iss": iss, "sub": "alice", "aud": aud, "exp": time.Now().Add(time.Hour).Unix()}
+	}
+	tokenInfo, err := new(Factory).New([]string{"--jwks-url", issuer.server.URL + "/keys", "--issuer", "https://idp.example.com", "--audience", "kafka"})
+	a.Nil(err)
+	for _, tc := range []struct {
+		name   string
+		claims map[string]interface{}
+		status int32
+	}{
+		{"valid", claims("https://idp.example.com", "kafka"), StatusOK},
+		{"wrong issuer", claims("https://other.example.com", "kafka"), StatusWrongIssuer},
+		{"wrong audience", claims("https://idp.example.com", "other"), StatusWrongAudience},
+	} {
+		token, err := signers[0].sign(tc.claims)
+		a.Nil(err)
+		a.Equal(tc.status, verify(tokenInfo, token), tc.name)
+	}
+
+	// the issuer check is skipped only explicitly, the audience is still checked
+	tokenInfo, err = new(Factory).New([]string{"--jwks-url", issuer.server.URL + "/keys", "--skip-issuer-check", "--audience", "kafka"})
+	a.Nil(err)
+	token, err := signers[0].sign(claims("https://other.example.com", "kafka"))
+	a.Nil(err)
+	a.Equal(int32(StatusOK), verify(tokenInfo, token))
+	token, err = signers[0].sign(claims("https://other.example.com", "other"))
+	a.Nil(err)
+	a.Equal(int32(StatusWrongAudience), verify(tokenInfo, token))
+}
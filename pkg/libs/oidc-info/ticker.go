@@ -0,0 +1,61 @@
+package oidcinfo
+
+import (
+	"github.com/cenkalti/backoff"
+	"github.com/sirupsen/logrus"
+	"sort"
+	"time"
+)
+
+type keysRefresher struct {
+	tokenInfo   *TokenInfo
+	stopChannel chan struct{}
+	interval    time.Duration
+}
+
+func newKeysRefresher(tokenInfo *TokenInfo, stopChannel chan struct{}, interval time.Duration) *keysRefresher {
+	return &keysRefresher{
+		tokenInfo:   tokenInfo,
+		stopChannel: stopChannel,
+		interval:    interval,
+	}
+}
+
+func (p *keysRefresher) refreshLoop() {
+	defer func() {
+		if r := recover(); r != nil {
+			var ok bool
+			err, ok := r.(error)
+			if ok {
+				logrus.Errorf("jwks refresh loop error %v", err)
+			}
+		}
+	}()
+	logrus.Infof("Refreshing jwks every: %v", p.interval)
+	syncTicker := time.NewTicker(p.interval)
+	for {
+		select {
+		case <-syncTicker.C:
+			p.refreshTick()
+		case <-p.stopChannel:
+			return
+		}
+	}
+}
+
+func (p *keysRefresher) refreshTick() error {
+	op := func() error {
+		return p.tokenInfo.refreshKeys()
+	}
+	backOff := backoff.NewExponentialBackOff()
+	backOff.MaxElapsedTime = 30 * time.Minute
+	backOff.MaxInterval = 2 * time.Minute
+	err := backoff.Retry(op, backOff)
+	if err != nil {
+		return err
+	}
+	kids := p.tokenInfo.getPublicKeyIDs()
+	sort.Strings(kids)
+	logrus.Infof("Refreshed jwks Key IDs: %v", kids)
+	return nil
+}
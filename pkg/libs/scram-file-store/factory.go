@@ -0,0 +1,40 @@
+package scramfilestore
+
+import (
+	"errors"
+	"flag"
+	"github.com/grepplabs/kafka-proxy/pkg/apis"
+	"github.com/grepplabs/kafka-proxy/pkg/registry"
+)
+
+func init() {
+	registry.NewComponentInterface(new(apis.ScramCredentialStoreFactory))
+	registry.Register(new(Factory), "scram-file-store")
+}
+
+func (f *pluginMeta) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("scram file store settings", flag.ContinueOnError)
+	return fs
+}
+
+type pluginMeta struct {
+	file string
+}
+
+type Factory struct {
+}
+
+// New implements apis.ScramCredentialStoreFactory
+func (t *Factory) New(params []string) (apis.ScramCredentialStore, error) {
+	pluginMeta := &pluginMeta{}
+	fs := pluginMeta.flagSet()
+	fs.StringVar(&pluginMeta.file, "file", "", "Path to the file with SCRAM credentials")
+
+	if err := fs.Parse(params); err != nil {
+		return nil, err
+	}
+	if pluginMeta.file == "" {
+		return nil, errors.New("parameter file is required")
+	}
+	return NewFileStore(pluginMeta.file)
+}
@@ -0,0 +1,176 @@
+package scramfilestore
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"github.com/grepplabs/kafka-proxy/pkg/apis"
+	"github.com/pkg/errors"
+	"github.com/xdg/scram"
+	"hash"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	MechanismSHA256 = "SCRAM-SHA-256"
+	MechanismSHA512 = "SCRAM-SHA-512"
+
+	// minimal iteration count required by Kafka
+	MinIterations     = 4096
+	DefaultSaltLength = 32
+)
+
+var hashGenerators = map[string]scram.HashGeneratorFcn{
+	MechanismSHA256: func() hash.Hash { return sha256.New() },
+	MechanismSHA512: func() hash.Hash { return sha512.New() },
+}
+
+type userMechanism struct {
+	username  string
+	mechanism string
+}
+
+// FileStore is a ScramCredentialStore which reads the salted credentials from a file. Every non-empty line,
+// which does not start with #, contains the username, the mechanism and the credentials separated by whitespaces e.g.
+//
+//	alice SCRAM-SHA-256 salt=<base64>,stored_key=<base64>,server_key=<base64>,iterations=4096
+type FileStore struct {
+	credentials map[userMechanism]apis.ScramCredentials
+}
+
+func NewFileStore(filename string) (*FileStore, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	credentials, err := readCredentials(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading SCRAM credentials from %s", filename)
+	}
+	return &FileStore{credentials: credentials}, nil
+}
+
+// GetCredentials implements apis.ScramCredentialStore
+func (s *FileStore) GetCredentials(mechanism, username string) (apis.ScramCredentials, bool, error) {
+	credentials, ok := s.credentials[userMechanism{username: username, mechanism: mechanism}]
+	return credentials, ok, nil
+}
+
+func readCredentials(reader io.Reader) (map[userMechanism]apis.ScramCredentials, error) {
+	result := make(map[userMechanism]apis.ScramCredentials)
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: expected username, mechanism and credentials, got %d fields", lineNumber, len(fields))
+		}
+		username, mechanism := fields[0], fields[1]
+		if _, ok := hashGenerators[mechanism]; !ok {
+			return nil, fmt.Errorf("line %d: unsupported mechanism %s", lineNumber, mechanism)
+		}
+		credentials, err := ParseCredentials(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+		key := userMechanism{username: username, mechanism: mechanism}
+		if _, ok := result[key]; ok {
+			return nil, fmt.Errorf("line %d: duplicate %s credentials of user %s", lineNumber, mechanism, username)
+		}
+		result[key] = credentials
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ParseCredentials parses the credentials in the format salt=<base64>,stored_key=<base64>,server_key=<base64>,iterations=<count>
+func ParseCredentials(value string) (apis.ScramCredentials, error) {
+	var credentials apis.ScramCredentials
+	seen := make(map[string]bool)
+	for _, pair := range strings.Split(value, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return apis.ScramCredentials{}, fmt.Errorf("invalid credentials attribute %q", pair)
+		}
+		var err error
+		switch kv[0] {
+		case "salt":
+			credentials.Salt, err = base64.StdEncoding.DecodeString(kv[1])
+		case "stored_key":
+			credentials.StoredKey, err = base64.StdEncoding.DecodeString(kv[1])
+		case "server_key":
+			credentials.ServerKey, err = base64.StdEncoding.DecodeString(kv[1])
+		case "iterations":
+			var iterations int64
+			iterations, err = strconv.ParseInt(kv[1], 10, 32)
+			credentials.Iterations = int32(iterations)
+		default:
+			return apis.ScramCredentials{}, fmt.Errorf("unknown credentials attribute %s", kv[0])
+		}
+		if err != nil {
+			return apis.ScramCredentials{}, fmt.Errorf("invalid credentials attribute %s: %v", kv[0], err)
+		}
+		seen[kv[0]] = true
+	}
+	for _, name := range []string{"salt", "stored_key", "server_key", "iterations"} {
+		if !seen[name] {
+			return apis.ScramCredentials{}, fmt.Errorf("credentials attribute %s is missing", name)
+		}
+	}
+	if credentials.Iterations < MinIterations {
+		return apis.ScramCredentials{}, fmt.Errorf("iterations %d must be at least %d", credentials.Iterations, MinIterations)
+	}
+	return credentials, nil
+}
+
+// FormatCredentials is the reverse of ParseCredentials
+func FormatCredentials(credentials apis.ScramCredentials) string {
+	return fmt.Sprintf("salt=%s,stored_key=%s,server_key=%s,iterations=%d",
+		base64.StdEncoding.EncodeToString(credentials.Salt),
+		base64.StdEncoding.EncodeToString(credentials.StoredKey),
+		base64.StdEncoding.EncodeToString(credentials.ServerKey),
+		credentials.Iterations)
+}
+
+// NewCredentials computes the salted credentials of the password. A random salt is generated when the salt is empty.
+func NewCredentials(mechanism, username, password string, salt []byte, iterations int) (apis.ScramCredentials, error) {
+	hashGenerator, ok := hashGenerators[mechanism]
+	if !ok {
+		return apis.ScramCredentials{}, fmt.Errorf("unsupported mechanism %s", mechanism)
+	}
+	if iterations < MinIterations {
+		return apis.ScramCredentials{}, fmt.Errorf("iterations %d must be at least %d", iterations, MinIterations)
+	}
+	if len(salt) == 0 {
+		salt = make([]byte, DefaultSaltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return apis.ScramCredentials{}, err
+		}
+	}
+	client, err := hashGenerator.NewClient(username, password, "")
+	if err != nil {
+		return apis.ScramCredentials{}, err
+	}
+	stored := client.GetStoredCredentials(scram.KeyFactors{Salt: string(salt), Iters: iterations})
+	return apis.ScramCredentials{
+		Salt:       salt,
+		Iterations: int32(iterations),
+		StoredKey:  stored.StoredKey,
+		ServerKey:  stored.ServerKey,
+	}, nil
+}
@@ -0,0 +1,78 @@
+package scramfilestore
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestReadCredentials(t *testing.T) {
+	a := assert.New(t)
+
+	alice, err := NewCredentials(MechanismSHA256, "alice", "alice-secret", nil, MinIterations)
+	a.Nil(err)
+	bob, err := NewCredentials(MechanismSHA512, "bob", "bob-secret", []byte("bob-salt"), 8192)
+	a.Nil(err)
+
+	content := fmt.Sprintf("# SCRAM credentials\n\nalice SCRAM-SHA-256 %s\n  bob  SCRAM-SHA-512  %s\n", FormatCredentials(alice), FormatCredentials(bob))
+	credentials, err := readCredentials(strings.NewReader(content))
+	a.Nil(err)
+	a.Len(credentials, 2)
+	a.Equal(alice, credentials[userMechanism{username: "alice", mechanism: MechanismSHA256}])
+	a.Equal(bob, credentials[userMechanism{username: "bob", mechanism: MechanismSHA512}])
+	a.Equal([]byte("bob-salt"), bob.Salt)
+	a.Equal(int32(8192), bob.Iterations)
+	a.Len(alice.Salt, DefaultSaltLength)
+}
+
+func TestReadCredentialsErrors(t *testing.T) {
+	valid := "salt=c2FsdA==,stored_key=a2V5,server_key=a2V5,iterations=4096"
+	tests := []struct {
+		content string
+		err     string
+	}{
+		{content: "alice SCRAM-SHA-256", err: "line 1: expected username, mechanism and credentials, got 2 fields"},
+		{content: "alice SCRAM-SHA-1 " + valid, err: "line 1: unsupported mechanism SCRAM-SHA-1"},
+		{content: "alice SCRAM-SHA-256 salt=c2FsdA==,stored_key=a2V5,iterations=4096", err: "line 1: credentials attribute server_key is missing"},
+		{content: "alice SCRAM-SHA-256 salt=c2FsdA==,stored_key=a2V5,server_key=a2V5,iterations=1024", err: "line 1: iterations 1024 must be at least 4096"},
+		{content: "alice SCRAM-SHA-256 salt=!,stored_key=a2V5,server_key=a2V5,iterations=4096", err: "line 1: invalid credentials attribute salt: illegal base64 data at input byte 0"},
+		{content: "alice SCRAM-SHA-256 " + valid + ",extension=1", err: "line 1: unknown credentials attribute extension"},
+		{content: "alice SCRAM-SHA-256 " + valid + "\nalice SCRAM-SHA-256 " + valid, err: "line 2: duplicate SCRAM-SHA-256 credentials of user alice"},
+	}
+	for _, tc := range tests {
+		_, err := readCredentials(strings.NewReader(tc.content))
+		assert.EqualError(t, err, tc.err)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	a := assert.New(t)
+
+	alice, err := NewCredentials(MechanismSHA512, "alice", "alice-secret", nil, MinIterations)
+	a.Nil(err)
+
+	file, err := ioutil.TempFile("", "scram-credentials")
+	a.Nil(err)
+	defer os.Remove(file.Name())
+	_, err = file.WriteString("alice SCRAM-SHA-512 " + FormatCredentials(alice) + "\n")
+	a.Nil(err)
+	a.Nil(file.Close())
+
+	store, err := new(Factory).New([]string{"--file", file.Name()})
+	a.Nil(err)
+
+	credentials, found, err := store.GetCredentials(MechanismSHA512, "alice")
+	a.Nil(err)
+	a.True(found)
+	a.Equal(alice, credentials)
+
+	_, found, err = store.GetCredentials(MechanismSHA256, "alice")
+	a.Nil(err)
+	a.False(found)
+
+	_, err = new(Factory).New([]string{})
+	a.EqualError(err, "parameter file is required")
+}
@@ -0,0 +1,212 @@
+package tokenexchange
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grepplabs/kafka-proxy/pkg/apis"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	StatusOK                 = 0
+	StatusMissingClientToken = 1
+	StatusExchangeFailed     = 2
+)
+
+const (
+	// GrantTypeTokenExchange is the grant type of RFC 8693 token exchange
+	GrantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+	TokenTypeAccessToken   = "urn:ietf:params:oauth:token-type:access_token"
+	TokenTypeJWT           = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+var (
+	clockSkew = 1 * time.Minute
+	nowFn     = time.Now
+)
+
+// TokenPassthrough returns the client token as the broker token
+type TokenPassthrough struct {
+}
+
+// GetToken implements apis.TokenProvider.GetToken method
+func (p *TokenPassthrough) GetToken(_ context.Context, request apis.TokenRequest) (apis.TokenResponse, error) {
+	if request.ClientToken == "" {
+		return apis.TokenResponse{Success: false, Status: StatusMissingClientToken}, nil
+	}
+	return apis.TokenResponse{Success: true, Status: StatusOK, Token: request.ClientToken}, nil
+}
+
+// TokenExchangeOptions - options of the RFC 8693 token exchange
+type TokenExchangeOptions struct {
+	Timeout time.Duration
+
+	TokenEndpoint      string
+	ClientID           string
+	ClientSecret       string
+	Audience           string
+	Scopes             []string
+	SubjectTokenType   string
+	RequestedTokenType string
+}
+
+// TokenExchanger exchanges the client token for a broker token at the token endpoint (RFC 8693).
+// Exchanged tokens are cached until they expire.
+type TokenExchanger struct {
+	options    TokenExchangeOptions
+	httpClient *http.Client
+
+	tokens map[string]exchangedToken
+	l      sync.Mutex
+}
+
+type exchangedToken struct {
+	token  string
+	expiry time.Time
+}
+
+type tokenExchangeResponse struct {
+	AccessToken      string `json:"access_token"`
+	IssuedTokenType  string `json:"issued_token_type"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// NewTokenExchanger - Generate new token exchange provider
+func NewTokenExchanger(options TokenExchangeOptions) (*TokenExchanger, error) {
+	if options.TokenEndpoint == "" {
+		return nil, errors.New("parameter token-endpoint is required")
+	}
+	if _, err := url.ParseRequestURI(options.TokenEndpoint); err != nil {
+		return nil, errors.Wrap(err, "invalid token-endpoint")
+	}
+	if options.SubjectTokenType == "" {
+		options.SubjectTokenType = TokenTypeAccessToken
+	}
+	return &TokenExchanger{
+		options:    options,
+		httpClient: &http.Client{Timeout: options.Timeout},
+		tokens:     make(map[string]exchangedToken),
+	}, nil
+}
+
+// GetToken implements apis.TokenProvider.GetToken method
+func (p *TokenExchanger) GetToken(ctx context.Context, request apis.TokenRequest) (apis.TokenResponse, error) {
+	if request.ClientToken == "" {
+		return apis.TokenResponse{Success: false, Status: StatusMissingClientToken}, nil
+	}
+	key := cacheKey(request.ClientToken)
+	if token := p.getCachedToken(key); token != "" {
+		return apis.TokenResponse{Success: true, Status: StatusOK, Token: token}, nil
+	}
+	token, err := p.exchange(ctx, request.ClientToken)
+	if err != nil {
+		logrus.Errorf("token exchange failed: %v", err)
+		return apis.TokenResponse{Success: false, Status: StatusExchangeFailed}, nil
+	}
+	if !token.expiry.IsZero() {
+		p.setCachedToken(key, token)
+	}
+	return apis.TokenResponse{Success: true, Status: StatusOK, Token: token.token}, nil
+}
+
+func (p *TokenExchanger) exchange(ctx context.Context, subjectToken string) (exchangedToken, error) {
+	form := url.Values{
+		"grant_type":         {GrantTypeTokenExchange},
+		"subject_token":      {subjectToken},
+		"subject_token_type": {p.options.SubjectTokenType},
+	}
+	if p.options.Audience != "" {
+		form.Set("audience", p.options.Audience)
+	}
+	if len(p.options.Scopes) != 0 {
+		form.Set("scope", strings.Join(p.options.Scopes, " "))
+	}
+	if p.options.RequestedTokenType != "" {
+		form.Set("requested_token_type", p.options.RequestedTokenType)
+	}
+	req, err := http.NewRequest(http.MethodPost, p.options.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return exchangedToken{}, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.options.ClientID != "" {
+		req.SetBasicAuth(url.QueryEscape(p.options.ClientID), url.QueryEscape(p.options.ClientSecret))
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return exchangedToken{}, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return exchangedToken{}, err
+	}
+	result := &tokenExchangeResponse{}
+	if err = json.Unmarshal(body, result); err != nil {
+		return exchangedToken{}, fmt.Errorf("cannot parse token endpoint response, status code %d: %v", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return exchangedToken{}, fmt.Errorf("token endpoint returned status code %d, error '%s': %s", resp.StatusCode, result.Error, result.ErrorDescription)
+	}
+	if result.AccessToken == "" {
+		return exchangedToken{}, errors.New("token endpoint response does not contain access_token")
+	}
+	token := exchangedToken{token: result.AccessToken}
+	if result.ExpiresIn > 0 {
+		token.expiry = nowFn().Add(time.Duration(result.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+func (p *TokenExchanger) getCachedToken(key string) string {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	token, ok := p.tokens[key]
+	if !ok {
+		return ""
+	}
+	// renew before expiry
+	if nowFn().Add(clockSkew).After(token.expiry) {
+		delete(p.tokens, key)
+		return ""
+	}
+	return token.token
+}
+
+func (p *TokenExchanger) setCachedToken(key string, token exchangedToken) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	now := nowFn()
+	for k, v := range p.tokens {
+		if now.After(v.expiry) {
+			delete(p.tokens, k)
+		}
+	}
+	p.tokens[key] = token
+}
+
+// cacheKey does not keep the client tokens in memory
+func cacheKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,90 @@
+package tokenexchange
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grepplabs/kafka-proxy/pkg/apis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenPassthrough(t *testing.T) {
+	a := assert.New(t)
+
+	provider, err := new(PassthroughFactory).New([]string{})
+	a.Nil(err)
+
+	resp, err := provider.GetToken(context.Background(), apis.TokenRequest{ClientToken: "client-token"})
+	a.Nil(err)
+	a.Equal(apis.TokenResponse{Success: true, Status: StatusOK, Token: "client-token"}, resp)
+
+	resp, err = provider.GetToken(context.Background(), apis.TokenRequest{})
+	a.Nil(err)
+	a.Equal(apis.TokenResponse{Success: false, Status: StatusMissingClientToken}, resp)
+}
+
+func TestTokenExchange(t *testing.T) {
+	a := assert.New(t)
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		a.Nil(r.ParseForm())
+		clientID, clientSecret, _ := r.BasicAuth()
+		a.Equal("proxy", clientID)
+		a.Equal("proxy-secret", clientSecret)
+		a.Equal(GrantTypeTokenExchange, r.PostForm.Get("grant_type"))
+		a.Equal(TokenTypeAccessToken, r.PostForm.Get("subject_token_type"))
+		a.Equal("kafka", r.PostForm.Get("audience"))
+		a.Equal("read write", r.PostForm.Get("scope"))
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.PostForm.Get("subject_token") != "client-token" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_request","error_description":"invalid subject token"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"broker-token","issued_token_type":"urn:ietf:params:oauth:token-type:access_token","token_type":"Bearer","expires_in":300}`))
+	}))
+	defer server.Close()
+
+	provider, err := new(Factory).New([]string{"--token-endpoint", server.URL, "--client-id", "proxy", "--client-secret", "proxy-secret", "--audience", "kafka", "--scopes", "read,write"})
+	a.Nil(err)
+
+	resp, err := provider.GetToken(context.Background(), apis.TokenRequest{ClientToken: "client-token"})
+	a.Nil(err)
+	a.Equal(apis.TokenResponse{Success: true, Status: StatusOK, Token: "broker-token"}, resp)
+	a.Equal(1, requests)
+
+	// exchanged token is cached
+	resp, err = provider.GetToken(context.Background(), apis.TokenRequest{ClientToken: "client-token"})
+	a.Nil(err)
+	a.Equal("broker-token", resp.Token)
+	a.Equal(1, requests)
+
+	// and renewed before expiry
+	defer func() { nowFn = time.Now }()
+	nowFn = func() time.Time { return time.Now().Add(250 * time.Second) }
+	resp, err = provider.GetToken(context.Background(), apis.TokenRequest{ClientToken: "client-token"})
+	a.Nil(err)
+	a.Equal("broker-token", resp.Token)
+	a.Equal(2, requests)
+
+	resp, err = provider.GetToken(context.Background(), apis.TokenRequest{ClientToken: "other-token"})
+	a.Nil(err)
+	a.Equal(apis.TokenResponse{Success: false, Status: StatusExchangeFailed}, resp)
+
+	resp, err = provider.GetToken(context.Background(), apis.TokenRequest{})
+	a.Nil(err)
+	a.Equal(apis.TokenResponse{Success: false, Status: StatusMissingClientToken}, resp)
+}
+
+func TestTokenExchangeEndpointRequired(t *testing.T) {
+	a := assert.New(t)
+
+	_, err := new(Factory).New([]string{"--audience", "kafka"})
+	a.EqualError(err, "parameter token-endpoint is required")
+}
@@ -0,0 +1,82 @@
+package tokenexchange
+
+import (
+	"flag"
+	"strings"
+	"time"
+
+	"github.com/grepplabs/kafka-proxy/pkg/apis"
+	"github.com/grepplabs/kafka-proxy/pkg/registry"
+)
+
+func init() {
+	registry.NewComponentInterface(new(apis.TokenProviderFactory))
+	registry.Register(new(Factory), "token-exchange")
+	registry.Register(new(PassthroughFactory), "token-passthrough")
+}
+
+func (f *pluginMeta) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("token exchange settings", flag.ContinueOnError)
+	return fs
+}
+
+type pluginMeta struct {
+	timeout time.Duration
+
+	tokenEndpoint      string
+	clientID           string
+	clientSecret       string
+	audience           string
+	scopes             string
+	subjectTokenType   string
+	requestedTokenType string
+}
+
+// Factory type
+type Factory struct {
+}
+
+// New implements apis.TokenProviderFactory
+func (t *Factory) New(params []string) (apis.TokenProvider, error) {
+	pluginMeta := &pluginMeta{}
+	fs := pluginMeta.flagSet()
+	fs.DurationVar(&pluginMeta.timeout, "timeout", 10*time.Second, "Token endpoint request timeout")
+	fs.StringVar(&pluginMeta.tokenEndpoint, "token-endpoint", "", "URL of the token endpoint supporting RFC 8693 token exchange")
+	fs.StringVar(&pluginMeta.clientID, "client-id", "", "Client ID used to authenticate at the token endpoint")
+	fs.StringVar(&pluginMeta.clientSecret, "client-secret", "", "Client secret used to authenticate at the token endpoint")
+	fs.StringVar(&pluginMeta.audience, "audience", "", "Audience of the requested token e.g. the Kafka cluster")
+	fs.StringVar(&pluginMeta.scopes, "scopes", "", "Comma separated list of the requested scopes")
+	fs.StringVar(&pluginMeta.subjectTokenType, "subject-token-type", TokenTypeAccessToken, "Type of the client token")
+	fs.StringVar(&pluginMeta.requestedTokenType, "requested-token-type", "", "Type of the requested token")
+
+	if err := fs.Parse(params); err != nil {
+		return nil, err
+	}
+
+	options := TokenExchangeOptions{
+		Timeout:            pluginMeta.timeout,
+		TokenEndpoint:      pluginMeta.tokenEndpoint,
+		ClientID:           pluginMeta.clientID,
+		ClientSecret:       pluginMeta.clientSecret,
+		Audience:           pluginMeta.audience,
+		SubjectTokenType:   pluginMeta.subjectTokenType,
+		RequestedTokenType: pluginMeta.requestedTokenType,
+	}
+	if pluginMeta.scopes != "" {
+		options.Scopes = strings.Split(pluginMeta.scopes, ",")
+	}
+	return NewTokenExchanger(options)
+}
+
+// PassthroughFactory type
+type PassthroughFactory struct {
+}
+
+// New implements apis.TokenProviderFactory
+func (t *PassthroughFactory) New(params []string) (apis.TokenProvider, error) {
+	fs := flag.NewFlagSet("token passthrough settings", flag.ContinueOnError)
+	if err := fs.Parse(params); err != nil {
+		return nil, err
+	}
+	return &TokenPassthrough{}, nil
+}
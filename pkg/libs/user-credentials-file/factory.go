@@ -0,0 +1,40 @@
+package usercredentialsfile
+
+import (
+	"errors"
+	"flag"
+	"github.com/grepplabs/kafka-proxy/pkg/apis"
+	"github.com/grepplabs/kafka-proxy/pkg/registry"
+)
+
+func init() {
+	registry.NewComponentInterface(new(apis.UserCredentialsProviderFactory))
+	registry.Register(new(Factory), "user-credentials-file")
+}
+
+func (f *pluginMeta) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("user credentials file settings", flag.ContinueOnError)
+	return fs
+}
+
+type pluginMeta struct {
+	file string
+}
+
+type Factory struct {
+}
+
+// New implements apis.UserCredentialsProviderFactory
+func (t *Factory) New(params []string) (apis.UserCredentialsProvider, error) {
+	pluginMeta := &pluginMeta{}
+	fs := pluginMeta.flagSet()
+	fs.StringVar(&pluginMeta.file, "file", "", "Path to the file with upstream credentials of the principals")
+
+	if err := fs.Parse(params); err != nil {
+		return nil, err
+	}
+	if pluginMeta.file == "" {
+		return nil, errors.New("parameter file is required")
+	}
+	return NewFileProvider(pluginMeta.file)
+}
@@ -0,0 +1,64 @@
+package usercredentialsfile
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/grepplabs/kafka-proxy/pkg/apis"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+	"strings"
+)
+
+// FileProvider is a UserCredentialsProvider which reads the upstream credentials from a file. Every non-empty line,
+// which does not start with #, contains the locally authenticated principal, the upstream username and password separated by whitespaces e.g.
+//
+//	alice alice-upstream alice-upstream-secret
+type FileProvider struct {
+	credentials map[string]apis.UserCredentials
+}
+
+func NewFileProvider(filename string) (*FileProvider, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	credentials, err := readUserCredentials(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading user credentials from %s", filename)
+	}
+	return &FileProvider{credentials: credentials}, nil
+}
+
+// GetUserCredentials implements apis.UserCredentialsProvider
+func (p *FileProvider) GetUserCredentials(principal string) (apis.UserCredentials, bool, error) {
+	credentials, ok := p.credentials[principal]
+	return credentials, ok, nil
+}
+
+func readUserCredentials(reader io.Reader) (map[string]apis.UserCredentials, error) {
+	result := make(map[string]apis.UserCredentials)
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: expected principal, username and password, got %d fields", lineNumber, len(fields))
+		}
+		if _, ok := result[fields[0]]; ok {
+			return nil, fmt.Errorf("line %d: duplicate credentials of principal %s", lineNumber, fields[0])
+		}
+		result[fields[0]] = apis.UserCredentials{Username: fields[1], Password: fields[2]}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
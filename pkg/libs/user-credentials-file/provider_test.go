@@ -0,0 +1,53 @@
+package usercredentialsfile
+
+import (
+	"github.com/grepplabs/kafka-proxy/pkg/apis"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestReadUserCredentials(t *testing.T) {
+	a := assert.New(t)
+
+	credentials, err := readUserCredentials(strings.NewReader("# upstream credentials\n\nalice alice-upstream alice-secret\n  bob\tbob-upstream   bob-secret \n"))
+	a.Nil(err)
+	a.Equal(map[string]apis.UserCredentials{
+		"alice": {Username: "alice-upstream", Password: "alice-secret"},
+		"bob":   {Username: "bob-upstream", Password: "bob-secret"},
+	}, credentials)
+
+	_, err = readUserCredentials(strings.NewReader("alice alice-upstream"))
+	a.EqualError(err, "line 1: expected principal, username and password, got 2 fields")
+
+	_, err = readUserCredentials(strings.NewReader("alice a b\nalice c d"))
+	a.EqualError(err, "line 2: duplicate credentials of principal alice")
+}
+
+func TestFileProvider(t *testing.T) {
+	a := assert.New(t)
+
+	file, err := ioutil.TempFile("", "user-credentials")
+	a.Nil(err)
+	defer os.Remove(file.Name())
+	_, err = file.WriteString("alice alice-upstream alice-secret\n")
+	a.Nil(err)
+	a.Nil(file.Close())
+
+	provider, err := new(Factory).New([]string{"--file", file.Name()})
+	a.Nil(err)
+
+	credentials, found, err := provider.GetUserCredentials("alice")
+	a.Nil(err)
+	a.True(found)
+	a.Equal(apis.UserCredentials{Username: "alice-upstream", Password: "alice-secret"}, credentials)
+
+	_, found, err = provider.GetUserCredentials("bob")
+	a.Nil(err)
+	a.False(found)
+
+	_, err = new(Factory).New([]string{})
+	a.EqualError(err, "parameter file is required")
+}
@@ -0,0 +1,50 @@
+// Package shared contains shared data between the host and plugins.
+package shared
+
+import (
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	"github.com/grepplabs/kafka-proxy/pkg/apis"
+	"github.com/grepplabs/kafka-proxy/plugin/scram-credential-store/proto"
+	"github.com/hashicorp/go-plugin"
+	"net/rpc"
+)
+
+// Handshake is a common handshake that is shared by plugin and host.
+var Handshake = plugin.HandshakeConfig{
+	ProtocolVersion:  1,
+	MagicCookieKey:   "SCRAM_PLUGIN",
+	MagicCookieValue: "hello",
+}
+
+var PluginMap = map[string]plugin.Plugin{
+	"scramCredentialStore": &ScramCredentialStorePlugin{},
+}
+
+type ScramCredentialStorePlugin struct {
+	Impl apis.ScramCredentialStore
+}
+
+func (p *ScramCredentialStorePlugin) GRPCServer(broker *plugin.GRPCBroker, s *grpc.Server) error {
+	proto.RegisterScramCredentialStoreServer(s, &GRPCServer{
+		Impl:   p.Impl,
+		broker: broker,
+	})
+	return nil
+}
+
+func (p *ScramCredentialStorePlugin) GRPCClient(ctx context.Context, broker *plugin.GRPCBroker, c *grpc.ClientConn) (interface{}, error) {
+	return &GRPCClient{
+		client: proto.NewScramCredentialStoreClient(c),
+		broker: broker,
+	}, nil
+}
+
+func (p *ScramCredentialStorePlugin) Server(*plugin.MuxBroker) (interface{}, error) {
+	return &RPCServer{Impl: p.Impl}, nil
+}
+
+func (*ScramCredentialStorePlugin) Client(b *plugin.MuxBroker, c *rpc.Client) (interface{}, error) {
+	return &RPCClient{client: c}, nil
+}
@@ -0,0 +1,41 @@
+package shared
+
+import (
+	"github.com/grepplabs/kafka-proxy/pkg/apis"
+	"net/rpc"
+)
+
+type RPCClient struct{ client *rpc.Client }
+
+func (m *RPCClient) GetCredentials(mechanism, username string) (apis.ScramCredentials, bool, error) {
+	var resp map[string]interface{}
+	err := m.client.Call("Plugin.GetCredentials", map[string]interface{}{
+		"mechanism": mechanism,
+		"username":  username,
+	}, &resp)
+	if err != nil {
+		return apis.ScramCredentials{}, false, err
+	}
+	return apis.ScramCredentials{
+		Salt:       resp["salt"].([]byte),
+		Iterations: resp["iterations"].(int32),
+		StoredKey:  resp["storedKey"].([]byte),
+		ServerKey:  resp["serverKey"].([]byte),
+	}, resp["found"].(bool), nil
+}
+
+type RPCServer struct {
+	Impl apis.ScramCredentialStore
+}
+
+func (m *RPCServer) GetCredentials(args map[string]interface{}, resp *map[string]interface{}) error {
+	c, f, err := m.Impl.GetCredentials(args["mechanism"].(string), args["username"].(string))
+	*resp = map[string]interface{}{
+		"found":      f,
+		"salt":       c.Salt,
+		"iterations": c.Iterations,
+		"storedKey":  c.StoredKey,
+		"serverKey":  c.ServerKey,
+	}
+	return err
+}
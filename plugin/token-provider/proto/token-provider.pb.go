@@ -34,7 +34,8 @@ var _ = math.Inf
 const _ = proto1.ProtoPackageIsVersion2 // please upgrade the proto package
 
 type TokenRequest struct {
-	Params []string `protobuf:"bytes,1,rep,name=params" json:"params,omitempty"`
+	Params      []string `protobuf:"bytes,1,rep,name=params" json:"params,omitempty"`
+	ClientToken string   `protobuf:"bytes,2,opt,name=client_token,json=clientToken" json:"client_token,omitempty"`
 }
 
 func (m *TokenRequest) Reset()                    { *m = TokenRequest{} }
@@ -49,6 +50,13 @@ func (m *TokenRequest) GetParams() []string {
 	return nil
 }
 
+func (m *TokenRequest) GetClientToken() string {
+	if m != nil {
+		return m.ClientToken
+	}
+	return ""
+}
+
 type TokenResponse struct {
 	Success bool   `protobuf:"varint,1,opt,name=success" json:"success,omitempty"`
 	Status  int32  `protobuf:"varint,2,opt,name=status" json:"status,omitempty"`
@@ -161,17 +169,17 @@ var _TokenProvider_serviceDesc = grpc.ServiceDesc{
 func init() { proto1.RegisterFile("token-provider.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 178 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x54, 0x8e, 0x31, 0x0b, 0xc2, 0x30,
-	0x10, 0x85, 0x89, 0xa5, 0xb5, 0x3d, 0x74, 0x89, 0x45, 0x82, 0x53, 0xe9, 0x20, 0x59, 0xec, 0xa0,
-	0xf8, 0x17, 0x74, 0x95, 0x20, 0x38, 0xd7, 0x7a, 0x83, 0x88, 0x4d, 0xcc, 0xa5, 0xfe, 0x7e, 0x69,
-	0x12, 0x41, 0xa7, 0xe3, 0x7b, 0xf7, 0x78, 0x7c, 0x50, 0x3a, 0xfd, 0xc0, 0x7e, 0x63, 0xac, 0x7e,
-	0xdf, 0x6f, 0x68, 0x1b, 0x63, 0xb5, 0xd3, 0x3c, 0xf5, 0xa7, 0x5e, 0xc3, 0xec, 0x3c, 0xbe, 0x15,
-	0xbe, 0x06, 0x24, 0xc7, 0x97, 0x90, 0x99, 0xd6, 0xb6, 0x4f, 0x12, 0xac, 0x4a, 0x64, 0xa1, 0x22,
-	0xd5, 0x17, 0x98, 0xc7, 0x1e, 0x19, 0xdd, 0x13, 0x72, 0x01, 0x53, 0x1a, 0xba, 0x0e, 0x69, 0x6c,
-	0x32, 0x99, 0xab, 0x2f, 0x8e, 0x13, 0xe4, 0x5a, 0x37, 0x90, 0x98, 0x54, 0x4c, 0xa6, 0x2a, 0x12,
-	0x2f, 0x21, 0xf5, 0x26, 0x22, 0xa9, 0x98, 0x2c, 0x54, 0x80, 0xed, 0x21, 0x0e, 0x9f, 0xa2, 0x1e,
-	0xdf, 0x43, 0x7e, 0x44, 0xe7, 0x33, 0xbe, 0x08, 0xb2, 0xcd, 0xaf, 0xe2, 0xaa, 0xfc, 0x0f, 0x83,
-	0xcf, 0x35, 0xf3, 0xe1, 0xee, 0x13, 0x00, 0x00, 0xff, 0xff, 0xe7, 0xe1, 0x96, 0xe0, 0xee, 0x00,
-	0x00, 0x00,
+	// 191 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x54, 0x8f, 0x31, 0x0f, 0x82, 0x30,
+	0x10, 0x85, 0x83, 0x04, 0x84, 0x13, 0x97, 0x4a, 0x0c, 0x71, 0x42, 0x26, 0x16, 0x19, 0x34, 0xfe,
+	0x05, 0x8d, 0x9b, 0x69, 0x4c, 0x1c, 0x0d, 0xe2, 0x0d, 0x44, 0xa5, 0xb5, 0x57, 0xfc, 0xfd, 0x86,
+	0xb6, 0x26, 0x3a, 0x35, 0xdf, 0x6b, 0xf2, 0xbd, 0x7b, 0x90, 0x6a, 0x71, 0xc7, 0x6e, 0x25, 0x95,
+	0x78, 0xb7, 0x37, 0x54, 0x95, 0x54, 0x42, 0x0b, 0x16, 0x98, 0xa7, 0x38, 0x40, 0x72, 0x1a, 0xbe,
+	0x39, 0xbe, 0x7a, 0x24, 0xcd, 0xe6, 0x10, 0xca, 0x5a, 0xd5, 0x4f, 0xca, 0xbc, 0xdc, 0x2f, 0x63,
+	0xee, 0x88, 0x2d, 0x21, 0x69, 0x1e, 0x2d, 0x76, 0xfa, 0x62, 0x6c, 0xd9, 0x28, 0xf7, 0xca, 0x98,
+	0x4f, 0x6c, 0x66, 0x0c, 0xc5, 0x19, 0xa6, 0x4e, 0x45, 0x52, 0x74, 0x84, 0x2c, 0x83, 0x31, 0xf5,
+	0x4d, 0x83, 0x34, 0xc8, 0xbc, 0x32, 0xe2, 0x5f, 0x1c, 0x5a, 0x48, 0xd7, 0xba, 0x27, 0xe3, 0x09,
+	0xb8, 0x23, 0x96, 0x42, 0x60, 0xf5, 0xbe, 0xd1, 0x5b, 0x58, 0xef, 0x9c, 0xf8, 0xe8, 0x16, 0xb0,
+	0x2d, 0x44, 0x7b, 0xb4, 0xad, 0x6c, 0x66, 0xf7, 0x54, 0xbf, 0x2b, 0x16, 0xe9, 0x7f, 0x68, 0xef,
+	0xb9, 0x86, 0x26, 0xdc, 0x7c, 0x06, 0x00, 0x85, 0xcf, 0x0f, 0x7c, 0x11, 0x01, 0x00, 0x00,
 }
@@ -14,7 +14,7 @@ type GRPCClient struct {
 }
 
 func (m *GRPCClient) GetToken(ctx context.Context, request apis.TokenRequest) (apis.TokenResponse, error) {
-	resp, err := m.client.GetToken(ctx, &proto.TokenRequest{Params: request.Params})
+	resp, err := m.client.GetToken(ctx, &proto.TokenRequest{Params: request.Params, ClientToken: request.ClientToken})
 	return apis.TokenResponse{Success: resp.Success, Status: resp.Status, Token: resp.Token}, err
 }
 
@@ -27,6 +27,6 @@ type GRPCServer struct {
 func (m *GRPCServer) GetToken(
 	ctx context.Context,
 	req *proto.TokenRequest) (*proto.TokenResponse, error) {
-	resp, err := m.Impl.GetToken(ctx, apis.TokenRequest{Params: req.Params})
+	resp, err := m.Impl.GetToken(ctx, apis.TokenRequest{Params: req.Params, ClientToken: req.ClientToken})
 	return &proto.TokenResponse{Success: resp.Success, Status: resp.Status, Token: resp.Token}, err
 }
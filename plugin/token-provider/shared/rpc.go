@@ -11,7 +11,8 @@ type RPCClient struct{ client *rpc.Client }
 func (m *RPCClient) GetToken(request apis.TokenRequest) (apis.TokenResponse, error) {
 	var resp map[string]interface{}
 	err := m.client.Call("Plugin.GetToken", map[string]interface{}{
-		"params": request.Params,
+		"params":      request.Params,
+		"clientToken": request.ClientToken,
 	}, &resp)
 	return apis.TokenResponse{Success: resp["success"].(bool), Status: resp["status"].(int32), Token: resp["token"].(string)}, err
 }
@@ -21,8 +22,8 @@ type RPCServer struct {
 }
 
 func (m *RPCServer) GetToken(args map[string]interface{}, resp *map[string]interface{}) error {
-
-	r, err := m.Impl.GetToken(context.Background(), apis.TokenRequest{Params: args["params"].([]string)})
+	clientToken, _ := args["clientToken"].(string)
+	r, err := m.Impl.GetToken(context.Background(), apis.TokenRequest{Params: args["params"].([]string), ClientToken: clientToken})
 	*resp = map[string]interface{}{
 		"success": r.Success,
 		"status":  r.Status,
@@ -0,0 +1,37 @@
+package shared
+
+import (
+	"github.com/grepplabs/kafka-proxy/pkg/apis"
+	"github.com/grepplabs/kafka-proxy/plugin/user-credentials/proto"
+	"github.com/hashicorp/go-plugin"
+	"golang.org/x/net/context"
+)
+
+// GRPCClient is an implementation of UserCredentialsProvider that talks over gRPC.
+type GRPCClient struct {
+	broker *plugin.GRPCBroker
+	client proto.UserCredentialsProviderClient
+}
+
+func (m *GRPCClient) GetUserCredentials(principal string) (apis.UserCredentials, bool, error) {
+	resp, err := m.client.GetUserCredentials(context.Background(), &proto.UserCredentialsRequest{
+		Principal: principal,
+	})
+	if err != nil {
+		return apis.UserCredentials{}, false, err
+	}
+	return apis.UserCredentials{Username: resp.Username, Password: resp.Password}, resp.Found, nil
+}
+
+// Here is the gRPC server that GRPCClient talks to.
+type GRPCServer struct {
+	broker *plugin.GRPCBroker
+	Impl   apis.UserCredentialsProvider
+}
+
+func (m *GRPCServer) GetUserCredentials(
+	ctx context.Context,
+	req *proto.UserCredentialsRequest) (*proto.UserCredentialsResponse, error) {
+	c, f, err := m.Impl.GetUserCredentials(req.Principal)
+	return &proto.UserCredentialsResponse{Found: f, Username: c.Username, Password: c.Password}, err
+}
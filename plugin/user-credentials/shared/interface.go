@@ -0,0 +1,50 @@
+// Package shared contains shared data between the host and plugins.
+package shared
+
+import (
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	"github.com/grepplabs/kafka-proxy/pkg/apis"
+	"github.com/grepplabs/kafka-proxy/plugin/user-credentials/proto"
+	"github.com/hashicorp/go-plugin"
+	"net/rpc"
+)
+
+// Handshake is a common handshake that is shared by plugin and host.
+var Handshake = plugin.HandshakeConfig{
+	ProtocolVersion:  1,
+	MagicCookieKey:   "USER_CREDENTIALS_PLUGIN",
+	MagicCookieValue: "hello",
+}
+
+var PluginMap = map[string]plugin.Plugin{
+	"userCredentialsProvider": &UserCredentialsProviderPlugin{},
+}
+
+type UserCredentialsProviderPlugin struct {
+	Impl apis.UserCredentialsProvider
+}
+
+func (p *UserCredentialsProviderPlugin) GRPCServer(broker *plugin.GRPCBroker, s *grpc.Server) error {
+	proto.RegisterUserCredentialsProviderServer(s, &GRPCServer{
+		Impl:   p.Impl,
+		broker: broker,
+	})
+	return nil
+}
+
+func (p *UserCredentialsProviderPlugin) GRPCClient(ctx context.Context, broker *plugin.GRPCBroker, c *grpc.ClientConn) (interface{}, error) {
+	return &GRPCClient{
+		client: proto.NewUserCredentialsProviderClient(c),
+		broker: broker,
+	}, nil
+}
+
+func (p *UserCredentialsProviderPlugin) Server(*plugin.MuxBroker) (interface{}, error) {
+	return &RPCServer{Impl: p.Impl}, nil
+}
+
+func (*UserCredentialsProviderPlugin) Client(b *plugin.MuxBroker, c *rpc.Client) (interface{}, error) {
+	return &RPCClient{client: c}, nil
+}
@@ -0,0 +1,36 @@
+package shared
+
+import (
+	"github.com/grepplabs/kafka-proxy/pkg/apis"
+	"net/rpc"
+)
+
+type RPCClient struct{ client *rpc.Client }
+
+func (m *RPCClient) GetUserCredentials(principal string) (apis.UserCredentials, bool, error) {
+	var resp map[string]interface{}
+	err := m.client.Call("Plugin.GetUserCredentials", map[string]interface{}{
+		"principal": principal,
+	}, &resp)
+	if err != nil {
+		return apis.UserCredentials{}, false, err
+	}
+	return apis.UserCredentials{
+		Username: resp["username"].(string),
+		Password: resp["password"].(string),
+	}, resp["found"].(bool), nil
+}
+
+type RPCServer struct {
+	Impl apis.UserCredentialsProvider
+}
+
+func (m *RPCServer) GetUserCredentials(args map[string]interface{}, resp *map[string]interface{}) error {
+	c, f, err := m.Impl.GetUserCredentials(args["principal"].(string))
+	*resp = map[string]interface{}{
+		"found":    f,
+		"username": c.Username,
+		"password": c.Password,
+	}
+	return err
+}
@@ -0,0 +1,196 @@
+package proxy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/grepplabs/kafka-proxy/proxy/protocol"
+)
+
+const (
+	aclOperationRead     = "read"
+	aclOperationWrite    = "write"
+	aclOperationDescribe = "describe"
+	aclOperationAll      = "all"
+
+	aclAnyPrincipal = "*"
+
+	// principal of connections without local SASL authentication and client certificate
+	anonymousPrincipal = "ANONYMOUS"
+)
+
+// operations checked for the api keys with topics
+var aclApiKeyOperations = map[int16]string{
+	0: aclOperationWrite,    // Produce
+	1: aclOperationRead,     // Fetch
+	2: aclOperationDescribe, // ListOffsets
+	3: aclOperationDescribe, // Metadata
+	8: aclOperationRead,     // OffsetCommit
+	9: aclOperationDescribe, // OffsetFetch
+}
+
+// api keys without topics which are forwarded unchecked with topic ACLs. Other api keys without the topic operation
+// are rejected, the requests e.g. DeleteTopics or AlterConfigs would bypass the ACLs.
+var aclTopicFreeApiKeys = map[int16]struct{}{
+	10: {}, // FindCoordinator
+	11: {}, // JoinGroup
+	12: {}, // Heartbeat
+	13: {}, // LeaveGroup
+	14: {}, // SyncGroup
+	15: {}, // DescribeGroups
+	16: {}, // ListGroups
+	17: {}, // SaslHandshake
+	18: {}, // ApiVersions
+	22: {}, // InitProducerId
+	25: {}, // AddOffsetsToTxn
+	26: {}, // EndTxn
+	36: {}, // SaslAuthenticate
+	42: {}, // DeleteGroups
+	60: {}, // DescribeCluster
+}
+
+type aclRule struct {
+	allow      bool
+	principal  string
+	operations map[string]struct{}
+	topic      string // path.Match pattern
+}
+
+func (r aclRule) matches(principal, operation, topic string) bool {
+	if r.principal != aclAnyPrincipal && r.principal != principal {
+		return false
+	}
+	if _, ok := r.operations[aclOperationAll]; !ok {
+		if _, ok := r.operations[operation]; !ok {
+			return false
+		}
+	}
+	ok, _ := path.Match(r.topic, topic)
+	return ok
+}
+
+// TopicAuthorizer decides which topics a principal may access. A deny rule takes precedence over an allow rule,
+// a topic without a matching rule is denied.
+type TopicAuthorizer struct {
+	rules []aclRule
+}
+
+// NewTopicAuthorizerFromFile reads ACL rules from the file. Each line which is not empty or a comment (#) has the format
+// "<allow|deny> <principal|*> <read,write,describe|all> <topic pattern>"
+func NewTopicAuthorizerFromFile(filename string) (*TopicAuthorizer, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	authorizer, err := newTopicAuthorizer(file)
+	if err != nil {
+		return nil, fmt.Errorf("ACL rules file %s: %v", filename, err)
+	}
+	return authorizer, nil
+}
+
+func newTopicAuthorizer(reader io.Reader) (*TopicAuthorizer, error) {
+	rules := make([]aclRule, 0)
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule, err := parseAclRule(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+		rules = append(rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return &TopicAuthorizer{rules: rules}, nil
+}
+
+func parseAclRule(line string) (aclRule, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 4 {
+		return aclRule{}, fmt.Errorf("4 fields expected, got %d", len(fields))
+	}
+	rule := aclRule{principal: fields[1], operations: make(map[string]struct{}), topic: fields[3]}
+	switch strings.ToLower(fields[0]) {
+	case "allow":
+		rule.allow = true
+	case "deny":
+		rule.allow = false
+	default:
+		return aclRule{}, fmt.Errorf("permission must be allow or deny, got %s", fields[0])
+	}
+	for _, operation := range strings.Split(strings.ToLower(fields[2]), ",") {
+		switch operation {
+		case aclOperationRead, aclOperationWrite, aclOperationDescribe, aclOperationAll:
+			rule.operations[operation] = struct{}{}
+		default:
+			return aclRule{}, fmt.Errorf("unknown operation %s", operation)
+		}
+	}
+	if _, err := path.Match(rule.topic, ""); err != nil {
+		return aclRule{}, fmt.Errorf("invalid topic pattern %s: %v", rule.topic, err)
+	}
+	return rule, nil
+}
+
+// Authorize returns true if the principal is allowed to perform the operation on the topic
+func (a *TopicAuthorizer) Authorize(principal, operation, topic string) bool {
+	allowed := false
+	for _, rule := range a.rules {
+		if !rule.matches(principal, operation, topic) {
+			continue
+		}
+		if !rule.allow {
+			return false
+		}
+		allowed = true
+	}
+	return allowed
+}
+
+// authorizeRequest removes the denied topics from the request. It returns the denied topics and the response modifier
+// which adds TOPIC_AUTHORIZATION_FAILED entries for them. For requests referring to all topics the denied topics are removed from the response.
+func (a *TopicAuthorizer) authorizeRequest(principal string, request *protocol.DecodedRequest) (denied []protocol.TopicPartitions, responseModifier protocol.ResponseModifier, err error) {
+	operation, ok := aclApiKeyOperations[request.ApiKey]
+	if !ok {
+		return nil, nil, nil
+	}
+	isDenied := func(topic string) bool {
+		// Metadata topics referenced only by the topic id have no name to authorize, they are denied
+		return topic == "" || !a.Authorize(principal, operation, topic)
+	}
+	_, allTopics, err := request.Topics()
+	if err != nil {
+		return nil, nil, err
+	}
+	if allTopics {
+		responseModifier, err = protocol.NewTopicsResponseModifier(request.ApiKey, request.ApiVersion, isDenied, nil, protocol.ErrTopicAuthorizationFailed)
+		return nil, responseModifier, err
+	}
+	denied, err = request.RemoveTopics(isDenied)
+	if err != nil || len(denied) == 0 {
+		return nil, nil, err
+	}
+	var remove func(topic string) bool
+	if _, allTopics, err = request.Topics(); err != nil {
+		return nil, nil, err
+	}
+	if allTopics {
+		// all requested topics were denied and empty topics in Metadata v0 request means all topics
+		remove = func(topic string) bool { return true }
+	}
+	responseModifier, err = protocol.NewTopicsResponseModifier(request.ApiKey, request.ApiVersion, remove, denied, protocol.ErrTopicAuthorizationFailed)
+	return denied, responseModifier, err
+}
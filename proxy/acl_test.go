@@ -0,0 +1,50 @@
+package proxy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testAclRules = `
+# consumers
+allow  alice  read,describe  orders-*
+allow  *      describe       public.*
+deny   *      all            orders-secret
+allow  bob    all            *
+`
+
+func TestTopicAuthorizer(t *testing.T) {
+	a := assert.New(t)
+
+	authorizer, err := newTopicAuthorizer(strings.NewReader(testAclRules))
+	a.Nil(err)
+
+	a.True(authorizer.Authorize("alice", aclOperationRead, "orders-eu"))
+	a.True(authorizer.Authorize("alice", aclOperationDescribe, "orders-eu"))
+	a.False(authorizer.Authorize("alice", aclOperationWrite, "orders-eu"))
+	a.False(authorizer.Authorize("alice", aclOperationRead, "orders-secret"))
+	a.True(authorizer.Authorize("alice", aclOperationDescribe, "public.events"))
+	a.False(authorizer.Authorize("alice", aclOperationRead, "public.events"))
+	a.True(authorizer.Authorize("bob", aclOperationWrite, "payments"))
+	a.False(authorizer.Authorize("bob", aclOperationWrite, "orders-secret"))
+	a.False(authorizer.Authorize(anonymousPrincipal, aclOperationRead, "payments"))
+	a.True(authorizer.Authorize(anonymousPrincipal, aclOperationDescribe, "public.events"))
+}
+
+func TestTopicAuthorizerInvalidRules(t *testing.T) {
+	tests := []struct {
+		rules string
+		err   string
+	}{
+		{rules: "allow alice read", err: "line 1: 4 fields expected, got 3"},
+		{rules: "\npermit alice read orders", err: "line 2: permission must be allow or deny, got permit"},
+		{rules: "allow alice create orders", err: "line 1: unknown operation create"},
+		{rules: "allow alice read orders[", err: "line 1: invalid topic pattern orders[: syntax error in pattern"},
+	}
+	for _, tc := range tests {
+		_, err := newTopicAuthorizer(strings.NewReader(tc.rules))
+		assert.EqualError(t, err, tc.err)
+	}
+}
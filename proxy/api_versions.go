@@ -0,0 +1,67 @@
+package proxy
+
+import (
+	"github.com/grepplabs/kafka-proxy/proxy/protocol"
+)
+
+const (
+	apiKeyFetch           = int16(1)
+	apiKeyFindCoordinator = int16(10)
+
+	// highest Produce and Fetch versions which responses carry no node_endpoints, responses of the newer versions
+	// must be read into memory to rewrite the broker addresses
+	maxProduceStreamedApiVersion = int16(9)
+	maxFetchStreamedApiVersion   = int16(15)
+	// highest produce version which acks can be read by mustReply
+	maxProduceAcksApiVersion = int16(12)
+	// highest versions handled by local SASL
+	maxLocalSaslHandshakeApiVersion    = int16(1)
+	maxLocalSaslAuthenticateApiVersion = int16(2)
+)
+
+// apiVersionLimits are the highest api versions the proxy can handle, they are used to lower max_version in ApiVersions responses
+// so clients negotiate versions which requests and responses the proxy is able to inspect or rewrite
+type apiVersionLimits map[int16]int16
+
+func (l apiVersionLimits) limit(apiKey int16, maxVersion int16) {
+	if current, ok := l[apiKey]; !ok || maxVersion < current {
+		l[apiKey] = maxVersion
+	}
+}
+
+// limitDecoded limits the api key to the versions which request and response (if known) can be decoded
+func (l apiVersionLimits) limitDecoded(apiKey int16) {
+	l.limit(apiKey, protocol.MaxRequestSchemaVersion(apiKey))
+	if maxVersion := protocol.MaxResponseSchemaVersion(apiKey); maxVersion >= 0 {
+		l.limit(apiKey, maxVersion)
+	}
+}
+
+func newApiVersionLimits(cfg ProcessorConfig) apiVersionLimits {
+	limits := make(apiVersionLimits)
+	// broker addresses are always rewritten
+	limits.limit(apiKeyMetadata, protocol.MaxResponseSchemaVersion(apiKeyMetadata))
+	limits.limit(apiKeyFindCoordinator, protocol.MaxResponseSchemaVersion(apiKeyFindCoordinator))
+	limits.limit(apiKeyDescribeCluster, protocol.MaxResponseSchemaVersion(apiKeyDescribeCluster))
+	// Produce and Fetch responses are streamed, the versions with node endpoints are not negotiated
+	limits.limit(apiKeyProduce, maxProduceStreamedApiVersion)
+	limits.limit(apiKeyFetch, maxFetchStreamedApiVersion)
+
+	if !cfg.ProducerAcks0Disabled {
+		limits.limit(apiKeyProduce, maxProduceAcksApiVersion)
+	}
+	if cfg.LocalSasl != nil && cfg.LocalSasl.enabled {
+		limits.limit(apiKeySaslHandshake, maxLocalSaslHandshakeApiVersion)
+		limits.limit(apiKeySaslAuthenticate, maxLocalSaslAuthenticateApiVersion)
+	}
+	for apiKey := minRequestApiKey; apiKey <= maxRequestApiKey; apiKey++ {
+		if (cfg.TopicAuthorizer != nil && protocol.IsTopicsApiKey(apiKey)) || (cfg.Namespaces != nil && protocol.IsNamesApiKey(apiKey)) {
+			limits.limitDecoded(apiKey)
+		}
+		// requests of newer versions would be forwarded without the modification
+		if maxVersion := cfg.RequestModifiers.MaxVersion(apiKey); maxVersion >= 0 {
+			limits.limit(apiKey, maxVersion)
+		}
+	}
+	return limits
+}
@@ -0,0 +1,30 @@
+package proxy
+
+import (
+	"testing"
+
+	"github.com/grepplabs/kafka-proxy/proxy/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewApiVersionLimits(t *testing.T) {
+	a := assert.New(t)
+
+	limits := newApiVersionLimits(ProcessorConfig{})
+	a.Equal(apiVersionLimits{apiKeyProduce: 9, apiKeyFetch: 15, apiKeyMetadata: 12, apiKeyFindCoordinator: 6, apiKeyDescribeCluster: 2}, limits)
+
+	limits = newApiVersionLimits(ProcessorConfig{ProducerAcks0Disabled: true, LocalSasl: &LocalSasl{enabled: true}})
+	a.Equal(apiVersionLimits{apiKeyProduce: 9, apiKeyFetch: 15, apiKeyMetadata: 12, apiKeyFindCoordinator: 6, apiKeyDescribeCluster: 2, apiKeySaslHandshake: 1, apiKeySaslAuthenticate: 2}, limits)
+
+	limits = newApiVersionLimits(ProcessorConfig{TopicAuthorizer: &TopicAuthorizer{}, Namespaces: &Namespaces{}})
+	a.Equal(maxProduceStreamedApiVersion, limits[apiKeyProduce])
+	a.Equal(protocol.MaxRequestSchemaVersion(apiKeyFetch), limits[apiKeyFetch])
+	a.Equal(protocol.MaxRequestSchemaVersion(11), limits[11])
+	_, ok := limits[apiKeySaslHandshake]
+	a.False(ok)
+
+	modifiers := protocol.NewRequestModifiers()
+	a.Nil(modifiers.Register(apiKeyProduce, 0, 8, protocol.RequestModifierFunc(func(request *protocol.DecodedRequest) error { return nil })))
+	limits = newApiVersionLimits(ProcessorConfig{ProducerAcks0Disabled: true, RequestModifiers: modifiers})
+	a.Equal(int16(8), limits[apiKeyProduce])
+}
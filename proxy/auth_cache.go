@@ -0,0 +1,231 @@
+package proxy
+
+import (
+	"container/list"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grepplabs/kafka-proxy/pkg/apis"
+	"github.com/pkg/errors"
+)
+
+const (
+	AuthCacheLocalPlain       = "local-plain"
+	AuthCacheLocalOauthBearer = "local-oauthbearer"
+	AuthCacheGatewayServer    = "gateway-server"
+)
+
+type AuthCacheOptions struct {
+	SuccessTTL time.Duration
+	FailureTTL time.Duration
+	MaxSize    int
+}
+
+type authCacheKey [sha256.Size]byte
+
+type authCacheEntry struct {
+	key     authCacheKey
+	value   interface{}
+	expires time.Time
+}
+
+// authCache is a size bounded LRU cache of authentication results with separate TTLs for successful and failed authentications.
+// The keys are HMACs of the credentials with a random salt, so the credentials are not kept in memory.
+type authCache struct {
+	name       string
+	salt       []byte
+	successTTL time.Duration
+	failureTTL time.Duration
+	maxSize    int
+	nowFn      func() time.Time
+
+	entries map[authCacheKey]*list.Element
+	lru     *list.List
+	l       sync.Mutex
+}
+
+func newAuthCache(name string, options AuthCacheOptions) (*authCache, error) {
+	if options.MaxSize <= 0 {
+		return nil, errors.New("auth cache max size must be greater than 0")
+	}
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, errors.Wrap(err, "generating auth cache salt")
+	}
+	return &authCache{
+		name:       name,
+		salt:       salt,
+		successTTL: options.SuccessTTL,
+		failureTTL: options.FailureTTL,
+		maxSize:    options.MaxSize,
+		nowFn:      time.Now,
+		entries:    make(map[authCacheKey]*list.Element),
+		lru:        list.New(),
+	}, nil
+}
+
+func (c *authCache) key(parts ...string) authCacheKey {
+	mac := hmac.New(sha256.New, c.salt)
+	length := make([]byte, 8)
+	for _, part := range parts {
+		binary.BigEndian.PutUint64(length, uint64(len(part)))
+		mac.Write(length)
+		mac.Write([]byte(part))
+	}
+	var result authCacheKey
+	copy(result[:], mac.Sum(nil))
+	return result
+}
+
+func (c *authCache) get(key authCacheKey) (interface{}, bool) {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		proxyAuthCacheTotal.WithLabelValues(c.name, "miss").Inc()
+		return nil, false
+	}
+	entry := elem.Value.(*authCacheEntry)
+	if !c.nowFn().Before(entry.expires) {
+		c.removeElement(elem)
+		proxyAuthCacheTotal.WithLabelValues(c.name, "miss").Inc()
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	proxyAuthCacheTotal.WithLabelValues(c.name, "hit").Inc()
+	return entry.value, true
+}
+
+// set stores the result for the success or failure TTL. The ttl parameter limits the TTL if it is greater than 0.
+func (c *authCache) set(key authCacheKey, value interface{}, success bool, ttl time.Duration) {
+	maxTTL := c.failureTTL
+	if success {
+		maxTTL = c.successTTL
+	}
+	if ttl <= 0 || ttl > maxTTL {
+		ttl = maxTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	expires := c.nowFn().Add(ttl)
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*authCacheEntry)
+		entry.value = value
+		entry.expires = expires
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&authCacheEntry{key: key, value: value, expires: expires})
+	for c.lru.Len() > c.maxSize {
+		c.removeElement(c.lru.Back())
+		proxyAuthCacheEvictionsTotal.WithLabelValues(c.name).Inc()
+	}
+	proxyAuthCacheEntries.WithLabelValues(c.name).Set(float64(c.lru.Len()))
+}
+
+func (c *authCache) removeElement(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*authCacheEntry).key)
+	proxyAuthCacheEntries.WithLabelValues(c.name).Set(float64(c.lru.Len()))
+}
+
+type passwordAuthResult struct {
+	ok     bool
+	status int32
+}
+
+type cachingPasswordAuthenticator struct {
+	delegate apis.PasswordAuthenticator
+	cache    *authCache
+}
+
+// NewCachingPasswordAuthenticator caches the results of the PasswordAuthenticator. Errors are not cached.
+func NewCachingPasswordAuthenticator(name string, delegate apis.PasswordAuthenticator, options AuthCacheOptions) (apis.PasswordAuthenticator, error) {
+	cache, err := newAuthCache(name, options)
+	if err != nil {
+		return nil, err
+	}
+	return &cachingPasswordAuthenticator{delegate: delegate, cache: cache}, nil
+}
+
+func (a *cachingPasswordAuthenticator) Authenticate(username, password string) (bool, int32, error) {
+	key := a.cache.key(username, password)
+	if value, ok := a.cache.get(key); ok {
+		result := value.(passwordAuthResult)
+		return result.ok, result.status, nil
+	}
+	ok, status, err := a.delegate.Authenticate(username, password)
+	if err != nil {
+		return ok, status, err
+	}
+	a.cache.set(key, passwordAuthResult{ok: ok, status: status}, ok, 0)
+	return ok, status, nil
+}
+
+type cachingTokenInfo struct {
+	delegate apis.TokenInfo
+	cache    *authCache
+}
+
+// NewCachingTokenInfo caches the results of the TokenInfo. Successful verifications of JWTs are not cached longer than the token expiration.
+func NewCachingTokenInfo(name string, delegate apis.TokenInfo, options AuthCacheOptions) (apis.TokenInfo, error) {
+	cache, err := newAuthCache(name, options)
+	if err != nil {
+		return nil, err
+	}
+	return &cachingTokenInfo{delegate: delegate, cache: cache}, nil
+}
+
+func (a *cachingTokenInfo) VerifyToken(ctx context.Context, request apis.VerifyRequest) (apis.VerifyResponse, error) {
+	key := a.cache.key(append([]string{request.Token}, request.Params...)...)
+	if value, ok := a.cache.get(key); ok {
+		return value.(apis.VerifyResponse), nil
+	}
+	resp, err := a.delegate.VerifyToken(ctx, request)
+	if err != nil {
+		return resp, err
+	}
+	var ttl time.Duration
+	if resp.Success {
+		if exp, ok := jwtExpiration(request.Token); ok {
+			ttl = exp.Sub(a.cache.nowFn())
+			if ttl <= 0 {
+				return resp, nil
+			}
+		}
+	}
+	a.cache.set(key, resp, resp.Success, ttl)
+	return resp, nil
+}
+
+// jwtExpiration returns the exp claim of the JWT, the token signature must be already verified
+func jwtExpiration(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, false
+	}
+	claims := struct {
+		Exp int64 `json:"exp"`
+	}{}
+	if err = json.Unmarshal(payload, &claims); err != nil || claims.Exp <= 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(claims.Exp, 0), true
+}
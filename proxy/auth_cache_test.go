@@ -0,0 +1,197 @@
+package proxy
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/grepplabs/kafka-proxy/pkg/apis"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingPasswordAuthenticator struct {
+	calls int
+	err   error
+}
+
+func (p *countingPasswordAuthenticator) Authenticate(username, password string) (bool, int32, error) {
+	p.calls++
+	if p.err != nil {
+		return false, 0, p.err
+	}
+	if password == username+"-secret" {
+		return true, 0, nil
+	}
+	return false, 3, nil
+}
+
+type countingTokenInfo struct {
+	calls int
+}
+
+func (p *countingTokenInfo) VerifyToken(ctx context.Context, request apis.VerifyRequest) (apis.VerifyResponse, error) {
+	p.calls++
+	if request.Token == "invalid" {
+		return apis.VerifyResponse{Success: false, Status: 5}, nil
+	}
+	return apis.VerifyResponse{Success: true}, nil
+}
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func unsignedJWT(exp int64) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	return encode([]byte(`{"alg":"none"}`)) + "." + encode([]byte(fmt.Sprintf(`{"sub":"alice","exp":%d}`, exp))) + "."
+}
+
+func TestCachingPasswordAuthenticator(t *testing.T) {
+	a := assert.New(t)
+
+	delegate := &countingPasswordAuthenticator{}
+	authenticator, err := NewCachingPasswordAuthenticator("test", delegate, AuthCacheOptions{SuccessTTL: time.Minute, FailureTTL: 10 * time.Second, MaxSize: 10})
+	a.Nil(err)
+	clock := &fakeClock{now: time.Now()}
+	authenticator.(*cachingPasswordAuthenticator).cache.nowFn = clock.Now
+
+	for i := 0; i < 3; i++ {
+		ok, status, err := authenticator.Authenticate("alice", "alice-secret")
+		a.Nil(err)
+		a.True(ok)
+		a.Equal(int32(0), status)
+		ok, status, err = authenticator.Authenticate("alice", "wrong")
+		a.Nil(err)
+		a.False(ok)
+		a.Equal(int32(3), status)
+	}
+	a.Equal(2, delegate.calls)
+
+	// failure expired, success still cached
+	clock.now = clock.now.Add(11 * time.Second)
+	_, _, _ = authenticator.Authenticate("alice", "alice-secret")
+	a.Equal(2, delegate.calls)
+	ok, _, _ := authenticator.Authenticate("alice", "wrong")
+	a.False(ok)
+	a.Equal(3, delegate.calls)
+
+	clock.now = clock.now.Add(time.Minute)
+	_, _, _ = authenticator.Authenticate("alice", "alice-secret")
+	a.Equal(4, delegate.calls)
+
+	// username and password are not concatenated ambiguously
+	ok, _, _ = authenticator.Authenticate("alice-", "secret")
+	a.False(ok)
+	a.Equal(5, delegate.calls)
+}
+
+func TestCachingPasswordAuthenticatorErrorsAreNotCached(t *testing.T) {
+	a := assert.New(t)
+
+	delegate := &countingPasswordAuthenticator{err: errors.New("rpc error")}
+	authenticator, err := NewCachingPasswordAuthenticator("test", delegate, AuthCacheOptions{SuccessTTL: time.Minute, FailureTTL: time.Minute, MaxSize: 10})
+	a.Nil(err)
+	_, _, err = authenticator.Authenticate("alice", "alice-secret")
+	a.NotNil(err)
+	_, _, err = authenticator.Authenticate("alice", "alice-secret")
+	a.NotNil(err)
+	a.Equal(2, delegate.calls)
+}
+
+func TestCachingPasswordAuthenticatorNoFailureTTL(t *testing.T) {
+	a := assert.New(t)
+
+	delegate := &countingPasswordAuthenticator{}
+	authenticator, err := NewCachingPasswordAuthenticator("test", delegate, AuthCacheOptions{SuccessTTL: time.Minute, MaxSize: 10})
+	a.Nil(err)
+	_, _, _ = authenticator.Authenticate("alice", "wrong")
+	_, _, _ = authenticator.Authenticate("alice", "wrong")
+	a.Equal(2, delegate.calls)
+}
+
+func TestAuthCacheMaxSize(t *testing.T) {
+	a := assert.New(t)
+
+	cache, err := newAuthCache("test", AuthCacheOptions{SuccessTTL: time.Minute, FailureTTL: time.Minute, MaxSize: 2})
+	a.Nil(err)
+	cache.set(cache.key("a"), 1, true, 0)
+	cache.set(cache.key("b"), 2, true, 0)
+	_, ok := cache.get(cache.key("a"))
+	a.True(ok)
+	cache.set(cache.key("c"), 3, true, 0)
+
+	// b is the least recently used
+	_, ok = cache.get(cache.key("b"))
+	a.False(ok)
+	value, ok := cache.get(cache.key("a"))
+	a.True(ok)
+	a.Equal(1, value)
+	value, ok = cache.get(cache.key("c"))
+	a.True(ok)
+	a.Equal(3, value)
+	a.Equal(2, cache.lru.Len())
+	a.Len(cache.entries, 2)
+
+	_, err = newAuthCache("test", AuthCacheOptions{SuccessTTL: time.Minute})
+	a.EqualError(err, "auth cache max size must be greater than 0")
+}
+
+func TestCachingTokenInfo(t *testing.T) {
+	a := assert.New(t)
+
+	delegate := &countingTokenInfo{}
+	tokenInfo, err := NewCachingTokenInfo("test", delegate, AuthCacheOptions{SuccessTTL: time.Hour, FailureTTL: time.Minute, MaxSize: 10})
+	a.Nil(err)
+	clock := &fakeClock{now: time.Now()}
+	tokenInfo.(*cachingTokenInfo).cache.nowFn = clock.Now
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		resp, err := tokenInfo.VerifyToken(ctx, apis.VerifyRequest{Token: "opaque"})
+		a.Nil(err)
+		a.True(resp.Success)
+		resp, err = tokenInfo.VerifyToken(ctx, apis.VerifyRequest{Token: "invalid"})
+		a.Nil(err)
+		a.Equal(apis.VerifyResponse{Success: false, Status: 5}, resp)
+	}
+	a.Equal(2, delegate.calls)
+
+	// params are part of the key
+	_, _ = tokenInfo.VerifyToken(ctx, apis.VerifyRequest{Token: "opaque", Params: []string{"--audience=kafka"}})
+	a.Equal(3, delegate.calls)
+
+	// JWT is not cached longer than the expiration
+	token := unsignedJWT(clock.now.Add(2 * time.Minute).Unix())
+	_, _ = tokenInfo.VerifyToken(ctx, apis.VerifyRequest{Token: token})
+	_, _ = tokenInfo.VerifyToken(ctx, apis.VerifyRequest{Token: token})
+	a.Equal(4, delegate.calls)
+	clock.now = clock.now.Add(3 * time.Minute)
+	_, _ = tokenInfo.VerifyToken(ctx, apis.VerifyRequest{Token: token})
+	a.Equal(5, delegate.calls)
+
+	// expired JWT is not cached
+	_, _ = tokenInfo.VerifyToken(ctx, apis.VerifyRequest{Token: token})
+	a.Equal(6, delegate.calls)
+}
+
+func TestJWTExpiration(t *testing.T) {
+	a := assert.New(t)
+
+	exp, ok := jwtExpiration(unsignedJWT(1600000000))
+	a.True(ok)
+	a.Equal(int64(1600000000), exp.Unix())
+
+	_, ok = jwtExpiration("opaque")
+	a.False(ok)
+	_, ok = jwtExpiration(unsignedJWT(0))
+	a.False(ok)
+	_, ok = jwtExpiration("a.!!!.c")
+	a.False(ok)
+}
@@ -2,7 +2,6 @@ package proxy
 
 import (
 	"crypto/tls"
-	"crypto/x509"
 	"fmt"
 	"net"
 	"sync"
@@ -10,6 +9,7 @@ import (
 
 	"github.com/grepplabs/kafka-proxy/config"
 	"github.com/grepplabs/kafka-proxy/pkg/apis"
+	"github.com/grepplabs/kafka-proxy/proxy/protocol"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
@@ -31,35 +31,69 @@ type Client struct {
 	// Config of Proxy request-response processor (instance p)
 	processorConfig ProcessorConfig
 
-	dialer         Dialer
-	tcpConnOptions TCPConnOptions
+	dialer          Dialer
+	tlsCertificates *tlsCertificates
+	tcpConnOptions  TCPConnOptions
 
 	stopRun  chan struct{}
 	stopOnce sync.Once
 
-	saslAuthByProxy SASLAuthByProxy
-	authClient      *AuthClient
+	saslAuthByProxy  SASLAuthByProxy
+	deferredSaslAuth *deferredSaslAuth
+	authClient       *AuthClient
+
+	// the broker SASL authentications are rebuilt with the reloaded timeouts
+	saslTokenProvider       apis.TokenProvider
+	userCredentialsProvider apis.UserCredentialsProvider
+
+	// timeout of the client TLS handshake
+	dialTimeout time.Duration
 
 	dialAddressMapping map[string]config.DialAddressMapping
 
-	kafkaClientCert *x509.Certificate
+	// the certificate of the proxy client must be the same as the client certificate of the broker connections
+	sameClientCertEnable bool
+	principalMapper      *PrincipalMapper
+
+	// guards the dialer, the dial address mapping, the dial timeout, the broker SASL authentications and the processor
+	// config, which are changed by Reload
+	reloadLock sync.RWMutex
 }
 
-func NewClient(conns *ConnSet, c *config.Config, netAddressMappingFunc config.NetAddressMappingFunc, localPasswordAuthenticator apis.PasswordAuthenticator, localTokenAuthenticator apis.TokenInfo, saslTokenProvider apis.TokenProvider, gatewayTokenProvider apis.TokenProvider, gatewayTokenInfo apis.TokenInfo) (*Client, error) {
-	tlsConfig, err := newTLSClientConfig(c)
+func NewClient(conns *ConnSet, c *config.Config, netAddressMappingFunc config.NetAddressMappingFunc, localPasswordAuthenticator apis.PasswordAuthenticator, localTokenAuthenticator apis.TokenInfo, localScramCredentialStore apis.ScramCredentialStore, saslTokenProvider apis.TokenProvider, userCredentialsProvider apis.UserCredentialsProvider, gatewayTokenProvider apis.TokenProvider, gatewayTokenInfo apis.TokenInfo) (*Client, error) {
+	tlsCertificates, err := newTLSClientCertificates(c)
 	if err != nil {
 		return nil, err
 	}
+	if c.Kafka.TLS.Enable {
+		// short-lived client certificates are used for the new broker connections after a rotation
+		if err = tlsCertificates.watch(); err != nil {
+			return nil, err
+		}
+	}
 
-	var kafkaClientCert *x509.Certificate = nil
-	if c.Kafka.TLS.SameClientCertEnable {
-		kafkaClientCert, err = parseCertificate(c.Kafka.TLS.ClientCertFile)
+	var principalMapper *PrincipalMapper
+	if len(c.Proxy.TLS.ClientCert.PrincipalMappingRules) != 0 {
+		principalMapper, err = NewPrincipalMapper(c.Proxy.TLS.ClientCert.PrincipalMappingRules)
 		if err != nil {
 			return nil, err
 		}
+		logrus.Infof("Client certificate principal mapping rules %v", c.Proxy.TLS.ClientCert.PrincipalMappingRules)
+	}
+
+	var bruteForceGuard *BruteForceGuard
+	if c.Auth.Local.Enable && c.Auth.Local.BruteForce.Enable {
+		bruteForceGuard = NewBruteForceGuard(BruteForceGuardOptions{
+			UsernameMaxFailures:        c.Auth.Local.BruteForce.UsernameMaxFailures,
+			IPMaxFailures:              c.Auth.Local.BruteForce.IPMaxFailures,
+			FailureWindow:              c.Auth.Local.BruteForce.FailureWindow,
+			Lockout:                    c.Auth.Local.BruteForce.Lockout,
+			MaxLockout:                 c.Auth.Local.BruteForce.MaxLockout,
+			GlobalMaxFailuresPerSecond: c.Auth.Local.BruteForce.GlobalMaxFailuresPerSecond,
+		})
 	}
 
-	dialer, err := newDialer(c, tlsConfig)
+	dialer, err := newDialer(c, tlsCertificates)
 	if err != nil {
 		return nil, err
 	}
@@ -69,15 +103,28 @@ func NewClient(conns *ConnSet, c *config.Config, netAddressMappingFunc config.Ne
 		ReadBufferSize:  c.Kafka.ConnectionReadBufferSize,
 	}
 
-	forbiddenApiKeys := make(map[int16]struct{})
-	if len(c.Kafka.ForbiddenApiKeys) != 0 {
-		logrus.Warnf("Kafka operations for Api Keys %v will be forbidden.", c.Kafka.ForbiddenApiKeys)
-		for _, apiKey := range c.Kafka.ForbiddenApiKeys {
-			forbiddenApiKeys[int16(apiKey)] = struct{}{}
+	forbiddenApiKeys := getForbiddenApiKeys(c)
+	var topicAuthorizer *TopicAuthorizer
+	if c.ACL.Enable {
+		if topicAuthorizer, err = NewTopicAuthorizerFromFile(c.ACL.RulesFile); err != nil {
+			return nil, err
 		}
+		logrus.Infof("Topic ACL rules loaded from %s", c.ACL.RulesFile)
+	}
+	metadataTopicFilter, err := NewMetadataTopicFilter(c)
+	if err != nil {
+		return nil, err
+	}
+	namespaces := NewNamespaces(c)
+	if namespaces != nil {
+		logrus.Infof("Namespaces enabled for principals %v and listeners %v", c.Namespace.PrincipalMappings, c.Namespace.ListenerMappings)
 	}
-	if c.Auth.Local.Enable && (localPasswordAuthenticator == nil && localTokenAuthenticator == nil) {
-		return nil, errors.New("Auth.Local.Enable is enabled but passwordAuthenticator and localTokenAuthenticator are nil")
+	if c.Auth.Local.Enable && (localPasswordAuthenticator == nil && localTokenAuthenticator == nil && localScramCredentialStore == nil) {
+		return nil, errors.New("Auth.Local.Enable is enabled but passwordAuthenticator, localTokenAuthenticator and localScramCredentialStore are nil")
+	}
+
+	if c.Kafka.SASL.UserCredentials.Enable && userCredentialsProvider == nil {
+		return nil, errors.New("Kafka.SASL.UserCredentials.Enable is enabled but userCredentialsProvider is nil")
 	}
 
 	if c.Auth.Gateway.Client.Enable && gatewayTokenProvider == nil {
@@ -86,7 +133,71 @@ func NewClient(conns *ConnSet, c *config.Config, netAddressMappingFunc config.Ne
 	if c.Auth.Gateway.Server.Enable && gatewayTokenInfo == nil {
 		return nil, errors.New("Auth.Gateway.Server.Enable is enabled but tokenInfo is nil")
 	}
-	var saslAuthByProxy SASLAuthByProxy
+	saslAuthByProxy, err := newSASLAuthByProxy(c, saslTokenProvider)
+	if err != nil {
+		return nil, err
+	}
+	dialAddressMapping, err := getAddressToDialAddressMapping(c)
+	if err != nil {
+		return nil, err
+	}
+	localSasl, err := NewLocalSasl(LocalSaslParams{
+		enabled:               c.Auth.Local.Enable,
+		timeout:               c.Auth.Local.Timeout,
+		passwordAuthenticator: localPasswordAuthenticator,
+		tokenAuthenticator:    localTokenAuthenticator,
+		scramMechanism:        c.Auth.Local.Mechanism,
+		scramCredentialStore:  localScramCredentialStore,
+		bruteForceGuard:       bruteForceGuard,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	client := &Client{conns: conns, config: c, dialer: dialer, tlsCertificates: tlsCertificates, tcpConnOptions: tcpConnOptions, stopRun: make(chan struct{}, 1),
+		saslAuthByProxy:         saslAuthByProxy,
+		saslTokenProvider:       saslTokenProvider,
+		userCredentialsProvider: userCredentialsProvider,
+		dialTimeout:             c.Kafka.DialTimeout,
+		authClient: &AuthClient{
+			enabled:       c.Auth.Gateway.Client.Enable,
+			magic:         c.Auth.Gateway.Client.Magic,
+			method:        c.Auth.Gateway.Client.Method,
+			timeout:       c.Auth.Gateway.Client.Timeout,
+			tokenProvider: gatewayTokenProvider,
+		},
+		processorConfig: ProcessorConfig{
+			MaxOpenRequests:       c.Kafka.MaxOpenRequests,
+			NetAddressMappingFunc: netAddressMappingFunc,
+			RequestBufferSize:     c.Proxy.RequestBufferSize,
+			ResponseBufferSize:    c.Proxy.ResponseBufferSize,
+			ReadTimeout:           c.Kafka.ReadTimeout,
+			WriteTimeout:          c.Kafka.WriteTimeout,
+			LocalSasl:             localSasl,
+			AuthServer: &AuthServer{
+				enabled:   c.Auth.Gateway.Server.Enable,
+				magic:     c.Auth.Gateway.Server.Magic,
+				method:    c.Auth.Gateway.Server.Method,
+				timeout:   c.Auth.Gateway.Server.Timeout,
+				tokenInfo: gatewayTokenInfo,
+			},
+			ForbiddenApiKeys:      forbiddenApiKeys,
+			ProducerAcks0Disabled: c.Kafka.Producer.Acks0Disabled,
+			TopicAuthorizer:       topicAuthorizer,
+			Namespaces:            namespaces,
+			MetadataTopicFilter:   metadataTopicFilter,
+			RequestModifiers:      protocol.NewRequestModifiers(),
+		},
+		dialAddressMapping:   dialAddressMapping,
+		sameClientCertEnable: c.Kafka.TLS.SameClientCertEnable,
+		principalMapper:      principalMapper,
+	}
+	client.deferredSaslAuth = newDeferredSaslAuth(c, client.processorConfig, saslTokenProvider, userCredentialsProvider)
+	return client, nil
+}
+
+// newSASLAuthByProxy returns the SASL authentication of the broker connections, it is nil when SASL is disabled
+func newSASLAuthByProxy(c *config.Config, saslTokenProvider apis.TokenProvider) (saslAuthByProxy SASLAuthByProxy, err error) {
 	if c.Kafka.SASL.Plugin.Enable {
 		if c.Kafka.SASL.Plugin.Mechanism == SASLOAuthBearer && saslTokenProvider != nil {
 			saslAuthByProxy = &SASLOAuthBearerAuth{
@@ -121,46 +232,50 @@ func NewClient(conns *ConnSet, c *config.Config, netAddressMappingFunc config.Ne
 			return nil, errors.Errorf("SASL Mechanism not valid '%s'", c.Kafka.SASL.Method)
 		}
 	}
-	dialAddressMapping, err := getAddressToDialAddressMapping(c)
-	if err != nil {
-		return nil, err
+	return saslAuthByProxy, nil
+}
+
+// newDeferredSaslAuth returns the broker authentication on behalf of the locally authenticated clients, it is nil when
+// the broker connections are not authenticated per client
+func newDeferredSaslAuth(c *config.Config, processorConfig ProcessorConfig, saslTokenProvider apis.TokenProvider, userCredentialsProvider apis.UserCredentialsProvider) *deferredSaslAuth {
+	var upstream upstreamSaslAuth
+	if c.Kafka.SASL.UserCredentials.Enable {
+		upstream = &userCredentialsAuth{
+			clientID:     c.Kafka.ClientID,
+			method:       c.Kafka.SASL.Method,
+			writeTimeout: c.Kafka.WriteTimeout,
+			readTimeout:  c.Kafka.ReadTimeout,
+			provider:     userCredentialsProvider,
+		}
+	} else if c.Kafka.SASL.Plugin.ClientTokenEnable {
+		upstream = &clientTokenAuth{
+			clientID:      c.Kafka.ClientID,
+			writeTimeout:  c.Kafka.WriteTimeout,
+			readTimeout:   c.Kafka.ReadTimeout,
+			tokenProvider: saslTokenProvider,
+		}
+	}
+	if upstream == nil {
+		return nil
+	}
+	return &deferredSaslAuth{
+		writeTimeout:     c.Kafka.WriteTimeout,
+		readTimeout:      c.Kafka.ReadTimeout,
+		localSasl:        processorConfig.LocalSasl,
+		apiVersionLimits: newApiVersionLimits(processorConfig),
+		upstream:         upstream,
 	}
+}
 
-	return &Client{conns: conns, config: c, dialer: dialer, tcpConnOptions: tcpConnOptions, stopRun: make(chan struct{}, 1),
-		saslAuthByProxy: saslAuthByProxy,
-		authClient: &AuthClient{
-			enabled:       c.Auth.Gateway.Client.Enable,
-			magic:         c.Auth.Gateway.Client.Magic,
-			method:        c.Auth.Gateway.Client.Method,
-			timeout:       c.Auth.Gateway.Client.Timeout,
-			tokenProvider: gatewayTokenProvider,
-		},
-		processorConfig: ProcessorConfig{
-			MaxOpenRequests:       c.Kafka.MaxOpenRequests,
-			NetAddressMappingFunc: netAddressMappingFunc,
-			RequestBufferSize:     c.Proxy.RequestBufferSize,
-			ResponseBufferSize:    c.Proxy.ResponseBufferSize,
-			ReadTimeout:           c.Kafka.ReadTimeout,
-			WriteTimeout:          c.Kafka.WriteTimeout,
-			LocalSasl: NewLocalSasl(LocalSaslParams{
-				enabled:               c.Auth.Local.Enable,
-				timeout:               c.Auth.Local.Timeout,
-				passwordAuthenticator: localPasswordAuthenticator,
-				tokenAuthenticator:    localTokenAuthenticator,
-			}),
-			AuthServer: &AuthServer{
-				enabled:   c.Auth.Gateway.Server.Enable,
-				magic:     c.Auth.Gateway.Server.Magic,
-				method:    c.Auth.Gateway.Server.Method,
-				timeout:   c.Auth.Gateway.Server.Timeout,
-				tokenInfo: gatewayTokenInfo,
-			},
-			ForbiddenApiKeys:      forbiddenApiKeys,
-			ProducerAcks0Disabled: c.Kafka.Producer.Acks0Disabled,
-		},
-		dialAddressMapping: dialAddressMapping,
-		kafkaClientCert:    kafkaClientCert,
-	}, nil
+func getForbiddenApiKeys(cfg *config.Config) map[int16]struct{} {
+	forbiddenApiKeys := make(map[int16]struct{})
+	if len(cfg.Kafka.ForbiddenApiKeys) != 0 {
+		logrus.Warnf("Kafka operations for Api Keys %v will be forbidden.", cfg.Kafka.ForbiddenApiKeys)
+		for _, apiKey := range cfg.Kafka.ForbiddenApiKeys {
+			forbiddenApiKeys[int16(apiKey)] = struct{}{}
+		}
+	}
+	return forbiddenApiKeys
 }
 
 func getAddressToDialAddressMapping(cfg *config.Config) (map[string]config.DialAddressMapping, error) {
@@ -179,7 +294,7 @@ func getAddressToDialAddressMapping(cfg *config.Config) (map[string]config.DialA
 	return addressToDialAddressMapping, nil
 }
 
-func newDialer(c *config.Config, tlsConfig *tls.Config) (Dialer, error) {
+func newDialer(c *config.Config, tlsCertificates *tlsCertificates) (Dialer, error) {
 	directDialer := directDialer{
 		dialTimeout: c.Kafka.DialTimeout,
 		keepAlive:   c.Kafka.KeepAlive,
@@ -214,13 +329,13 @@ func newDialer(c *config.Config, tlsConfig *tls.Config) (Dialer, error) {
 		rawDialer = directDialer
 	}
 	if c.Kafka.TLS.Enable {
-		if tlsConfig == nil {
-			return nil, errors.New("tlsConfig must not be nil")
+		if tlsCertificates == nil {
+			return nil, errors.New("tlsCertificates must not be nil")
 		}
 		tlsDialer := tlsDialer{
 			timeout:   c.Kafka.DialTimeout,
 			rawDialer: rawDialer,
-			config:    tlsConfig,
+			certs:     tlsCertificates,
 		}
 		return tlsDialer, nil
 	}
@@ -250,6 +365,38 @@ STOP:
 	return nil
 }
 
+// Reload applies the dial address mappings, the forbidden api keys and the timeouts to new connections. The timeouts
+// apply also to the client TLS handshake and to the SASL authentication of the broker connections.
+// Established connections keep the previous settings.
+func (c *Client) Reload(cfg *config.Config) error {
+	dialAddressMapping, err := getAddressToDialAddressMapping(cfg)
+	if err != nil {
+		return err
+	}
+	dialer, err := newDialer(cfg, c.tlsCertificates)
+	if err != nil {
+		return err
+	}
+	saslAuthByProxy, err := newSASLAuthByProxy(cfg, c.saslTokenProvider)
+	if err != nil {
+		return err
+	}
+	forbiddenApiKeys := getForbiddenApiKeys(cfg)
+
+	c.reloadLock.Lock()
+	defer c.reloadLock.Unlock()
+
+	c.dialer = dialer
+	c.dialAddressMapping = dialAddressMapping
+	c.processorConfig.ForbiddenApiKeys = forbiddenApiKeys
+	c.processorConfig.ReadTimeout = cfg.Kafka.ReadTimeout
+	c.processorConfig.WriteTimeout = cfg.Kafka.WriteTimeout
+	c.dialTimeout = cfg.Kafka.DialTimeout
+	c.saslAuthByProxy = saslAuthByProxy
+	c.deferredSaslAuth = newDeferredSaslAuth(cfg, c.processorConfig, c.saslTokenProvider, c.userCredentialsProvider)
+	return nil
+}
+
 func (c *Client) Close() {
 	c.stopOnce.Do(func() {
 		close(c.stopRun)
@@ -257,9 +404,13 @@ func (c *Client) Close() {
 }
 
 func (c *Client) handleConn(conn Conn) {
+	c.reloadLock.RLock()
+	processorConfig, dialAddressMapping, dialTimeout, deferredSaslAuth := c.processorConfig, c.dialAddressMapping, c.dialTimeout, c.deferredSaslAuth
+	c.reloadLock.RUnlock()
+
 	localConn := conn.LocalConnection
-	if c.kafkaClientCert != nil {
-		err := handshakeAsTLSAndValidateClientCert(localConn, c.kafkaClientCert, c.config.Kafka.DialTimeout)
+	if c.sameClientCertEnable {
+		err := handshakeAsTLSAndValidateClientCert(localConn, c.tlsCertificates.getLeaf(), dialTimeout)
 
 		if err != nil {
 			logrus.Info(err.Error())
@@ -268,15 +419,43 @@ func (c *Client) handleConn(conn Conn) {
 		}
 	}
 
+	var certPrincipal string
+	if c.principalMapper != nil {
+		var err error
+		certPrincipal, err = handshakeAndMapPrincipal(localConn, c.principalMapper, dialTimeout)
+		if err != nil {
+			logrus.Infof("Client certificate of %s rejected: %v", localConn.RemoteAddr().String(), err)
+			_ = localConn.Close()
+			return
+		}
+		logrus.Infof("Client certificate of %s mapped to principal %s", localConn.RemoteAddr().String(), certPrincipal)
+		proxyPrincipalConnectionsTotal.WithLabelValues(conn.BrokerAddress, certPrincipal).Inc()
+	}
+	// the client certificate is known before the first request, the principal of the connection is set once
+	if tlsConn, ok := localConn.(*tls.Conn); ok {
+		if err := handshakeTLSConn(tlsConn, dialTimeout); err != nil {
+			logrus.Infof("Client connection of %s rejected: %v", localConn.RemoteAddr().String(), err)
+			_ = localConn.Close()
+			return
+		}
+	}
+
 	proxyConnectionsTotal.WithLabelValues(conn.BrokerAddress).Inc()
 
 	dialAddress := conn.BrokerAddress
-	if addressMapping, ok := c.dialAddressMapping[dialAddress]; ok {
+	if addressMapping, ok := dialAddressMapping[dialAddress]; ok {
 		dialAddress = addressMapping.DestinationAddress
 		logrus.Infof("Dial address changed from %s to %s", conn.BrokerAddress, dialAddress)
 	}
 
-	server, err := c.DialAndAuth(dialAddress)
+	var server net.Conn
+	var principal string
+	var err error
+	if deferredSaslAuth != nil {
+		server, principal, err = c.localAuthAndDial(deferredSaslAuth, conn.LocalConnection, dialAddress)
+	} else {
+		server, err = c.DialAndAuth(dialAddress)
+	}
 	if err != nil {
 		logrus.Infof("couldn't connect to %s(%s): %v", dialAddress, conn.BrokerAddress, err)
 		_ = conn.LocalConnection.Close()
@@ -287,51 +466,115 @@ func (c *Client) handleConn(conn Conn) {
 			logrus.Infof("WARNING: Error while setting TCP options for kafka connection %s on %v: %v", conn.BrokerAddress, server.LocalAddr(), err)
 		}
 	}
-	c.conns.Add(conn.BrokerAddress, conn.LocalConnection)
+	infoPrincipal := connInfoPrincipal(localConn, principal, certPrincipal, processorConfig.LocalSasl != nil && processorConfig.LocalSasl.enabled)
+	info := c.conns.addWithInfo(conn.BrokerAddress, conn.LocalConnection, infoPrincipal)
 	localDesc := "local connection on " + conn.LocalConnection.LocalAddr().String() + " from " + conn.LocalConnection.RemoteAddr().String() + " (" + conn.BrokerAddress + ")"
-	copyThenClose(c.processorConfig, server, conn.LocalConnection, conn.BrokerAddress, conn.BrokerAddress, localDesc)
+	copyThenClose(processorConfig, server, conn.LocalConnection, conn.BrokerAddress, principal, certPrincipal, info, conn.BrokerAddress, localDesc)
 	if err := c.conns.Remove(conn.BrokerAddress, conn.LocalConnection); err != nil {
 		logrus.Info(err)
 	}
 }
 
+// connInfoPrincipal returns the principal of the connection in the same order as the processor. The principal is empty
+// until the processor authenticates the client by the local SASL.
+func connInfoPrincipal(localConn net.Conn, principal string, certPrincipal string, localSaslEnabled bool) string {
+	if principal != "" {
+		return principal
+	}
+	if certPrincipal != "" {
+		return certPrincipal
+	}
+	if tlsConn, ok := localConn.(*tls.Conn); ok {
+		state := tlsConn.ConnectionState()
+		if len(state.PeerCertificates) > 0 && state.PeerCertificates[0].Subject.CommonName != "" {
+			return state.PeerCertificates[0].Subject.CommonName
+		}
+	}
+	if localSaslEnabled {
+		return ""
+	}
+	return anonymousPrincipal
+}
+
 func (c *Client) DialAndAuth(brokerAddress string) (net.Conn, error) {
-	conn, err := c.dialer.Dial("tcp", brokerAddress)
+	conn, err := c.dial(brokerAddress)
 	if err != nil {
 		return nil, err
 	}
-	if err := conn.SetDeadline(time.Time{}); err != nil {
-		_ = conn.Close()
-		return nil, err
+	if c.config.Kafka.SASL.Enable {
+		c.reloadLock.RLock()
+		saslAuthByProxy := c.saslAuthByProxy
+		c.reloadLock.RUnlock()
+		if err = c.saslAuth(conn, saslAuthByProxy); err != nil {
+			return nil, err
+		}
 	}
-	err = c.auth(conn)
+	return conn, nil
+}
+
+// localAuthAndDial authenticates the local connection first and then the broker connection on behalf of the authenticated client
+func (c *Client) localAuthAndDial(deferredSaslAuth *deferredSaslAuth, local net.Conn, dialAddress string) (net.Conn, string, error) {
+	result, server, err := deferredSaslAuth.receiveLocalAuth(local, func() (net.Conn, error) {
+		return c.dial(dialAddress)
+	})
 	if err != nil {
-		return nil, err
+		if server != nil {
+			_ = server.Close()
+		}
+		return nil, "", errors.Wrap(err, "local authentication failed")
 	}
-	return conn, nil
+	if server == nil {
+		if server, err = c.dial(dialAddress); err != nil {
+			return nil, "", err
+		}
+	}
+	saslAuthByProxy, err := deferredSaslAuth.upstream.saslAuthByProxy(result)
+	if err != nil {
+		_ = server.Close()
+		return nil, "", err
+	}
+	if err = c.saslAuth(server, saslAuthByProxy); err != nil {
+		return nil, "", errors.Wrapf(err, "upstream authentication of principal %s failed", result.principal)
+	}
+	return server, result.principal, nil
 }
 
-func (c *Client) auth(conn net.Conn) error {
+// dial connects to the broker and performs the gateway authentication
+func (c *Client) dial(brokerAddress string) (net.Conn, error) {
+	c.reloadLock.RLock()
+	dialer := c.dialer
+	c.reloadLock.RUnlock()
+
+	conn, err := dialer.Dial("tcp", brokerAddress)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
 	if c.config.Auth.Gateway.Client.Enable {
 		if err := c.authClient.sendAndReceiveGatewayAuth(conn); err != nil {
 			_ = conn.Close()
-			return err
+			return nil, err
 		}
 		if err := conn.SetDeadline(time.Time{}); err != nil {
 			_ = conn.Close()
-			return err
+			return nil, err
 		}
 	}
-	if c.config.Kafka.SASL.Enable {
-		err := c.saslAuthByProxy.sendAndReceiveSASLAuth(conn)
-		if err != nil {
-			_ = conn.Close()
-			return err
-		}
-		if err := conn.SetDeadline(time.Time{}); err != nil {
-			_ = conn.Close()
-			return err
-		}
+	return conn, nil
+}
+
+func (c *Client) saslAuth(conn net.Conn, saslAuthByProxy SASLAuthByProxy) error {
+	err := saslAuthByProxy.sendAndReceiveSASLAuth(conn)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		_ = conn.Close()
+		return err
 	}
 	return nil
 }
@@ -0,0 +1,59 @@
+package proxy
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/grepplabs/kafka-proxy/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientReload(t *testing.T) {
+	a := assert.New(t)
+
+	client := &Client{processorConfig: ProcessorConfig{MaxOpenRequests: 16, ReadTimeout: time.Second, WriteTimeout: time.Second}}
+
+	cfg := config.NewConfig()
+	cfg.Kafka.ReadTimeout = time.Minute
+	cfg.Kafka.WriteTimeout = 2 * time.Minute
+	cfg.Kafka.DialTimeout = 3 * time.Minute
+	cfg.Kafka.ForbiddenApiKeys = []int{32, 33}
+	cfg.Proxy.DialAddressMappings = []config.DialAddressMapping{{SourceAddress: "kafka-0:9092", DestinationAddress: "10.0.0.1:9092"}}
+	cfg.Kafka.SASL.Enable = true
+	cfg.Kafka.SASL.Method = SASLPlain
+	cfg.Kafka.SASL.UserCredentials.Enable = true
+	a.Nil(client.Reload(cfg))
+
+	a.Equal(16, client.processorConfig.MaxOpenRequests)
+	a.Equal(time.Minute, client.processorConfig.ReadTimeout)
+	a.Equal(2*time.Minute, client.processorConfig.WriteTimeout)
+	a.Equal(map[int16]struct{}{32: {}, 33: {}}, client.processorConfig.ForbiddenApiKeys)
+	a.Equal("10.0.0.1:9092", client.dialAddressMapping["kafka-0:9092"].DestinationAddress)
+	a.Equal(3*time.Minute, client.dialer.(directDialer).dialTimeout)
+	a.Equal(3*time.Minute, client.dialTimeout)
+	// the broker SASL authentications use the reloaded timeouts
+	a.Equal(time.Minute, client.saslAuthByProxy.(*SASLPlainAuth).readTimeout)
+	a.Equal(2*time.Minute, client.saslAuthByProxy.(*SASLPlainAuth).writeTimeout)
+	a.Equal(time.Minute, client.deferredSaslAuth.readTimeout)
+	a.Equal(2*time.Minute, client.deferredSaslAuth.upstream.(*userCredentialsAuth).writeTimeout)
+
+	cfg.Proxy.DialAddressMappings = append(cfg.Proxy.DialAddressMappings, config.DialAddressMapping{SourceAddress: "kafka-0:9092", DestinationAddress: "10.0.0.2:9092"})
+	cfg.Kafka.ReadTimeout = time.Hour
+	a.NotNil(client.Reload(cfg))
+	a.Equal(time.Minute, client.processorConfig.ReadTimeout)
+}
+
+func TestConnInfoPrincipal(t *testing.T) {
+	a := assert.New(t)
+
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	a.Equal("alice", connInfoPrincipal(serverConn, "alice", "bob", true))
+	a.Equal("bob", connInfoPrincipal(serverConn, "", "bob", true))
+	// the local SASL principal is set by the processor after the authentication
+	a.Equal("", connInfoPrincipal(serverConn, "", "", true))
+	a.Equal(anonymousPrincipal, connInfoPrincipal(serverConn, "", "", false))
+}
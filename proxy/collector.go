@@ -35,6 +35,61 @@ var (
 		prometheus.CounterOpts{Name: "proxy_local_auth_total",
 			Help: "Total number of local auth requests sent"},
 		[]string{"success", "status"})
+
+	proxyLocalAuthLockoutsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "proxy_local_auth_lockouts_total",
+			Help: "Total number of local auth lockouts triggered by failed attempts"},
+		[]string{"type"})
+
+	proxyLocalAuthRejectedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "proxy_local_auth_rejected_total",
+			Help: "Total number of local auth attempts rejected because of a lockout"},
+		[]string{"reason"})
+
+	proxyPrincipalConnectionsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "proxy_principal_connections_total",
+			Help: "Total number of created connections per client certificate principal"},
+		[]string{"broker", "principal"})
+
+	proxyAclDeniedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "proxy_acl_denied_topics_total",
+			Help: "Total number of topics denied by ACL rules"},
+		[]string{"broker", "api_key"})
+
+	proxyAuthCacheTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "proxy_auth_cache_total",
+			Help: "Total number of auth cache lookups"},
+		[]string{"cache", "result"})
+
+	proxyAuthCacheEvictionsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "proxy_auth_cache_evictions_total",
+			Help: "Total number of auth cache entries evicted by the size bound"},
+		[]string{"cache"})
+
+	proxyAuthCacheEntries = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{Name: "proxy_auth_cache_entries",
+			Help: "Number of auth cache entries"},
+		[]string{"cache"})
+
+	proxyTLSCertificateExpiry = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{Name: "proxy_tls_certificate_expiry_timestamp_seconds",
+			Help: "Expiry of the loaded TLS certificate, the earliest expiry of the CA certificates"},
+		[]string{"config", "type"})
+
+	proxyTLSReloadsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "proxy_tls_reloads_total",
+			Help: "Total number of TLS certificate reloads"},
+		[]string{"config", "result"})
+
+	proxyTLSLastReloadSuccess = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{Name: "proxy_tls_last_reload_success",
+			Help: "Whether the last TLS certificate reload succeeded"},
+		[]string{"config"})
+
+	proxyTLSLastReloadTimestamp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{Name: "proxy_tls_last_reload_timestamp_seconds",
+			Help: "Time of the last TLS certificate reload"},
+		[]string{"config"})
 )
 
 func init() {
@@ -43,6 +98,17 @@ func init() {
 	prometheus.MustRegister(proxyRequestsBytes)
 	prometheus.MustRegister(proxyResponsesBytes)
 	prometheus.MustRegister(proxyLocalAuthTotal)
+	prometheus.MustRegister(proxyLocalAuthLockoutsTotal)
+	prometheus.MustRegister(proxyLocalAuthRejectedTotal)
+	prometheus.MustRegister(proxyPrincipalConnectionsTotal)
+	prometheus.MustRegister(proxyAclDeniedTotal)
+	prometheus.MustRegister(proxyAuthCacheTotal)
+	prometheus.MustRegister(proxyAuthCacheEvictionsTotal)
+	prometheus.MustRegister(proxyAuthCacheEntries)
+	prometheus.MustRegister(proxyTLSCertificateExpiry)
+	prometheus.MustRegister(proxyTLSReloadsTotal)
+	prometheus.MustRegister(proxyTLSLastReloadSuccess)
+	prometheus.MustRegister(proxyTLSLastReloadTimestamp)
 }
 
 type proxyCollector struct {
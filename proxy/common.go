@@ -7,6 +7,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"io"
 	"net"
+	"sort"
 	"sync"
 	"time"
 )
@@ -111,9 +112,14 @@ func copyError(readDesc, writeDesc string, readErr bool, err error) {
 	logrus.Infof("%v had error: %s", desc, err.Error())
 }
 
-func copyThenClose(cfg ProcessorConfig, remote, local DeadlineReadWriteCloser, brokerAddress string, remoteDesc, localDesc string) {
+// copyThenClose proxies the connections. The principal is not empty, if the local connection was already authenticated by local SASL,
+// the certPrincipal is not empty, if the client certificate was mapped by the principal mapping rules
+func copyThenClose(cfg ProcessorConfig, remote, local DeadlineReadWriteCloser, brokerAddress string, principal string, certPrincipal string, info *connInfo, remoteDesc, localDesc string) {
 
 	processor := newProcessor(cfg, brokerAddress)
+	processor.principal = principal
+	processor.certPrincipal = certPrincipal
+	processor.connInfo = info
 
 	firstErr := make(chan error, 1)
 
@@ -150,13 +156,16 @@ func copyThenClose(cfg ProcessorConfig, remote, local DeadlineReadWriteCloser, b
 
 // NewConnSet initializes a new ConnSet and returns it.
 func NewConnSet() *ConnSet {
-	return &ConnSet{m: make(map[string][]net.Conn)}
+	return &ConnSet{m: make(map[string][]net.Conn), infos: make(map[net.Conn]*connInfo)}
 }
 
 // A ConnSet tracks net.Conns associated with a provided ID.
 type ConnSet struct {
 	sync.RWMutex
 	m map[string][]net.Conn
+	// connections added by addWithInfo
+	infos  map[net.Conn]*connInfo
+	lastID uint64
 }
 
 // String returns a debug string for the ConnSet.
@@ -183,6 +192,71 @@ func (c *ConnSet) Add(id string, conn net.Conn) {
 	c.Unlock()
 }
 
+// addWithInfo saves the provided conn as Add does and returns its description which is updated by the processor
+func (c *ConnSet) addWithInfo(id string, conn net.Conn, principal string) *connInfo {
+	info := &connInfo{brokerAddress: id, created: time.Now()}
+	if conn.RemoteAddr() != nil {
+		info.clientAddress = conn.RemoteAddr().String()
+	}
+	info.setPrincipal(principal)
+
+	c.Lock()
+	c.lastID++
+	info.id = c.lastID
+	c.m[id] = append(c.m[id], conn)
+	c.infos[conn] = info
+	c.Unlock()
+
+	return info
+}
+
+// Connections returns the descriptions of the active connections ordered by the connection id
+func (c *ConnSet) Connections() []ConnectionInfo {
+	now := time.Now()
+
+	c.RLock()
+	ret := make([]ConnectionInfo, 0, len(c.infos))
+	for _, info := range c.infos {
+		ret = append(ret, info.info(now))
+	}
+	c.RUnlock()
+
+	sort.Slice(ret, func(i, j int) bool { return ret[i].ID < ret[j].ID })
+	return ret
+}
+
+// CloseConnection closes the connection with the provided connection id, false is returned if it is not active
+func (c *ConnSet) CloseConnection(connID uint64) bool {
+	conns := c.connsWithInfo(func(info *connInfo) bool { return info.id == connID })
+	for _, conn := range conns {
+		_ = conn.Close()
+	}
+	return len(conns) != 0
+}
+
+// ClosePrincipalConnections closes all connections of the principal and returns their number
+func (c *ConnSet) ClosePrincipalConnections(principal string) int {
+	conns := c.connsWithInfo(func(info *connInfo) bool { return info.getPrincipal() == principal })
+	for _, conn := range conns {
+		_ = conn.Close()
+	}
+	return len(conns)
+}
+
+func (c *ConnSet) connsWithInfo(match func(info *connInfo) bool) []net.Conn {
+	var ret []net.Conn
+
+	c.RLock()
+	for conn, info := range c.infos {
+		if match(info) {
+			ret = append(ret, conn)
+		}
+	}
+	c.RUnlock()
+
+	return ret
+}
+
 // IDs returns a slice of all identifiers which still have active connections.
 func (c *ConnSet) IDs() []string {
 	ret := make([]string, 0, len(c.m))
@@ -243,6 +317,7 @@ func (c *ConnSet) Remove(id string, conn net.Conn) error {
 		return fmt.Errorf("couldn't find connection %v for id %s", conn, id)
 	}
 
+	delete(c.infos, conn)
 	if len(conns) == 1 {
 		delete(c.m, id)
 	} else {
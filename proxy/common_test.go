@@ -5,6 +5,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"io"
 	"math/rand"
+	"net"
 	"testing"
 )
 
@@ -93,3 +94,42 @@ func randomString(n int) string {
 	}
 	return string(b)
 }
+
+func TestConnSetConnections(t *testing.T) {
+	a := assert.New(t)
+
+	connset := NewConnSet()
+	c1, _ := net.Pipe()
+	c2, _ := net.Pipe()
+	c3, _ := net.Pipe()
+	info1 := connset.addWithInfo("kafka-0:9092", c1, "alice")
+	connset.addWithInfo("kafka-1:9092", c2, "")
+	info3 := connset.addWithInfo("kafka-1:9092", c3, "alice")
+
+	info1.addRequestBytes(10)
+	info1.addResponseBytes(20)
+
+	connections := connset.Connections()
+	a.Len(connections, 3)
+	a.Equal(uint64(1), connections[0].ID)
+	a.Equal("kafka-0:9092", connections[0].BrokerAddress)
+	a.Equal("alice", connections[0].Principal)
+	a.Equal(int64(10), connections[0].RequestBytes)
+	a.Equal(int64(20), connections[0].ResponseBytes)
+	a.Equal("", connections[1].Principal)
+
+	a.False(connset.CloseConnection(4))
+	a.True(connset.CloseConnection(2))
+	_, err := c2.Write([]byte{0})
+	a.NotNil(err)
+
+	a.Equal(2, connset.ClosePrincipalConnections("alice"))
+	_, err = c1.Write([]byte{0})
+	a.NotNil(err)
+
+	a.Nil(connset.Remove("kafka-1:9092", c3))
+	connections = connset.Connections()
+	a.Len(connections, 2)
+	a.Equal(info1.id, connections[0].ID)
+	a.NotEqual(info3.id, connections[1].ID)
+}
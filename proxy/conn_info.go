@@ -0,0 +1,70 @@
+package proxy
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// ConnectionInfo describes an active client connection
+type ConnectionInfo struct {
+	ID            uint64    `json:"id"`
+	BrokerAddress string    `json:"brokerAddress"`
+	ClientAddress string    `json:"clientAddress"`
+	Principal     string    `json:"principal"`
+	Created       time.Time `json:"created"`
+	AgeSeconds    int64     `json:"ageSeconds"`
+	RequestBytes  int64     `json:"requestBytes"`
+	ResponseBytes int64     `json:"responseBytes"`
+}
+
+// connInfo is updated by the processor of the connection, the methods accept the nil receiver
+type connInfo struct {
+	// 64-bit aligned for the atomic operations
+	requestBytes  int64
+	responseBytes int64
+
+	id            uint64
+	brokerAddress string
+	clientAddress string
+	created       time.Time
+	principal     atomic.Value
+}
+
+func (i *connInfo) addRequestBytes(n int64) {
+	if i != nil {
+		atomic.AddInt64(&i.requestBytes, n)
+	}
+}
+
+func (i *connInfo) addResponseBytes(n int64) {
+	if i != nil {
+		atomic.AddInt64(&i.responseBytes, n)
+	}
+}
+
+func (i *connInfo) setPrincipal(principal string) {
+	if i != nil && principal != "" {
+		i.principal.Store(principal)
+	}
+}
+
+func (i *connInfo) getPrincipal() string {
+	if i == nil {
+		return ""
+	}
+	principal, _ := i.principal.Load().(string)
+	return principal
+}
+
+func (i *connInfo) info(now time.Time) ConnectionInfo {
+	return ConnectionInfo{
+		ID:            i.id,
+		BrokerAddress: i.brokerAddress,
+		ClientAddress: i.clientAddress,
+		Principal:     i.getPrincipal(),
+		Created:       i.created,
+		AgeSeconds:    int64(now.Sub(i.created).Seconds()),
+		RequestBytes:  atomic.LoadInt64(&i.requestBytes),
+		ResponseBytes: atomic.LoadInt64(&i.responseBytes),
+	}
+}
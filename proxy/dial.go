@@ -72,11 +72,20 @@ type tlsDialer struct {
 	timeout   time.Duration
 	rawDialer Dialer
 	config    *tls.Config
+	// reloaded client certificates, they take precedence over the config
+	certs *tlsCertificates
+}
+
+func (d tlsDialer) tlsConfig() *tls.Config {
+	if d.certs != nil {
+		return d.certs.getConfig()
+	}
+	return d.config
 }
 
 // see tls.DialWithDialer
 func (d tlsDialer) Dial(network, addr string) (net.Conn, error) {
-	if d.config == nil {
+	if d.tlsConfig() == nil {
 		return nil, errors.New("tlsConfig must not be nil")
 	}
 	if d.rawDialer == nil {
@@ -106,7 +115,7 @@ func (d tlsDialer) Dial(network, addr string) (net.Conn, error) {
 	}
 	hostname := addr[:colonPos]
 
-	config := d.config
+	config := d.tlsConfig()
 
 	// If no ServerName is set, infer the ServerName
 	// from the hostname we're connecting to.
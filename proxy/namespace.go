@@ -0,0 +1,61 @@
+package proxy
+
+import (
+	"github.com/grepplabs/kafka-proxy/config"
+)
+
+const (
+	apiKeySaslAuthenticate = int16(36)
+	apiKeyDescribeCluster  = int16(60)
+)
+
+// api keys without topics, consumer groups or transactional ids which are forwarded unchanged in a namespace
+var namespacePassThroughApiKeys = map[int16]struct{}{
+	apiKeySaslHandshake:    {},
+	apiKeyApiApiVersions:   {},
+	apiKeySaslAuthenticate: {},
+	apiKeyDescribeCluster:  {},
+}
+
+// Namespaces maps principals and listeners to the prefix which is added to topic names, consumer group ids and
+// transactional ids of their requests. The principal mapping takes precedence over the listener mapping.
+type Namespaces struct {
+	principalPrefixes map[string]string
+	// listener prefixes by broker address of the listener
+	brokerPrefixes map[string]string
+}
+
+// NewNamespaces creates namespaces from the configured mappings. It returns nil if no mapping is configured.
+func NewNamespaces(cfg *config.Config) *Namespaces {
+	if len(cfg.Namespace.PrincipalMappings) == 0 && len(cfg.Namespace.ListenerMappings) == 0 {
+		return nil
+	}
+	namespaces := &Namespaces{
+		principalPrefixes: make(map[string]string),
+		brokerPrefixes:    make(map[string]string),
+	}
+	for _, mapping := range cfg.Namespace.PrincipalMappings {
+		namespaces.principalPrefixes[mapping.Name] = mapping.Prefix
+	}
+	for _, mapping := range cfg.Namespace.ListenerMappings {
+		for _, servers := range [][]config.ListenerConfig{cfg.Proxy.BootstrapServers, cfg.Proxy.ExternalServers} {
+			for _, v := range servers {
+				if v.ListenerAddress == mapping.Name {
+					namespaces.brokerPrefixes[v.BrokerAddress] = mapping.Prefix
+				}
+			}
+		}
+	}
+	return namespaces
+}
+
+// prefix returns the prefix of the principal or of the listener of the broker, an empty string means no namespace
+func (n *Namespaces) prefix(principal, brokerAddress string) string {
+	if n == nil {
+		return ""
+	}
+	if prefix, ok := n.principalPrefixes[principal]; ok {
+		return prefix
+	}
+	return n.brokerPrefixes[brokerAddress]
+}
@@ -0,0 +1,28 @@
+package proxy
+
+import (
+	"testing"
+
+	"github.com/grepplabs/kafka-proxy/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespacesPrefix(t *testing.T) {
+	a := assert.New(t)
+
+	cfg := config.NewConfig()
+	a.Nil(NewNamespaces(cfg))
+
+	a.Nil(cfg.InitBootstrapServers([]string{"kafka-0:9092,0.0.0.0:32400", "kafka-1:9092,0.0.0.0:32401"}))
+	a.Nil(cfg.InitNamespaceMappings([]string{"alice,tenant-a."}, []string{"0.0.0.0:32401,tenant-b."}))
+	a.Nil(cfg.Validate())
+
+	namespaces := NewNamespaces(cfg)
+	a.Equal("tenant-a.", namespaces.prefix("alice", "kafka-1:9092"))
+	a.Equal("tenant-b.", namespaces.prefix("bob", "kafka-1:9092"))
+	a.Equal("", namespaces.prefix("bob", "kafka-0:9092"))
+
+	a.Nil(cfg.InitNamespaceMappings(nil, []string{"0.0.0.0:32402,tenant-c."}))
+	a.EqualError(cfg.Validate(), "Namespace listener 0.0.0.0:32402 is not a bootstrap or external server listener")
+	a.EqualError(cfg.InitNamespaceMappings([]string{"alice"}, nil), "namespace-principal-mapping must be in form 'principal,prefix'")
+}
@@ -1,7 +1,10 @@
 package proxy
 
 import (
+	"crypto/tls"
 	"errors"
+	"sync"
+
 	"github.com/grepplabs/kafka-proxy/config"
 	"github.com/grepplabs/kafka-proxy/proxy/protocol"
 	"time"
@@ -17,6 +20,7 @@ const (
 	minOpenRequests           = 16
 
 	apiKeyProduce        = int16(0)
+	apiKeyMetadata       = int16(3)
 	apiKeySaslHandshake  = int16(17)
 	apiKeyApiApiVersions = int16(18)
 
@@ -42,6 +46,10 @@ type ProcessorConfig struct {
 	AuthServer            *AuthServer
 	ForbiddenApiKeys      map[int16]struct{}
 	ProducerAcks0Disabled bool
+	TopicAuthorizer       *TopicAuthorizer
+	Namespaces            *Namespaces
+	MetadataTopicFilter   *MetadataTopicFilter
+	RequestModifiers      *protocol.RequestModifiers
 }
 
 type processor struct {
@@ -57,12 +65,25 @@ type processor struct {
 
 	localSasl  *LocalSasl
 	authServer *AuthServer
+	// principal authenticated by local SASL before the processing started
+	principal string
+	// principal mapped from the client certificate
+	certPrincipal string
+	// description of the connection for the admin API
+	connInfo *connInfo
 
 	forbiddenApiKeys map[int16]struct{}
 	// metrics
 	brokerAddress string
 	// producer will never send request with acks=0
 	producerAcks0Disabled bool
+
+	topicAuthorizer     *TopicAuthorizer
+	namespaces          *Namespaces
+	metadataTopicFilter *MetadataTopicFilter
+	requestModifiers    *protocol.RequestModifiers
+	pendingResponses    *pendingResponses
+	apiVersionLimits    apiVersionLimits
 }
 
 func newProcessor(cfg ProcessorConfig, brokerAddress string) *processor {
@@ -107,6 +128,12 @@ func newProcessor(cfg ProcessorConfig, brokerAddress string) *processor {
 		authServer:                 cfg.AuthServer,
 		forbiddenApiKeys:           cfg.ForbiddenApiKeys,
 		producerAcks0Disabled:      cfg.ProducerAcks0Disabled,
+		topicAuthorizer:            cfg.TopicAuthorizer,
+		namespaces:                 cfg.Namespaces,
+		metadataTopicFilter:        cfg.MetadataTopicFilter,
+		requestModifiers:           cfg.RequestModifiers,
+		pendingResponses:           newPendingResponses(),
+		apiVersionLimits:           newApiVersionLimits(cfg),
 	}
 }
 
@@ -128,8 +155,16 @@ func (p *processor) RequestsLoop(dst DeadlineWriter, src DeadlineReaderWriter) (
 		forbiddenApiKeys:           p.forbiddenApiKeys,
 		buf:                        make([]byte, p.requestBufferSize),
 		localSasl:                  p.localSasl,
-		localSaslDone:              false, // sequential processing - mutex is required
+		localSaslDone:              p.principal != "", // sequential processing - mutex is required
+		principal:                  p.principal,
+		certPrincipal:              p.certPrincipal,
+		connInfo:                   p.connInfo,
 		producerAcks0Disabled:      p.producerAcks0Disabled,
+		topicAuthorizer:            p.topicAuthorizer,
+		namespaces:                 p.namespaces,
+		metadataTopicFilter:        p.metadataTopicFilter,
+		requestModifiers:           p.requestModifiers,
+		pendingResponses:           p.pendingResponses,
 	}
 
 	return ctx.requestsLoop(dst, src)
@@ -147,8 +182,44 @@ type RequestsLoopContext struct {
 
 	localSasl     *LocalSasl
 	localSaslDone bool
+	principal     string
+	certPrincipal string
+	connInfo      *connInfo
 
 	producerAcks0Disabled bool
+
+	topicAuthorizer     *TopicAuthorizer
+	namespaces          *Namespaces
+	metadataTopicFilter *MetadataTopicFilter
+	requestModifiers    *protocol.RequestModifiers
+	pendingResponses    *pendingResponses
+}
+
+// getPrincipal returns the principal authenticated by local SASL, the principal mapped from the client certificate
+// or the common name of the client certificate
+func (ctx *RequestsLoopContext) getPrincipal(src interface{}) string {
+	if ctx.principal != "" {
+		return ctx.principal
+	}
+	if ctx.certPrincipal != "" {
+		return ctx.certPrincipal
+	}
+	if tlsConn, ok := src.(*tls.Conn); ok {
+		state := tlsConn.ConnectionState()
+		if len(state.PeerCertificates) > 0 && state.PeerCertificates[0].Subject.CommonName != "" {
+			ctx.principal = state.PeerCertificates[0].Subject.CommonName
+			return ctx.principal
+		}
+	}
+	return anonymousPrincipal
+}
+
+// getNamespacePrefix returns the prefix of topic names, consumer group ids and transactional ids or an empty string if the connection is not in a namespace
+func (ctx *RequestsLoopContext) getNamespacePrefix(src interface{}) string {
+	if ctx.namespaces == nil {
+		return ""
+	}
+	return ctx.namespaces.prefix(ctx.getPrincipal(src), ctx.brokerAddress)
 }
 
 // used by local authentication
@@ -218,6 +289,9 @@ func (p *processor) ResponsesLoop(dst DeadlineWriter, src DeadlineReader) (readE
 		timeout:                    p.readTimeout,
 		brokerAddress:              p.brokerAddress,
 		buf:                        make([]byte, p.responseBufferSize),
+		pendingResponses:           p.pendingResponses,
+		apiVersionLimits:           p.apiVersionLimits,
+		connInfo:                   p.connInfo,
 	}
 	return ctx.responsesLoop(dst, src)
 }
@@ -229,6 +303,9 @@ type ResponsesLoopContext struct {
 	timeout                    time.Duration
 	brokerAddress              string
 	buf                        []byte // bufSize
+	pendingResponses           *pendingResponses
+	apiVersionLimits           apiVersionLimits
+	connInfo                   *connInfo
 }
 
 type ResponseHandler interface {
@@ -263,3 +340,42 @@ func (r *ResponsesLoopContext) getNextResponseHandler() (ResponseHandler, error)
 		}
 	}
 }
+
+// pendingResponse describes how the proxy handles the response to a request which was changed by the proxy
+type pendingResponse struct {
+	// modifier is applied to the broker response body
+	modifier protocol.ResponseModifier
+	// topicFilter removes topics from the Metadata response
+	topicFilter protocol.TopicFilterFunc
+	// localResponse replaces the broker response body, it includes the response header tagged fields
+	localResponse []byte
+}
+
+// pendingResponses are shared by the requests and responses loops of a connection and are keyed by correlation id
+type pendingResponses struct {
+	mu        sync.Mutex
+	responses map[int32]pendingResponse
+}
+
+func newPendingResponses() *pendingResponses {
+	return &pendingResponses{responses: make(map[int32]pendingResponse)}
+}
+
+func (p *pendingResponses) put(correlationID int32, response pendingResponse) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.responses[correlationID] = response
+}
+
+func (p *pendingResponses) take(correlationID int32) (pendingResponse, bool) {
+	if p == nil {
+		return pendingResponse{}, false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	response, ok := p.responses[correlationID]
+	if ok {
+		delete(p.responses, correlationID)
+	}
+	return response, ok
+}
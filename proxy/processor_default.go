@@ -2,11 +2,13 @@ package proxy
 
 import (
 	"bytes"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"github.com/grepplabs/kafka-proxy/proxy/protocol"
 	"github.com/sirupsen/logrus"
 	"io"
+	"io/ioutil"
 	"strconv"
 	"time"
 )
@@ -46,6 +48,7 @@ func (handler *DefaultRequestHandler) handleRequest(dst DeadlineWriter, src Dead
 
 	proxyRequestsTotal.WithLabelValues(ctx.brokerAddress, strconv.Itoa(int(requestKeyVersion.ApiKey)), strconv.Itoa(int(requestKeyVersion.ApiVersion))).Inc()
 	proxyRequestsBytes.WithLabelValues(ctx.brokerAddress).Add(float64(requestKeyVersion.Length + 4))
+	ctx.connInfo.addRequestBytes(int64(requestKeyVersion.Length + 4))
 
 	if _, ok := ctx.forbiddenApiKeys[requestKeyVersion.ApiKey]; ok {
 		return true, fmt.Errorf("api key %d is forbidden", requestKeyVersion.ApiKey)
@@ -61,13 +64,19 @@ func (handler *DefaultRequestHandler) handleRequest(dst DeadlineWriter, src Dead
 			case apiKeySaslHandshake:
 				switch requestKeyVersion.ApiVersion {
 				case 0:
-					if err = ctx.localSasl.receiveAndSendSASLAuthV0(src, keyVersionBuf); err != nil {
+					result, err := ctx.localSasl.receiveAndSendSASLAuthV0(src, keyVersionBuf)
+					if err != nil {
 						return true, err
 					}
+					ctx.principal = result.principal
+					ctx.connInfo.setPrincipal(result.principal)
 				case 1:
-					if err = ctx.localSasl.receiveAndSendSASLAuthV1(src, keyVersionBuf); err != nil {
+					result, err := ctx.localSasl.receiveAndSendSASLAuthV1(src, keyVersionBuf)
+					if err != nil {
 						return true, err
 					}
+					ctx.principal = result.principal
+					ctx.connInfo.setPrincipal(result.principal)
 				default:
 					return true, fmt.Errorf("only saslHandshake version 0 and 1 are supported, got version %d", requestKeyVersion.ApiVersion)
 				}
@@ -85,6 +94,22 @@ func (handler *DefaultRequestHandler) handleRequest(dst DeadlineWriter, src Dead
 		}
 	}
 
+	namespacePrefix := ctx.getNamespacePrefix(src)
+	if namespacePrefix != "" && !protocol.IsNamesApiKey(requestKeyVersion.ApiKey) {
+		if _, ok := namespacePassThroughApiKeys[requestKeyVersion.ApiKey]; !ok {
+			return true, fmt.Errorf("api key %d is not supported in a namespace", requestKeyVersion.ApiKey)
+		}
+	}
+	if ctx.topicAuthorizer != nil && !protocol.IsTopicsApiKey(requestKeyVersion.ApiKey) {
+		if _, ok := aclTopicFreeApiKeys[requestKeyVersion.ApiKey]; !ok {
+			return true, fmt.Errorf("api key %d is not supported with topic ACLs", requestKeyVersion.ApiKey)
+		}
+	}
+	if (ctx.topicAuthorizer != nil && protocol.IsTopicsApiKey(requestKeyVersion.ApiKey)) || (namespacePrefix != "" && protocol.IsNamesApiKey(requestKeyVersion.ApiKey)) ||
+		(ctx.metadataTopicFilter != nil && requestKeyVersion.ApiKey == apiKeyMetadata) || ctx.requestModifiers.Has(requestKeyVersion.ApiKey, requestKeyVersion.ApiVersion) {
+		return handler.handleDecodedRequest(dst, src, ctx, requestKeyVersion, keyVersionBuf, namespacePrefix)
+	}
+
 	mustReply, readBytes, err := handler.mustReply(requestKeyVersion, src, ctx)
 	if err != nil {
 		return true, err
@@ -133,12 +158,142 @@ func (handler *DefaultRequestHandler) handleRequest(dst DeadlineWriter, src Dead
 	}
 }
 
+// handleDecodedRequest buffers and decodes the request, applies the registered request modifiers, removes topics denied to the principal and forwards the rest to the broker.
+// Denied topics are added to the response with TOPIC_AUTHORIZATION_FAILED error. When all topics are denied the proxy answers itself,
+// but an ApiVersions request is still sent to the broker to keep the responses in order.
+// In a namespace the prefix is added to the names in the request and stripped from the names in the response.
+func (handler *DefaultRequestHandler) handleDecodedRequest(dst DeadlineWriter, src DeadlineReaderWriter, ctx *RequestsLoopContext, requestKeyVersion *protocol.RequestKeyVersion, keyVersionBuf []byte, namespacePrefix string) (readErr bool, err error) {
+	if requestKeyVersion.Length > protocol.MaxRequestSize {
+		return true, protocol.PacketDecodingError{Info: fmt.Sprintf("message of length %d too large", requestKeyVersion.Length)}
+	}
+	requestDeadline := time.Now().Add(ctx.timeout)
+	if err = dst.SetWriteDeadline(requestDeadline); err != nil {
+		return false, err
+	}
+	if err = src.SetReadDeadline(requestDeadline); err != nil {
+		return true, err
+	}
+	// ApiKey, ApiVersion and the rest of the request without Size
+	payload := make([]byte, int(requestKeyVersion.Length))
+	copy(payload, keyVersionBuf[4:])
+	if _, err = io.ReadFull(src, payload[4:]); err != nil {
+		return true, err
+	}
+	request, err := protocol.DecodeRequest(payload)
+	if err != nil {
+		return true, err
+	}
+	modified, err := ctx.requestModifiers.Apply(request)
+	if err != nil {
+		return true, err
+	}
+	var (
+		denied           []protocol.TopicPartitions
+		responseModifier protocol.ResponseModifier
+	)
+	principal := ctx.getPrincipal(src)
+	if ctx.topicAuthorizer != nil && protocol.IsTopicsApiKey(request.ApiKey) {
+		if denied, responseModifier, err = ctx.topicAuthorizer.authorizeRequest(principal, request); err != nil {
+			return true, err
+		}
+	}
+	mustReply := true
+	if request.ApiKey == apiKeyProduce && !ctx.producerAcks0Disabled {
+		if acks, ok := request.Body.Get("acks").(int16); ok {
+			mustReply = acks != 0
+		}
+	}
+
+	forwardKeyVersion := requestKeyVersion
+	var forwardBuf []byte
+	if len(denied) != 0 {
+		proxyAclDeniedTotal.WithLabelValues(ctx.brokerAddress, strconv.Itoa(int(request.ApiKey))).Add(float64(len(denied)))
+		logrus.Debugf("Principal %s denied topics %v of request key %d", principal, denied, request.ApiKey)
+
+		remaining, allTopics, err := request.Topics()
+		if err != nil {
+			return true, err
+		}
+		if len(remaining) == 0 && !allTopics && request.ApiKey != apiKeyMetadata {
+			if !mustReply {
+				// nothing to forward and nothing to answer
+				return false, ctx.putNextRequestHandler(defaultRequestHandler)
+			}
+			localResponse, err := protocol.NewTopicsErrorResponse(request.ApiKey, request.ApiVersion, denied, protocol.ErrTopicAuthorizationFailed)
+			if err != nil {
+				return true, err
+			}
+			if requestKeyVersion.ResponseHeaderVersion() == 1 {
+				// empty response header tagged fields
+				localResponse = append([]byte{0}, localResponse...)
+			}
+			responseModifier = nil
+			ctx.pendingResponses.put(request.CorrelationID, pendingResponse{localResponse: localResponse})
+
+			clientID := ""
+			if request.ClientID != nil {
+				clientID = *request.ClientID
+			}
+			apiVersionsBuf, err := protocol.Encode(&protocol.Request{CorrelationID: request.CorrelationID, ClientID: clientID, Body: &protocol.ApiVersionsRequestV0{}})
+			if err != nil {
+				return true, err
+			}
+			forwardKeyVersion = &protocol.RequestKeyVersion{Length: int32(len(apiVersionsBuf)), ApiKey: apiKeyApiApiVersions, ApiVersion: 0}
+			forwardBuf = make([]byte, 4, 4+len(apiVersionsBuf))
+			binary.BigEndian.PutUint32(forwardBuf, uint32(len(apiVersionsBuf)))
+			forwardBuf = append(forwardBuf, apiVersionsBuf...)
+		}
+	}
+	if forwardBuf == nil && namespacePrefix != "" {
+		if err = request.AddNamePrefix(namespacePrefix); err != nil {
+			return true, err
+		}
+		namespaceModifier, err := protocol.NewNamePrefixResponseModifier(request.ApiKey, request.ApiVersion, namespacePrefix)
+		if err != nil {
+			return true, err
+		}
+		// the prefix is stripped before topics denied by ACL are added to the response
+		responseModifier = chainResponseModifiers(namespaceModifier, responseModifier)
+	}
+	if forwardBuf == nil {
+		if !modified && len(denied) == 0 && namespacePrefix == "" {
+			forwardBuf = append(keyVersionBuf[:4:4], payload...)
+		} else if forwardBuf, err = request.Encode(); err != nil {
+			return true, err
+		}
+	}
+	var topicFilter protocol.TopicFilterFunc
+	if ctx.metadataTopicFilter != nil && request.ApiKey == apiKeyMetadata {
+		// the filter is applied before the namespace modifier strips the prefix
+		topicFilter = ctx.metadataTopicFilter.topicFilterFunc(principal, ctx.brokerAddress, namespacePrefix)
+	}
+	if (responseModifier != nil || topicFilter != nil) && mustReply {
+		ctx.pendingResponses.put(request.CorrelationID, pendingResponse{modifier: responseModifier, topicFilter: topicFilter})
+	}
+	// send inFlightRequest to channel before writing to prevent race condition in proxyResponses
+	if mustReply {
+		if err = sendRequestKeyVersion(ctx.openRequestsChannel, openRequestSendTimeout, forwardKeyVersion); err != nil {
+			return true, err
+		}
+	}
+	// write - send to broker
+	if _, err = dst.Write(forwardBuf); err != nil {
+		return false, err
+	}
+	if mustReply {
+		return false, ctx.putNextHandlers(defaultRequestHandler, defaultResponseHandler)
+	} else {
+		return false, ctx.putNextRequestHandler(defaultRequestHandler)
+	}
+}
+
 func (handler *DefaultRequestHandler) mustReply(requestKeyVersion *protocol.RequestKeyVersion, src io.Reader, ctx *RequestsLoopContext) (bool, []byte, error) {
 	if requestKeyVersion.ApiKey == apiKeyProduce {
 		if ctx.producerAcks0Disabled {
 			return true, nil, nil
 		}
 		// header version for produce [0..8] is 1 (request_api_key,request_api_version,correlation_id (INT32),client_id, NULLABLE_STRING )
+		// header version for produce [9..12] is 2 (header version 1 followed by tagged fields)
 		acksReader := protocol.RequestAcksReader{}
 
 		var (
@@ -169,6 +324,16 @@ func (handler *DefaultRequestHandler) mustReply(requestKeyVersion *protocol.Requ
 			if err != nil {
 				return false, nil, err
 			}
+		case 9, 10, 11, 12:
+			// CorrelationID + ClientID + TaggedFields
+			if err = acksReader.ReadAndDiscardHeaderV2Part(reader); err != nil {
+				return false, nil, err
+			}
+			// transactional_id (COMPACT_NULLABLE_STRING),acks (INT16)
+			acks, err = acksReader.ReadAndDiscardProduceCompactTxnAcks(reader)
+			if err != nil {
+				return false, nil, err
+			}
 		default:
 			return false, nil, fmt.Errorf("produce version %d is not supported", requestKeyVersion.ApiVersion)
 		}
@@ -204,6 +369,7 @@ func (handler *DefaultResponseHandler) handleResponse(dst DeadlineWriter, src De
 		return true, err
 	}
 	proxyResponsesBytes.WithLabelValues(ctx.brokerAddress).Add(float64(responseHeader.Length + 4))
+	ctx.connInfo.addResponseBytes(int64(responseHeader.Length + 4))
 	logrus.Debugf("Kafka response key %v, version %v, length %v", requestKeyVersion.ApiKey, requestKeyVersion.ApiVersion, responseHeader.Length)
 
 	responseDeadline := time.Now().Add(ctx.timeout)
@@ -225,10 +391,39 @@ func (handler *DefaultResponseHandler) handleResponse(dst DeadlineWriter, src De
 	}
 	readResponsesHeaderLength := int32(4 + len(unknownTaggedFields)) // 4 = Length + CorrelationID
 
-	responseModifier, err := protocol.GetResponseModifier(requestKeyVersion.ApiKey, requestKeyVersion.ApiVersion, ctx.netAddressMappingFunc)
+	pending, ok := ctx.pendingResponses.take(responseHeader.CorrelationID)
+	if ok && pending.localResponse != nil {
+		// discard the broker response and send the response prepared by the proxy
+		if readErr, err = myCopyN(ioutil.Discard, src, int64(responseHeader.Length-readResponsesHeaderLength), ctx.buf); err != nil {
+			return readErr, err
+		}
+		newHeaderBuf, err := protocol.Encode(&protocol.ResponseHeader{Length: int32(len(pending.localResponse) + 4), CorrelationID: responseHeader.CorrelationID})
+		if err != nil {
+			return true, err
+		}
+		if _, err := dst.Write(newHeaderBuf); err != nil {
+			return false, err
+		}
+		if _, err := dst.Write(pending.localResponse); err != nil {
+			return false, err
+		}
+		return false, nil
+	}
+
+	responseModifier, err := protocol.GetTopicFilteringResponseModifier(requestKeyVersion.ApiKey, requestKeyVersion.ApiVersion, ctx.netAddressMappingFunc, pending.topicFilter)
 	if err != nil {
 		return true, err
 	}
+	if pending.modifier != nil {
+		responseModifier = chainResponseModifiers(responseModifier, pending.modifier)
+	}
+	if requestKeyVersion.ApiKey == apiKeyApiApiVersions && len(ctx.apiVersionLimits) != 0 {
+		apiVersionsModifier, err := protocol.NewApiVersionsResponseModifier(requestKeyVersion.ApiVersion, ctx.apiVersionLimits)
+		if err != nil {
+			return true, err
+		}
+		responseModifier = chainResponseModifiers(responseModifier, apiVersionsModifier)
+	}
 	if responseModifier != nil {
 		if responseHeader.Length > protocol.MaxResponseSize {
 			return true, protocol.PacketDecodingError{Info: fmt.Sprintf("message of length %d too large", responseHeader.Length)}
@@ -271,6 +466,36 @@ func (handler *DefaultResponseHandler) handleResponse(dst DeadlineWriter, src De
 	return false, nil // continue nextResponse
 }
 
+type responseModifiers []protocol.ResponseModifier
+
+func (m responseModifiers) Apply(resp []byte) ([]byte, error) {
+	var err error
+	for _, modifier := range m {
+		if resp, err = modifier.Apply(resp); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// chainResponseModifiers applies the modifiers in order, nil modifiers are skipped. It returns nil if there is nothing to apply.
+func chainResponseModifiers(modifiers ...protocol.ResponseModifier) protocol.ResponseModifier {
+	result := make(responseModifiers, 0, len(modifiers))
+	for _, modifier := range modifiers {
+		if modifier != nil {
+			result = append(result, modifier)
+		}
+	}
+	switch len(result) {
+	case 0:
+		return nil
+	case 1:
+		return result[0]
+	default:
+		return result
+	}
+}
+
 func sendRequestKeyVersion(openRequestsChannel chan<- protocol.RequestKeyVersion, timeout time.Duration, request *protocol.RequestKeyVersion) error {
 	select {
 	case openRequestsChannel <- *request:
@@ -3,9 +3,11 @@ package proxy
 import (
 	"bytes"
 	"encoding/hex"
+	"github.com/grepplabs/kafka-proxy/config"
 	"github.com/grepplabs/kafka-proxy/proxy/protocol"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
 	"time"
 )
@@ -57,6 +59,22 @@ func TestHandleRequest(t *testing.T) {
 			hexInput:  "000000c2000000080000000300144b61666b614578616d706c6550726f6475636572ffff00000000753000000001000f746573742d6e6f2d6865616465727300000001000000000000007b00000000000000000000006fffffffff02021b145f000000000000000001734a6c14d6000001734a6c14d6ffffffffffffffffffffffffffff000000017a00000010000001734a6c13e42e48656c6c6f204d6f6d203135393436383134363337383002146865616465722d6b6579186865616465722d76616c7565",
 			mustReply: false,
 		},
+		{name: "Produce v9, acks=1", apiKey: 0, apiVersion: 9,
+			hexInput:  "00000040000000090000000500144b61666b614578616d706c6550726f647563657200000001000075300210746573742d6e6f2d68656164657273020000000001000000",
+			mustReply: true,
+		},
+		{name: "Produce v9, transactional, acks=all", apiKey: 0, apiVersion: 9,
+			hexInput:  "00000044000000090000000500144b61666b614578616d706c6550726f6475636572000574782d31ffff000075300210746573742d6e6f2d68656164657273020000000001000000",
+			mustReply: true,
+		},
+		{name: "Produce v9, header tagged fields, transactional, acks=0", apiKey: 0, apiVersion: 9,
+			hexInput:  "0000004a000000090000000500144b61666b614578616d706c6550726f6475636572010004deadbeef0574782d310000000075300210746573742d6e6f2d68656164657273020000000001000000",
+			mustReply: false,
+		},
+		{name: "Produce v12, acks=0", apiKey: 0, apiVersion: 12,
+			hexInput:  "000000400000000c0000000500144b61666b614578616d706c6550726f647563657200000000000075300210746573742d6e6f2d68656164657273020000000001000000",
+			mustReply: false,
+		},
 		{name: "ApiVersions v3, kafka-client 2.5.0", apiKey: 18, apiVersion: 3,
 			hexInput:  "00000038001200030000000000144b61666b614578616d706c6550726f647563657200126170616368652d6b61666b612d6a61766106322e352e3000",
 			mustReply: true,
@@ -313,3 +331,339 @@ func (w *TestDeadlineReaderWriter) Read(p []byte) (n int, err error) {
 func (w *TestDeadlineReaderWriter) Write(p []byte) (n int, err error) {
 	return w.reader.Write(p)
 }
+
+func TestHandleRequestTopicAuthorization(t *testing.T) {
+	buf := make([]byte, defaultRequestBufferSize)
+
+	tt := []struct {
+		name     string
+		rules    string
+		hexInput string
+
+		hexOutput       string // empty when the input is forwarded
+		openRequest     *protocol.RequestKeyVersion
+		localResponse   bool
+		pendingModifier bool
+	}{
+		{name: "Produce v3, acks=1, allowed", rules: "allow ANONYMOUS write test-*",
+			hexInput:    "000000c2000000030000000500144b61666b614578616d706c6550726f6475636572ffff00010000753000000001000f746573742d6e6f2d6865616465727300000001000000000000007b00000000000000000000006fffffffff0231f7fe0e000000000000000001734a66bef6000001734a66bef6ffffffffffffffffffffffffffff000000017a00000010000001734a66be5f2e48656c6c6f204d6f6d203135393436383131313432303702146865616465722d6b6579186865616465722d76616c7565",
+			openRequest: &protocol.RequestKeyVersion{ApiKey: 0, ApiVersion: 3},
+		},
+		{name: "Produce v3, acks=1, denied", rules: "allow ANONYMOUS read test-*",
+			hexInput:      "000000c2000000030000000500144b61666b614578616d706c6550726f6475636572ffff00010000753000000001000f746573742d6e6f2d6865616465727300000001000000000000007b00000000000000000000006fffffffff0231f7fe0e000000000000000001734a66bef6000001734a66bef6ffffffffffffffffffffffffffff000000017a00000010000001734a66be5f2e48656c6c6f204d6f6d203135393436383131313432303702146865616465722d6b6579186865616465722d76616c7565",
+			hexOutput:     "0000001e001200000000000500144b61666b614578616d706c6550726f6475636572",
+			openRequest:   &protocol.RequestKeyVersion{ApiKey: 18, ApiVersion: 0},
+			localResponse: true,
+		},
+		{name: "Produce v3, acks=0, denied", rules: "",
+			hexInput:  "000000c2000000030000000500144b61666b614578616d706c6550726f6475636572ffff00000000753000000001000f746573742d6e6f2d6865616465727300000001000000000000007b00000000000000000000006fffffffff0274ff21b4000000000000000001734a68c09a000001734a68c09affffffffffffffffffffffffffff000000017a00000010000001734a68c0162e48656c6c6f204d6f6d203135393436383132343537313802146865616465722d6b6579186865616465722d76616c7565",
+			hexOutput: "",
+		},
+		{name: "Metadata v9, denied", rules: "allow * describe other",
+			hexInput:        "00000035000300090000000100144b61666b614578616d706c6550726f6475636572000210746573742d6e6f2d686561646572730001000000",
+			hexOutput:       "00000024000300090000000100144b61666b614578616d706c6550726f6475636572000101000000",
+			openRequest:     &protocol.RequestKeyVersion{ApiKey: 3, ApiVersion: 9},
+			pendingModifier: true,
+		},
+		{name: "Metadata v12, topic id without name, denied", rules: "allow * all *",
+			hexInput:        "000000350003000c0000000100144b61666b614578616d706c6550726f647563657200020102030405060708090a0b0c0d0e0f100000010000",
+			hexOutput:       "000000230003000c0000000100144b61666b614578616d706c6550726f64756365720001010000",
+			openRequest:     &protocol.RequestKeyVersion{ApiKey: 3, ApiVersion: 12},
+			pendingModifier: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			a := assert.New(t)
+
+			input, err := hex.DecodeString(tc.hexInput)
+			a.Nil(err)
+			authorizer, err := newTopicAuthorizer(strings.NewReader(tc.rules))
+			a.Nil(err)
+
+			output := bytes.NewBuffer(make([]byte, 0))
+			dst := &TestDeadlineWriter{
+				Buffer: output,
+			}
+			readBuffer := bytes.NewBuffer(input)
+			src := &TestDeadlineReaderWriter{
+				reader: readBuffer,
+				writer: bytes.NewBuffer(make([]byte, 0)),
+			}
+			openRequestsChannel := make(chan protocol.RequestKeyVersion, 1)
+			ctx := &RequestsLoopContext{
+				openRequestsChannel:        openRequestsChannel,
+				nextRequestHandlerChannel:  make(chan RequestHandler, 1),
+				nextResponseHandlerChannel: make(chan ResponseHandler, 1),
+				timeout:                    1 * time.Second,
+				buf:                        buf,
+				localSasl:                  &LocalSasl{},
+				topicAuthorizer:            authorizer,
+				pendingResponses:           newPendingResponses(),
+			}
+			_, err = defaultRequestHandler.handleRequest(dst, src, ctx)
+			a.Nil(err)
+			a.Empty(readBuffer.Bytes())
+			if tc.openRequest != nil && tc.hexOutput == "" {
+				a.Equal(input, output.Bytes())
+			} else {
+				a.Equal(tc.hexOutput, hex.EncodeToString(output.Bytes()))
+			}
+
+			select {
+			case openRequest := <-openRequestsChannel:
+				a.NotNil(tc.openRequest)
+				a.Equal(tc.openRequest.ApiKey, openRequest.ApiKey)
+				a.Equal(tc.openRequest.ApiVersion, openRequest.ApiVersion)
+			default:
+				a.Nil(tc.openRequest)
+			}
+
+			pending, ok := ctx.pendingResponses.take(0x05)
+			if !ok {
+				pending, ok = ctx.pendingResponses.take(0x01)
+			}
+			a.Equal(tc.localResponse || tc.pendingModifier, ok)
+			a.Equal(tc.localResponse, pending.localResponse != nil)
+			a.Equal(tc.pendingModifier, pending.modifier != nil)
+		})
+	}
+}
+
+func TestHandleRequestTopicAuthorizationRejectsUncheckedApiKeys(t *testing.T) {
+	a := assert.New(t)
+
+	authorizer, err := newTopicAuthorizer(strings.NewReader("allow * all *"))
+	a.Nil(err)
+	// DeleteTopics v0 of topic t
+	input, err := hex.DecodeString("000000150014000000000001ffff0000000100017400000000")
+	a.Nil(err)
+	output := bytes.NewBuffer(make([]byte, 0))
+	dst := &TestDeadlineWriter{
+		Buffer: output,
+	}
+	src := &TestDeadlineReaderWriter{
+		reader: bytes.NewBuffer(input),
+		writer: bytes.NewBuffer(make([]byte, 0)),
+	}
+	ctx := &RequestsLoopContext{
+		openRequestsChannel:        make(chan protocol.RequestKeyVersion, 1),
+		nextRequestHandlerChannel:  make(chan RequestHandler, 1),
+		nextResponseHandlerChannel: make(chan ResponseHandler, 1),
+		timeout:                    1 * time.Second,
+		buf:                        make([]byte, defaultRequestBufferSize),
+		localSasl:                  &LocalSasl{},
+		topicAuthorizer:            authorizer,
+		pendingResponses:           newPendingResponses(),
+	}
+	_, err = defaultRequestHandler.handleRequest(dst, src, ctx)
+	a.EqualError(err, "api key 20 is not supported with topic ACLs")
+	a.Empty(output.Bytes())
+}
+
+func TestHandleRequestNamespace(t *testing.T) {
+	a := assert.New(t)
+
+	input, err := hex.DecodeString("00000035000300090000000100144b61666b614578616d706c6550726f6475636572000210746573742d6e6f2d686561646572730001000000")
+	a.Nil(err)
+	output := bytes.NewBuffer(make([]byte, 0))
+	dst := &TestDeadlineWriter{
+		Buffer: output,
+	}
+	src := &TestDeadlineReaderWriter{
+		reader: bytes.NewBuffer(input),
+		writer: bytes.NewBuffer(make([]byte, 0)),
+	}
+	openRequestsChannel := make(chan protocol.RequestKeyVersion, 1)
+	ctx := &RequestsLoopContext{
+		openRequestsChannel:        openRequestsChannel,
+		nextRequestHandlerChannel:  make(chan RequestHandler, 1),
+		nextResponseHandlerChannel: make(chan ResponseHandler, 1),
+		timeout:                    1 * time.Second,
+		buf:                        make([]byte, defaultRequestBufferSize),
+		localSasl:                  &LocalSasl{},
+		namespaces:                 &Namespaces{principalPrefixes: map[string]string{anonymousPrincipal: "t1."}},
+		pendingResponses:           newPendingResponses(),
+	}
+	_, err = defaultRequestHandler.handleRequest(dst, src, ctx)
+	a.Nil(err)
+	// topic test-no-headers is sent as t1.test-no-headers
+	a.Equal("00000038000300090000000100144b61666b614578616d706c6550726f647563657200021374312e746573742d6e6f2d686561646572730001000000", hex.EncodeToString(output.Bytes()))
+	openRequest := <-openRequestsChannel
+	a.Equal(int16(3), openRequest.ApiKey)
+	pending, ok := ctx.pendingResponses.take(0x01)
+	a.True(ok)
+	a.NotNil(pending.modifier)
+
+	// CreateTopics v0 is not supported in a namespace
+	input, err = hex.DecodeString("0000000c0013000000000002000000000000")
+	a.Nil(err)
+	src = &TestDeadlineReaderWriter{
+		reader: bytes.NewBuffer(input),
+		writer: bytes.NewBuffer(make([]byte, 0)),
+	}
+	_, err = defaultRequestHandler.handleRequest(dst, src, ctx)
+	a.EqualError(err, "api key 19 is not supported in a namespace")
+}
+
+func TestHandleRequestNamespaceTopicFilter(t *testing.T) {
+	a := assert.New(t)
+
+	cfg := config.NewConfig()
+	a.Nil(cfg.InitMetadataTopicFilter([]string{"deny,*,secret", "allow,*,test-*"}))
+	topicFilter, err := NewMetadataTopicFilter(cfg)
+	a.Nil(err)
+
+	input, err := hex.DecodeString("00000035000300090000000100144b61666b614578616d706c6550726f6475636572000210746573742d6e6f2d686561646572730001000000")
+	a.Nil(err)
+	src := &TestDeadlineReaderWriter{
+		reader: bytes.NewBuffer(input),
+		writer: bytes.NewBuffer(make([]byte, 0)),
+	}
+	ctx := &RequestsLoopContext{
+		openRequestsChannel:        make(chan protocol.RequestKeyVersion, 1),
+		nextRequestHandlerChannel:  make(chan RequestHandler, 1),
+		nextResponseHandlerChannel: make(chan ResponseHandler, 1),
+		timeout:                    1 * time.Second,
+		buf:                        make([]byte, defaultRequestBufferSize),
+		localSasl:                  &LocalSasl{},
+		namespaces:                 &Namespaces{principalPrefixes: map[string]string{anonymousPrincipal: "t1."}},
+		metadataTopicFilter:        topicFilter,
+		pendingResponses:           newPendingResponses(),
+	}
+	_, err = defaultRequestHandler.handleRequest(&TestDeadlineWriter{Buffer: bytes.NewBuffer(make([]byte, 0))}, src, ctx)
+	a.Nil(err)
+	pending, ok := ctx.pendingResponses.take(0x01)
+	a.True(ok)
+	a.NotNil(pending.topicFilter)
+
+	// the response modifiers are chained as by the response handler, the rules use the names without the prefix
+	resp, err := protocol.NewTopicsErrorResponse(apiKeyMetadata, 9, []protocol.TopicPartitions{{Topic: "t1.test-no-headers"}, {Topic: "t1.secret"}, {Topic: "t2.test-other"}}, protocol.ErrNoError)
+	a.Nil(err)
+	mapping := func(brokerHost string, brokerPort int32) (string, int32, error) { return brokerHost, brokerPort, nil }
+	responseModifier, err := protocol.GetTopicFilteringResponseModifier(apiKeyMetadata, 9, mapping, pending.topicFilter)
+	a.Nil(err)
+	resp, err = chainResponseModifiers(responseModifier, pending.modifier).Apply(resp)
+	a.Nil(err)
+	a.True(bytes.Contains(resp, []byte("test-no-headers")))
+	a.False(bytes.Contains(resp, []byte("t1.")))
+	a.False(bytes.Contains(resp, []byte("secret")))
+	a.False(bytes.Contains(resp, []byte("test-other")))
+}
+
+func TestHandleRequestModifiers(t *testing.T) {
+	a := assert.New(t)
+
+	modifiers := protocol.NewRequestModifiers()
+	a.Nil(modifiers.Register(apiKeyProduce, 3, 8, protocol.RequestModifierFunc(func(request *protocol.DecodedRequest) error {
+		return request.Body.Replace("acks", int16(-1))
+	})))
+
+	// Produce v3, acks=0
+	hexInput := "000000c2000000030000000500144b61666b614578616d706c6550726f6475636572ffff00000000753000000001000f746573742d6e6f2d6865616465727300000001000000000000007b00000000000000000000006fffffffff0274ff21b4000000000000000001734a68c09a000001734a68c09affffffffffffffffffffffffffff000000017a00000010000001734a68c0162e48656c6c6f204d6f6d203135393436383132343537313802146865616465722d6b6579186865616465722d76616c7565"
+	input, err := hex.DecodeString(hexInput)
+	a.Nil(err)
+	output := bytes.NewBuffer(make([]byte, 0))
+	dst := &TestDeadlineWriter{
+		Buffer: output,
+	}
+	src := &TestDeadlineReaderWriter{
+		reader: bytes.NewBuffer(input),
+		writer: bytes.NewBuffer(make([]byte, 0)),
+	}
+	openRequestsChannel := make(chan protocol.RequestKeyVersion, 1)
+	ctx := &RequestsLoopContext{
+		openRequestsChannel:        openRequestsChannel,
+		nextRequestHandlerChannel:  make(chan RequestHandler, 1),
+		nextResponseHandlerChannel: make(chan ResponseHandler, 1),
+		timeout:                    1 * time.Second,
+		buf:                        make([]byte, defaultRequestBufferSize),
+		localSasl:                  &LocalSasl{},
+		requestModifiers:           modifiers,
+		pendingResponses:           newPendingResponses(),
+	}
+	_, err = defaultRequestHandler.handleRequest(dst, src, ctx)
+	a.Nil(err)
+	// acks=-1 is forwarded and a response is awaited
+	a.Equal(strings.Replace(hexInput, "6572ffff0000", "6572ffffffff", 1), hex.EncodeToString(output.Bytes()))
+	openRequest := <-openRequestsChannel
+	a.Equal(int16(0), openRequest.ApiKey)
+	a.Equal(int16(3), openRequest.ApiVersion)
+}
+
+func TestHandleResponseLocalResponse(t *testing.T) {
+	a := assert.New(t)
+
+	// ApiVersions v0 response sent by the broker instead of the denied request
+	input, err := hex.DecodeString("0000001000000007000000000001001200000003")
+	a.Nil(err)
+	readBuffer := bytes.NewBuffer(input)
+	src := &TestDeadlineReader{
+		Buffer: readBuffer,
+	}
+	output := bytes.NewBuffer(make([]byte, 0))
+	dst := &TestDeadlineWriter{
+		Buffer: output,
+	}
+	openRequestsChannel := make(chan protocol.RequestKeyVersion, 1)
+	openRequestsChannel <- protocol.RequestKeyVersion{ApiKey: 18, ApiVersion: 0}
+
+	pendingResponses := newPendingResponses()
+	pendingResponses.put(7, pendingResponse{localResponse: []byte{0xde, 0xad}})
+
+	ctx := &ResponsesLoopContext{openRequestsChannel: openRequestsChannel, timeout: 1 * time.Second, buf: make([]byte, defaultResponseBufferSize), pendingResponses: pendingResponses}
+	_, err = defaultResponseHandler.handleResponse(dst, src, ctx)
+	a.Nil(err)
+	a.Equal("0000000600000007dead", hex.EncodeToString(output.Bytes()))
+	a.Empty(readBuffer.Bytes())
+
+	_, ok := pendingResponses.take(7)
+	a.False(ok)
+}
+
+func TestHandleResponseLargeFetchRejected(t *testing.T) {
+	a := assert.New(t)
+
+	// Fetch v16 response larger than MaxResponseSize, only the beginning of the body is available
+	input, err := hex.DecodeString("0640000100000007" + "00" + "aabbccdd")
+	a.Nil(err)
+	src := &TestDeadlineReader{
+		Buffer: bytes.NewBuffer(input),
+	}
+	output := bytes.NewBuffer(make([]byte, 0))
+	dst := &TestDeadlineWriter{
+		Buffer: output,
+	}
+	openRequestsChannel := make(chan protocol.RequestKeyVersion, 1)
+	openRequestsChannel <- protocol.RequestKeyVersion{ApiKey: apiKeyFetch, ApiVersion: 16}
+
+	ctx := &ResponsesLoopContext{openRequestsChannel: openRequestsChannel, timeout: 1 * time.Second, buf: make([]byte, defaultResponseBufferSize), pendingResponses: newPendingResponses()}
+	readErr, err := defaultResponseHandler.handleResponse(dst, src, ctx)
+	// node endpoints cannot be rewritten, the response is not forwarded
+	a.True(readErr)
+	a.Equal(protocol.PacketDecodingError{Info: "message of length 104857601 too large"}, err)
+	a.Empty(output.Bytes())
+}
+
+func TestHandleResponseApiVersionsLimits(t *testing.T) {
+	a := assert.New(t)
+
+	// ApiVersions v0 response with Produce versions 0-13
+	input, err := hex.DecodeString("000000100000000700000000000100000000000d")
+	a.Nil(err)
+	src := &TestDeadlineReader{
+		Buffer: bytes.NewBuffer(input),
+	}
+	output := bytes.NewBuffer(make([]byte, 0))
+	dst := &TestDeadlineWriter{
+		Buffer: output,
+	}
+	openRequestsChannel := make(chan protocol.RequestKeyVersion, 1)
+	openRequestsChannel <- protocol.RequestKeyVersion{ApiKey: 18, ApiVersion: 0}
+
+	ctx := &ResponsesLoopContext{openRequestsChannel: openRequestsChannel, timeout: 1 * time.Second, buf: make([]byte, defaultResponseBufferSize),
+		apiVersionLimits: newApiVersionLimits(ProcessorConfig{})}
+	_, err = defaultResponseHandler.handleResponse(dst, src, ctx)
+	a.Nil(err)
+	a.Equal("0000001000000007000000000001000000000009", hex.EncodeToString(output.Bytes()))
+}
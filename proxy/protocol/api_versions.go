@@ -0,0 +1,22 @@
+package protocol
+
+// ApiVersionsRequestV0 has an empty body. The proxy sends it to the broker in place of requests answered locally,
+// so the broker responses stay in order with the client requests.
+type ApiVersionsRequestV0 struct {
+}
+
+func (r *ApiVersionsRequestV0) encode(pe packetEncoder) error {
+	return nil
+}
+
+func (r *ApiVersionsRequestV0) decode(pd packetDecoder) (err error) {
+	return nil
+}
+
+func (r *ApiVersionsRequestV0) key() int16 {
+	return 18
+}
+
+func (r *ApiVersionsRequestV0) version() int16 {
+	return 0
+}
@@ -0,0 +1,172 @@
+package protocol
+
+import (
+	"fmt"
+	"strings"
+)
+
+// namesSpec lists the paths to topic names, group ids and transactional ids in the request and response of an api key.
+// Path segments are separated by a dot, the suffix [] marks an array and alternative field names are separated by |
+type namesSpec struct {
+	request  []string
+	response []string
+}
+
+var namesSpecs = map[int16]namesSpec{
+	apiKeyProduce:              {request: []string{"transactional_id", "topic_data[].name"}, response: []string{"responses[].name"}},
+	apiKeyFetch:                {request: []string{"topics[].topic", "forgotten_topics_data[].topic"}, response: []string{"responses[].topic"}},
+	apiKeyListOffsets:          {request: []string{"topics[].name"}, response: []string{"topics[].name"}},
+	apiKeyMetadata:             {request: []string{"topics[].name"}, response: []string{"topic_metadata[].name|topic"}},
+	apiKeyOffsetCommit:         {request: []string{"group_id", "topics[].name"}, response: []string{"topics[].name"}},
+	apiKeyOffsetFetch:          {request: []string{"group_id", "topics[].name"}, response: []string{"topics[].name"}},
+	apiKeyFindCoordinator:      {request: []string{"key", "coordinator_keys[]"}, response: []string{"coordinators[].key"}},
+	apiKeyJoinGroup:            {request: []string{"group_id"}},
+	apiKeyHeartbeat:            {request: []string{"group_id"}},
+	apiKeyLeaveGroup:           {request: []string{"group_id"}},
+	apiKeySyncGroup:            {request: []string{"group_id"}},
+	apiKeyDescribeGroups:       {request: []string{"groups[]"}, response: []string{"groups[].group_id"}},
+	apiKeyListGroups:           {response: []string{"groups[].group_id"}},
+	apiKeyInitProducerId:       {request: []string{"transactional_id"}},
+	apiKeyOffsetForLeaderEpoch: {request: []string{"topics[].topic"}, response: []string{"topics[].topic"}},
+	apiKeyAddPartitionsToTxn:   {request: []string{"transactional_id", "topics[].name"}, response: []string{"results[].name"}},
+	apiKeyAddOffsetsToTxn:      {request: []string{"transactional_id", "group_id"}},
+	apiKeyEndTxn:               {request: []string{"transactional_id"}},
+	apiKeyTxnOffsetCommit:      {request: []string{"transactional_id", "group_id", "topics[].name"}, response: []string{"topics[].name"}},
+	apiKeyDeleteGroups:         {request: []string{"groups_names[]"}, response: []string{"results[].group_id"}},
+}
+
+// IsNamesApiKey returns true if topic names, group ids and transactional ids of the request and response with the api key can be rewritten
+func IsNamesApiKey(apiKey int16) bool {
+	_, ok := namesSpecs[apiKey]
+	return ok
+}
+
+// AddNamePrefix prepends the prefix to all topic names, group ids and transactional ids of the request
+func (r *DecodedRequest) AddNamePrefix(prefix string) error {
+	spec, ok := namesSpecs[r.ApiKey]
+	if !ok {
+		return fmt.Errorf("names of api key %d are not supported", r.ApiKey)
+	}
+	addPrefix := func(name string) (string, bool) {
+		return prefix + name, true
+	}
+	for _, path := range spec.request {
+		if _, err := rewriteNames(r.Body, strings.Split(path, "."), addPrefix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewNamePrefixResponseModifier creates the response modifier which strips the prefix from topic names and group ids.
+// Entries which names do not start with the prefix are removed. A nil modifier is returned if the response contains no names.
+func NewNamePrefixResponseModifier(apiKey, apiVersion int16, prefix string) (ResponseModifier, error) {
+	spec, ok := namesSpecs[apiKey]
+	if !ok {
+		return nil, fmt.Errorf("names of api key %d are not supported", apiKey)
+	}
+	if len(spec.response) == 0 {
+		return nil, nil
+	}
+	schema, err := getResponseSchema(apiKey, apiVersion, getResponseSchemaVersions(apiKey))
+	if err != nil {
+		return nil, err
+	}
+	stripPrefix := func(name string) (string, bool) {
+		if !strings.HasPrefix(name, prefix) {
+			return name, false
+		}
+		return strings.TrimPrefix(name, prefix), true
+	}
+	return &structResponseModifier{
+		schema: schema,
+		modifyFunc: func(body *Struct) error {
+			for _, path := range spec.response {
+				if _, err := rewriteNames(body, strings.Split(path, "."), stripPrefix); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}, nil
+}
+
+// rewriteNames replaces names found by the path with the result of the rewrite function. If the rewrite function
+// does not keep a name, the enclosing array element is removed and false is returned for names outside of arrays.
+func rewriteNames(s *Struct, path []string, rewrite func(name string) (string, bool)) (bool, error) {
+	key, isArray := namesFieldKey(s, path[0])
+	if key == "" {
+		// field is not present in this version
+		return true, nil
+	}
+	value := s.Get(key)
+	if !isArray {
+		if len(path) != 1 {
+			return false, SchemaDecodingError{fmt.Sprintf("names field %s is not an array", key)}
+		}
+		switch name := value.(type) {
+		case string:
+			newName, keep := rewrite(name)
+			if !keep {
+				return false, nil
+			}
+			return true, s.Replace(key, newName)
+		case *string:
+			if name == nil {
+				return true, nil
+			}
+			newName, keep := rewrite(*name)
+			if !keep {
+				return false, nil
+			}
+			return true, s.Replace(key, &newName)
+		default:
+			return false, SchemaDecodingError{fmt.Sprintf("names field %s value %T is not a string", key, value)}
+		}
+	}
+	if value == nil {
+		return true, nil
+	}
+	elements, ok := value.([]interface{})
+	if !ok {
+		return false, SchemaDecodingError{fmt.Sprintf("names field %s is not an array", key)}
+	}
+	kept := make([]interface{}, 0, len(elements))
+	for _, element := range elements {
+		var keep bool
+		if len(path) == 1 {
+			name, ok := element.(string)
+			if !ok {
+				return false, SchemaDecodingError{fmt.Sprintf("names field %s element %T is not a string", key, element)}
+			}
+			element, keep = rewrite(name)
+		} else {
+			es, ok := element.(*Struct)
+			if !ok {
+				return false, SchemaDecodingError{fmt.Sprintf("names field %s element %T is not a *Struct", key, element)}
+			}
+			var err error
+			if keep, err = rewriteNames(es, path[1:], rewrite); err != nil {
+				return false, err
+			}
+		}
+		if keep {
+			kept = append(kept, element)
+		}
+	}
+	return true, s.Replace(key, kept)
+}
+
+func namesFieldKey(s *Struct, segment string) (key string, isArray bool) {
+	if strings.HasSuffix(segment, "[]") {
+		segment = strings.TrimSuffix(segment, "[]")
+		isArray = true
+	}
+	fields := s.GetSchema().GetFieldsByName()
+	for _, key := range strings.Split(segment, "|") {
+		if fields[key] != nil {
+			return key, isArray
+		}
+	}
+	return "", isArray
+}
@@ -0,0 +1,139 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddNamePrefixAllVersions(t *testing.T) {
+	for apiKey := range namesSpecs {
+		for apiVersion := range getRequestSchemaVersions(apiKey) {
+			a := assert.New(t)
+
+			schema, err := GetRequestSchema(apiKey, int16(apiVersion))
+			a.Nil(err)
+			body, err := newDefaultStruct(schema)
+			a.Nil(err)
+
+			request, err := DecodeRequest(encodeTestRequest(t, apiKey, int16(apiVersion), 5, body))
+			a.Nil(err, "api key %d version %d", apiKey, apiVersion)
+			a.Nil(request.AddNamePrefix("tenant."))
+			_, err = request.Encode()
+			a.Nil(err)
+		}
+	}
+}
+
+func TestAddNamePrefix(t *testing.T) {
+	a := assert.New(t)
+
+	schema, _ := GetRequestSchema(apiKeyProduce, 3)
+	topicSchema := schema.GetFieldsByName()["topic_data"].def.GetSchema()
+	transactionalID := "tx-1"
+	body := newTestStruct(t, schema, map[string]interface{}{
+		"transactional_id": &transactionalID,
+		"acks":             int16(-1),
+		"topic_data":       []interface{}{newTestStruct(t, topicSchema, map[string]interface{}{"name": "orders"})},
+	})
+	request, err := DecodeRequest(encodeTestRequest(t, apiKeyProduce, 3, 1, body))
+	a.Nil(err)
+	a.Nil(request.AddNamePrefix("tenant."))
+
+	encoded, err := request.Encode()
+	a.Nil(err)
+	request, err = DecodeRequest(encoded[4:])
+	a.Nil(err)
+	a.Equal("tenant.tx-1", *request.Body.Get("transactional_id").(*string))
+	topics, _, err := request.Topics()
+	a.Nil(err)
+	a.Equal([]TopicPartitions{{Topic: "tenant.orders"}}, topics)
+
+	schema, _ = GetRequestSchema(apiKeyDescribeGroups, 5)
+	body = newTestStruct(t, schema, map[string]interface{}{"groups": []interface{}{"g1", "g2"}})
+	request, err = DecodeRequest(encodeTestRequest(t, apiKeyDescribeGroups, 5, 2, body))
+	a.Nil(err)
+	a.Nil(request.AddNamePrefix("tenant."))
+	a.Equal([]interface{}{"tenant.g1", "tenant.g2"}, request.Body.Get("groups"))
+
+	schema, _ = GetRequestSchema(apiKeyFindCoordinator, 4)
+	body = newTestStruct(t, schema, map[string]interface{}{"coordinator_keys": []interface{}{"g1", "tx-1"}})
+	request, err = DecodeRequest(encodeTestRequest(t, apiKeyFindCoordinator, 4, 3, body))
+	a.Nil(err)
+	a.Nil(request.AddNamePrefix("tenant."))
+	a.Equal([]interface{}{"tenant.g1", "tenant.tx-1"}, request.Body.Get("coordinator_keys"))
+}
+
+func TestNamePrefixResponseModifierFindCoordinator(t *testing.T) {
+	a := assert.New(t)
+
+	schema, _ := getResponseSchema(apiKeyFindCoordinator, 4, findCoordinatorResponseSchemaVersions)
+	coordinatorSchema := schema.GetFieldsByName()["coordinators"].def.GetSchema()
+	body := newTestStruct(t, schema, map[string]interface{}{"coordinators": []interface{}{
+		newTestStruct(t, coordinatorSchema, map[string]interface{}{"key": "tenant.g1", "host": "localhost", "port": int32(9092)}),
+	}})
+	buf, err := EncodeSchema(body, schema)
+	a.Nil(err)
+
+	modifier, err := NewNamePrefixResponseModifier(apiKeyFindCoordinator, 4, "tenant.")
+	a.Nil(err)
+	buf, err = modifier.Apply(buf)
+	a.Nil(err)
+
+	response, err := DecodeSchema(buf, schema)
+	a.Nil(err)
+	coordinators := response.Get("coordinators").([]interface{})
+	a.Len(coordinators, 1)
+	a.Equal("g1", coordinators[0].(*Struct).Get("key"))
+}
+
+func TestNamePrefixResponseModifierMetadata(t *testing.T) {
+	for apiVersion := int16(0); int(apiVersion) < len(metadataResponseSchemaVersions); apiVersion++ {
+		a := assert.New(t)
+
+		buf, err := NewTopicsErrorResponse(apiKeyMetadata, apiVersion, []TopicPartitions{{Topic: "tenant.a"}, {Topic: "other.b"}, {Topic: "__consumer_offsets"}}, ErrNoError)
+		a.Nil(err)
+
+		modifier, err := NewNamePrefixResponseModifier(apiKeyMetadata, apiVersion, "tenant.")
+		a.Nil(err)
+		buf, err = modifier.Apply(buf)
+		a.Nil(err)
+
+		response, err := DecodeSchema(buf, metadataResponseSchemaVersions[apiVersion])
+		a.Nil(err)
+		elements := response.Get("topic_metadata").([]interface{})
+		a.Len(elements, 1)
+		name, _ := topicName(elements[0].(*Struct))
+		a.Equal("a", name)
+	}
+}
+
+func TestNamePrefixResponseModifierGroups(t *testing.T) {
+	for apiVersion := int16(0); apiVersion < 5; apiVersion++ {
+		a := assert.New(t)
+
+		schema, _ := getResponseSchema(apiKeyListGroups, apiVersion, listGroupsResponseSchemaVersions)
+		groupSchema := schema.GetFieldsByName()["groups"].def.GetSchema()
+		body := newTestStruct(t, schema, map[string]interface{}{"groups": []interface{}{
+			newTestStruct(t, groupSchema, map[string]interface{}{"group_id": "tenant.g1"}),
+			newTestStruct(t, groupSchema, map[string]interface{}{"group_id": "other.g2"}),
+		}})
+		buf, err := EncodeSchema(body, schema)
+		a.Nil(err)
+
+		modifier, err := NewNamePrefixResponseModifier(apiKeyListGroups, apiVersion, "tenant.")
+		a.Nil(err)
+		buf, err = modifier.Apply(buf)
+		a.Nil(err)
+
+		response, err := DecodeSchema(buf, schema)
+		a.Nil(err)
+		groups := response.Get("groups").([]interface{})
+		a.Len(groups, 1)
+		a.Equal("g1", groups[0].(*Struct).Get("group_id"))
+	}
+
+	modifier, err := NewNamePrefixResponseModifier(apiKeyJoinGroup, 7, "tenant.")
+	assert.Nil(t, err)
+	assert.Nil(t, modifier)
+}
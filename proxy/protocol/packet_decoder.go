@@ -21,10 +21,12 @@ type packetDecoder interface {
 	getStringArray() ([]string, error)
 
 	getVarintBytes() ([]byte, error)
+	getRawBytes(length int) ([]byte, error)
 
 	getCompactBytes() ([]byte, error)
 	getCompactString() (string, error)
 	getCompactNullableString() (*string, error)
+	getCompactNullableBytes() ([]byte, error)
 	getCompactArrayLength() (int, error)
 	getCompactNullableArrayLength() (int, error)
 
@@ -21,10 +21,12 @@ type packetEncoder interface {
 	putInt64Array(in []int64) error
 
 	putVarintBytes(in []byte) error
+	putRawBytes(in []byte) error
 
 	putCompactBytes(in []byte) error
 	putCompactString(in string) error
 	putCompactNullableString(in *string) error
+	putCompactNullableBytes(in []byte) error
 	putCompactArrayLength(in int) error
 	putCompactNullableArrayLength(in int) error
 
@@ -158,6 +158,16 @@ func (pe *prepEncoder) putCompactNullableString(in *string) error {
 	return pe.putCompactString(*in)
 }
 
+func (pe *prepEncoder) putCompactNullableBytes(in []byte) error {
+	if in == nil {
+		// A null byte array is represented with a length of 0.
+		pe.length += 1 // pe.putVarint(0) is always 1
+		return nil
+	}
+	pe.putVarint(int64(len(in) + 1))
+	return pe.putRawBytes(in)
+}
+
 func (pe *prepEncoder) putCompactArrayLength(in int) error {
 	switch {
 	case in > math.MaxInt16:
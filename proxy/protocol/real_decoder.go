@@ -333,6 +333,17 @@ func (rd *realDecoder) getCompactNullableString() (*string, error) {
 	return &tmpStr, nil
 }
 
+func (rd *realDecoder) getCompactNullableBytes() ([]byte, error) {
+
+	n, err := rd.getCompactNullableLength()
+	if err != nil || n < 0 {
+		return nil, err
+	}
+	tmp := rd.raw[rd.off : rd.off+n]
+	rd.off += n
+	return tmp, nil
+}
+
 func (rd *realDecoder) getCompactArrayLength() (int, error) {
 	return rd.getCompactLength()
 }
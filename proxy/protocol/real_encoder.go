@@ -158,6 +158,15 @@ func (re *realEncoder) putCompactNullableString(in *string) error {
 	return re.putCompactString(*in)
 }
 
+func (re *realEncoder) putCompactNullableBytes(in []byte) error {
+	if in == nil {
+		re.putVarint(0)
+		return nil
+	}
+	re.putVarint(int64(len(in) + 1))
+	return re.putRawBytes(in)
+}
+
 func (pe *realEncoder) putCompactArrayLength(in int) error {
 	pe.putVarint(int64(in + 1))
 	return nil
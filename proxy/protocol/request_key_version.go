@@ -149,13 +149,29 @@ func (r *RequestKeyVersion) ResponseHeaderVersion() int16 {
 			return 0
 		}
 	case 23: // OffsetForLeaderEpoch
-		return 0
+		if r.ApiVersion >= 4 {
+			return 1
+		} else {
+			return 0
+		}
 	case 24: // AddPartitionsToTxn
-		return 0
+		if r.ApiVersion >= 3 {
+			return 1
+		} else {
+			return 0
+		}
 	case 25: // AddOffsetsToTxn
-		return 0
+		if r.ApiVersion >= 3 {
+			return 1
+		} else {
+			return 0
+		}
 	case 26: // EndTxn
-		return 0
+		if r.ApiVersion >= 3 {
+			return 1
+		} else {
+			return 0
+		}
 	case 27: // WriteTxnMarkers
 		return 0
 	case 28: // TxnOffsetCommit
@@ -262,8 +278,36 @@ func (r *RequestKeyVersion) ResponseHeaderVersion() int16 {
 		return 1
 	case 51: // AlterUserScramCredentials
 		return 1
+	case 60: // DescribeCluster
+		return 1
 	default:
 		// throw new UnsupportedVersionException("Unsupported API key " + apiKey);
 		return -1
 	}
 }
+
+// Determine request header version. Function returns -1 for unknown api key.
+// See also public short requestHeaderVersion(short _version) in kafka/clients/src/generated/java/org/apache/kafka/common/message/ApiMessageType.java
+func (r *RequestKeyVersion) RequestHeaderVersion() int16 {
+	switch r.ApiKey {
+	case 7: // ControlledShutdown
+		// Version 0 of ControlledShutdownRequest has a non-standard request header which does not include clientId.
+		if r.ApiVersion == 0 {
+			return 0
+		}
+		return 1
+	case 18: // ApiVersions
+		if r.ApiVersion >= 3 {
+			return 2
+		}
+		return 1
+	}
+	switch r.ResponseHeaderVersion() {
+	case -1:
+		return -1
+	case 1:
+		return 2
+	default:
+		return 1
+	}
+}
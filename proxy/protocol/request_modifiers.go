@@ -0,0 +1,100 @@
+package protocol
+
+import (
+	"fmt"
+	"sync"
+)
+
+// RequestModifier changes a decoded request before it is encoded and forwarded to the broker
+type RequestModifier interface {
+	Apply(request *DecodedRequest) error
+}
+
+// RequestModifierFunc is an adapter to use a function as RequestModifier
+type RequestModifierFunc func(request *DecodedRequest) error
+
+func (f RequestModifierFunc) Apply(request *DecodedRequest) error {
+	return f(request)
+}
+
+type requestModifierKey struct {
+	apiKey     int16
+	apiVersion int16
+}
+
+// RequestModifiers is a registry of request modifiers keyed by api key and version. Modifiers registered
+// for the same api key and version are applied in the registration order.
+type RequestModifiers struct {
+	mu        sync.RWMutex
+	modifiers map[requestModifierKey][]RequestModifier
+}
+
+func NewRequestModifiers() *RequestModifiers {
+	return &RequestModifiers{modifiers: make(map[requestModifierKey][]RequestModifier)}
+}
+
+// Register adds the modifier for the api versions from minVersion to maxVersion inclusive. Only versions which
+// request schema is known can be registered.
+func (r *RequestModifiers) Register(apiKey int16, minVersion int16, maxVersion int16, modifier RequestModifier) error {
+	if modifier == nil {
+		return fmt.Errorf("request modifier for key %d must not be nil", apiKey)
+	}
+	if minVersion > maxVersion {
+		return fmt.Errorf("invalid request modifier versions %d-%d for key %d", minVersion, maxVersion, apiKey)
+	}
+	for apiVersion := minVersion; apiVersion <= maxVersion; apiVersion++ {
+		if _, err := GetRequestSchema(apiKey, apiVersion); err != nil {
+			return err
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for apiVersion := minVersion; apiVersion <= maxVersion; apiVersion++ {
+		key := requestModifierKey{apiKey: apiKey, apiVersion: apiVersion}
+		r.modifiers[key] = append(r.modifiers[key], modifier)
+	}
+	return nil
+}
+
+// Has returns true if at least one modifier is registered for the api key and version
+func (r *RequestModifiers) Has(apiKey int16, apiVersion int16) bool {
+	if r == nil {
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.modifiers[requestModifierKey{apiKey: apiKey, apiVersion: apiVersion}]) != 0
+}
+
+// MaxVersion returns the highest api version of the api key with a registered modifier or -1 if none is registered
+func (r *RequestModifiers) MaxVersion(apiKey int16) int16 {
+	maxVersion := int16(-1)
+	if r == nil {
+		return maxVersion
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for key, modifiers := range r.modifiers {
+		if key.apiKey == apiKey && len(modifiers) != 0 && key.apiVersion > maxVersion {
+			maxVersion = key.apiVersion
+		}
+	}
+	return maxVersion
+}
+
+// Apply runs the modifiers registered for the api key and version of the request. It returns true if any modifier was applied.
+func (r *RequestModifiers) Apply(request *DecodedRequest) (bool, error) {
+	if r == nil {
+		return false, nil
+	}
+	r.mu.RLock()
+	modifiers := r.modifiers[requestModifierKey{apiKey: request.ApiKey, apiVersion: request.ApiVersion}]
+	r.mu.RUnlock()
+
+	for _, modifier := range modifiers {
+		if err := modifier.Apply(request); err != nil {
+			return false, err
+		}
+	}
+	return len(modifiers) != 0, nil
+}
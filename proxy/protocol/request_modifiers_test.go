@@ -0,0 +1,56 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestModifiers(t *testing.T) {
+	a := assert.New(t)
+
+	modifiers := NewRequestModifiers()
+	a.Nil(modifiers.Register(apiKeyProduce, 3, 9, RequestModifierFunc(func(request *DecodedRequest) error {
+		return request.Body.Replace("acks", int16(-1))
+	})))
+	clientID := "injected-client-id"
+	a.Nil(modifiers.Register(apiKeyProduce, 9, 9, RequestModifierFunc(func(request *DecodedRequest) error {
+		request.ClientID = &clientID
+		return nil
+	})))
+	a.EqualError(modifiers.Register(apiKeyProduce, 9, 10, RequestModifierFunc(func(request *DecodedRequest) error { return nil })), "Unsupported request schema version 10 for key 0 ")
+	a.True(modifiers.Has(apiKeyProduce, 3))
+	a.False(modifiers.Has(apiKeyProduce, 2))
+	a.False(modifiers.Has(apiKeyFetch, 3))
+
+	for _, apiVersion := range []int16{2, 3, 9} {
+		schema, _ := GetRequestSchema(apiKeyProduce, apiVersion)
+		body := newTestStruct(t, schema, map[string]interface{}{"acks": int16(1), "timeout_ms": int32(1000)})
+		request, err := DecodeRequest(encodeTestRequest(t, apiKeyProduce, apiVersion, 4, body))
+		a.Nil(err)
+
+		applied, err := modifiers.Apply(request)
+		a.Nil(err)
+		a.Equal(apiVersion != 2, applied)
+
+		encoded, err := request.Encode()
+		a.Nil(err)
+		a.Equal(uint32(len(encoded)-4), binary.BigEndian.Uint32(encoded))
+
+		request, err = DecodeRequest(encoded[4:])
+		a.Nil(err)
+		a.Equal(int32(4), request.CorrelationID)
+		switch apiVersion {
+		case 2:
+			a.Equal(int16(1), request.Body.Get("acks"))
+			a.Equal("client", *request.ClientID)
+		case 3:
+			a.Equal(int16(-1), request.Body.Get("acks"))
+			a.Equal("client", *request.ClientID)
+		case 9:
+			a.Equal(int16(-1), request.Body.Get("acks"))
+			a.Equal(clientID, *request.ClientID)
+		}
+	}
+}
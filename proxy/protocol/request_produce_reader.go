@@ -58,3 +58,79 @@ func (r RequestAcksReader) ReadAndDiscardProduceTxnAcks(reader io.Reader) (acks
 	}
 	return acks, nil
 }
+
+func (r RequestAcksReader) readUvarint(reader io.Reader) (uint64, error) {
+	var (
+		value uint64
+		shift uint
+		b     [1]byte
+	)
+	for i := 0; i < binary.MaxVarintLen64; i++ {
+		if _, err := io.ReadFull(reader, b[:]); err != nil {
+			return 0, err
+		}
+		if b[0] < 0x80 {
+			return value | uint64(b[0])<<shift, nil
+		}
+		value |= uint64(b[0]&0x7f) << shift
+		shift += 7
+	}
+	return 0, errVarintOverflow
+}
+
+func (r RequestAcksReader) readAndDiscardCompactNullableString(reader io.Reader) (err error) {
+	// length + 1, 0 is null
+	length, err := r.readUvarint(reader)
+	if err != nil {
+		return err
+	}
+	if length > 1 {
+		if _, err = io.CopyN(ioutil.Discard, reader, int64(length-1)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r RequestAcksReader) readAndDiscardTaggedFields(reader io.Reader) (err error) {
+	numTaggedFields, err := r.readUvarint(reader)
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < numTaggedFields; i++ {
+		// tag
+		if _, err = r.readUvarint(reader); err != nil {
+			return err
+		}
+		size, err := r.readUvarint(reader)
+		if err != nil {
+			return err
+		}
+		if _, err = io.CopyN(ioutil.Discard, reader, int64(size)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r RequestAcksReader) ReadAndDiscardHeaderV2Part(reader io.Reader) (err error) {
+	// CorrelationID + ClientID (not compact)
+	if err = r.ReadAndDiscardHeaderV1Part(reader); err != nil {
+		return err
+	}
+	// TaggedFields
+	return r.readAndDiscardTaggedFields(reader)
+}
+
+func (r RequestAcksReader) ReadAndDiscardProduceCompactTxnAcks(reader io.Reader) (acks int16, err error) {
+	// TransactionalId COMPACT_NULLABLE_STRING
+	if err = r.readAndDiscardCompactNullableString(reader); err != nil {
+		return 0, err
+	}
+
+	// Acks int16
+	if err = binary.Read(reader, binary.BigEndian, &acks); err != nil {
+		return 0, err
+	}
+	return acks, nil
+}
@@ -0,0 +1,701 @@
+package protocol
+
+import (
+	"fmt"
+)
+
+const (
+	apiKeyProduce      = 0
+	apiKeyFetch        = 1
+	apiKeyListOffsets  = 2
+	apiKeyOffsetCommit = 8
+	apiKeyOffsetFetch  = 9
+)
+
+var (
+	produceRequestSchemaVersions      = createProduceRequestSchemaVersions()
+	fetchRequestSchemaVersions        = createFetchRequestSchemaVersions()
+	listOffsetsRequestSchemaVersions  = createListOffsetsRequestSchemaVersions()
+	metadataRequestSchemaVersions     = createMetadataRequestSchemaVersions()
+	offsetCommitRequestSchemaVersions = createOffsetCommitRequestSchemaVersions()
+	offsetFetchRequestSchemaVersions  = createOffsetFetchRequestSchemaVersions()
+)
+
+func createProduceRequestSchemaVersions() []Schema {
+	partitionDataV0 := NewSchema("partition_data_v0",
+		&Mfield{Name: "index", Ty: TypeInt32},
+		&Mfield{Name: "records", Ty: TypeNullableBytes},
+	)
+
+	topicDataV0 := NewSchema("topic_data_v0",
+		&Mfield{Name: "name", Ty: TypeStr},
+		&Array{Name: "partition_data", Ty: partitionDataV0},
+	)
+
+	produceRequestV0 := NewSchema("produce_request_v0",
+		&Mfield{Name: "acks", Ty: TypeInt16},
+		&Mfield{Name: "timeout_ms", Ty: TypeInt32},
+		&Array{Name: "topic_data", Ty: topicDataV0},
+	)
+
+	produceRequestV1 := produceRequestV0
+	produceRequestV2 := produceRequestV0
+
+	produceRequestV3 := NewSchema("produce_request_v3",
+		&Mfield{Name: "transactional_id", Ty: TypeNullableStr},
+		&Mfield{Name: "acks", Ty: TypeInt16},
+		&Mfield{Name: "timeout_ms", Ty: TypeInt32},
+		&Array{Name: "topic_data", Ty: topicDataV0},
+	)
+
+	produceRequestV4 := produceRequestV3
+	produceRequestV5 := produceRequestV3
+	produceRequestV6 := produceRequestV3
+	produceRequestV7 := produceRequestV3
+	produceRequestV8 := produceRequestV3
+
+	partitionDataSchema9 := NewSchema("partition_data_schema9",
+		&Mfield{Name: "index", Ty: TypeInt32},
+		&Mfield{Name: "records", Ty: TypeCompactNullableBytes},
+		&SchemaTaggedFields{Name: "partition_data_tagged_fields"},
+	)
+
+	topicDataSchema9 := NewSchema("topic_data_schema9",
+		&Mfield{Name: "name", Ty: TypeCompactStr},
+		&CompactArray{Name: "partition_data", Ty: partitionDataSchema9},
+		&SchemaTaggedFields{Name: "topic_data_tagged_fields"},
+	)
+
+	produceRequestV9 := NewSchema("produce_request_v9",
+		&Mfield{Name: "transactional_id", Ty: TypeCompactNullableStr},
+		&Mfield{Name: "acks", Ty: TypeInt16},
+		&Mfield{Name: "timeout_ms", Ty: TypeInt32},
+		&CompactArray{Name: "topic_data", Ty: topicDataSchema9},
+		&SchemaTaggedFields{Name: "request_tagged_fields"},
+	)
+
+	return []Schema{produceRequestV0, produceRequestV1, produceRequestV2, produceRequestV3, produceRequestV4, produceRequestV5, produceRequestV6, produceRequestV7, produceRequestV8, produceRequestV9}
+}
+
+func createFetchRequestSchemaVersions() []Schema {
+	fetchPartitionV0 := NewSchema("fetch_partition_v0",
+		&Mfield{Name: "partition", Ty: TypeInt32},
+		&Mfield{Name: "fetch_offset", Ty: TypeInt64},
+		&Mfield{Name: "partition_max_bytes", Ty: TypeInt32},
+	)
+
+	fetchPartitionV5 := NewSchema("fetch_partition_v5",
+		&Mfield{Name: "partition", Ty: TypeInt32},
+		&Mfield{Name: "fetch_offset", Ty: TypeInt64},
+		&Mfield{Name: "log_start_offset", Ty: TypeInt64},
+		&Mfield{Name: "partition_max_bytes", Ty: TypeInt32},
+	)
+
+	fetchPartitionV9 := NewSchema("fetch_partition_v9",
+		&Mfield{Name: "partition", Ty: TypeInt32},
+		&Mfield{Name: "current_leader_epoch", Ty: TypeInt32},
+		&Mfield{Name: "fetch_offset", Ty: TypeInt64},
+		&Mfield{Name: "log_start_offset", Ty: TypeInt64},
+		&Mfield{Name: "partition_max_bytes", Ty: TypeInt32},
+	)
+
+	fetchPartitionSchema12 := NewSchema("fetch_partition_schema12",
+		&Mfield{Name: "partition", Ty: TypeInt32},
+		&Mfield{Name: "current_leader_epoch", Ty: TypeInt32},
+		&Mfield{Name: "fetch_offset", Ty: TypeInt64},
+		&Mfield{Name: "last_fetched_epoch", Ty: TypeInt32},
+		&Mfield{Name: "log_start_offset", Ty: TypeInt64},
+		&Mfield{Name: "partition_max_bytes", Ty: TypeInt32},
+		&SchemaTaggedFields{Name: "partition_tagged_fields"},
+	)
+
+	fetchTopicV0 := NewSchema("fetch_topic_v0",
+		&Mfield{Name: "topic", Ty: TypeStr},
+		&Array{Name: "partitions", Ty: fetchPartitionV0},
+	)
+
+	fetchTopicV5 := NewSchema("fetch_topic_v5",
+		&Mfield{Name: "topic", Ty: TypeStr},
+		&Array{Name: "partitions", Ty: fetchPartitionV5},
+	)
+
+	fetchTopicV9 := NewSchema("fetch_topic_v9",
+		&Mfield{Name: "topic", Ty: TypeStr},
+		&Array{Name: "partitions", Ty: fetchPartitionV9},
+	)
+
+	fetchTopicSchema12 := NewSchema("fetch_topic_schema12",
+		&Mfield{Name: "topic", Ty: TypeCompactStr},
+		&CompactArray{Name: "partitions", Ty: fetchPartitionSchema12},
+		&SchemaTaggedFields{Name: "topic_tagged_fields"},
+	)
+
+	forgottenTopicV7 := NewSchema("forgotten_topic_v7",
+		&Mfield{Name: "topic", Ty: TypeStr},
+		&Array{Name: "partitions", Ty: TypeInt32},
+	)
+
+	forgottenTopicSchema12 := NewSchema("forgotten_topic_schema12",
+		&Mfield{Name: "topic", Ty: TypeCompactStr},
+		&CompactArray{Name: "partitions", Ty: TypeInt32},
+		&SchemaTaggedFields{Name: "forgotten_topic_tagged_fields"},
+	)
+
+	fetchRequestV0 := NewSchema("fetch_request_v0",
+		&Mfield{Name: "replica_id", Ty: TypeInt32},
+		&Mfield{Name: "max_wait_ms", Ty: TypeInt32},
+		&Mfield{Name: "min_bytes", Ty: TypeInt32},
+		&Array{Name: "topics", Ty: fetchTopicV0},
+	)
+
+	fetchRequestV1 := fetchRequestV0
+	fetchRequestV2 := fetchRequestV0
+
+	fetchRequestV3 := NewSchema("fetch_request_v3",
+		&Mfield{Name: "replica_id", Ty: TypeInt32},
+		&Mfield{Name: "max_wait_ms", Ty: TypeInt32},
+		&Mfield{Name: "min_bytes", Ty: TypeInt32},
+		&Mfield{Name: "max_bytes", Ty: TypeInt32},
+		&Array{Name: "topics", Ty: fetchTopicV0},
+	)
+
+	fetchRequestV4 := NewSchema("fetch_request_v4",
+		&Mfield{Name: "replica_id", Ty: TypeInt32},
+		&Mfield{Name: "max_wait_ms", Ty: TypeInt32},
+		&Mfield{Name: "min_bytes", Ty: TypeInt32},
+		&Mfield{Name: "max_bytes", Ty: TypeInt32},
+		&Mfield{Name: "isolation_level", Ty: TypeInt8},
+		&Array{Name: "topics", Ty: fetchTopicV0},
+	)
+
+	fetchRequestV5 := NewSchema("fetch_request_v5",
+		&Mfield{Name: "replica_id", Ty: TypeInt32},
+		&Mfield{Name: "max_wait_ms", Ty: TypeInt32},
+		&Mfield{Name: "min_bytes", Ty: TypeInt32},
+		&Mfield{Name: "max_bytes", Ty: TypeInt32},
+		&Mfield{Name: "isolation_level", Ty: TypeInt8},
+		&Array{Name: "topics", Ty: fetchTopicV5},
+	)
+
+	fetchRequestV6 := fetchRequestV5
+
+	fetchRequestV7 := NewSchema("fetch_request_v7",
+		&Mfield{Name: "replica_id", Ty: TypeInt32},
+		&Mfield{Name: "max_wait_ms", Ty: TypeInt32},
+		&Mfield{Name: "min_bytes", Ty: TypeInt32},
+		&Mfield{Name: "max_bytes", Ty: TypeInt32},
+		&Mfield{Name: "isolation_level", Ty: TypeInt8},
+		&Mfield{Name: "session_id", Ty: TypeInt32},
+		&Mfield{Name: "session_epoch", Ty: TypeInt32},
+		&Array{Name: "topics", Ty: fetchTopicV5},
+		&Array{Name: "forgotten_topics_data", Ty: forgottenTopicV7},
+	)
+
+	fetchRequestV8 := fetchRequestV7
+
+	fetchRequestV9 := NewSchema("fetch_request_v9",
+		&Mfield{Name: "replica_id", Ty: TypeInt32},
+		&Mfield{Name: "max_wait_ms", Ty: TypeInt32},
+		&Mfield{Name: "min_bytes", Ty: TypeInt32},
+		&Mfield{Name: "max_bytes", Ty: TypeInt32},
+		&Mfield{Name: "isolation_level", Ty: TypeInt8},
+		&Mfield{Name: "session_id", Ty: TypeInt32},
+		&Mfield{Name: "session_epoch", Ty: TypeInt32},
+		&Array{Name: "topics", Ty: fetchTopicV9},
+		&Array{Name: "forgotten_topics_data", Ty: forgottenTopicV7},
+	)
+
+	fetchRequestV10 := fetchRequestV9
+
+	fetchRequestV11 := NewSchema("fetch_request_v11",
+		&Mfield{Name: "replica_id", Ty: TypeInt32},
+		&Mfield{Name: "max_wait_ms", Ty: TypeInt32},
+		&Mfield{Name: "min_bytes", Ty: TypeInt32},
+		&Mfield{Name: "max_bytes", Ty: TypeInt32},
+		&Mfield{Name: "isolation_level", Ty: TypeInt8},
+		&Mfield{Name: "session_id", Ty: TypeInt32},
+		&Mfield{Name: "session_epoch", Ty: TypeInt32},
+		&Array{Name: "topics", Ty: fetchTopicV9},
+		&Array{Name: "forgotten_topics_data", Ty: forgottenTopicV7},
+		&Mfield{Name: "rack_id", Ty: TypeStr},
+	)
+
+	fetchRequestV12 := NewSchema("fetch_request_v12",
+		&Mfield{Name: "replica_id", Ty: TypeInt32},
+		&Mfield{Name: "max_wait_ms", Ty: TypeInt32},
+		&Mfield{Name: "min_bytes", Ty: TypeInt32},
+		&Mfield{Name: "max_bytes", Ty: TypeInt32},
+		&Mfield{Name: "isolation_level", Ty: TypeInt8},
+		&Mfield{Name: "session_id", Ty: TypeInt32},
+		&Mfield{Name: "session_epoch", Ty: TypeInt32},
+		&CompactArray{Name: "topics", Ty: fetchTopicSchema12},
+		&CompactArray{Name: "forgotten_topics_data", Ty: forgottenTopicSchema12},
+		&Mfield{Name: "rack_id", Ty: TypeCompactStr},
+		&SchemaTaggedFields{Name: "request_tagged_fields"},
+	)
+
+	return []Schema{fetchRequestV0, fetchRequestV1, fetchRequestV2, fetchRequestV3, fetchRequestV4, fetchRequestV5, fetchRequestV6, fetchRequestV7, fetchRequestV8, fetchRequestV9, fetchRequestV10, fetchRequestV11, fetchRequestV12}
+}
+
+func createListOffsetsRequestSchemaVersions() []Schema {
+	listOffsetsPartitionV0 := NewSchema("list_offsets_partition_v0",
+		&Mfield{Name: "partition_index", Ty: TypeInt32},
+		&Mfield{Name: "timestamp", Ty: TypeInt64},
+		&Mfield{Name: "max_num_offsets", Ty: TypeInt32},
+	)
+
+	listOffsetsPartitionV1 := NewSchema("list_offsets_partition_v1",
+		&Mfield{Name: "partition_index", Ty: TypeInt32},
+		&Mfield{Name: "timestamp", Ty: TypeInt64},
+	)
+
+	listOffsetsPartitionV4 := NewSchema("list_offsets_partition_v4",
+		&Mfield{Name: "partition_index", Ty: TypeInt32},
+		&Mfield{Name: "current_leader_epoch", Ty: TypeInt32},
+		&Mfield{Name: "timestamp", Ty: TypeInt64},
+	)
+
+	listOffsetsPartitionSchema6 := NewSchema("list_offsets_partition_schema6",
+		&Mfield{Name: "partition_index", Ty: TypeInt32},
+		&Mfield{Name: "current_leader_epoch", Ty: TypeInt32},
+		&Mfield{Name: "timestamp", Ty: TypeInt64},
+		&SchemaTaggedFields{Name: "partition_tagged_fields"},
+	)
+
+	listOffsetsTopicV0 := NewSchema("list_offsets_topic_v0",
+		&Mfield{Name: "name", Ty: TypeStr},
+		&Array{Name: "partitions", Ty: listOffsetsPartitionV0},
+	)
+
+	listOffsetsTopicV1 := NewSchema("list_offsets_topic_v1",
+		&Mfield{Name: "name", Ty: TypeStr},
+		&Array{Name: "partitions", Ty: listOffsetsPartitionV1},
+	)
+
+	listOffsetsTopicV4 := NewSchema("list_offsets_topic_v4",
+		&Mfield{Name: "name", Ty: TypeStr},
+		&Array{Name: "partitions", Ty: listOffsetsPartitionV4},
+	)
+
+	listOffsetsTopicSchema6 := NewSchema("list_offsets_topic_schema6",
+		&Mfield{Name: "name", Ty: TypeCompactStr},
+		&CompactArray{Name: "partitions", Ty: listOffsetsPartitionSchema6},
+		&SchemaTaggedFields{Name: "topic_tagged_fields"},
+	)
+
+	listOffsetsRequestV0 := NewSchema("list_offsets_request_v0",
+		&Mfield{Name: "replica_id", Ty: TypeInt32},
+		&Array{Name: "topics", Ty: listOffsetsTopicV0},
+	)
+
+	listOffsetsRequestV1 := NewSchema("list_offsets_request_v1",
+		&Mfield{Name: "replica_id", Ty: TypeInt32},
+		&Array{Name: "topics", Ty: listOffsetsTopicV1},
+	)
+
+	listOffsetsRequestV2 := NewSchema("list_offsets_request_v2",
+		&Mfield{Name: "replica_id", Ty: TypeInt32},
+		&Mfield{Name: "isolation_level", Ty: TypeInt8},
+		&Array{Name: "topics", Ty: listOffsetsTopicV1},
+	)
+
+	listOffsetsRequestV3 := listOffsetsRequestV2
+
+	listOffsetsRequestV4 := NewSchema("list_offsets_request_v4",
+		&Mfield{Name: "replica_id", Ty: TypeInt32},
+		&Mfield{Name: "isolation_level", Ty: TypeInt8},
+		&Array{Name: "topics", Ty: listOffsetsTopicV4},
+	)
+
+	listOffsetsRequestV5 := listOffsetsRequestV4
+
+	listOffsetsRequestV6 := NewSchema("list_offsets_request_v6",
+		&Mfield{Name: "replica_id", Ty: TypeInt32},
+		&Mfield{Name: "isolation_level", Ty: TypeInt8},
+		&CompactArray{Name: "topics", Ty: listOffsetsTopicSchema6},
+		&SchemaTaggedFields{Name: "request_tagged_fields"},
+	)
+
+	listOffsetsRequestV7 := listOffsetsRequestV6
+
+	return []Schema{listOffsetsRequestV0, listOffsetsRequestV1, listOffsetsRequestV2, listOffsetsRequestV3, listOffsetsRequestV4, listOffsetsRequestV5, listOffsetsRequestV6, listOffsetsRequestV7}
+}
+
+func createMetadataRequestSchemaVersions() []Schema {
+	metadataRequestTopicV0 := NewSchema("metadata_request_topic_v0",
+		&Mfield{Name: "name", Ty: TypeStr},
+	)
+
+	metadataRequestTopicSchema9 := NewSchema("metadata_request_topic_schema9",
+		&Mfield{Name: "name", Ty: TypeCompactStr},
+		&SchemaTaggedFields{Name: "topic_tagged_fields"},
+	)
+
+	metadataRequestTopicSchema10 := NewSchema("metadata_request_topic_schema10",
+		&Mfield{Name: "topic_id", Ty: TypeUuid},
+		&Mfield{Name: "name", Ty: TypeCompactNullableStr},
+		&SchemaTaggedFields{Name: "topic_tagged_fields"},
+	)
+
+	metadataRequestV0 := NewSchema("metadata_request_v0",
+		&Array{Name: "topics", Ty: metadataRequestTopicV0},
+	)
+
+	metadataRequestV1 := NewSchema("metadata_request_v1",
+		&NullableArray{Name: "topics", Ty: metadataRequestTopicV0},
+	)
+
+	metadataRequestV2 := metadataRequestV1
+	metadataRequestV3 := metadataRequestV1
+
+	metadataRequestV4 := NewSchema("metadata_request_v4",
+		&NullableArray{Name: "topics", Ty: metadataRequestTopicV0},
+		&Mfield{Name: "allow_auto_topic_creation", Ty: TypeBool},
+	)
+
+	metadataRequestV5 := metadataRequestV4
+	metadataRequestV6 := metadataRequestV4
+	metadataRequestV7 := metadataRequestV4
+
+	metadataRequestV8 := NewSchema("metadata_request_v8",
+		&NullableArray{Name: "topics", Ty: metadataRequestTopicV0},
+		&Mfield{Name: "allow_auto_topic_creation", Ty: TypeBool},
+		&Mfield{Name: "include_cluster_authorized_operations", Ty: TypeBool},
+		&Mfield{Name: "include_topic_authorized_operations", Ty: TypeBool},
+	)
+
+	metadataRequestV9 := NewSchema("metadata_request_v9",
+		&CompactNullableArray{Name: "topics", Ty: metadataRequestTopicSchema9},
+		&Mfield{Name: "allow_auto_topic_creation", Ty: TypeBool},
+		&Mfield{Name: "include_cluster_authorized_operations", Ty: TypeBool},
+		&Mfield{Name: "include_topic_authorized_operations", Ty: TypeBool},
+		&SchemaTaggedFields{Name: "request_tagged_fields"},
+	)
+
+	metadataRequestV10 := NewSchema("metadata_request_v10",
+		&CompactNullableArray{Name: "topics", Ty: metadataRequestTopicSchema10},
+		&Mfield{Name: "allow_auto_topic_creation", Ty: TypeBool},
+		&Mfield{Name: "include_cluster_authorized_operations", Ty: TypeBool},
+		&Mfield{Name: "include_topic_authorized_operations", Ty: TypeBool},
+		&SchemaTaggedFields{Name: "request_tagged_fields"},
+	)
+
+	metadataRequestV11 := NewSchema("metadata_request_v11",
+		&CompactNullableArray{Name: "topics", Ty: metadataRequestTopicSchema10},
+		&Mfield{Name: "allow_auto_topic_creation", Ty: TypeBool},
+		&Mfield{Name: "include_topic_authorized_operations", Ty: TypeBool},
+		&SchemaTaggedFields{Name: "request_tagged_fields"},
+	)
+
+	metadataRequestV12 := metadataRequestV11
+
+	return []Schema{metadataRequestV0, metadataRequestV1, metadataRequestV2, metadataRequestV3, metadataRequestV4, metadataRequestV5, metadataRequestV6, metadataRequestV7, metadataRequestV8, metadataRequestV9,
+		metadataRequestV10, metadataRequestV11, metadataRequestV12}
+}
+
+func createOffsetCommitRequestSchemaVersions() []Schema {
+	offsetCommitPartitionV0 := NewSchema("offset_commit_partition_v0",
+		&Mfield{Name: "partition_index", Ty: TypeInt32},
+		&Mfield{Name: "committed_offset", Ty: TypeInt64},
+		&Mfield{Name: "committed_metadata", Ty: TypeNullableStr},
+	)
+
+	offsetCommitPartitionV1 := NewSchema("offset_commit_partition_v1",
+		&Mfield{Name: "partition_index", Ty: TypeInt32},
+		&Mfield{Name: "committed_offset", Ty: TypeInt64},
+		&Mfield{Name: "commit_timestamp", Ty: TypeInt64},
+		&Mfield{Name: "committed_metadata", Ty: TypeNullableStr},
+	)
+
+	offsetCommitPartitionV6 := NewSchema("offset_commit_partition_v6",
+		&Mfield{Name: "partition_index", Ty: TypeInt32},
+		&Mfield{Name: "committed_offset", Ty: TypeInt64},
+		&Mfield{Name: "committed_leader_epoch", Ty: TypeInt32},
+		&Mfield{Name: "committed_metadata", Ty: TypeNullableStr},
+	)
+
+	offsetCommitPartitionSchema8 := NewSchema("offset_commit_partition_schema8",
+		&Mfield{Name: "partition_index", Ty: TypeInt32},
+		&Mfield{Name: "committed_offset", Ty: TypeInt64},
+		&Mfield{Name: "committed_leader_epoch", Ty: TypeInt32},
+		&Mfield{Name: "committed_metadata", Ty: TypeCompactNullableStr},
+		&SchemaTaggedFields{Name: "partition_tagged_fields"},
+	)
+
+	offsetCommitTopicV0 := NewSchema("offset_commit_topic_v0",
+		&Mfield{Name: "name", Ty: TypeStr},
+		&Array{Name: "partitions", Ty: offsetCommitPartitionV0},
+	)
+
+	offsetCommitTopicV1 := NewSchema("offset_commit_topic_v1",
+		&Mfield{Name: "name", Ty: TypeStr},
+		&Array{Name: "partitions", Ty: offsetCommitPartitionV1},
+	)
+
+	offsetCommitTopicV6 := NewSchema("offset_commit_topic_v6",
+		&Mfield{Name: "name", Ty: TypeStr},
+		&Array{Name: "partitions", Ty: offsetCommitPartitionV6},
+	)
+
+	offsetCommitTopicSchema8 := NewSchema("offset_commit_topic_schema8",
+		&Mfield{Name: "name", Ty: TypeCompactStr},
+		&CompactArray{Name: "partitions", Ty: offsetCommitPartitionSchema8},
+		&SchemaTaggedFields{Name: "topic_tagged_fields"},
+	)
+
+	offsetCommitRequestV0 := NewSchema("offset_commit_request_v0",
+		&Mfield{Name: "group_id", Ty: TypeStr},
+		&Array{Name: "topics", Ty: offsetCommitTopicV0},
+	)
+
+	offsetCommitRequestV1 := NewSchema("offset_commit_request_v1",
+		&Mfield{Name: "group_id", Ty: TypeStr},
+		&Mfield{Name: "generation_id", Ty: TypeInt32},
+		&Mfield{Name: "member_id", Ty: TypeStr},
+		&Array{Name: "topics", Ty: offsetCommitTopicV1},
+	)
+
+	offsetCommitRequestV2 := NewSchema("offset_commit_request_v2",
+		&Mfield{Name: "group_id", Ty: TypeStr},
+		&Mfield{Name: "generation_id", Ty: TypeInt32},
+		&Mfield{Name: "member_id", Ty: TypeStr},
+		&Mfield{Name: "retention_time_ms", Ty: TypeInt64},
+		&Array{Name: "topics", Ty: offsetCommitTopicV0},
+	)
+
+	offsetCommitRequestV3 := offsetCommitRequestV2
+	offsetCommitRequestV4 := offsetCommitRequestV2
+
+	offsetCommitRequestV5 := NewSchema("offset_commit_request_v5",
+		&Mfield{Name: "group_id", Ty: TypeStr},
+		&Mfield{Name: "generation_id", Ty: TypeInt32},
+		&Mfield{Name: "member_id", Ty: TypeStr},
+		&Array{Name: "topics", Ty: offsetCommitTopicV0},
+	)
+
+	offsetCommitRequestV6 := NewSchema("offset_commit_request_v6",
+		&Mfield{Name: "group_id", Ty: TypeStr},
+		&Mfield{Name: "generation_id", Ty: TypeInt32},
+		&Mfield{Name: "member_id", Ty: TypeStr},
+		&Array{Name: "topics", Ty: offsetCommitTopicV6},
+	)
+
+	offsetCommitRequestV7 := NewSchema("offset_commit_request_v7",
+		&Mfield{Name: "group_id", Ty: TypeStr},
+		&Mfield{Name: "generation_id", Ty: TypeInt32},
+		&Mfield{Name: "member_id", Ty: TypeStr},
+		&Mfield{Name: "group_instance_id", Ty: TypeNullableStr},
+		&Array{Name: "topics", Ty: offsetCommitTopicV6},
+	)
+
+	offsetCommitRequestV8 := NewSchema("offset_commit_request_v8",
+		&Mfield{Name: "group_id", Ty: TypeCompactStr},
+		&Mfield{Name: "generation_id", Ty: TypeInt32},
+		&Mfield{Name: "member_id", Ty: TypeCompactStr},
+		&Mfield{Name: "group_instance_id", Ty: TypeCompactNullableStr},
+		&CompactArray{Name: "topics", Ty: offsetCommitTopicSchema8},
+		&SchemaTaggedFields{Name: "request_tagged_fields"},
+	)
+
+	return []Schema{offsetCommitRequestV0, offsetCommitRequestV1, offsetCommitRequestV2, offsetCommitRequestV3, offsetCommitRequestV4, offsetCommitRequestV5, offsetCommitRequestV6, offsetCommitRequestV7, offsetCommitRequestV8}
+}
+
+func createOffsetFetchRequestSchemaVersions() []Schema {
+	offsetFetchTopicV0 := NewSchema("offset_fetch_topic_v0",
+		&Mfield{Name: "name", Ty: TypeStr},
+		&Array{Name: "partition_indexes", Ty: TypeInt32},
+	)
+
+	offsetFetchTopicSchema6 := NewSchema("offset_fetch_topic_schema6",
+		&Mfield{Name: "name", Ty: TypeCompactStr},
+		&CompactArray{Name: "partition_indexes", Ty: TypeInt32},
+		&SchemaTaggedFields{Name: "topic_tagged_fields"},
+	)
+
+	offsetFetchRequestV0 := NewSchema("offset_fetch_request_v0",
+		&Mfield{Name: "group_id", Ty: TypeStr},
+		&Array{Name: "topics", Ty: offsetFetchTopicV0},
+	)
+
+	offsetFetchRequestV1 := offsetFetchRequestV0
+
+	offsetFetchRequestV2 := NewSchema("offset_fetch_request_v2",
+		&Mfield{Name: "group_id", Ty: TypeStr},
+		&NullableArray{Name: "topics", Ty: offsetFetchTopicV0},
+	)
+
+	offsetFetchRequestV3 := offsetFetchRequestV2
+	offsetFetchRequestV4 := offsetFetchRequestV2
+	offsetFetchRequestV5 := offsetFetchRequestV2
+
+	offsetFetchRequestV6 := NewSchema("offset_fetch_request_v6",
+		&Mfield{Name: "group_id", Ty: TypeCompactStr},
+		&CompactNullableArray{Name: "topics", Ty: offsetFetchTopicSchema6},
+		&SchemaTaggedFields{Name: "request_tagged_fields"},
+	)
+
+	offsetFetchRequestV7 := NewSchema("offset_fetch_request_v7",
+		&Mfield{Name: "group_id", Ty: TypeCompactStr},
+		&CompactNullableArray{Name: "topics", Ty: offsetFetchTopicSchema6},
+		&Mfield{Name: "require_stable", Ty: TypeBool},
+		&SchemaTaggedFields{Name: "request_tagged_fields"},
+	)
+
+	return []Schema{offsetFetchRequestV0, offsetFetchRequestV1, offsetFetchRequestV2, offsetFetchRequestV3, offsetFetchRequestV4, offsetFetchRequestV5, offsetFetchRequestV6, offsetFetchRequestV7}
+}
+
+func getRequestSchemaVersions(apiKey int16) []Schema {
+	switch apiKey {
+	case apiKeyProduce:
+		return produceRequestSchemaVersions
+	case apiKeyFetch:
+		return fetchRequestSchemaVersions
+	case apiKeyListOffsets:
+		return listOffsetsRequestSchemaVersions
+	case apiKeyMetadata:
+		return metadataRequestSchemaVersions
+	case apiKeyOffsetCommit:
+		return offsetCommitRequestSchemaVersions
+	case apiKeyOffsetFetch:
+		return offsetFetchRequestSchemaVersions
+	case apiKeyFindCoordinator:
+		return findCoordinatorRequestSchemaVersions
+	case apiKeyJoinGroup:
+		return joinGroupRequestSchemaVersions
+	case apiKeyHeartbeat:
+		return heartbeatRequestSchemaVersions
+	case apiKeyLeaveGroup:
+		return leaveGroupRequestSchemaVersions
+	case apiKeySyncGroup:
+		return syncGroupRequestSchemaVersions
+	case apiKeyDescribeGroups:
+		return describeGroupsRequestSchemaVersions
+	case apiKeyListGroups:
+		return listGroupsRequestSchemaVersions
+	case apiKeyInitProducerId:
+		return initProducerIdRequestSchemaVersions
+	case apiKeyOffsetForLeaderEpoch:
+		return offsetForLeaderEpochRequestSchemaVersions
+	case apiKeyAddPartitionsToTxn:
+		return addPartitionsToTxnRequestSchemaVersions
+	case apiKeyAddOffsetsToTxn:
+		return addOffsetsToTxnRequestSchemaVersions
+	case apiKeyEndTxn:
+		return endTxnRequestSchemaVersions
+	case apiKeyTxnOffsetCommit:
+		return txnOffsetCommitRequestSchemaVersions
+	case apiKeyDeleteGroups:
+		return deleteGroupsRequestSchemaVersions
+	default:
+		return nil
+	}
+}
+
+// GetRequestSchema returns the schema of the request body. An error is returned for api keys or versions which cannot be decoded.
+func GetRequestSchema(apiKey, apiVersion int16) (Schema, error) {
+	schemas := getRequestSchemaVersions(apiKey)
+	if apiVersion < 0 || int(apiVersion) >= len(schemas) {
+		return nil, fmt.Errorf("Unsupported request schema version %d for key %d ", apiVersion, apiKey)
+	}
+	return schemas[apiVersion], nil
+}
+
+// DecodedRequest is a request with the header fields and the body decoded by the request schema
+type DecodedRequest struct {
+	ApiKey        int16
+	ApiVersion    int16
+	CorrelationID int32
+	ClientID      *string
+	Body          *Struct
+
+	// request header tagged fields, nil for request header version lower than 2
+	taggedFields *TaggedFields
+	schema       Schema
+}
+
+type decodedRequestHeader struct {
+	request *DecodedRequest
+}
+
+func (h *decodedRequestHeader) encode(pe packetEncoder) (err error) {
+	pe.putInt16(h.request.ApiKey)
+	pe.putInt16(h.request.ApiVersion)
+	pe.putInt32(h.request.CorrelationID)
+	if err = pe.putNullableString(h.request.ClientID); err != nil {
+		return err
+	}
+	if h.request.taggedFields != nil {
+		return h.request.taggedFields.encode(pe)
+	}
+	return nil
+}
+
+// DecodeRequest decodes request bytes (without the Size prefix) starting with ApiKey and ApiVersion
+func DecodeRequest(buf []byte) (*DecodedRequest, error) {
+	helper := realDecoder{raw: buf}
+
+	apiKey, err := helper.getInt16()
+	if err != nil {
+		return nil, err
+	}
+	apiVersion, err := helper.getInt16()
+	if err != nil {
+		return nil, err
+	}
+	schema, err := GetRequestSchema(apiKey, apiVersion)
+	if err != nil {
+		return nil, err
+	}
+	correlationID, err := helper.getInt32()
+	if err != nil {
+		return nil, err
+	}
+	clientID, err := helper.getNullableString()
+	if err != nil {
+		return nil, err
+	}
+	var taggedFields *TaggedFields
+	requestKeyVersion := &RequestKeyVersion{ApiKey: apiKey, ApiVersion: apiVersion}
+	if requestKeyVersion.RequestHeaderVersion() >= 2 {
+		taggedFields = &TaggedFields{}
+		if err = taggedFields.decode(&helper); err != nil {
+			return nil, err
+		}
+	}
+	headerLength := helper.off
+
+	body, err := DecodeSchema(buf[headerLength:], schema)
+	if err != nil {
+		return nil, err
+	}
+	return &DecodedRequest{
+		ApiKey:        apiKey,
+		ApiVersion:    apiVersion,
+		CorrelationID: correlationID,
+		ClientID:      clientID,
+		Body:          body,
+		taggedFields:  taggedFields,
+		schema:        schema,
+	}, nil
+}
+
+// Encode encodes the request including the Size prefix
+func (r *DecodedRequest) Encode() ([]byte, error) {
+	header, err := Encode(&decodedRequestHeader{request: r})
+	if err != nil {
+		return nil, err
+	}
+	body, err := EncodeSchema(r.Body, r.schema)
+	if err != nil {
+		return nil, err
+	}
+	length := len(header) + len(body)
+	if length > int(MaxRequestSize) {
+		return nil, PacketEncodingError{fmt.Sprintf("invalid request size (%d)", length)}
+	}
+	buf := make([]byte, 4, 4+length)
+	realEnc := realEncoder{raw: buf}
+	realEnc.putInt32(int32(length))
+	buf = append(buf, header...)
+	return append(buf, body...), nil
+}
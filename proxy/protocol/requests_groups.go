@@ -0,0 +1,529 @@
+package protocol
+
+const (
+	apiKeyJoinGroup            = 11
+	apiKeyHeartbeat            = 12
+	apiKeyLeaveGroup           = 13
+	apiKeySyncGroup            = 14
+	apiKeyDescribeGroups       = 15
+	apiKeyListGroups           = 16
+	apiKeyInitProducerId       = 22
+	apiKeyOffsetForLeaderEpoch = 23
+	apiKeyAddPartitionsToTxn   = 24
+	apiKeyAddOffsetsToTxn      = 25
+	apiKeyEndTxn               = 26
+	apiKeyTxnOffsetCommit      = 28
+	apiKeyDeleteGroups         = 42
+)
+
+var (
+	findCoordinatorRequestSchemaVersions      = createFindCoordinatorRequestSchemaVersions()
+	joinGroupRequestSchemaVersions            = createJoinGroupRequestSchemaVersions()
+	heartbeatRequestSchemaVersions            = createHeartbeatRequestSchemaVersions()
+	leaveGroupRequestSchemaVersions           = createLeaveGroupRequestSchemaVersions()
+	syncGroupRequestSchemaVersions            = createSyncGroupRequestSchemaVersions()
+	describeGroupsRequestSchemaVersions       = createDescribeGroupsRequestSchemaVersions()
+	listGroupsRequestSchemaVersions           = createListGroupsRequestSchemaVersions()
+	initProducerIdRequestSchemaVersions       = createInitProducerIdRequestSchemaVersions()
+	offsetForLeaderEpochRequestSchemaVersions = createOffsetForLeaderEpochRequestSchemaVersions()
+	addPartitionsToTxnRequestSchemaVersions   = createAddPartitionsToTxnRequestSchemaVersions()
+	addOffsetsToTxnRequestSchemaVersions      = createAddOffsetsToTxnRequestSchemaVersions()
+	endTxnRequestSchemaVersions               = createEndTxnRequestSchemaVersions()
+	txnOffsetCommitRequestSchemaVersions      = createTxnOffsetCommitRequestSchemaVersions()
+	deleteGroupsRequestSchemaVersions         = createDeleteGroupsRequestSchemaVersions()
+)
+
+func createFindCoordinatorRequestSchemaVersions() []Schema {
+	findCoordinatorRequestV0 := NewSchema("find_coordinator_request_v0",
+		&Mfield{Name: "key", Ty: TypeStr},
+	)
+
+	findCoordinatorRequestV1 := NewSchema("find_coordinator_request_v1",
+		&Mfield{Name: "key", Ty: TypeStr},
+		&Mfield{Name: "key_type", Ty: TypeInt8},
+	)
+
+	findCoordinatorRequestV2 := findCoordinatorRequestV1
+
+	findCoordinatorRequestV3 := NewSchema("find_coordinator_request_v3",
+		&Mfield{Name: "key", Ty: TypeCompactStr},
+		&Mfield{Name: "key_type", Ty: TypeInt8},
+		&SchemaTaggedFields{Name: "request_tagged_fields"},
+	)
+
+	findCoordinatorRequestV4 := NewSchema("find_coordinator_request_v4",
+		&Mfield{Name: "key_type", Ty: TypeInt8},
+		&CompactArray{Name: "coordinator_keys", Ty: TypeCompactStr},
+		&SchemaTaggedFields{Name: "request_tagged_fields"},
+	)
+
+	findCoordinatorRequestV5 := findCoordinatorRequestV4
+	findCoordinatorRequestV6 := findCoordinatorRequestV4
+
+	return []Schema{findCoordinatorRequestV0, findCoordinatorRequestV1, findCoordinatorRequestV2, findCoordinatorRequestV3, findCoordinatorRequestV4, findCoordinatorRequestV5, findCoordinatorRequestV6}
+}
+
+func createJoinGroupRequestSchemaVersions() []Schema {
+	joinGroupProtocolV0 := NewSchema("join_group_protocol_v0",
+		&Mfield{Name: "name", Ty: TypeStr},
+		&Mfield{Name: "metadata", Ty: TypeBytes},
+	)
+
+	joinGroupProtocolSchema6 := NewSchema("join_group_protocol_schema6",
+		&Mfield{Name: "name", Ty: TypeCompactStr},
+		&Mfield{Name: "metadata", Ty: TypeCompactBytes},
+		&SchemaTaggedFields{Name: "protocol_tagged_fields"},
+	)
+
+	joinGroupRequestV0 := NewSchema("join_group_request_v0",
+		&Mfield{Name: "group_id", Ty: TypeStr},
+		&Mfield{Name: "session_timeout_ms", Ty: TypeInt32},
+		&Mfield{Name: "member_id", Ty: TypeStr},
+		&Mfield{Name: "protocol_type", Ty: TypeStr},
+		&Array{Name: "protocols", Ty: joinGroupProtocolV0},
+	)
+
+	joinGroupRequestV1 := NewSchema("join_group_request_v1",
+		&Mfield{Name: "group_id", Ty: TypeStr},
+		&Mfield{Name: "session_timeout_ms", Ty: TypeInt32},
+		&Mfield{Name: "rebalance_timeout_ms", Ty: TypeInt32},
+		&Mfield{Name: "member_id", Ty: TypeStr},
+		&Mfield{Name: "protocol_type", Ty: TypeStr},
+		&Array{Name: "protocols", Ty: joinGroupProtocolV0},
+	)
+
+	joinGroupRequestV2 := joinGroupRequestV1
+	joinGroupRequestV3 := joinGroupRequestV1
+	joinGroupRequestV4 := joinGroupRequestV1
+
+	joinGroupRequestV5 := NewSchema("join_group_request_v5",
+		&Mfield{Name: "group_id", Ty: TypeStr},
+		&Mfield{Name: "session_timeout_ms", Ty: TypeInt32},
+		&Mfield{Name: "rebalance_timeout_ms", Ty: TypeInt32},
+		&Mfield{Name: "member_id", Ty: TypeStr},
+		&Mfield{Name: "group_instance_id", Ty: TypeNullableStr},
+		&Mfield{Name: "protocol_type", Ty: TypeStr},
+		&Array{Name: "protocols", Ty: joinGroupProtocolV0},
+	)
+
+	joinGroupRequestV6 := NewSchema("join_group_request_v6",
+		&Mfield{Name: "group_id", Ty: TypeCompactStr},
+		&Mfield{Name: "session_timeout_ms", Ty: TypeInt32},
+		&Mfield{Name: "rebalance_timeout_ms", Ty: TypeInt32},
+		&Mfield{Name: "member_id", Ty: TypeCompactStr},
+		&Mfield{Name: "group_instance_id", Ty: TypeCompactNullableStr},
+		&Mfield{Name: "protocol_type", Ty: TypeCompactStr},
+		&CompactArray{Name: "protocols", Ty: joinGroupProtocolSchema6},
+		&SchemaTaggedFields{Name: "request_tagged_fields"},
+	)
+
+	joinGroupRequestV7 := joinGroupRequestV6
+
+	return []Schema{joinGroupRequestV0, joinGroupRequestV1, joinGroupRequestV2, joinGroupRequestV3, joinGroupRequestV4, joinGroupRequestV5, joinGroupRequestV6, joinGroupRequestV7}
+}
+
+func createHeartbeatRequestSchemaVersions() []Schema {
+	heartbeatRequestV0 := NewSchema("heartbeat_request_v0",
+		&Mfield{Name: "group_id", Ty: TypeStr},
+		&Mfield{Name: "generation_id", Ty: TypeInt32},
+		&Mfield{Name: "member_id", Ty: TypeStr},
+	)
+
+	heartbeatRequestV1 := heartbeatRequestV0
+	heartbeatRequestV2 := heartbeatRequestV0
+
+	heartbeatRequestV3 := NewSchema("heartbeat_request_v3",
+		&Mfield{Name: "group_id", Ty: TypeStr},
+		&Mfield{Name: "generation_id", Ty: TypeInt32},
+		&Mfield{Name: "member_id", Ty: TypeStr},
+		&Mfield{Name: "group_instance_id", Ty: TypeNullableStr},
+	)
+
+	heartbeatRequestV4 := NewSchema("heartbeat_request_v4",
+		&Mfield{Name: "group_id", Ty: TypeCompactStr},
+		&Mfield{Name: "generation_id", Ty: TypeInt32},
+		&Mfield{Name: "member_id", Ty: TypeCompactStr},
+		&Mfield{Name: "group_instance_id", Ty: TypeCompactNullableStr},
+		&SchemaTaggedFields{Name: "request_tagged_fields"},
+	)
+
+	return []Schema{heartbeatRequestV0, heartbeatRequestV1, heartbeatRequestV2, heartbeatRequestV3, heartbeatRequestV4}
+}
+
+func createLeaveGroupRequestSchemaVersions() []Schema {
+	leaveGroupRequestV0 := NewSchema("leave_group_request_v0",
+		&Mfield{Name: "group_id", Ty: TypeStr},
+		&Mfield{Name: "member_id", Ty: TypeStr},
+	)
+
+	leaveGroupRequestV1 := leaveGroupRequestV0
+	leaveGroupRequestV2 := leaveGroupRequestV0
+
+	memberIdentityV3 := NewSchema("member_identity_v3",
+		&Mfield{Name: "member_id", Ty: TypeStr},
+		&Mfield{Name: "group_instance_id", Ty: TypeNullableStr},
+	)
+
+	leaveGroupRequestV3 := NewSchema("leave_group_request_v3",
+		&Mfield{Name: "group_id", Ty: TypeStr},
+		&Array{Name: "members", Ty: memberIdentityV3},
+	)
+
+	memberIdentitySchema4 := NewSchema("member_identity_schema4",
+		&Mfield{Name: "member_id", Ty: TypeCompactStr},
+		&Mfield{Name: "group_instance_id", Ty: TypeCompactNullableStr},
+		&SchemaTaggedFields{Name: "member_tagged_fields"},
+	)
+
+	leaveGroupRequestV4 := NewSchema("leave_group_request_v4",
+		&Mfield{Name: "group_id", Ty: TypeCompactStr},
+		&CompactArray{Name: "members", Ty: memberIdentitySchema4},
+		&SchemaTaggedFields{Name: "request_tagged_fields"},
+	)
+
+	return []Schema{leaveGroupRequestV0, leaveGroupRequestV1, leaveGroupRequestV2, leaveGroupRequestV3, leaveGroupRequestV4}
+}
+
+func createSyncGroupRequestSchemaVersions() []Schema {
+	syncGroupAssignmentV0 := NewSchema("sync_group_assignment_v0",
+		&Mfield{Name: "member_id", Ty: TypeStr},
+		&Mfield{Name: "assignment", Ty: TypeBytes},
+	)
+
+	syncGroupAssignmentSchema4 := NewSchema("sync_group_assignment_schema4",
+		&Mfield{Name: "member_id", Ty: TypeCompactStr},
+		&Mfield{Name: "assignment", Ty: TypeCompactBytes},
+		&SchemaTaggedFields{Name: "assignment_tagged_fields"},
+	)
+
+	syncGroupRequestV0 := NewSchema("sync_group_request_v0",
+		&Mfield{Name: "group_id", Ty: TypeStr},
+		&Mfield{Name: "generation_id", Ty: TypeInt32},
+		&Mfield{Name: "member_id", Ty: TypeStr},
+		&Array{Name: "assignments", Ty: syncGroupAssignmentV0},
+	)
+
+	syncGroupRequestV1 := syncGroupRequestV0
+	syncGroupRequestV2 := syncGroupRequestV0
+
+	syncGroupRequestV3 := NewSchema("sync_group_request_v3",
+		&Mfield{Name: "group_id", Ty: TypeStr},
+		&Mfield{Name: "generation_id", Ty: TypeInt32},
+		&Mfield{Name: "member_id", Ty: TypeStr},
+		&Mfield{Name: "group_instance_id", Ty: TypeNullableStr},
+		&Array{Name: "assignments", Ty: syncGroupAssignmentV0},
+	)
+
+	syncGroupRequestV4 := NewSchema("sync_group_request_v4",
+		&Mfield{Name: "group_id", Ty: TypeCompactStr},
+		&Mfield{Name: "generation_id", Ty: TypeInt32},
+		&Mfield{Name: "member_id", Ty: TypeCompactStr},
+		&Mfield{Name: "group_instance_id", Ty: TypeCompactNullableStr},
+		&CompactArray{Name: "assignments", Ty: syncGroupAssignmentSchema4},
+		&SchemaTaggedFields{Name: "request_tagged_fields"},
+	)
+
+	syncGroupRequestV5 := NewSchema("sync_group_request_v5",
+		&Mfield{Name: "group_id", Ty: TypeCompactStr},
+		&Mfield{Name: "generation_id", Ty: TypeInt32},
+		&Mfield{Name: "member_id", Ty: TypeCompactStr},
+		&Mfield{Name: "group_instance_id", Ty: TypeCompactNullableStr},
+		&Mfield{Name: "protocol_type", Ty: TypeCompactNullableStr},
+		&Mfield{Name: "protocol_name", Ty: TypeCompactNullableStr},
+		&CompactArray{Name: "assignments", Ty: syncGroupAssignmentSchema4},
+		&SchemaTaggedFields{Name: "request_tagged_fields"},
+	)
+
+	return []Schema{syncGroupRequestV0, syncGroupRequestV1, syncGroupRequestV2, syncGroupRequestV3, syncGroupRequestV4, syncGroupRequestV5}
+}
+
+func createDescribeGroupsRequestSchemaVersions() []Schema {
+	describeGroupsRequestV0 := NewSchema("describe_groups_request_v0",
+		&Array{Name: "groups", Ty: TypeStr},
+	)
+
+	describeGroupsRequestV1 := describeGroupsRequestV0
+	describeGroupsRequestV2 := describeGroupsRequestV0
+
+	describeGroupsRequestV3 := NewSchema("describe_groups_request_v3",
+		&Array{Name: "groups", Ty: TypeStr},
+		&Mfield{Name: "include_authorized_operations", Ty: TypeBool},
+	)
+
+	describeGroupsRequestV4 := describeGroupsRequestV3
+
+	describeGroupsRequestV5 := NewSchema("describe_groups_request_v5",
+		&CompactArray{Name: "groups", Ty: TypeCompactStr},
+		&Mfield{Name: "include_authorized_operations", Ty: TypeBool},
+		&SchemaTaggedFields{Name: "request_tagged_fields"},
+	)
+
+	return []Schema{describeGroupsRequestV0, describeGroupsRequestV1, describeGroupsRequestV2, describeGroupsRequestV3, describeGroupsRequestV4, describeGroupsRequestV5}
+}
+
+func createListGroupsRequestSchemaVersions() []Schema {
+	listGroupsRequestV0 := NewSchema("list_groups_request_v0")
+
+	listGroupsRequestV1 := listGroupsRequestV0
+	listGroupsRequestV2 := listGroupsRequestV0
+
+	listGroupsRequestV3 := NewSchema("list_groups_request_v3",
+		&SchemaTaggedFields{Name: "request_tagged_fields"},
+	)
+
+	listGroupsRequestV4 := NewSchema("list_groups_request_v4",
+		&CompactArray{Name: "states_filter", Ty: TypeCompactStr},
+		&SchemaTaggedFields{Name: "request_tagged_fields"},
+	)
+
+	return []Schema{listGroupsRequestV0, listGroupsRequestV1, listGroupsRequestV2, listGroupsRequestV3, listGroupsRequestV4}
+}
+
+func createInitProducerIdRequestSchemaVersions() []Schema {
+	initProducerIdRequestV0 := NewSchema("init_producer_id_request_v0",
+		&Mfield{Name: "transactional_id", Ty: TypeNullableStr},
+		&Mfield{Name: "transaction_timeout_ms", Ty: TypeInt32},
+	)
+
+	initProducerIdRequestV1 := initProducerIdRequestV0
+
+	initProducerIdRequestV2 := NewSchema("init_producer_id_request_v2",
+		&Mfield{Name: "transactional_id", Ty: TypeCompactNullableStr},
+		&Mfield{Name: "transaction_timeout_ms", Ty: TypeInt32},
+		&SchemaTaggedFields{Name: "request_tagged_fields"},
+	)
+
+	initProducerIdRequestV3 := NewSchema("init_producer_id_request_v3",
+		&Mfield{Name: "transactional_id", Ty: TypeCompactNullableStr},
+		&Mfield{Name: "transaction_timeout_ms", Ty: TypeInt32},
+		&Mfield{Name: "producer_id", Ty: TypeInt64},
+		&Mfield{Name: "producer_epoch", Ty: TypeInt16},
+		&SchemaTaggedFields{Name: "request_tagged_fields"},
+	)
+
+	initProducerIdRequestV4 := initProducerIdRequestV3
+
+	return []Schema{initProducerIdRequestV0, initProducerIdRequestV1, initProducerIdRequestV2, initProducerIdRequestV3, initProducerIdRequestV4}
+}
+
+func createOffsetForLeaderEpochRequestSchemaVersions() []Schema {
+	offsetForLeaderPartitionV0 := NewSchema("offset_for_leader_partition_v0",
+		&Mfield{Name: "partition", Ty: TypeInt32},
+		&Mfield{Name: "leader_epoch", Ty: TypeInt32},
+	)
+
+	offsetForLeaderPartitionV2 := NewSchema("offset_for_leader_partition_v2",
+		&Mfield{Name: "partition", Ty: TypeInt32},
+		&Mfield{Name: "current_leader_epoch", Ty: TypeInt32},
+		&Mfield{Name: "leader_epoch", Ty: TypeInt32},
+	)
+
+	offsetForLeaderPartitionSchema4 := NewSchema("offset_for_leader_partition_schema4",
+		&Mfield{Name: "partition", Ty: TypeInt32},
+		&Mfield{Name: "current_leader_epoch", Ty: TypeInt32},
+		&Mfield{Name: "leader_epoch", Ty: TypeInt32},
+		&SchemaTaggedFields{Name: "partition_tagged_fields"},
+	)
+
+	offsetForLeaderTopicV0 := NewSchema("offset_for_leader_topic_v0",
+		&Mfield{Name: "topic", Ty: TypeStr},
+		&Array{Name: "partitions", Ty: offsetForLeaderPartitionV0},
+	)
+
+	offsetForLeaderTopicV2 := NewSchema("offset_for_leader_topic_v2",
+		&Mfield{Name: "topic", Ty: TypeStr},
+		&Array{Name: "partitions", Ty: offsetForLeaderPartitionV2},
+	)
+
+	offsetForLeaderTopicSchema4 := NewSchema("offset_for_leader_topic_schema4",
+		&Mfield{Name: "topic", Ty: TypeCompactStr},
+		&CompactArray{Name: "partitions", Ty: offsetForLeaderPartitionSchema4},
+		&SchemaTaggedFields{Name: "topic_tagged_fields"},
+	)
+
+	offsetForLeaderEpochRequestV0 := NewSchema("offset_for_leader_epoch_request_v0",
+		&Array{Name: "topics", Ty: offsetForLeaderTopicV0},
+	)
+
+	offsetForLeaderEpochRequestV1 := offsetForLeaderEpochRequestV0
+
+	offsetForLeaderEpochRequestV2 := NewSchema("offset_for_leader_epoch_request_v2",
+		&Array{Name: "topics", Ty: offsetForLeaderTopicV2},
+	)
+
+	offsetForLeaderEpochRequestV3 := NewSchema("offset_for_leader_epoch_request_v3",
+		&Mfield{Name: "replica_id", Ty: TypeInt32},
+		&Array{Name: "topics", Ty: offsetForLeaderTopicV2},
+	)
+
+	offsetForLeaderEpochRequestV4 := NewSchema("offset_for_leader_epoch_request_v4",
+		&Mfield{Name: "replica_id", Ty: TypeInt32},
+		&CompactArray{Name: "topics", Ty: offsetForLeaderTopicSchema4},
+		&SchemaTaggedFields{Name: "request_tagged_fields"},
+	)
+
+	return []Schema{offsetForLeaderEpochRequestV0, offsetForLeaderEpochRequestV1, offsetForLeaderEpochRequestV2, offsetForLeaderEpochRequestV3, offsetForLeaderEpochRequestV4}
+}
+
+func createAddPartitionsToTxnRequestSchemaVersions() []Schema {
+	addPartitionsToTxnTopicV0 := NewSchema("add_partitions_to_txn_topic_v0",
+		&Mfield{Name: "name", Ty: TypeStr},
+		&Array{Name: "partitions", Ty: TypeInt32},
+	)
+
+	addPartitionsToTxnTopicSchema3 := NewSchema("add_partitions_to_txn_topic_schema3",
+		&Mfield{Name: "name", Ty: TypeCompactStr},
+		&CompactArray{Name: "partitions", Ty: TypeInt32},
+		&SchemaTaggedFields{Name: "topic_tagged_fields"},
+	)
+
+	addPartitionsToTxnRequestV0 := NewSchema("add_partitions_to_txn_request_v0",
+		&Mfield{Name: "transactional_id", Ty: TypeStr},
+		&Mfield{Name: "producer_id", Ty: TypeInt64},
+		&Mfield{Name: "producer_epoch", Ty: TypeInt16},
+		&Array{Name: "topics", Ty: addPartitionsToTxnTopicV0},
+	)
+
+	addPartitionsToTxnRequestV1 := addPartitionsToTxnRequestV0
+	addPartitionsToTxnRequestV2 := addPartitionsToTxnRequestV0
+
+	addPartitionsToTxnRequestV3 := NewSchema("add_partitions_to_txn_request_v3",
+		&Mfield{Name: "transactional_id", Ty: TypeCompactStr},
+		&Mfield{Name: "producer_id", Ty: TypeInt64},
+		&Mfield{Name: "producer_epoch", Ty: TypeInt16},
+		&CompactArray{Name: "topics", Ty: addPartitionsToTxnTopicSchema3},
+		&SchemaTaggedFields{Name: "request_tagged_fields"},
+	)
+
+	return []Schema{addPartitionsToTxnRequestV0, addPartitionsToTxnRequestV1, addPartitionsToTxnRequestV2, addPartitionsToTxnRequestV3}
+}
+
+func createAddOffsetsToTxnRequestSchemaVersions() []Schema {
+	addOffsetsToTxnRequestV0 := NewSchema("add_offsets_to_txn_request_v0",
+		&Mfield{Name: "transactional_id", Ty: TypeStr},
+		&Mfield{Name: "producer_id", Ty: TypeInt64},
+		&Mfield{Name: "producer_epoch", Ty: TypeInt16},
+		&Mfield{Name: "group_id", Ty: TypeStr},
+	)
+
+	addOffsetsToTxnRequestV1 := addOffsetsToTxnRequestV0
+	addOffsetsToTxnRequestV2 := addOffsetsToTxnRequestV0
+
+	addOffsetsToTxnRequestV3 := NewSchema("add_offsets_to_txn_request_v3",
+		&Mfield{Name: "transactional_id", Ty: TypeCompactStr},
+		&Mfield{Name: "producer_id", Ty: TypeInt64},
+		&Mfield{Name: "producer_epoch", Ty: TypeInt16},
+		&Mfield{Name: "group_id", Ty: TypeCompactStr},
+		&SchemaTaggedFields{Name: "request_tagged_fields"},
+	)
+
+	return []Schema{addOffsetsToTxnRequestV0, addOffsetsToTxnRequestV1, addOffsetsToTxnRequestV2, addOffsetsToTxnRequestV3}
+}
+
+func createEndTxnRequestSchemaVersions() []Schema {
+	endTxnRequestV0 := NewSchema("end_txn_request_v0",
+		&Mfield{Name: "transactional_id", Ty: TypeStr},
+		&Mfield{Name: "producer_id", Ty: TypeInt64},
+		&Mfield{Name: "producer_epoch", Ty: TypeInt16},
+		&Mfield{Name: "committed", Ty: TypeBool},
+	)
+
+	endTxnRequestV1 := endTxnRequestV0
+	endTxnRequestV2 := endTxnRequestV0
+
+	endTxnRequestV3 := NewSchema("end_txn_request_v3",
+		&Mfield{Name: "transactional_id", Ty: TypeCompactStr},
+		&Mfield{Name: "producer_id", Ty: TypeInt64},
+		&Mfield{Name: "producer_epoch", Ty: TypeInt16},
+		&Mfield{Name: "committed", Ty: TypeBool},
+		&SchemaTaggedFields{Name: "request_tagged_fields"},
+	)
+
+	return []Schema{endTxnRequestV0, endTxnRequestV1, endTxnRequestV2, endTxnRequestV3}
+}
+
+func createTxnOffsetCommitRequestSchemaVersions() []Schema {
+	txnOffsetCommitPartitionV0 := NewSchema("txn_offset_commit_partition_v0",
+		&Mfield{Name: "partition_index", Ty: TypeInt32},
+		&Mfield{Name: "committed_offset", Ty: TypeInt64},
+		&Mfield{Name: "committed_metadata", Ty: TypeNullableStr},
+	)
+
+	txnOffsetCommitPartitionV2 := NewSchema("txn_offset_commit_partition_v2",
+		&Mfield{Name: "partition_index", Ty: TypeInt32},
+		&Mfield{Name: "committed_offset", Ty: TypeInt64},
+		&Mfield{Name: "committed_leader_epoch", Ty: TypeInt32},
+		&Mfield{Name: "committed_metadata", Ty: TypeNullableStr},
+	)
+
+	txnOffsetCommitPartitionSchema3 := NewSchema("txn_offset_commit_partition_schema3",
+		&Mfield{Name: "partition_index", Ty: TypeInt32},
+		&Mfield{Name: "committed_offset", Ty: TypeInt64},
+		&Mfield{Name: "committed_leader_epoch", Ty: TypeInt32},
+		&Mfield{Name: "committed_metadata", Ty: TypeCompactNullableStr},
+		&SchemaTaggedFields{Name: "partition_tagged_fields"},
+	)
+
+	txnOffsetCommitTopicV0 := NewSchema("txn_offset_commit_topic_v0",
+		&Mfield{Name: "name", Ty: TypeStr},
+		&Array{Name: "partitions", Ty: txnOffsetCommitPartitionV0},
+	)
+
+	txnOffsetCommitTopicV2 := NewSchema("txn_offset_commit_topic_v2",
+		&Mfield{Name: "name", Ty: TypeStr},
+		&Array{Name: "partitions", Ty: txnOffsetCommitPartitionV2},
+	)
+
+	txnOffsetCommitTopicSchema3 := NewSchema("txn_offset_commit_topic_schema3",
+		&Mfield{Name: "name", Ty: TypeCompactStr},
+		&CompactArray{Name: "partitions", Ty: txnOffsetCommitPartitionSchema3},
+		&SchemaTaggedFields{Name: "topic_tagged_fields"},
+	)
+
+	txnOffsetCommitRequestV0 := NewSchema("txn_offset_commit_request_v0",
+		&Mfield{Name: "transactional_id", Ty: TypeStr},
+		&Mfield{Name: "group_id", Ty: TypeStr},
+		&Mfield{Name: "producer_id", Ty: TypeInt64},
+		&Mfield{Name: "producer_epoch", Ty: TypeInt16},
+		&Array{Name: "topics", Ty: txnOffsetCommitTopicV0},
+	)
+
+	txnOffsetCommitRequestV1 := txnOffsetCommitRequestV0
+
+	txnOffsetCommitRequestV2 := NewSchema("txn_offset_commit_request_v2",
+		&Mfield{Name: "transactional_id", Ty: TypeStr},
+		&Mfield{Name: "group_id", Ty: TypeStr},
+		&Mfield{Name: "producer_id", Ty: TypeInt64},
+		&Mfield{Name: "producer_epoch", Ty: TypeInt16},
+		&Array{Name: "topics", Ty: txnOffsetCommitTopicV2},
+	)
+
+	txnOffsetCommitRequestV3 := NewSchema("txn_offset_commit_request_v3",
+		&Mfield{Name: "transactional_id", Ty: TypeCompactStr},
+		&Mfield{Name: "group_id", Ty: TypeCompactStr},
+		&Mfield{Name: "producer_id", Ty: TypeInt64},
+		&Mfield{Name: "producer_epoch", Ty: TypeInt16},
+		&Mfield{Name: "generation_id", Ty: TypeInt32},
+		&Mfield{Name: "member_id", Ty: TypeCompactStr},
+		&Mfield{Name: "group_instance_id", Ty: TypeCompactNullableStr},
+		&CompactArray{Name: "topics", Ty: txnOffsetCommitTopicSchema3},
+		&SchemaTaggedFields{Name: "request_tagged_fields"},
+	)
+
+	return []Schema{txnOffsetCommitRequestV0, txnOffsetCommitRequestV1, txnOffsetCommitRequestV2, txnOffsetCommitRequestV3}
+}
+
+func createDeleteGroupsRequestSchemaVersions() []Schema {
+	deleteGroupsRequestV0 := NewSchema("delete_groups_request_v0",
+		&Array{Name: "groups_names", Ty: TypeStr},
+	)
+
+	deleteGroupsRequestV1 := deleteGroupsRequestV0
+
+	deleteGroupsRequestV2 := NewSchema("delete_groups_request_v2",
+		&CompactArray{Name: "groups_names", Ty: TypeCompactStr},
+		&SchemaTaggedFields{Name: "request_tagged_fields"},
+	)
+
+	return []Schema{deleteGroupsRequestV0, deleteGroupsRequestV1, deleteGroupsRequestV2}
+}
@@ -10,17 +10,32 @@ import (
 const (
 	apiKeyMetadata        = 3
 	apiKeyFindCoordinator = 10
+	apiKeyDescribeCluster = 60
 
 	brokersKeyName = "brokers"
 	hostKeyName    = "host"
 	portKeyName    = "port"
 
-	coordinatorKeyName = "coordinator"
+	coordinatorKeyName  = "coordinator"
+	coordinatorsKeyName = "coordinators"
+
+	topicMetadataKeyName = "topic_metadata"
+
+	responseTaggedFieldsKeyName = "response_tagged_fields"
+	nodeEndpointsKeyName        = "node_endpoints"
+	nodeEndpointsTag            = 0
+
+	// first versions with node_endpoints response tagged field
+	minProduceNodeEndpointsVersion = 10
+	minFetchNodeEndpointsVersion   = 16
 )
 
 var (
 	metadataResponseSchemaVersions        = createMetadataResponseSchemaVersions()
 	findCoordinatorResponseSchemaVersions = createFindCoordinatorResponseSchemaVersions()
+	describeClusterResponseSchemaVersions = createDescribeClusterResponseSchemaVersions()
+	nodeEndpointsSchema                   = createNodeEndpointsSchema()
+	responseTaggedFieldsSchema            = NewSchema("response_tagged_fields", &SchemaTaggedFields{Name: responseTaggedFieldsKeyName})
 )
 
 func createMetadataResponseSchemaVersions() []Schema {
@@ -134,6 +149,26 @@ func createMetadataResponseSchemaVersions() []Schema {
 		&SchemaTaggedFields{Name: "topic_metadata_tagged_fields"},
 	)
 
+	topicMetadataSchema10 := NewSchema("topic_metadata_schema10",
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Mfield{Name: "name", Ty: TypeCompactStr},
+		&Mfield{Name: "topic_id", Ty: TypeUuid},
+		&Mfield{Name: "is_internal", Ty: TypeBool},
+		&CompactArray{Name: "partition_metadata", Ty: partitionMetadataSchema9},
+		&Mfield{Name: "topic_authorized_operations", Ty: TypeInt32},
+		&SchemaTaggedFields{Name: "topic_metadata_tagged_fields"},
+	)
+
+	topicMetadataSchema12 := NewSchema("topic_metadata_schema12",
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Mfield{Name: "name", Ty: TypeCompactNullableStr},
+		&Mfield{Name: "topic_id", Ty: TypeUuid},
+		&Mfield{Name: "is_internal", Ty: TypeBool},
+		&CompactArray{Name: "partition_metadata", Ty: partitionMetadataSchema9},
+		&Mfield{Name: "topic_authorized_operations", Ty: TypeInt32},
+		&SchemaTaggedFields{Name: "topic_metadata_tagged_fields"},
+	)
+
 	metadataResponseV1 := NewSchema("metadata_response_v1",
 		&Array{Name: brokersKeyName, Ty: metadataBrokerV1},
 		&Mfield{Name: "controller_id", Ty: TypeInt32},
@@ -194,7 +229,38 @@ func createMetadataResponseSchemaVersions() []Schema {
 		&SchemaTaggedFields{Name: "response_tagged_fields"},
 	)
 
-	return []Schema{metadataResponseV0, metadataResponseV1, metadataResponseV2, metadataResponseV3, metadataResponseV4, metadataResponseV5, metadataResponseV6, metadataResponseV7, metadataResponseV8, metadataResponseV9}
+	metadataResponseV10 := NewSchema("metadata_response_v10",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&CompactArray{Name: brokersKeyName, Ty: metadataBrokerSchema9},
+		&Mfield{Name: "cluster_id", Ty: TypeCompactNullableStr},
+		&Mfield{Name: "controller_id", Ty: TypeInt32},
+		&CompactArray{Name: "topic_metadata", Ty: topicMetadataSchema10},
+		&Mfield{Name: "cluster_authorized_operations", Ty: TypeInt32},
+		&SchemaTaggedFields{Name: "response_tagged_fields"},
+	)
+
+	// cluster_authorized_operations was removed
+	metadataResponseV11 := NewSchema("metadata_response_v11",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&CompactArray{Name: brokersKeyName, Ty: metadataBrokerSchema9},
+		&Mfield{Name: "cluster_id", Ty: TypeCompactNullableStr},
+		&Mfield{Name: "controller_id", Ty: TypeInt32},
+		&CompactArray{Name: "topic_metadata", Ty: topicMetadataSchema10},
+		&SchemaTaggedFields{Name: "response_tagged_fields"},
+	)
+
+	// topic name is nullable
+	metadataResponseV12 := NewSchema("metadata_response_v12",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&CompactArray{Name: brokersKeyName, Ty: metadataBrokerSchema9},
+		&Mfield{Name: "cluster_id", Ty: TypeCompactNullableStr},
+		&Mfield{Name: "controller_id", Ty: TypeInt32},
+		&CompactArray{Name: "topic_metadata", Ty: topicMetadataSchema12},
+		&SchemaTaggedFields{Name: "response_tagged_fields"},
+	)
+
+	return []Schema{metadataResponseV0, metadataResponseV1, metadataResponseV2, metadataResponseV3, metadataResponseV4, metadataResponseV5, metadataResponseV6, metadataResponseV7, metadataResponseV8, metadataResponseV9,
+		metadataResponseV10, metadataResponseV11, metadataResponseV12}
 }
 
 func createFindCoordinatorResponseSchemaVersions() []Schema {
@@ -232,29 +298,169 @@ func createFindCoordinatorResponseSchemaVersions() []Schema {
 		&SchemaTaggedFields{Name: "response_tagged_fields"},
 	)
 
-	return []Schema{findCoordinatorResponseV0, findCoordinatorResponseV1, findCoordinatorResponseV2, findCoordinatorResponseV3}
+	findCoordinatorCoordinatorSchema4 := NewSchema("find_coordinator_coordinator_schema4",
+		&Mfield{Name: "key", Ty: TypeCompactStr},
+		&Mfield{Name: "node_id", Ty: TypeInt32},
+		&Mfield{Name: hostKeyName, Ty: TypeCompactStr},
+		&Mfield{Name: portKeyName, Ty: TypeInt32},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Mfield{Name: "error_message", Ty: TypeCompactNullableStr},
+		&SchemaTaggedFields{Name: "coordinator_tagged_fields"},
+	)
+
+	findCoordinatorResponseV4 := NewSchema("find_coordinator_response_v4",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&CompactArray{Name: coordinatorsKeyName, Ty: findCoordinatorCoordinatorSchema4},
+		&SchemaTaggedFields{Name: "response_tagged_fields"},
+	)
+
+	// v5 adds TRANSACTION_ABORTABLE error and v6 share groups, the layout is unchanged
+	findCoordinatorResponseV5 := findCoordinatorResponseV4
+	findCoordinatorResponseV6 := findCoordinatorResponseV4
+
+	return []Schema{findCoordinatorResponseV0, findCoordinatorResponseV1, findCoordinatorResponseV2, findCoordinatorResponseV3, findCoordinatorResponseV4, findCoordinatorResponseV5, findCoordinatorResponseV6}
 }
 
-func modifyMetadataResponse(decodedStruct *Struct, fn config.NetAddressMappingFunc) error {
+func createDescribeClusterResponseSchemaVersions() []Schema {
+	describeClusterBrokerV0 := NewSchema("describe_cluster_broker_v0",
+		&Mfield{Name: "broker_id", Ty: TypeInt32},
+		&Mfield{Name: hostKeyName, Ty: TypeCompactStr},
+		&Mfield{Name: portKeyName, Ty: TypeInt32},
+		&Mfield{Name: "rack", Ty: TypeCompactNullableStr},
+		&SchemaTaggedFields{Name: "broker_tagged_fields"},
+	)
+
+	describeClusterBrokerV2 := NewSchema("describe_cluster_broker_v2",
+		&Mfield{Name: "broker_id", Ty: TypeInt32},
+		&Mfield{Name: hostKeyName, Ty: TypeCompactStr},
+		&Mfield{Name: portKeyName, Ty: TypeInt32},
+		&Mfield{Name: "rack", Ty: TypeCompactNullableStr},
+		&Mfield{Name: "is_fenced", Ty: TypeBool},
+		&SchemaTaggedFields{Name: "broker_tagged_fields"},
+	)
+
+	describeClusterResponseV0 := NewSchema("describe_cluster_response_v0",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Mfield{Name: "error_message", Ty: TypeCompactNullableStr},
+		&Mfield{Name: "cluster_id", Ty: TypeCompactStr},
+		&Mfield{Name: "controller_id", Ty: TypeInt32},
+		&CompactArray{Name: brokersKeyName, Ty: describeClusterBrokerV0},
+		&Mfield{Name: "cluster_authorized_operations", Ty: TypeInt32},
+		&SchemaTaggedFields{Name: "response_tagged_fields"},
+	)
+
+	describeClusterResponseV1 := NewSchema("describe_cluster_response_v1",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Mfield{Name: "error_message", Ty: TypeCompactNullableStr},
+		&Mfield{Name: "endpoint_type", Ty: TypeInt8},
+		&Mfield{Name: "cluster_id", Ty: TypeCompactStr},
+		&Mfield{Name: "controller_id", Ty: TypeInt32},
+		&CompactArray{Name: brokersKeyName, Ty: describeClusterBrokerV0},
+		&Mfield{Name: "cluster_authorized_operations", Ty: TypeInt32},
+		&SchemaTaggedFields{Name: "response_tagged_fields"},
+	)
+
+	describeClusterResponseV2 := NewSchema("describe_cluster_response_v2",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Mfield{Name: "error_message", Ty: TypeCompactNullableStr},
+		&Mfield{Name: "endpoint_type", Ty: TypeInt8},
+		&Mfield{Name: "cluster_id", Ty: TypeCompactStr},
+		&Mfield{Name: "controller_id", Ty: TypeInt32},
+		&CompactArray{Name: brokersKeyName, Ty: describeClusterBrokerV2},
+		&Mfield{Name: "cluster_authorized_operations", Ty: TypeInt32},
+		&SchemaTaggedFields{Name: "response_tagged_fields"},
+	)
+
+	return []Schema{describeClusterResponseV0, describeClusterResponseV1, describeClusterResponseV2}
+}
+
+// createNodeEndpointsSchema returns the schema of the node_endpoints tagged field value
+func createNodeEndpointsSchema() Schema {
+	nodeEndpoint := NewSchema("node_endpoint",
+		&Mfield{Name: "node_id", Ty: TypeInt32},
+		&Mfield{Name: hostKeyName, Ty: TypeCompactStr},
+		&Mfield{Name: portKeyName, Ty: TypeInt32},
+		&Mfield{Name: "rack", Ty: TypeCompactNullableStr},
+		&SchemaTaggedFields{Name: "node_endpoint_tagged_fields"},
+	)
+
+	return NewSchema("node_endpoints",
+		&CompactArray{Name: nodeEndpointsKeyName, Ty: nodeEndpoint},
+	)
+}
+
+func modifyMetadataResponse(decodedStruct *Struct, fn config.NetAddressMappingFunc, topicFilter TopicFilterFunc) error {
+	if decodedStruct == nil {
+		return errors.New("decoded struct must not be nil")
+	}
+	if fn == nil {
+		return errors.New("net address mapper must not be nil")
+	}
+	if topicFilter != nil {
+		if err := filterMetadataTopics(decodedStruct, topicFilter); err != nil {
+			return err
+		}
+	}
+	return modifyBrokers(decodedStruct, brokersKeyName, fn)
+}
+
+func modifyDescribeClusterResponse(decodedStruct *Struct, fn config.NetAddressMappingFunc, _ TopicFilterFunc) error {
+	if decodedStruct == nil {
+		return errors.New("decoded struct must not be nil")
+	}
+	if fn == nil {
+		return errors.New("net address mapper must not be nil")
+	}
+	return modifyBrokers(decodedStruct, brokersKeyName, fn)
+}
+
+// modifyNodeEndpointsResponse maps the addresses of the node_endpoints response tagged field of Produce and Fetch responses (KIP-951)
+func modifyNodeEndpointsResponse(decodedStruct *Struct, fn config.NetAddressMappingFunc, _ TopicFilterFunc) error {
 	if decodedStruct == nil {
 		return errors.New("decoded struct must not be nil")
 	}
 	if fn == nil {
 		return errors.New("net address mapper must not be nil")
 	}
-	brokersArray, ok := decodedStruct.Get(brokersKeyName).([]interface{})
+	taggedFields, ok := decodedStruct.Get(responseTaggedFieldsKeyName).([]rawTaggedField)
 	if !ok {
-		return errors.New("brokers list not found")
+		return errors.New("response tagged fields not found")
+	}
+	for i, taggedField := range taggedFields {
+		if taggedField.tag != nodeEndpointsTag {
+			continue
+		}
+		nodeEndpoints, err := DecodeSchema(taggedField.data, nodeEndpointsSchema)
+		if err != nil {
+			return err
+		}
+		if err = modifyBrokers(nodeEndpoints, nodeEndpointsKeyName, fn); err != nil {
+			return err
+		}
+		if taggedFields[i].data, err = EncodeSchema(nodeEndpoints, nodeEndpointsSchema); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func modifyBrokers(decodedStruct *Struct, brokersKey string, fn config.NetAddressMappingFunc) error {
+	brokersArray, ok := decodedStruct.Get(brokersKey).([]interface{})
+	if !ok {
+		return fmt.Errorf("%s list not found", brokersKey)
 	}
 	for _, brokerElement := range brokersArray {
 		broker := brokerElement.(*Struct)
 		host, ok := broker.Get(hostKeyName).(string)
 		if !ok {
-			return errors.New("broker.host not found")
+			return fmt.Errorf("%s.host not found", brokersKey)
 		}
 		port, ok := broker.Get(portKeyName).(int32)
 		if !ok {
-			return errors.New("broker.port not found")
+			return fmt.Errorf("%s.port not found", brokersKey)
 		}
 
 		if host == "" && port <= 0 {
@@ -281,24 +487,70 @@ func modifyMetadataResponse(decodedStruct *Struct, fn config.NetAddressMappingFu
 	return nil
 }
 
-func modifyFindCoordinatorResponse(decodedStruct *Struct, fn config.NetAddressMappingFunc) error {
+func filterMetadataTopics(decodedStruct *Struct, topicFilter TopicFilterFunc) error {
+	topicsArray, ok := decodedStruct.Get(topicMetadataKeyName).([]interface{})
+	if !ok {
+		return errors.New("topic_metadata list not found")
+	}
+	kept := make([]interface{}, 0, len(topicsArray))
+	for _, topicElement := range topicsArray {
+		topic, ok := topicElement.(*Struct)
+		if !ok {
+			return errors.New("topic_metadata element is not a struct")
+		}
+		name, err := topicName(topic)
+		if err != nil {
+			return err
+		}
+		if topicFilter(name) {
+			kept = append(kept, topicElement)
+		}
+	}
+	if len(kept) == len(topicsArray) {
+		return nil
+	}
+	return decodedStruct.Replace(topicMetadataKeyName, kept)
+}
+
+func modifyFindCoordinatorResponse(decodedStruct *Struct, fn config.NetAddressMappingFunc, _ TopicFilterFunc) error {
 	if decodedStruct == nil {
 		return errors.New("decoded struct must not be nil")
 	}
 	if fn == nil {
 		return errors.New("net address mapper must not be nil")
 	}
+	// since v4 coordinators of a batch of keys are returned
+	if decodedStruct.GetSchema().GetFieldsByName()[coordinatorsKeyName] != nil {
+		coordinatorsArray, ok := decodedStruct.Get(coordinatorsKeyName).([]interface{})
+		if !ok {
+			return errors.New("coordinators list not found")
+		}
+		for _, coordinatorElement := range coordinatorsArray {
+			coordinator, ok := coordinatorElement.(*Struct)
+			if !ok {
+				return errors.New("coordinators element is not a struct")
+			}
+			if err := modifyCoordinatorAddress(coordinator, coordinatorsKeyName, fn); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
 	coordinator, ok := decodedStruct.Get(coordinatorKeyName).(*Struct)
 	if !ok {
 		return errors.New("coordinator not found")
 	}
+	return modifyCoordinatorAddress(coordinator, coordinatorKeyName, fn)
+}
+
+func modifyCoordinatorAddress(coordinator *Struct, name string, fn config.NetAddressMappingFunc) error {
 	host, ok := coordinator.Get(hostKeyName).(string)
 	if !ok {
-		return errors.New("coordinator.host not found")
+		return fmt.Errorf("%s.host not found", name)
 	}
 	port, ok := coordinator.Get(portKeyName).(int32)
 	if !ok {
-		return errors.New("coordinator.port not found")
+		return fmt.Errorf("%s.port not found", name)
 	}
 
 	if host == "" && port <= 0 {
@@ -328,12 +580,16 @@ type ResponseModifier interface {
 	Apply(resp []byte) ([]byte, error)
 }
 
-type modifyResponseFunc func(decodedStruct *Struct, fn config.NetAddressMappingFunc) error
+// TopicFilterFunc returns true if the topic is kept in the response
+type TopicFilterFunc func(topic string) bool
+
+type modifyResponseFunc func(decodedStruct *Struct, fn config.NetAddressMappingFunc, topicFilter TopicFilterFunc) error
 
 type responseModifier struct {
 	schema                Schema
 	modifyResponseFunc    modifyResponseFunc
 	netAddressMappingFunc config.NetAddressMappingFunc
+	topicFilterFunc       TopicFilterFunc
 }
 
 func (f *responseModifier) Apply(resp []byte) ([]byte, error) {
@@ -341,7 +597,7 @@ func (f *responseModifier) Apply(resp []byte) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = f.modifyResponseFunc(decodedStruct, f.netAddressMappingFunc)
+	err = f.modifyResponseFunc(decodedStruct, f.netAddressMappingFunc, f.topicFilterFunc)
 	if err != nil {
 		return nil, err
 	}
@@ -349,17 +605,35 @@ func (f *responseModifier) Apply(resp []byte) ([]byte, error) {
 }
 
 func GetResponseModifier(apiKey int16, apiVersion int16, addressMappingFunc config.NetAddressMappingFunc) (ResponseModifier, error) {
+	return GetTopicFilteringResponseModifier(apiKey, apiVersion, addressMappingFunc, nil)
+}
+
+// GetTopicFilteringResponseModifier returns the response modifier which also removes topics rejected by the filter from Metadata responses.
+// The topic filter can be nil.
+func GetTopicFilteringResponseModifier(apiKey int16, apiVersion int16, addressMappingFunc config.NetAddressMappingFunc, topicFilter TopicFilterFunc) (ResponseModifier, error) {
 	switch apiKey {
 	case apiKeyMetadata:
-		return newResponseModifier(apiKey, apiVersion, addressMappingFunc, metadataResponseSchemaVersions, modifyMetadataResponse)
+		return newResponseModifier(apiKey, apiVersion, addressMappingFunc, topicFilter, metadataResponseSchemaVersions, modifyMetadataResponse)
 	case apiKeyFindCoordinator:
-		return newResponseModifier(apiKey, apiVersion, addressMappingFunc, findCoordinatorResponseSchemaVersions, modifyFindCoordinatorResponse)
+		return newResponseModifier(apiKey, apiVersion, addressMappingFunc, nil, findCoordinatorResponseSchemaVersions, modifyFindCoordinatorResponse)
+	case apiKeyDescribeCluster:
+		return newResponseModifier(apiKey, apiVersion, addressMappingFunc, nil, describeClusterResponseSchemaVersions, modifyDescribeClusterResponse)
+	case apiKeyProduce:
+		if apiVersion < minProduceNodeEndpointsVersion {
+			return nil, nil
+		}
+		return newNodeEndpointsResponseModifier(apiKey, apiVersion, addressMappingFunc, produceResponseSchemaVersions)
+	case apiKeyFetch:
+		if apiVersion < minFetchNodeEndpointsVersion {
+			return nil, nil
+		}
+		return newNodeEndpointsResponseModifier(apiKey, apiVersion, addressMappingFunc, fetchResponseSchemaVersions)
 	default:
 		return nil, nil
 	}
 }
 
-func newResponseModifier(apiKey int16, apiVersion int16, netAddressMappingFunc config.NetAddressMappingFunc, schemas []Schema, modifyResponseFunc modifyResponseFunc) (ResponseModifier, error) {
+func newResponseModifier(apiKey int16, apiVersion int16, netAddressMappingFunc config.NetAddressMappingFunc, topicFilter TopicFilterFunc, schemas []Schema, modifyResponseFunc modifyResponseFunc) (ResponseModifier, error) {
 	schema, err := getResponseSchema(apiKey, apiVersion, schemas)
 	if err != nil {
 		return nil, err
@@ -368,9 +642,71 @@ func newResponseModifier(apiKey int16, apiVersion int16, netAddressMappingFunc c
 		schema:                schema,
 		modifyResponseFunc:    modifyResponseFunc,
 		netAddressMappingFunc: netAddressMappingFunc,
+		topicFilterFunc:       topicFilter,
 	}, nil
 }
 
+// nodeEndpointsResponseModifier rewrites the node_endpoints tagged field of Produce and Fetch responses. The body before
+// the response tagged fields is only decoded to find them (records are not copied) and is kept as it is. The response
+// is returned unchanged if it has no node_endpoints.
+type nodeEndpointsResponseModifier struct {
+	// response schema without the response tagged fields
+	bodySchema            Schema
+	netAddressMappingFunc config.NetAddressMappingFunc
+}
+
+func newNodeEndpointsResponseModifier(apiKey int16, apiVersion int16, netAddressMappingFunc config.NetAddressMappingFunc, schemas []Schema) (ResponseModifier, error) {
+	schema, err := getResponseSchema(apiKey, apiVersion, schemas)
+	if err != nil {
+		return nil, err
+	}
+	fields := schema.GetFields()
+	if len(fields) == 0 || fields[len(fields)-1].def.GetName() != responseTaggedFieldsKeyName {
+		return nil, fmt.Errorf("response schema version %d for key %d does not end with %s", apiVersion, apiKey, responseTaggedFieldsKeyName)
+	}
+	bodyFields := make([]Field, 0, len(fields)-1)
+	for _, field := range fields[:len(fields)-1] {
+		bodyFields = append(bodyFields, field.def)
+	}
+	return &nodeEndpointsResponseModifier{
+		bodySchema:            NewSchema(schema.GetName(), bodyFields...),
+		netAddressMappingFunc: netAddressMappingFunc,
+	}, nil
+}
+
+func (f *nodeEndpointsResponseModifier) Apply(resp []byte) ([]byte, error) {
+	helper := realDecoder{raw: resp}
+	if _, err := f.bodySchema.decode(&helper); err != nil {
+		return nil, err
+	}
+	offset := helper.off
+	taggedFields, err := DecodeSchema(resp[offset:], responseTaggedFieldsSchema)
+	if err != nil {
+		return nil, err
+	}
+	if !hasNodeEndpoints(taggedFields) {
+		return resp, nil
+	}
+	if err = modifyNodeEndpointsResponse(taggedFields, f.netAddressMappingFunc, nil); err != nil {
+		return nil, err
+	}
+	taggedFieldsBuf, err := EncodeSchema(taggedFields, responseTaggedFieldsSchema)
+	if err != nil {
+		return nil, err
+	}
+	return append(resp[:offset:offset], taggedFieldsBuf...), nil
+}
+
+func hasNodeEndpoints(decodedStruct *Struct) bool {
+	taggedFields, _ := decodedStruct.Get(responseTaggedFieldsKeyName).([]rawTaggedField)
+	for _, taggedField := range taggedFields {
+		if taggedField.tag == nodeEndpointsTag {
+			return true
+		}
+	}
+	return false
+}
+
 func getResponseSchema(apiKey, apiVersion int16, schemas []Schema) (Schema, error) {
 	if apiVersion < 0 || int(apiVersion) >= len(schemas) {
 		return nil, fmt.Errorf("Unsupported response schema version %d for key %d ", apiVersion, apiKey)
@@ -0,0 +1,142 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	apiKeyApiVersions = 18
+
+	apiKeysKeyName    = "api_keys"
+	apiKeyKeyName     = "api_key"
+	minVersionKeyName = "min_version"
+	maxVersionKeyName = "max_version"
+)
+
+var apiVersionsResponseSchemaVersions = createApiVersionsResponseSchemaVersions()
+
+func createApiVersionsResponseSchemaVersions() []Schema {
+	apiVersionV0 := NewSchema("api_version_v0",
+		&Mfield{Name: apiKeyKeyName, Ty: TypeInt16},
+		&Mfield{Name: minVersionKeyName, Ty: TypeInt16},
+		&Mfield{Name: maxVersionKeyName, Ty: TypeInt16},
+	)
+
+	apiVersionSchema3 := NewSchema("api_version_schema3",
+		&Mfield{Name: apiKeyKeyName, Ty: TypeInt16},
+		&Mfield{Name: minVersionKeyName, Ty: TypeInt16},
+		&Mfield{Name: maxVersionKeyName, Ty: TypeInt16},
+		&SchemaTaggedFields{Name: "api_version_tagged_fields"},
+	)
+
+	apiVersionsResponseV0 := NewSchema("api_versions_response_v0",
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Array{Name: apiKeysKeyName, Ty: apiVersionV0},
+	)
+
+	apiVersionsResponseV1 := NewSchema("api_versions_response_v1",
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Array{Name: apiKeysKeyName, Ty: apiVersionV0},
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+	)
+
+	apiVersionsResponseV2 := apiVersionsResponseV1
+
+	// supported features, finalized features epoch and finalized features are tagged fields
+	apiVersionsResponseV3 := NewSchema("api_versions_response_v3",
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&CompactArray{Name: apiKeysKeyName, Ty: apiVersionSchema3},
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&SchemaTaggedFields{Name: "response_tagged_fields"},
+	)
+
+	apiVersionsResponseV4 := apiVersionsResponseV3
+
+	return []Schema{apiVersionsResponseV0, apiVersionsResponseV1, apiVersionsResponseV2, apiVersionsResponseV3, apiVersionsResponseV4}
+}
+
+// MaxRequestSchemaVersion returns the highest version of the api key which request body can be decoded or -1 if none can be decoded
+func MaxRequestSchemaVersion(apiKey int16) int16 {
+	return int16(len(getRequestSchemaVersions(apiKey)) - 1)
+}
+
+// MaxResponseSchemaVersion returns the highest version of the api key which response body can be decoded or -1 if none can be decoded
+func MaxResponseSchemaVersion(apiKey int16) int16 {
+	return int16(len(getResponseSchemaVersions(apiKey)) - 1)
+}
+
+// NewApiVersionsResponseModifier creates the response modifier which lowers max_version of the api keys in the ApiVersions response
+// to the given max versions. Api keys which min_version is higher than the max version are removed.
+// A nil modifier is returned for ApiVersions versions which response cannot be decoded.
+func NewApiVersionsResponseModifier(apiVersion int16, maxVersions map[int16]int16) (ResponseModifier, error) {
+	if apiVersion < 0 || apiVersion > MaxResponseSchemaVersion(apiKeyApiVersions) {
+		return nil, nil
+	}
+	return &apiVersionsResponseModifier{
+		schema:      apiVersionsResponseSchemaVersions[apiVersion],
+		maxVersions: maxVersions,
+	}, nil
+}
+
+type apiVersionsResponseModifier struct {
+	schema      Schema
+	maxVersions map[int16]int16
+}
+
+func (f *apiVersionsResponseModifier) Apply(resp []byte) ([]byte, error) {
+	// on error (e.g. UNSUPPORTED_VERSION) the broker can answer with the v0 response, it is passed unchanged
+	if len(resp) < 2 || binary.BigEndian.Uint16(resp) != uint16(ErrNoError) {
+		return resp, nil
+	}
+	decodedStruct, err := DecodeSchema(resp, f.schema)
+	if err != nil {
+		return nil, err
+	}
+	if err = clampApiVersions(decodedStruct, f.maxVersions); err != nil {
+		return nil, err
+	}
+	return EncodeSchema(decodedStruct, f.schema)
+}
+
+func clampApiVersions(decodedStruct *Struct, maxVersions map[int16]int16) error {
+	apiKeysArray, ok := decodedStruct.Get(apiKeysKeyName).([]interface{})
+	if !ok {
+		return errors.New("api_keys list not found")
+	}
+	kept := make([]interface{}, 0, len(apiKeysArray))
+	for _, apiKeyElement := range apiKeysArray {
+		apiVersion, ok := apiKeyElement.(*Struct)
+		if !ok {
+			return errors.New("api_keys element is not a struct")
+		}
+		apiKey, ok := apiVersion.Get(apiKeyKeyName).(int16)
+		if !ok {
+			return errors.New("api_keys.api_key not found")
+		}
+		maxVersion, ok := maxVersions[apiKey]
+		if !ok {
+			kept = append(kept, apiKeyElement)
+			continue
+		}
+		brokerMinVersion, ok := apiVersion.Get(minVersionKeyName).(int16)
+		if !ok {
+			return errors.New("api_keys.min_version not found")
+		}
+		brokerMaxVersion, ok := apiVersion.Get(maxVersionKeyName).(int16)
+		if !ok {
+			return errors.New("api_keys.max_version not found")
+		}
+		if brokerMinVersion > maxVersion {
+			// no version of the api key can be handled
+			continue
+		}
+		if brokerMaxVersion > maxVersion {
+			if err := apiVersion.Replace(maxVersionKeyName, maxVersion); err != nil {
+				return err
+			}
+		}
+		kept = append(kept, apiKeyElement)
+	}
+	return decodedStruct.Replace(apiKeysKeyName, kept)
+}
@@ -0,0 +1,55 @@
+package protocol
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiVersionsResponseModifier(t *testing.T) {
+	maxVersions := map[int16]int16{apiKeyProduce: 8, apiKeyMetadata: 9, apiKeyListGroups: 2, 50: 2}
+
+	tt := []struct {
+		name       string
+		apiVersion int16
+		input      string
+		expected   string
+	}{
+		{
+			name:       "v1 max versions are lowered",
+			apiVersion: 1,
+			input:      "0000" + "00000003" + "00000000000b" + "00030000000c" + "001000000001" + "00000000",
+			expected:   "0000" + "00000003" + "000000000008" + "000300000009" + "001000000001" + "00000000",
+		},
+		{
+			name:       "v3 api keys which min version is too high are removed, tagged fields are kept",
+			apiVersion: 3,
+			input:      "0000" + "05" + "00000000000b00" + "00030000000c00" + "00120000000400" + "00320003000400" + "00000000" + "0101080000000000000005",
+			expected:   "0000" + "04" + "00000000000800" + "00030000000900" + "00120000000400" + "00000000" + "0101080000000000000005",
+		},
+		{
+			name:       "error response in v0 format is unchanged",
+			apiVersion: 3,
+			input:      "0023" + "00000001" + "001200000002",
+			expected:   "0023" + "00000001" + "001200000002",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			a := assert.New(t)
+			input, err := hex.DecodeString(tc.input)
+			a.Nil(err)
+
+			modifier, err := NewApiVersionsResponseModifier(tc.apiVersion, maxVersions)
+			a.Nil(err)
+			output, err := modifier.Apply(input)
+			a.Nil(err)
+			a.Equal(tc.expected, hex.EncodeToString(output))
+		})
+	}
+
+	modifier, err := NewApiVersionsResponseModifier(5, maxVersions)
+	assert.Nil(t, err)
+	assert.Nil(t, modifier)
+}
@@ -0,0 +1,327 @@
+package protocol
+
+var (
+	describeGroupsResponseSchemaVersions       = createDescribeGroupsResponseSchemaVersions()
+	listGroupsResponseSchemaVersions           = createListGroupsResponseSchemaVersions()
+	offsetForLeaderEpochResponseSchemaVersions = createOffsetForLeaderEpochResponseSchemaVersions()
+	addPartitionsToTxnResponseSchemaVersions   = createAddPartitionsToTxnResponseSchemaVersions()
+	txnOffsetCommitResponseSchemaVersions      = createTxnOffsetCommitResponseSchemaVersions()
+	deleteGroupsResponseSchemaVersions         = createDeleteGroupsResponseSchemaVersions()
+)
+
+func createDescribeGroupsResponseSchemaVersions() []Schema {
+	describedGroupMemberV0 := NewSchema("described_group_member_v0",
+		&Mfield{Name: "member_id", Ty: TypeStr},
+		&Mfield{Name: "client_id", Ty: TypeStr},
+		&Mfield{Name: "client_host", Ty: TypeStr},
+		&Mfield{Name: "member_metadata", Ty: TypeBytes},
+		&Mfield{Name: "member_assignment", Ty: TypeBytes},
+	)
+
+	describedGroupMemberV4 := NewSchema("described_group_member_v4",
+		&Mfield{Name: "member_id", Ty: TypeStr},
+		&Mfield{Name: "group_instance_id", Ty: TypeNullableStr},
+		&Mfield{Name: "client_id", Ty: TypeStr},
+		&Mfield{Name: "client_host", Ty: TypeStr},
+		&Mfield{Name: "member_metadata", Ty: TypeBytes},
+		&Mfield{Name: "member_assignment", Ty: TypeBytes},
+	)
+
+	describedGroupMemberSchema5 := NewSchema("described_group_member_schema5",
+		&Mfield{Name: "member_id", Ty: TypeCompactStr},
+		&Mfield{Name: "group_instance_id", Ty: TypeCompactNullableStr},
+		&Mfield{Name: "client_id", Ty: TypeCompactStr},
+		&Mfield{Name: "client_host", Ty: TypeCompactStr},
+		&Mfield{Name: "member_metadata", Ty: TypeCompactBytes},
+		&Mfield{Name: "member_assignment", Ty: TypeCompactBytes},
+		&SchemaTaggedFields{Name: "member_tagged_fields"},
+	)
+
+	describedGroupV0 := NewSchema("described_group_v0",
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Mfield{Name: "group_id", Ty: TypeStr},
+		&Mfield{Name: "group_state", Ty: TypeStr},
+		&Mfield{Name: "protocol_type", Ty: TypeStr},
+		&Mfield{Name: "protocol_data", Ty: TypeStr},
+		&Array{Name: "members", Ty: describedGroupMemberV0},
+	)
+
+	describedGroupV3 := NewSchema("described_group_v3",
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Mfield{Name: "group_id", Ty: TypeStr},
+		&Mfield{Name: "group_state", Ty: TypeStr},
+		&Mfield{Name: "protocol_type", Ty: TypeStr},
+		&Mfield{Name: "protocol_data", Ty: TypeStr},
+		&Array{Name: "members", Ty: describedGroupMemberV0},
+		&Mfield{Name: "authorized_operations", Ty: TypeInt32},
+	)
+
+	describedGroupV4 := NewSchema("described_group_v4",
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Mfield{Name: "group_id", Ty: TypeStr},
+		&Mfield{Name: "group_state", Ty: TypeStr},
+		&Mfield{Name: "protocol_type", Ty: TypeStr},
+		&Mfield{Name: "protocol_data", Ty: TypeStr},
+		&Array{Name: "members", Ty: describedGroupMemberV4},
+		&Mfield{Name: "authorized_operations", Ty: TypeInt32},
+	)
+
+	describedGroupSchema5 := NewSchema("described_group_schema5",
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Mfield{Name: "group_id", Ty: TypeCompactStr},
+		&Mfield{Name: "group_state", Ty: TypeCompactStr},
+		&Mfield{Name: "protocol_type", Ty: TypeCompactStr},
+		&Mfield{Name: "protocol_data", Ty: TypeCompactStr},
+		&CompactArray{Name: "members", Ty: describedGroupMemberSchema5},
+		&Mfield{Name: "authorized_operations", Ty: TypeInt32},
+		&SchemaTaggedFields{Name: "group_tagged_fields"},
+	)
+
+	describeGroupsResponseV0 := NewSchema("describe_groups_response_v0",
+		&Array{Name: "groups", Ty: describedGroupV0},
+	)
+
+	describeGroupsResponseV1 := NewSchema("describe_groups_response_v1",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&Array{Name: "groups", Ty: describedGroupV0},
+	)
+
+	describeGroupsResponseV2 := describeGroupsResponseV1
+
+	describeGroupsResponseV3 := NewSchema("describe_groups_response_v3",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&Array{Name: "groups", Ty: describedGroupV3},
+	)
+
+	describeGroupsResponseV4 := NewSchema("describe_groups_response_v4",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&Array{Name: "groups", Ty: describedGroupV4},
+	)
+
+	describeGroupsResponseV5 := NewSchema("describe_groups_response_v5",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&CompactArray{Name: "groups", Ty: describedGroupSchema5},
+		&SchemaTaggedFields{Name: "response_tagged_fields"},
+	)
+
+	return []Schema{describeGroupsResponseV0, describeGroupsResponseV1, describeGroupsResponseV2, describeGroupsResponseV3, describeGroupsResponseV4, describeGroupsResponseV5}
+}
+
+func createListGroupsResponseSchemaVersions() []Schema {
+	listedGroupV0 := NewSchema("listed_group_v0",
+		&Mfield{Name: "group_id", Ty: TypeStr},
+		&Mfield{Name: "protocol_type", Ty: TypeStr},
+	)
+
+	listedGroupSchema3 := NewSchema("listed_group_schema3",
+		&Mfield{Name: "group_id", Ty: TypeCompactStr},
+		&Mfield{Name: "protocol_type", Ty: TypeCompactStr},
+		&SchemaTaggedFields{Name: "group_tagged_fields"},
+	)
+
+	listedGroupSchema4 := NewSchema("listed_group_schema4",
+		&Mfield{Name: "group_id", Ty: TypeCompactStr},
+		&Mfield{Name: "protocol_type", Ty: TypeCompactStr},
+		&Mfield{Name: "group_state", Ty: TypeCompactStr},
+		&SchemaTaggedFields{Name: "group_tagged_fields"},
+	)
+
+	listGroupsResponseV0 := NewSchema("list_groups_response_v0",
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Array{Name: "groups", Ty: listedGroupV0},
+	)
+
+	listGroupsResponseV1 := NewSchema("list_groups_response_v1",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Array{Name: "groups", Ty: listedGroupV0},
+	)
+
+	listGroupsResponseV2 := listGroupsResponseV1
+
+	listGroupsResponseV3 := NewSchema("list_groups_response_v3",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&CompactArray{Name: "groups", Ty: listedGroupSchema3},
+		&SchemaTaggedFields{Name: "response_tagged_fields"},
+	)
+
+	listGroupsResponseV4 := NewSchema("list_groups_response_v4",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&CompactArray{Name: "groups", Ty: listedGroupSchema4},
+		&SchemaTaggedFields{Name: "response_tagged_fields"},
+	)
+
+	return []Schema{listGroupsResponseV0, listGroupsResponseV1, listGroupsResponseV2, listGroupsResponseV3, listGroupsResponseV4}
+}
+
+func createOffsetForLeaderEpochResponseSchemaVersions() []Schema {
+	epochEndOffsetV0 := NewSchema("epoch_end_offset_v0",
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Mfield{Name: "partition", Ty: TypeInt32},
+		&Mfield{Name: "end_offset", Ty: TypeInt64},
+	)
+
+	epochEndOffsetV1 := NewSchema("epoch_end_offset_v1",
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Mfield{Name: "partition", Ty: TypeInt32},
+		&Mfield{Name: "leader_epoch", Ty: TypeInt32},
+		&Mfield{Name: "end_offset", Ty: TypeInt64},
+	)
+
+	epochEndOffsetSchema4 := NewSchema("epoch_end_offset_schema4",
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Mfield{Name: "partition", Ty: TypeInt32},
+		&Mfield{Name: "leader_epoch", Ty: TypeInt32},
+		&Mfield{Name: "end_offset", Ty: TypeInt64},
+		&SchemaTaggedFields{Name: "partition_tagged_fields"},
+	)
+
+	offsetForLeaderTopicResultV0 := NewSchema("offset_for_leader_topic_result_v0",
+		&Mfield{Name: "topic", Ty: TypeStr},
+		&Array{Name: "partitions", Ty: epochEndOffsetV0},
+	)
+
+	offsetForLeaderTopicResultV1 := NewSchema("offset_for_leader_topic_result_v1",
+		&Mfield{Name: "topic", Ty: TypeStr},
+		&Array{Name: "partitions", Ty: epochEndOffsetV1},
+	)
+
+	offsetForLeaderTopicResultSchema4 := NewSchema("offset_for_leader_topic_result_schema4",
+		&Mfield{Name: "topic", Ty: TypeCompactStr},
+		&CompactArray{Name: "partitions", Ty: epochEndOffsetSchema4},
+		&SchemaTaggedFields{Name: "topic_tagged_fields"},
+	)
+
+	offsetForLeaderEpochResponseV0 := NewSchema("offset_for_leader_epoch_response_v0",
+		&Array{Name: "topics", Ty: offsetForLeaderTopicResultV0},
+	)
+
+	offsetForLeaderEpochResponseV1 := NewSchema("offset_for_leader_epoch_response_v1",
+		&Array{Name: "topics", Ty: offsetForLeaderTopicResultV1},
+	)
+
+	offsetForLeaderEpochResponseV2 := NewSchema("offset_for_leader_epoch_response_v2",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&Array{Name: "topics", Ty: offsetForLeaderTopicResultV1},
+	)
+
+	offsetForLeaderEpochResponseV3 := offsetForLeaderEpochResponseV2
+
+	offsetForLeaderEpochResponseV4 := NewSchema("offset_for_leader_epoch_response_v4",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&CompactArray{Name: "topics", Ty: offsetForLeaderTopicResultSchema4},
+		&SchemaTaggedFields{Name: "response_tagged_fields"},
+	)
+
+	return []Schema{offsetForLeaderEpochResponseV0, offsetForLeaderEpochResponseV1, offsetForLeaderEpochResponseV2, offsetForLeaderEpochResponseV3, offsetForLeaderEpochResponseV4}
+}
+
+func createAddPartitionsToTxnResponseSchemaVersions() []Schema {
+	addPartitionsToTxnPartitionResultV0 := NewSchema("add_partitions_to_txn_partition_result_v0",
+		&Mfield{Name: "partition_index", Ty: TypeInt32},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+	)
+
+	addPartitionsToTxnPartitionResultSchema3 := NewSchema("add_partitions_to_txn_partition_result_schema3",
+		&Mfield{Name: "partition_index", Ty: TypeInt32},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&SchemaTaggedFields{Name: "partition_tagged_fields"},
+	)
+
+	addPartitionsToTxnTopicResultV0 := NewSchema("add_partitions_to_txn_topic_result_v0",
+		&Mfield{Name: "name", Ty: TypeStr},
+		&Array{Name: "results", Ty: addPartitionsToTxnPartitionResultV0},
+	)
+
+	addPartitionsToTxnTopicResultSchema3 := NewSchema("add_partitions_to_txn_topic_result_schema3",
+		&Mfield{Name: "name", Ty: TypeCompactStr},
+		&CompactArray{Name: "results", Ty: addPartitionsToTxnPartitionResultSchema3},
+		&SchemaTaggedFields{Name: "topic_tagged_fields"},
+	)
+
+	addPartitionsToTxnResponseV0 := NewSchema("add_partitions_to_txn_response_v0",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&Array{Name: "results", Ty: addPartitionsToTxnTopicResultV0},
+	)
+
+	addPartitionsToTxnResponseV1 := addPartitionsToTxnResponseV0
+	addPartitionsToTxnResponseV2 := addPartitionsToTxnResponseV0
+
+	addPartitionsToTxnResponseV3 := NewSchema("add_partitions_to_txn_response_v3",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&CompactArray{Name: "results", Ty: addPartitionsToTxnTopicResultSchema3},
+		&SchemaTaggedFields{Name: "response_tagged_fields"},
+	)
+
+	return []Schema{addPartitionsToTxnResponseV0, addPartitionsToTxnResponseV1, addPartitionsToTxnResponseV2, addPartitionsToTxnResponseV3}
+}
+
+func createTxnOffsetCommitResponseSchemaVersions() []Schema {
+	txnOffsetCommitPartitionResponseV0 := NewSchema("txn_offset_commit_partition_response_v0",
+		&Mfield{Name: "partition_index", Ty: TypeInt32},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+	)
+
+	txnOffsetCommitPartitionResponseSchema3 := NewSchema("txn_offset_commit_partition_response_schema3",
+		&Mfield{Name: "partition_index", Ty: TypeInt32},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&SchemaTaggedFields{Name: "partition_tagged_fields"},
+	)
+
+	txnOffsetCommitTopicResponseV0 := NewSchema("txn_offset_commit_topic_response_v0",
+		&Mfield{Name: "name", Ty: TypeStr},
+		&Array{Name: "partitions", Ty: txnOffsetCommitPartitionResponseV0},
+	)
+
+	txnOffsetCommitTopicResponseSchema3 := NewSchema("txn_offset_commit_topic_response_schema3",
+		&Mfield{Name: "name", Ty: TypeCompactStr},
+		&CompactArray{Name: "partitions", Ty: txnOffsetCommitPartitionResponseSchema3},
+		&SchemaTaggedFields{Name: "topic_tagged_fields"},
+	)
+
+	txnOffsetCommitResponseV0 := NewSchema("txn_offset_commit_response_v0",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&Array{Name: "topics", Ty: txnOffsetCommitTopicResponseV0},
+	)
+
+	txnOffsetCommitResponseV1 := txnOffsetCommitResponseV0
+	txnOffsetCommitResponseV2 := txnOffsetCommitResponseV0
+
+	txnOffsetCommitResponseV3 := NewSchema("txn_offset_commit_response_v3",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&CompactArray{Name: "topics", Ty: txnOffsetCommitTopicResponseSchema3},
+		&SchemaTaggedFields{Name: "response_tagged_fields"},
+	)
+
+	return []Schema{txnOffsetCommitResponseV0, txnOffsetCommitResponseV1, txnOffsetCommitResponseV2, txnOffsetCommitResponseV3}
+}
+
+func createDeleteGroupsResponseSchemaVersions() []Schema {
+	deletableGroupResultV0 := NewSchema("deletable_group_result_v0",
+		&Mfield{Name: "group_id", Ty: TypeStr},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+	)
+
+	deletableGroupResultSchema2 := NewSchema("deletable_group_result_schema2",
+		&Mfield{Name: "group_id", Ty: TypeCompactStr},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&SchemaTaggedFields{Name: "group_tagged_fields"},
+	)
+
+	deleteGroupsResponseV0 := NewSchema("delete_groups_response_v0",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&Array{Name: "results", Ty: deletableGroupResultV0},
+	)
+
+	deleteGroupsResponseV1 := deleteGroupsResponseV0
+
+	deleteGroupsResponseV2 := NewSchema("delete_groups_response_v2",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&CompactArray{Name: "results", Ty: deletableGroupResultSchema2},
+		&SchemaTaggedFields{Name: "response_tagged_fields"},
+	)
+
+	return []Schema{deleteGroupsResponseV0, deleteGroupsResponseV1, deleteGroupsResponseV2}
+}
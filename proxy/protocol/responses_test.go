@@ -2510,6 +2510,24 @@ func TestMetadataResponses(t *testing.T) {
 			modifier: testResponseModifier2,
 			modified: []string{"throttle_time_ms int32 0", "[brokers]", "brokers struct", "node_id int32 0", "host string myhost", "port int32 34000", "rack *string <nil>", "[broker_tagged_fields]", "broker_tagged_fields tag 0 value 0x4711", "cluster_id *string clusterId", "controller_id int32 0", "[topic_metadata]", "topic_metadata struct", "error_code int16 0", "name string __consumer_offsets", "is_internal bool true", "[partition_metadata]", "partition_metadata struct", "error_code int16 0", "partition int32 0", "leader int32 -1", "leader_epoch int32 -1", "[replicas]", "replicas int32 1", "[isr]", "isr int32 2", "[offline_replicas]", "offline_replicas int32 3", "[partition_metadata_tagged_fields]", "topic_authorized_operations int32 -2147483648", "[topic_metadata_tagged_fields]", "cluster_authorized_operations int32 -2147483648", "[response_tagged_fields]"},
 		},
+		{name: "v10", apiVersion: int16(10),
+			hexInput: "0000000002000000010a6c6f63616c686f7374000071a4000005616263640000000102000010746573742d6e6f2d68656164657273000102030405060708090a0b0c0d0e0f0002000000000000000000010000000002000000010200000001010080000000008000000000",
+			expected: []string{"throttle_time_ms int32 0", "[brokers]", "brokers struct", "node_id int32 1", "host string localhost", "port int32 29092", "rack *string <nil>", "[broker_tagged_fields]", "cluster_id *string abcd", "controller_id int32 1", "[topic_metadata]", "topic_metadata struct", "error_code int16 0", "name string test-no-headers", "topic_id uuid 000102030405060708090a0b0c0d0e0f", "is_internal bool false", "[partition_metadata]", "partition_metadata struct", "error_code int16 0", "partition int32 0", "leader int32 1", "leader_epoch int32 0", "[replicas]", "replicas int32 1", "[isr]", "isr int32 1", "[offline_replicas]", "[partition_metadata_tagged_fields]", "topic_authorized_operations int32 -2147483648", "[topic_metadata_tagged_fields]", "cluster_authorized_operations int32 -2147483648", "[response_tagged_fields]"},
+			modifier: testResponseModifier2,
+			modified: []string{"throttle_time_ms int32 0", "[brokers]", "brokers struct", "node_id int32 1", "host string myhost2", "port int32 34002", "rack *string <nil>", "[broker_tagged_fields]", "cluster_id *string abcd", "controller_id int32 1", "[topic_metadata]", "topic_metadata struct", "error_code int16 0", "name string test-no-headers", "topic_id uuid 000102030405060708090a0b0c0d0e0f", "is_internal bool false", "[partition_metadata]", "partition_metadata struct", "error_code int16 0", "partition int32 0", "leader int32 1", "leader_epoch int32 0", "[replicas]", "replicas int32 1", "[isr]", "isr int32 1", "[offline_replicas]", "[partition_metadata_tagged_fields]", "topic_authorized_operations int32 -2147483648", "[topic_metadata_tagged_fields]", "cluster_authorized_operations int32 -2147483648", "[response_tagged_fields]"},
+		},
+		{name: "v11", apiVersion: int16(11),
+			hexInput: "0000000002000000010a6c6f63616c686f7374000071a4000005616263640000000102000010746573742d6e6f2d68656164657273000102030405060708090a0b0c0d0e0f00020000000000000000000100000000020000000102000000010100800000000000",
+			expected: []string{"throttle_time_ms int32 0", "[brokers]", "brokers struct", "node_id int32 1", "host string localhost", "port int32 29092", "rack *string <nil>", "[broker_tagged_fields]", "cluster_id *string abcd", "controller_id int32 1", "[topic_metadata]", "topic_metadata struct", "error_code int16 0", "name string test-no-headers", "topic_id uuid 000102030405060708090a0b0c0d0e0f", "is_internal bool false", "[partition_metadata]", "partition_metadata struct", "error_code int16 0", "partition int32 0", "leader int32 1", "leader_epoch int32 0", "[replicas]", "replicas int32 1", "[isr]", "isr int32 1", "[offline_replicas]", "[partition_metadata_tagged_fields]", "topic_authorized_operations int32 -2147483648", "[topic_metadata_tagged_fields]", "[response_tagged_fields]"},
+			modifier: testResponseModifier2,
+			modified: []string{"throttle_time_ms int32 0", "[brokers]", "brokers struct", "node_id int32 1", "host string myhost2", "port int32 34002", "rack *string <nil>", "[broker_tagged_fields]", "cluster_id *string abcd", "controller_id int32 1", "[topic_metadata]", "topic_metadata struct", "error_code int16 0", "name string test-no-headers", "topic_id uuid 000102030405060708090a0b0c0d0e0f", "is_internal bool false", "[partition_metadata]", "partition_metadata struct", "error_code int16 0", "partition int32 0", "leader int32 1", "leader_epoch int32 0", "[replicas]", "replicas int32 1", "[isr]", "isr int32 1", "[offline_replicas]", "[partition_metadata_tagged_fields]", "topic_authorized_operations int32 -2147483648", "[topic_metadata_tagged_fields]", "[response_tagged_fields]"},
+		},
+		{name: "v12", apiVersion: int16(12),
+			hexInput: "0000000002000000010a6c6f63616c686f7374000071a4000005616263640000000103000010746573742d6e6f2d68656164657273000102030405060708090a0b0c0d0e0f000200000000000000000001000000000200000001020000000101008000000000000300000102030405060708090a0b0c0d0e0f0001800000000000",
+			expected: []string{"throttle_time_ms int32 0", "[brokers]", "brokers struct", "node_id int32 1", "host string localhost", "port int32 29092", "rack *string <nil>", "[broker_tagged_fields]", "cluster_id *string abcd", "controller_id int32 1", "[topic_metadata]", "topic_metadata struct", "error_code int16 0", "name *string test-no-headers", "topic_id uuid 000102030405060708090a0b0c0d0e0f", "is_internal bool false", "[partition_metadata]", "partition_metadata struct", "error_code int16 0", "partition int32 0", "leader int32 1", "leader_epoch int32 0", "[replicas]", "replicas int32 1", "[isr]", "isr int32 1", "[offline_replicas]", "[partition_metadata_tagged_fields]", "topic_authorized_operations int32 -2147483648", "[topic_metadata_tagged_fields]", "topic_metadata struct", "error_code int16 3", "name *string <nil>", "topic_id uuid 000102030405060708090a0b0c0d0e0f", "is_internal bool false", "[partition_metadata]", "topic_authorized_operations int32 -2147483648", "[topic_metadata_tagged_fields]", "[response_tagged_fields]"},
+			modifier: testResponseModifier2,
+			modified: []string{"throttle_time_ms int32 0", "[brokers]", "brokers struct", "node_id int32 1", "host string myhost2", "port int32 34002", "rack *string <nil>", "[broker_tagged_fields]", "cluster_id *string abcd", "controller_id int32 1", "[topic_metadata]", "topic_metadata struct", "error_code int16 0", "name *string test-no-headers", "topic_id uuid 000102030405060708090a0b0c0d0e0f", "is_internal bool false", "[partition_metadata]", "partition_metadata struct", "error_code int16 0", "partition int32 0", "leader int32 1", "leader_epoch int32 0", "[replicas]", "replicas int32 1", "[isr]", "isr int32 1", "[offline_replicas]", "[partition_metadata_tagged_fields]", "topic_authorized_operations int32 -2147483648", "[topic_metadata_tagged_fields]", "topic_metadata struct", "error_code int16 3", "name *string <nil>", "topic_id uuid 000102030405060708090a0b0c0d0e0f", "is_internal bool false", "[partition_metadata]", "topic_authorized_operations int32 -2147483648", "[topic_metadata_tagged_fields]", "[response_tagged_fields]"},
+		},
 	}
 	for _, tc := range tt {
 		bytes, err := hex.DecodeString(tc.hexInput)
@@ -2610,6 +2628,12 @@ func TestFindCoordinatorResponse(t *testing.T) {
 			modifier: testResponseModifier2,
 			modified: []string{"throttle_time_ms int32 0", "error_code int16 8", "error_message *string The broker is not available.", "coordinator struct", "node_id int32 0", "host string myhost", "port int32 34000", "[response_tagged_fields]", "response_tagged_fields tag 0 value 0x4711"},
 		},
+		{name: "v4, batched coordinators", apiVersion: int16(4),
+			hexInput: "0000000003036731000000000a6c6f63616c686f73740000270f00000000036732ffffffff01ffffffff000f000000",
+			expected: []string{"throttle_time_ms int32 0", "[coordinators]", "coordinators struct", "key string g1", "node_id int32 0", "host string localhost", "port int32 9999", "error_code int16 0", "error_message *string <nil>", "[coordinator_tagged_fields]", "coordinators struct", "key string g2", "node_id int32 -1", "host string ", "port int32 -1", "error_code int16 15", "error_message *string <nil>", "[coordinator_tagged_fields]", "[response_tagged_fields]"},
+			modifier: testResponseModifier2,
+			modified: []string{"throttle_time_ms int32 0", "[coordinators]", "coordinators struct", "key string g1", "node_id int32 0", "host string myhost", "port int32 34000", "error_code int16 0", "error_message *string <nil>", "[coordinator_tagged_fields]", "coordinators struct", "key string g2", "node_id int32 -1", "host string ", "port int32 -1", "error_code int16 15", "error_message *string <nil>", "[coordinator_tagged_fields]", "[response_tagged_fields]"},
+		},
 	}
 	for _, tc := range tt {
 		bytes, err := hex.DecodeString(tc.hexInput)
@@ -2659,6 +2683,139 @@ func TestFindCoordinatorResponse(t *testing.T) {
 	}
 }
 
+func TestMetadataResponseTopicFilter(t *testing.T) {
+	for apiVersion := int16(0); int(apiVersion) < len(metadataResponseSchemaVersions); apiVersion++ {
+		a := assert.New(t)
+
+		resp, err := NewTopicsErrorResponse(apiKeyMetadata, apiVersion, []TopicPartitions{{Topic: "orders"}, {Topic: "__consumer_offsets"}, {Topic: "payments"}}, ErrNoError)
+		a.Nil(err)
+
+		modifier, err := GetTopicFilteringResponseModifier(apiKeyMetadata, apiVersion, testResponseModifier, func(topic string) bool {
+			return !strings.HasPrefix(topic, "__")
+		})
+		a.Nil(err)
+		resp, err = modifier.Apply(resp)
+		a.Nil(err)
+
+		s, err := DecodeSchema(resp, metadataResponseSchemaVersions[apiVersion])
+		a.Nil(err)
+		topics := s.Get(topicMetadataKeyName).([]interface{})
+		a.Len(topics, 2, "version %d", apiVersion)
+		for i, expected := range []string{"orders", "payments"} {
+			name, err := topicName(topics[i].(*Struct))
+			a.Nil(err)
+			a.Equal(expected, name)
+		}
+	}
+}
+
+func TestDescribeClusterResponse(t *testing.T) {
+	tt := []struct {
+		name       string
+		apiVersion int16
+		hexInput   string
+		expected   []string
+		modifier   config.NetAddressMappingFunc
+		modified   []string
+	}{
+		{name: "v0", apiVersion: int16(0),
+			hexInput: "0000000000000005616263640000000103000000010a6c6f63616c686f737400004a940000000000020a6c6f63616c686f7374000071a400008000000000",
+			expected: []string{"throttle_time_ms int32 0", "error_code int16 0", "error_message *string <nil>", "cluster_id string abcd", "controller_id int32 1", "[brokers]", "brokers struct", "broker_id int32 1", "host string localhost", "port int32 19092", "rack *string <nil>", "[broker_tagged_fields]", "brokers struct", "broker_id int32 2", "host string localhost", "port int32 29092", "rack *string <nil>", "[broker_tagged_fields]", "cluster_authorized_operations int32 -2147483648", "[response_tagged_fields]"},
+			modifier: testResponseModifier2,
+			modified: []string{"throttle_time_ms int32 0", "error_code int16 0", "error_message *string <nil>", "cluster_id string abcd", "controller_id int32 1", "[brokers]", "brokers struct", "broker_id int32 1", "host string myhost1", "port int32 34001", "rack *string <nil>", "[broker_tagged_fields]", "brokers struct", "broker_id int32 2", "host string myhost2", "port int32 34002", "rack *string <nil>", "[broker_tagged_fields]", "cluster_authorized_operations int32 -2147483648", "[response_tagged_fields]"},
+		},
+		{name: "v1", apiVersion: int16(1),
+			hexInput: "000000000000000105616263640000000103000000010a6c6f63616c686f737400004a940000000000020a6c6f63616c686f7374000071a400008000000000",
+			expected: []string{"throttle_time_ms int32 0", "error_code int16 0", "error_message *string <nil>", "endpoint_type int8 1", "cluster_id string abcd", "controller_id int32 1", "[brokers]", "brokers struct", "broker_id int32 1", "host string localhost", "port int32 19092", "rack *string <nil>", "[broker_tagged_fields]", "brokers struct", "broker_id int32 2", "host string localhost", "port int32 29092", "rack *string <nil>", "[broker_tagged_fields]", "cluster_authorized_operations int32 -2147483648", "[response_tagged_fields]"},
+			modifier: testResponseModifier2,
+			modified: []string{"throttle_time_ms int32 0", "error_code int16 0", "error_message *string <nil>", "endpoint_type int8 1", "cluster_id string abcd", "controller_id int32 1", "[brokers]", "brokers struct", "broker_id int32 1", "host string myhost1", "port int32 34001", "rack *string <nil>", "[broker_tagged_fields]", "brokers struct", "broker_id int32 2", "host string myhost2", "port int32 34002", "rack *string <nil>", "[broker_tagged_fields]", "cluster_authorized_operations int32 -2147483648", "[response_tagged_fields]"},
+		},
+		{name: "v2", apiVersion: int16(2),
+			hexInput: "000000000000000105616263640000000103000000010a6c6f63616c686f737400004a94000000000000020a6c6f63616c686f7374000071a40000008000000000",
+			expected: []string{"throttle_time_ms int32 0", "error_code int16 0", "error_message *string <nil>", "endpoint_type int8 1", "cluster_id string abcd", "controller_id int32 1", "[brokers]", "brokers struct", "broker_id int32 1", "host string localhost", "port int32 19092", "rack *string <nil>", "is_fenced bool false", "[broker_tagged_fields]", "brokers struct", "broker_id int32 2", "host string localhost", "port int32 29092", "rack *string <nil>", "is_fenced bool false", "[broker_tagged_fields]", "cluster_authorized_operations int32 -2147483648", "[response_tagged_fields]"},
+			modifier: testResponseModifier2,
+			modified: []string{"throttle_time_ms int32 0", "error_code int16 0", "error_message *string <nil>", "endpoint_type int8 1", "cluster_id string abcd", "controller_id int32 1", "[brokers]", "brokers struct", "broker_id int32 1", "host string myhost1", "port int32 34001", "rack *string <nil>", "is_fenced bool false", "[broker_tagged_fields]", "brokers struct", "broker_id int32 2", "host string myhost2", "port int32 34002", "rack *string <nil>", "is_fenced bool false", "[broker_tagged_fields]", "cluster_authorized_operations int32 -2147483648", "[response_tagged_fields]"},
+		},
+	}
+	for _, tc := range tt {
+		a := assert.New(t)
+		bytes, err := hex.DecodeString(tc.hexInput)
+		a.Nil(err)
+		schema := describeClusterResponseSchemaVersions[tc.apiVersion]
+
+		s, err := DecodeSchema(bytes, schema)
+		a.Nil(err)
+		dc := NewDecodeCheck()
+		a.Nil(dc.Traverse(s))
+		a.Equal(tc.expected, dc.AttrValues(), "decode:"+tc.name)
+
+		resp, err := EncodeSchema(s, schema)
+		a.Nil(err)
+		a.Equal(bytes, resp, "encode:"+tc.name)
+
+		modifier, err := GetResponseModifier(apiKeyDescribeCluster, tc.apiVersion, tc.modifier)
+		a.Nil(err)
+		resp, err = modifier.Apply(resp)
+		a.Nil(err)
+		s, err = DecodeSchema(resp, schema)
+		a.Nil(err)
+		dc = NewDecodeCheck()
+		a.Nil(dc.Traverse(s))
+		a.Equal(tc.modified, dc.AttrValues(), "modify:"+tc.name)
+	}
+}
+
+func TestNodeEndpointsResponseModifier(t *testing.T) {
+	tt := []struct {
+		name       string
+		apiKey     int16
+		apiVersion int16
+		hexInput   string
+		hexOutput  string
+	}{
+		{name: "Produce v10", apiKey: apiKeyProduce, apiVersion: 10,
+			hexInput:  "020574657374020000000000000000000000000005ffffffffffffffff000000000000000001000100090000000100000005000000000000" + "01002903000000010a6c6f63616c686f737400004a940000000000020a6c6f63616c686f7374000071a40000",
+			hexOutput: "020574657374020000000000000000000000000005ffffffffffffffff000000000000000001000100090000000100000005000000000000" + "0100250300000001086d79686f737431000084d1000000000002086d79686f737432000084d20000",
+		},
+		{name: "Produce v10, without node endpoints", apiKey: apiKeyProduce, apiVersion: 10,
+			hexInput:  "020574657374020000000000000000000000000005ffffffffffffffff000000000000000001000100090000000100000005000000000000" + "00",
+			hexOutput: "020574657374020000000000000000000000000005ffffffffffffffff000000000000000001000100090000000100000005000000000000" + "00",
+		},
+		{name: "Fetch v16", apiKey: apiKeyFetch, apiVersion: 16,
+			hexInput:  "0000000000000000000002000102030405060708090a0b0c0d0e0f0200000000000000000000000000050000000000000005000000000000000000ffffffff04aabbcc0000" + "01002903000000010a6c6f63616c686f737400004a940000000000020a6c6f63616c686f7374000071a40000",
+			hexOutput: "0000000000000000000002000102030405060708090a0b0c0d0e0f0200000000000000000000000000050000000000000005000000000000000000ffffffff04aabbcc0000" + "0100250300000001086d79686f737431000084d1000000000002086d79686f737432000084d20000",
+		},
+	}
+	for _, tc := range tt {
+		a := assert.New(t)
+		input, err := hex.DecodeString(tc.hexInput)
+		a.Nil(err)
+
+		modifier, err := GetResponseModifier(tc.apiKey, tc.apiVersion, testResponseModifier2)
+		a.Nil(err)
+		output, err := modifier.Apply(input)
+		a.Nil(err, tc.name)
+		a.Equal(tc.hexOutput, hex.EncodeToString(output), tc.name)
+	}
+
+	// the response without node endpoints is returned without copying
+	input, err := hex.DecodeString(tt[1].hexInput)
+	assert.Nil(t, err)
+	modifier, err := GetResponseModifier(apiKeyProduce, 10, testResponseModifier2)
+	assert.Nil(t, err)
+	output, err := modifier.Apply(input)
+	assert.Nil(t, err)
+	assert.True(t, &input[0] == &output[0])
+
+	// older versions are not modified
+	modifier, err = GetResponseModifier(apiKeyProduce, 9, testResponseModifier2)
+	assert.Nil(t, err)
+	assert.Nil(t, modifier)
+	modifier, err = GetResponseModifier(apiKeyFetch, 15, testResponseModifier2)
+	assert.Nil(t, err)
+	assert.Nil(t, modifier)
+}
+
 type decodeCheck struct {
 	attrValues []string
 }
@@ -2683,6 +2840,8 @@ func (t *decodeCheck) value(s *Struct, arg interface{}, sindex int) error {
 	switch v := arg.(type) {
 	case bool:
 		t.append(name, "bool", v)
+	case int8:
+		t.append(name, "int8", v)
 	case int16:
 		t.append(name, "int16", v)
 	case int32:
@@ -2695,6 +2854,8 @@ func (t *decodeCheck) value(s *Struct, arg interface{}, sindex int) error {
 		} else {
 			t.append(name, "*string", nil)
 		}
+	case [16]byte:
+		t.append(name, "uuid", hex.EncodeToString(v[:]))
 	case *Struct:
 		t.append(name, "struct")
 		err := t.Traverse(v)
@@ -0,0 +1,545 @@
+package protocol
+
+var (
+	produceResponseSchemaVersions      = createProduceResponseSchemaVersions()
+	fetchResponseSchemaVersions        = createFetchResponseSchemaVersions()
+	listOffsetsResponseSchemaVersions  = createListOffsetsResponseSchemaVersions()
+	offsetCommitResponseSchemaVersions = createOffsetCommitResponseSchemaVersions()
+	offsetFetchResponseSchemaVersions  = createOffsetFetchResponseSchemaVersions()
+)
+
+func createProduceResponseSchemaVersions() []Schema {
+	partitionResponseV0 := NewSchema("partition_response_v0",
+		&Mfield{Name: "index", Ty: TypeInt32},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Mfield{Name: "base_offset", Ty: TypeInt64},
+	)
+
+	partitionResponseV2 := NewSchema("partition_response_v2",
+		&Mfield{Name: "index", Ty: TypeInt32},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Mfield{Name: "base_offset", Ty: TypeInt64},
+		&Mfield{Name: "log_append_time_ms", Ty: TypeInt64},
+	)
+
+	partitionResponseV5 := NewSchema("partition_response_v5",
+		&Mfield{Name: "index", Ty: TypeInt32},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Mfield{Name: "base_offset", Ty: TypeInt64},
+		&Mfield{Name: "log_append_time_ms", Ty: TypeInt64},
+		&Mfield{Name: "log_start_offset", Ty: TypeInt64},
+	)
+
+	batchIndexAndErrorMessageV8 := NewSchema("batch_index_and_error_message_v8",
+		&Mfield{Name: "batch_index", Ty: TypeInt32},
+		&Mfield{Name: "batch_index_error_message", Ty: TypeNullableStr},
+	)
+
+	partitionResponseV8 := NewSchema("partition_response_v8",
+		&Mfield{Name: "index", Ty: TypeInt32},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Mfield{Name: "base_offset", Ty: TypeInt64},
+		&Mfield{Name: "log_append_time_ms", Ty: TypeInt64},
+		&Mfield{Name: "log_start_offset", Ty: TypeInt64},
+		&Array{Name: "record_errors", Ty: batchIndexAndErrorMessageV8},
+		&Mfield{Name: "error_message", Ty: TypeNullableStr},
+	)
+
+	batchIndexAndErrorMessageSchema9 := NewSchema("batch_index_and_error_message_schema9",
+		&Mfield{Name: "batch_index", Ty: TypeInt32},
+		&Mfield{Name: "batch_index_error_message", Ty: TypeCompactNullableStr},
+		&SchemaTaggedFields{Name: "record_errors_tagged_fields"},
+	)
+
+	partitionResponseSchema9 := NewSchema("partition_response_schema9",
+		&Mfield{Name: "index", Ty: TypeInt32},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Mfield{Name: "base_offset", Ty: TypeInt64},
+		&Mfield{Name: "log_append_time_ms", Ty: TypeInt64},
+		&Mfield{Name: "log_start_offset", Ty: TypeInt64},
+		&CompactArray{Name: "record_errors", Ty: batchIndexAndErrorMessageSchema9},
+		&Mfield{Name: "error_message", Ty: TypeCompactNullableStr},
+		&SchemaTaggedFields{Name: "partition_response_tagged_fields"},
+	)
+
+	topicProduceResponseV0 := NewSchema("topic_produce_response_v0",
+		&Mfield{Name: "name", Ty: TypeStr},
+		&Array{Name: "partition_responses", Ty: partitionResponseV0},
+	)
+
+	topicProduceResponseV2 := NewSchema("topic_produce_response_v2",
+		&Mfield{Name: "name", Ty: TypeStr},
+		&Array{Name: "partition_responses", Ty: partitionResponseV2},
+	)
+
+	topicProduceResponseV5 := NewSchema("topic_produce_response_v5",
+		&Mfield{Name: "name", Ty: TypeStr},
+		&Array{Name: "partition_responses", Ty: partitionResponseV5},
+	)
+
+	topicProduceResponseV8 := NewSchema("topic_produce_response_v8",
+		&Mfield{Name: "name", Ty: TypeStr},
+		&Array{Name: "partition_responses", Ty: partitionResponseV8},
+	)
+
+	topicProduceResponseSchema9 := NewSchema("topic_produce_response_schema9",
+		&Mfield{Name: "name", Ty: TypeCompactStr},
+		&CompactArray{Name: "partition_responses", Ty: partitionResponseSchema9},
+		&SchemaTaggedFields{Name: "topic_produce_response_tagged_fields"},
+	)
+
+	produceResponseV0 := NewSchema("produce_response_v0",
+		&Array{Name: "responses", Ty: topicProduceResponseV0},
+	)
+
+	produceResponseV1 := NewSchema("produce_response_v1",
+		&Array{Name: "responses", Ty: topicProduceResponseV0},
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+	)
+
+	produceResponseV2 := NewSchema("produce_response_v2",
+		&Array{Name: "responses", Ty: topicProduceResponseV2},
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+	)
+
+	produceResponseV3 := produceResponseV2
+	produceResponseV4 := produceResponseV2
+
+	produceResponseV5 := NewSchema("produce_response_v5",
+		&Array{Name: "responses", Ty: topicProduceResponseV5},
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+	)
+
+	produceResponseV6 := produceResponseV5
+	produceResponseV7 := produceResponseV5
+
+	produceResponseV8 := NewSchema("produce_response_v8",
+		&Array{Name: "responses", Ty: topicProduceResponseV8},
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+	)
+
+	produceResponseV9 := NewSchema("produce_response_v9",
+		&CompactArray{Name: "responses", Ty: topicProduceResponseSchema9},
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&SchemaTaggedFields{Name: "response_tagged_fields"},
+	)
+
+	// v10 adds current_leader partition tagged field and node_endpoints response tagged field (KIP-951)
+	produceResponseV10 := produceResponseV9
+	produceResponseV11 := produceResponseV9
+	produceResponseV12 := produceResponseV9
+
+	return []Schema{produceResponseV0, produceResponseV1, produceResponseV2, produceResponseV3, produceResponseV4, produceResponseV5, produceResponseV6, produceResponseV7, produceResponseV8, produceResponseV9,
+		produceResponseV10, produceResponseV11, produceResponseV12}
+}
+
+func createFetchResponseSchemaVersions() []Schema {
+	partitionDataV0 := NewSchema("fetch_partition_data_v0",
+		&Mfield{Name: "partition", Ty: TypeInt32},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Mfield{Name: "high_watermark", Ty: TypeInt64},
+		&Mfield{Name: "records", Ty: TypeNullableBytes},
+	)
+
+	abortedTransactionV4 := NewSchema("aborted_transaction_v4",
+		&Mfield{Name: "producer_id", Ty: TypeInt64},
+		&Mfield{Name: "first_offset", Ty: TypeInt64},
+	)
+
+	partitionDataV4 := NewSchema("fetch_partition_data_v4",
+		&Mfield{Name: "partition", Ty: TypeInt32},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Mfield{Name: "high_watermark", Ty: TypeInt64},
+		&Mfield{Name: "last_stable_offset", Ty: TypeInt64},
+		&NullableArray{Name: "aborted_transactions", Ty: abortedTransactionV4},
+		&Mfield{Name: "records", Ty: TypeNullableBytes},
+	)
+
+	partitionDataV5 := NewSchema("fetch_partition_data_v5",
+		&Mfield{Name: "partition", Ty: TypeInt32},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Mfield{Name: "high_watermark", Ty: TypeInt64},
+		&Mfield{Name: "last_stable_offset", Ty: TypeInt64},
+		&Mfield{Name: "log_start_offset", Ty: TypeInt64},
+		&NullableArray{Name: "aborted_transactions", Ty: abortedTransactionV4},
+		&Mfield{Name: "records", Ty: TypeNullableBytes},
+	)
+
+	partitionDataV11 := NewSchema("fetch_partition_data_v11",
+		&Mfield{Name: "partition", Ty: TypeInt32},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Mfield{Name: "high_watermark", Ty: TypeInt64},
+		&Mfield{Name: "last_stable_offset", Ty: TypeInt64},
+		&Mfield{Name: "log_start_offset", Ty: TypeInt64},
+		&NullableArray{Name: "aborted_transactions", Ty: abortedTransactionV4},
+		&Mfield{Name: "preferred_read_replica", Ty: TypeInt32},
+		&Mfield{Name: "records", Ty: TypeNullableBytes},
+	)
+
+	abortedTransactionSchema12 := NewSchema("aborted_transaction_schema12",
+		&Mfield{Name: "producer_id", Ty: TypeInt64},
+		&Mfield{Name: "first_offset", Ty: TypeInt64},
+		&SchemaTaggedFields{Name: "aborted_transaction_tagged_fields"},
+	)
+
+	partitionDataSchema12 := NewSchema("fetch_partition_data_schema12",
+		&Mfield{Name: "partition", Ty: TypeInt32},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Mfield{Name: "high_watermark", Ty: TypeInt64},
+		&Mfield{Name: "last_stable_offset", Ty: TypeInt64},
+		&Mfield{Name: "log_start_offset", Ty: TypeInt64},
+		&CompactNullableArray{Name: "aborted_transactions", Ty: abortedTransactionSchema12},
+		&Mfield{Name: "preferred_read_replica", Ty: TypeInt32},
+		&Mfield{Name: "records", Ty: TypeCompactNullableBytes},
+		&SchemaTaggedFields{Name: "partition_data_tagged_fields"},
+	)
+
+	fetchableTopicResponseV0 := NewSchema("fetchable_topic_response_v0",
+		&Mfield{Name: "topic", Ty: TypeStr},
+		&Array{Name: "partitions", Ty: partitionDataV0},
+	)
+
+	fetchableTopicResponseV4 := NewSchema("fetchable_topic_response_v4",
+		&Mfield{Name: "topic", Ty: TypeStr},
+		&Array{Name: "partitions", Ty: partitionDataV4},
+	)
+
+	fetchableTopicResponseV5 := NewSchema("fetchable_topic_response_v5",
+		&Mfield{Name: "topic", Ty: TypeStr},
+		&Array{Name: "partitions", Ty: partitionDataV5},
+	)
+
+	fetchableTopicResponseV11 := NewSchema("fetchable_topic_response_v11",
+		&Mfield{Name: "topic", Ty: TypeStr},
+		&Array{Name: "partitions", Ty: partitionDataV11},
+	)
+
+	fetchableTopicResponseSchema12 := NewSchema("fetchable_topic_response_schema12",
+		&Mfield{Name: "topic", Ty: TypeCompactStr},
+		&CompactArray{Name: "partitions", Ty: partitionDataSchema12},
+		&SchemaTaggedFields{Name: "fetchable_topic_response_tagged_fields"},
+	)
+
+	fetchableTopicResponseSchema13 := NewSchema("fetchable_topic_response_schema13",
+		&Mfield{Name: "topic_id", Ty: TypeUuid},
+		&CompactArray{Name: "partitions", Ty: partitionDataSchema12},
+		&SchemaTaggedFields{Name: "fetchable_topic_response_tagged_fields"},
+	)
+
+	fetchResponseV0 := NewSchema("fetch_response_v0",
+		&Array{Name: "responses", Ty: fetchableTopicResponseV0},
+	)
+
+	fetchResponseV1 := NewSchema("fetch_response_v1",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&Array{Name: "responses", Ty: fetchableTopicResponseV0},
+	)
+
+	fetchResponseV2 := fetchResponseV1
+	fetchResponseV3 := fetchResponseV1
+
+	fetchResponseV4 := NewSchema("fetch_response_v4",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&Array{Name: "responses", Ty: fetchableTopicResponseV4},
+	)
+
+	fetchResponseV5 := NewSchema("fetch_response_v5",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&Array{Name: "responses", Ty: fetchableTopicResponseV5},
+	)
+
+	fetchResponseV6 := fetchResponseV5
+
+	fetchResponseV7 := NewSchema("fetch_response_v7",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Mfield{Name: "session_id", Ty: TypeInt32},
+		&Array{Name: "responses", Ty: fetchableTopicResponseV5},
+	)
+
+	fetchResponseV8 := fetchResponseV7
+	fetchResponseV9 := fetchResponseV7
+	fetchResponseV10 := fetchResponseV7
+
+	fetchResponseV11 := NewSchema("fetch_response_v11",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Mfield{Name: "session_id", Ty: TypeInt32},
+		&Array{Name: "responses", Ty: fetchableTopicResponseV11},
+	)
+
+	fetchResponseV12 := NewSchema("fetch_response_v12",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Mfield{Name: "session_id", Ty: TypeInt32},
+		&CompactArray{Name: "responses", Ty: fetchableTopicResponseSchema12},
+		&SchemaTaggedFields{Name: "response_tagged_fields"},
+	)
+
+	// topics are identified by topic id
+	fetchResponseV13 := NewSchema("fetch_response_v13",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Mfield{Name: "session_id", Ty: TypeInt32},
+		&CompactArray{Name: "responses", Ty: fetchableTopicResponseSchema13},
+		&SchemaTaggedFields{Name: "response_tagged_fields"},
+	)
+
+	fetchResponseV14 := fetchResponseV13
+	fetchResponseV15 := fetchResponseV13
+	// v16 adds node_endpoints response tagged field (KIP-951)
+	fetchResponseV16 := fetchResponseV13
+	fetchResponseV17 := fetchResponseV13
+
+	return []Schema{fetchResponseV0, fetchResponseV1, fetchResponseV2, fetchResponseV3, fetchResponseV4, fetchResponseV5, fetchResponseV6, fetchResponseV7, fetchResponseV8, fetchResponseV9, fetchResponseV10, fetchResponseV11, fetchResponseV12,
+		fetchResponseV13, fetchResponseV14, fetchResponseV15, fetchResponseV16, fetchResponseV17}
+}
+
+func createListOffsetsResponseSchemaVersions() []Schema {
+	listOffsetsPartitionResponseV0 := NewSchema("list_offsets_partition_response_v0",
+		&Mfield{Name: "partition_index", Ty: TypeInt32},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Array{Name: "old_style_offsets", Ty: TypeInt64},
+	)
+
+	listOffsetsPartitionResponseV1 := NewSchema("list_offsets_partition_response_v1",
+		&Mfield{Name: "partition_index", Ty: TypeInt32},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Mfield{Name: "timestamp", Ty: TypeInt64},
+		&Mfield{Name: "offset", Ty: TypeInt64},
+	)
+
+	listOffsetsPartitionResponseV4 := NewSchema("list_offsets_partition_response_v4",
+		&Mfield{Name: "partition_index", Ty: TypeInt32},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Mfield{Name: "timestamp", Ty: TypeInt64},
+		&Mfield{Name: "offset", Ty: TypeInt64},
+		&Mfield{Name: "leader_epoch", Ty: TypeInt32},
+	)
+
+	listOffsetsPartitionResponseSchema6 := NewSchema("list_offsets_partition_response_schema6",
+		&Mfield{Name: "partition_index", Ty: TypeInt32},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&Mfield{Name: "timestamp", Ty: TypeInt64},
+		&Mfield{Name: "offset", Ty: TypeInt64},
+		&Mfield{Name: "leader_epoch", Ty: TypeInt32},
+		&SchemaTaggedFields{Name: "partition_tagged_fields"},
+	)
+
+	listOffsetsTopicResponseV0 := NewSchema("list_offsets_topic_response_v0",
+		&Mfield{Name: "name", Ty: TypeStr},
+		&Array{Name: "partitions", Ty: listOffsetsPartitionResponseV0},
+	)
+
+	listOffsetsTopicResponseV1 := NewSchema("list_offsets_topic_response_v1",
+		&Mfield{Name: "name", Ty: TypeStr},
+		&Array{Name: "partitions", Ty: listOffsetsPartitionResponseV1},
+	)
+
+	listOffsetsTopicResponseV4 := NewSchema("list_offsets_topic_response_v4",
+		&Mfield{Name: "name", Ty: TypeStr},
+		&Array{Name: "partitions", Ty: listOffsetsPartitionResponseV4},
+	)
+
+	listOffsetsTopicResponseSchema6 := NewSchema("list_offsets_topic_response_schema6",
+		&Mfield{Name: "name", Ty: TypeCompactStr},
+		&CompactArray{Name: "partitions", Ty: listOffsetsPartitionResponseSchema6},
+		&SchemaTaggedFields{Name: "topic_tagged_fields"},
+	)
+
+	listOffsetsResponseV0 := NewSchema("list_offsets_response_v0",
+		&Array{Name: "topics", Ty: listOffsetsTopicResponseV0},
+	)
+
+	listOffsetsResponseV1 := NewSchema("list_offsets_response_v1",
+		&Array{Name: "topics", Ty: listOffsetsTopicResponseV1},
+	)
+
+	listOffsetsResponseV2 := NewSchema("list_offsets_response_v2",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&Array{Name: "topics", Ty: listOffsetsTopicResponseV1},
+	)
+
+	listOffsetsResponseV3 := listOffsetsResponseV2
+
+	listOffsetsResponseV4 := NewSchema("list_offsets_response_v4",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&Array{Name: "topics", Ty: listOffsetsTopicResponseV4},
+	)
+
+	listOffsetsResponseV5 := listOffsetsResponseV4
+
+	listOffsetsResponseV6 := NewSchema("list_offsets_response_v6",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&CompactArray{Name: "topics", Ty: listOffsetsTopicResponseSchema6},
+		&SchemaTaggedFields{Name: "response_tagged_fields"},
+	)
+
+	listOffsetsResponseV7 := listOffsetsResponseV6
+
+	return []Schema{listOffsetsResponseV0, listOffsetsResponseV1, listOffsetsResponseV2, listOffsetsResponseV3, listOffsetsResponseV4, listOffsetsResponseV5, listOffsetsResponseV6, listOffsetsResponseV7}
+}
+
+func createOffsetCommitResponseSchemaVersions() []Schema {
+	offsetCommitPartitionResponseV0 := NewSchema("offset_commit_partition_response_v0",
+		&Mfield{Name: "partition_index", Ty: TypeInt32},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+	)
+
+	offsetCommitPartitionResponseSchema8 := NewSchema("offset_commit_partition_response_schema8",
+		&Mfield{Name: "partition_index", Ty: TypeInt32},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&SchemaTaggedFields{Name: "partition_tagged_fields"},
+	)
+
+	offsetCommitTopicResponseV0 := NewSchema("offset_commit_topic_response_v0",
+		&Mfield{Name: "name", Ty: TypeStr},
+		&Array{Name: "partitions", Ty: offsetCommitPartitionResponseV0},
+	)
+
+	offsetCommitTopicResponseSchema8 := NewSchema("offset_commit_topic_response_schema8",
+		&Mfield{Name: "name", Ty: TypeCompactStr},
+		&CompactArray{Name: "partitions", Ty: offsetCommitPartitionResponseSchema8},
+		&SchemaTaggedFields{Name: "topic_tagged_fields"},
+	)
+
+	offsetCommitResponseV0 := NewSchema("offset_commit_response_v0",
+		&Array{Name: "topics", Ty: offsetCommitTopicResponseV0},
+	)
+
+	offsetCommitResponseV1 := offsetCommitResponseV0
+	offsetCommitResponseV2 := offsetCommitResponseV0
+
+	offsetCommitResponseV3 := NewSchema("offset_commit_response_v3",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&Array{Name: "topics", Ty: offsetCommitTopicResponseV0},
+	)
+
+	offsetCommitResponseV4 := offsetCommitResponseV3
+	offsetCommitResponseV5 := offsetCommitResponseV3
+	offsetCommitResponseV6 := offsetCommitResponseV3
+	offsetCommitResponseV7 := offsetCommitResponseV3
+
+	offsetCommitResponseV8 := NewSchema("offset_commit_response_v8",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&CompactArray{Name: "topics", Ty: offsetCommitTopicResponseSchema8},
+		&SchemaTaggedFields{Name: "response_tagged_fields"},
+	)
+
+	return []Schema{offsetCommitResponseV0, offsetCommitResponseV1, offsetCommitResponseV2, offsetCommitResponseV3, offsetCommitResponseV4, offsetCommitResponseV5, offsetCommitResponseV6, offsetCommitResponseV7, offsetCommitResponseV8}
+}
+
+func createOffsetFetchResponseSchemaVersions() []Schema {
+	offsetFetchPartitionResponseV0 := NewSchema("offset_fetch_partition_response_v0",
+		&Mfield{Name: "partition_index", Ty: TypeInt32},
+		&Mfield{Name: "committed_offset", Ty: TypeInt64},
+		&Mfield{Name: "metadata", Ty: TypeNullableStr},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+	)
+
+	offsetFetchPartitionResponseV5 := NewSchema("offset_fetch_partition_response_v5",
+		&Mfield{Name: "partition_index", Ty: TypeInt32},
+		&Mfield{Name: "committed_offset", Ty: TypeInt64},
+		&Mfield{Name: "committed_leader_epoch", Ty: TypeInt32},
+		&Mfield{Name: "metadata", Ty: TypeNullableStr},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+	)
+
+	offsetFetchPartitionResponseSchema6 := NewSchema("offset_fetch_partition_response_schema6",
+		&Mfield{Name: "partition_index", Ty: TypeInt32},
+		&Mfield{Name: "committed_offset", Ty: TypeInt64},
+		&Mfield{Name: "committed_leader_epoch", Ty: TypeInt32},
+		&Mfield{Name: "metadata", Ty: TypeCompactNullableStr},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&SchemaTaggedFields{Name: "partition_tagged_fields"},
+	)
+
+	offsetFetchTopicResponseV0 := NewSchema("offset_fetch_topic_response_v0",
+		&Mfield{Name: "name", Ty: TypeStr},
+		&Array{Name: "partitions", Ty: offsetFetchPartitionResponseV0},
+	)
+
+	offsetFetchTopicResponseV5 := NewSchema("offset_fetch_topic_response_v5",
+		&Mfield{Name: "name", Ty: TypeStr},
+		&Array{Name: "partitions", Ty: offsetFetchPartitionResponseV5},
+	)
+
+	offsetFetchTopicResponseSchema6 := NewSchema("offset_fetch_topic_response_schema6",
+		&Mfield{Name: "name", Ty: TypeCompactStr},
+		&CompactArray{Name: "partitions", Ty: offsetFetchPartitionResponseSchema6},
+		&SchemaTaggedFields{Name: "topic_tagged_fields"},
+	)
+
+	offsetFetchResponseV0 := NewSchema("offset_fetch_response_v0",
+		&Array{Name: "topics", Ty: offsetFetchTopicResponseV0},
+	)
+
+	offsetFetchResponseV1 := offsetFetchResponseV0
+
+	offsetFetchResponseV2 := NewSchema("offset_fetch_response_v2",
+		&Array{Name: "topics", Ty: offsetFetchTopicResponseV0},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+	)
+
+	offsetFetchResponseV3 := NewSchema("offset_fetch_response_v3",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&Array{Name: "topics", Ty: offsetFetchTopicResponseV0},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+	)
+
+	offsetFetchResponseV4 := offsetFetchResponseV3
+
+	offsetFetchResponseV5 := NewSchema("offset_fetch_response_v5",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&Array{Name: "topics", Ty: offsetFetchTopicResponseV5},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+	)
+
+	offsetFetchResponseV6 := NewSchema("offset_fetch_response_v6",
+		&Mfield{Name: "throttle_time_ms", Ty: TypeInt32},
+		&CompactArray{Name: "topics", Ty: offsetFetchTopicResponseSchema6},
+		&Mfield{Name: "error_code", Ty: TypeInt16},
+		&SchemaTaggedFields{Name: "response_tagged_fields"},
+	)
+
+	offsetFetchResponseV7 := offsetFetchResponseV6
+
+	return []Schema{offsetFetchResponseV0, offsetFetchResponseV1, offsetFetchResponseV2, offsetFetchResponseV3, offsetFetchResponseV4, offsetFetchResponseV5, offsetFetchResponseV6, offsetFetchResponseV7}
+}
+
+func getResponseSchemaVersions(apiKey int16) []Schema {
+	switch apiKey {
+	case apiKeyProduce:
+		return produceResponseSchemaVersions
+	case apiKeyFetch:
+		return fetchResponseSchemaVersions
+	case apiKeyListOffsets:
+		return listOffsetsResponseSchemaVersions
+	case apiKeyMetadata:
+		return metadataResponseSchemaVersions
+	case apiKeyOffsetCommit:
+		return offsetCommitResponseSchemaVersions
+	case apiKeyOffsetFetch:
+		return offsetFetchResponseSchemaVersions
+	case apiKeyFindCoordinator:
+		return findCoordinatorResponseSchemaVersions
+	case apiKeyApiVersions:
+		return apiVersionsResponseSchemaVersions
+	case apiKeyDescribeCluster:
+		return describeClusterResponseSchemaVersions
+	case apiKeyDescribeGroups:
+		return describeGroupsResponseSchemaVersions
+	case apiKeyListGroups:
+		return listGroupsResponseSchemaVersions
+	case apiKeyOffsetForLeaderEpoch:
+		return offsetForLeaderEpochResponseSchemaVersions
+	case apiKeyAddPartitionsToTxn:
+		return addPartitionsToTxnResponseSchemaVersions
+	case apiKeyTxnOffsetCommit:
+		return txnOffsetCommitResponseSchemaVersions
+	case apiKeyDeleteGroups:
+		return deleteGroupsResponseSchemaVersions
+	default:
+		return nil
+	}
+}
@@ -9,13 +9,20 @@ import (
 )
 
 var (
-	TypeBool               = &Bool{}
-	TypeInt16              = &Int16{}
-	TypeInt32              = &Int32{}
-	TypeStr                = &Str{}
-	TypeNullableStr        = &NullableStr{}
-	TypeCompactStr         = &CompactStr{}
-	TypeCompactNullableStr = &CompactNullableStr{}
+	TypeBool                 = &Bool{}
+	TypeInt8                 = &Int8{}
+	TypeInt16                = &Int16{}
+	TypeInt32                = &Int32{}
+	TypeInt64                = &Int64{}
+	TypeStr                  = &Str{}
+	TypeNullableStr          = &NullableStr{}
+	TypeCompactStr           = &CompactStr{}
+	TypeCompactNullableStr   = &CompactNullableStr{}
+	TypeBytes                = &Bytes{}
+	TypeCompactBytes         = &CompactBytes{}
+	TypeNullableBytes        = &NullableBytes{}
+	TypeCompactNullableBytes = &CompactNullableBytes{}
+	TypeUuid                 = &Uuid{}
 )
 
 type EncoderDecoder interface {
@@ -102,6 +109,35 @@ func (f *Bool) GetName() string {
 	return "bool"
 }
 
+// Field int8
+
+type Int8 struct{}
+
+func (f *Int8) decode(pd packetDecoder) (interface{}, error) {
+	return pd.getInt8()
+}
+
+func (f *Int8) encode(pe packetEncoder, value interface{}) error {
+	in, ok := value.(int8)
+	if !ok {
+		return SchemaEncodingError{fmt.Sprintf("value %T not a int8", value)}
+	}
+	pe.putInt8(in)
+	return nil
+}
+
+func (f *Int8) GetFields() []boundField {
+	return nil
+}
+
+func (f *Int8) GetFieldsByName() map[string]*boundField {
+	return nil
+}
+
+func (f *Int8) GetName() string {
+	return "int8"
+}
+
 // Field int16
 
 type Int16 struct{}
@@ -159,6 +195,35 @@ func (f *Int32) GetName() string {
 	return "int32"
 }
 
+// Field int64
+
+type Int64 struct{}
+
+func (f *Int64) decode(pd packetDecoder) (interface{}, error) {
+	return pd.getInt64()
+}
+
+func (f *Int64) encode(pe packetEncoder, value interface{}) error {
+	in, ok := value.(int64)
+	if !ok {
+		return SchemaEncodingError{fmt.Sprintf("value %T not a int64", value)}
+	}
+	pe.putInt64(in)
+	return nil
+}
+
+func (f *Int64) GetFields() []boundField {
+	return nil
+}
+
+func (f *Int64) GetFieldsByName() map[string]*boundField {
+	return nil
+}
+
+func (f *Int64) GetName() string {
+	return "int64"
+}
+
 // Field string
 
 type Str struct {
@@ -198,9 +263,7 @@ func (f *NullableStr) decode(pd packetDecoder) (interface{}, error) {
 
 func (f *NullableStr) encode(pe packetEncoder, value interface{}) error {
 	if value == nil {
-		if err := pe.putNullableString(nil); err != nil {
-			return err
-		}
+		return pe.putNullableString(nil)
 	}
 
 	in, ok := value.(*string)
@@ -261,9 +324,7 @@ func (f *CompactNullableStr) decode(pd packetDecoder) (interface{}, error) {
 
 func (f *CompactNullableStr) encode(pe packetEncoder, value interface{}) error {
 	if value == nil {
-		if err := pe.putCompactNullableString(nil); err != nil {
-			return err
-		}
+		return pe.putCompactNullableString(nil)
 	}
 
 	in, ok := value.(*string)
@@ -285,6 +346,158 @@ func (f *CompactNullableStr) GetName() string {
 	return "compactnullablestr"
 }
 
+// Field bytes
+
+type Bytes struct{}
+
+func (f *Bytes) decode(pd packetDecoder) (interface{}, error) {
+	return pd.getBytes()
+}
+
+func (f *Bytes) encode(pe packetEncoder, value interface{}) error {
+	in, ok := value.([]byte)
+	if !ok {
+		return SchemaEncodingError{fmt.Sprintf("value %T not a []byte", value)}
+	}
+	return pe.putBytes(in)
+}
+
+func (f *Bytes) GetFields() []boundField {
+	return nil
+}
+
+func (f *Bytes) GetFieldsByName() map[string]*boundField {
+	return nil
+}
+
+func (f *Bytes) GetName() string {
+	return "bytes"
+}
+
+// Field compact bytes
+
+type CompactBytes struct{}
+
+func (f *CompactBytes) decode(pd packetDecoder) (interface{}, error) {
+	return pd.getCompactBytes()
+}
+
+func (f *CompactBytes) encode(pe packetEncoder, value interface{}) error {
+	in, ok := value.([]byte)
+	if !ok {
+		return SchemaEncodingError{fmt.Sprintf("value %T not a []byte", value)}
+	}
+	return pe.putCompactBytes(in)
+}
+
+func (f *CompactBytes) GetFields() []boundField {
+	return nil
+}
+
+func (f *CompactBytes) GetFieldsByName() map[string]*boundField {
+	return nil
+}
+
+func (f *CompactBytes) GetName() string {
+	return "compactbytes"
+}
+
+// Field nullable bytes (e.g. records)
+
+type NullableBytes struct{}
+
+func (f *NullableBytes) decode(pd packetDecoder) (interface{}, error) {
+	return pd.getBytes()
+}
+
+func (f *NullableBytes) encode(pe packetEncoder, value interface{}) error {
+	if value == nil {
+		return pe.putBytes(nil)
+	}
+	in, ok := value.([]byte)
+	if !ok {
+		return SchemaEncodingError{fmt.Sprintf("value %T not a []byte", value)}
+	}
+	return pe.putBytes(in)
+}
+
+func (f *NullableBytes) GetFields() []boundField {
+	return nil
+}
+
+func (f *NullableBytes) GetFieldsByName() map[string]*boundField {
+	return nil
+}
+
+func (f *NullableBytes) GetName() string {
+	return "nullablebytes"
+}
+
+// Field compact nullable bytes (e.g. compact records)
+
+type CompactNullableBytes struct{}
+
+func (f *CompactNullableBytes) decode(pd packetDecoder) (interface{}, error) {
+	return pd.getCompactNullableBytes()
+}
+
+func (f *CompactNullableBytes) encode(pe packetEncoder, value interface{}) error {
+	if value == nil {
+		return pe.putCompactNullableBytes(nil)
+	}
+	in, ok := value.([]byte)
+	if !ok {
+		return SchemaEncodingError{fmt.Sprintf("value %T not a []byte", value)}
+	}
+	return pe.putCompactNullableBytes(in)
+}
+
+func (f *CompactNullableBytes) GetFields() []boundField {
+	return nil
+}
+
+func (f *CompactNullableBytes) GetFieldsByName() map[string]*boundField {
+	return nil
+}
+
+func (f *CompactNullableBytes) GetName() string {
+	return "compactnullablebytes"
+}
+
+// Field UUID, 16 bytes
+
+type Uuid struct{}
+
+func (f *Uuid) decode(pd packetDecoder) (interface{}, error) {
+	raw, err := pd.getRawBytes(16)
+	if err != nil {
+		return nil, err
+	}
+	var result [16]byte
+	copy(result[:], raw)
+	return result, nil
+}
+
+func (f *Uuid) encode(pe packetEncoder, value interface{}) error {
+	in, ok := value.([16]byte)
+	if !ok {
+		return SchemaEncodingError{fmt.Sprintf("value %T not a [16]byte", value)}
+	}
+	return pe.putRawBytes(in[:])
+}
+
+func (f *Uuid) GetFields() []boundField {
+	return nil
+}
+
+func (f *Uuid) GetFieldsByName() map[string]*boundField {
+	return nil
+}
+
+func (f *Uuid) GetName() string {
+	return "uuid"
+}
+
 // Arrays helper
 
 func encodeArrayElements(in []interface{}, elementEncode func(pe packetEncoder, value interface{}) error, pe packetEncoder) (err error) {
@@ -408,6 +621,47 @@ func (f *Array) GetSchema() Schema {
 	return f.Ty
 }
 
+// Nullable Array
+
+type NullableArray struct {
+	Name string
+	Ty   Schema
+}
+
+func (f *NullableArray) decode(pd packetDecoder) (interface{}, error) {
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return nil, err
+	}
+	if n == -1 {
+		return nil, nil
+	}
+	return decodeArrayElements(n, f.Ty.decode, pd)
+}
+
+func (f *NullableArray) encode(pe packetEncoder, value interface{}) error {
+	if value == nil {
+		return pe.putArrayLength(-1)
+	}
+	in, ok := value.([]interface{})
+	if !ok {
+		return SchemaEncodingError{fmt.Sprintf("value %T not a []interface{}", value)}
+	}
+	err := pe.putArrayLength(len(in))
+	if err != nil {
+		return err
+	}
+	return encodeArrayElements(in, f.Ty.encode, pe)
+}
+
+func (f *NullableArray) GetName() string {
+	return f.Name
+}
+
+func (f *NullableArray) GetSchema() Schema {
+	return f.Ty
+}
+
 // Compact Array
 
 type CompactArray struct {
@@ -450,7 +704,7 @@ func (f *CompactArray) GetSchema() Schema {
 
 type CompactNullableArray struct {
 	Name string
-	Ty   EncoderDecoder
+	Ty   Schema
 }
 
 func (f *CompactNullableArray) decode(pd packetDecoder) (interface{}, error) {
@@ -484,6 +738,10 @@ func (f *CompactNullableArray) GetName() string {
 	return f.Name
 }
 
+func (f *CompactNullableArray) GetSchema() Schema {
+	return f.Ty
+}
+
 type Struct struct {
 	Schema Schema
 	Values []interface{}
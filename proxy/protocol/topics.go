@@ -0,0 +1,375 @@
+package protocol
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	errorCodeKeyName = "error_code"
+)
+
+// topicsSpec describes where topics and partitions are located in the request and response of an api key
+type topicsSpec struct {
+	requestTopics      string
+	requestPartitions  string
+	requestPartition   string // empty when partitions are an array of INT32
+	responseTopics     string
+	responsePartitions string
+	responsePartition  string // empty when the error code is set on the topic level
+}
+
+var topicsSpecs = map[int16]topicsSpec{
+	apiKeyProduce:      {requestTopics: "topic_data", requestPartitions: "partition_data", requestPartition: "index", responseTopics: "responses", responsePartitions: "partition_responses", responsePartition: "index"},
+	apiKeyFetch:        {requestTopics: "topics", requestPartitions: "partitions", requestPartition: "partition", responseTopics: "responses", responsePartitions: "partitions", responsePartition: "partition"},
+	apiKeyListOffsets:  {requestTopics: "topics", requestPartitions: "partitions", requestPartition: "partition_index", responseTopics: "topics", responsePartitions: "partitions", responsePartition: "partition_index"},
+	apiKeyMetadata:     {requestTopics: "topics", responseTopics: "topic_metadata", responsePartitions: "partition_metadata"},
+	apiKeyOffsetCommit: {requestTopics: "topics", requestPartitions: "partitions", requestPartition: "partition_index", responseTopics: "topics", responsePartitions: "partitions", responsePartition: "partition_index"},
+	apiKeyOffsetFetch:  {requestTopics: "topics", requestPartitions: "partition_indexes", responseTopics: "topics", responsePartitions: "partitions", responsePartition: "partition_index"},
+}
+
+// fields which default value is -1 (or MinInt32 for authorized operations) instead of 0
+var defaultFieldValues = map[string]interface{}{
+	"base_offset":                 int64(-1),
+	"log_append_time_ms":          int64(-1),
+	"log_start_offset":            int64(-1),
+	"high_watermark":              int64(-1),
+	"last_stable_offset":          int64(-1),
+	"preferred_read_replica":      int32(-1),
+	"offset":                      int64(-1),
+	"timestamp":                   int64(-1),
+	"leader_epoch":                int32(-1),
+	"committed_offset":            int64(-1),
+	"committed_leader_epoch":      int32(-1),
+	"topic_authorized_operations": int32(math.MinInt32),
+}
+
+// TopicPartitions is a topic with partitions referenced by a request
+type TopicPartitions struct {
+	// Topic is empty for the topics referenced only by TopicID
+	Topic      string
+	TopicID    [16]byte
+	Partitions []int32
+}
+
+// IsTopicsApiKey returns true if topics of the request and response with the api key can be inspected
+func IsTopicsApiKey(apiKey int16) bool {
+	_, ok := topicsSpecs[apiKey]
+	return ok
+}
+
+// Topics returns the topics referenced by the request. allTopics is true if the request refers to all topics (e.g. Metadata or OffsetFetch with null topics)
+func (r *DecodedRequest) Topics() (topics []TopicPartitions, allTopics bool, err error) {
+	spec, ok := topicsSpecs[r.ApiKey]
+	if !ok {
+		return nil, false, fmt.Errorf("topics of api key %d are not supported", r.ApiKey)
+	}
+	value := r.Body.Get(spec.requestTopics)
+	if value == nil {
+		return nil, true, nil
+	}
+	elements, ok := value.([]interface{})
+	if !ok {
+		return nil, false, SchemaDecodingError{fmt.Sprintf("topics field %s is not an array", spec.requestTopics)}
+	}
+	if r.ApiKey == apiKeyMetadata && r.ApiVersion == 0 && len(elements) == 0 {
+		return nil, true, nil
+	}
+	topics = make([]TopicPartitions, 0, len(elements))
+	for _, element := range elements {
+		topic, err := requestTopicPartitions(element, spec)
+		if err != nil {
+			return nil, false, err
+		}
+		topics = append(topics, topic)
+	}
+	return topics, false, nil
+}
+
+// RemoveTopics removes topics for which the remove function returns true. The removed topics are returned.
+func (r *DecodedRequest) RemoveTopics(remove func(topic string) bool) ([]TopicPartitions, error) {
+	spec, ok := topicsSpecs[r.ApiKey]
+	if !ok {
+		return nil, fmt.Errorf("topics of api key %d are not supported", r.ApiKey)
+	}
+	value := r.Body.Get(spec.requestTopics)
+	if value == nil {
+		return nil, nil
+	}
+	elements, ok := value.([]interface{})
+	if !ok {
+		return nil, SchemaDecodingError{fmt.Sprintf("topics field %s is not an array", spec.requestTopics)}
+	}
+	kept := make([]interface{}, 0, len(elements))
+	removed := make([]TopicPartitions, 0)
+	for _, element := range elements {
+		topic, err := requestTopicPartitions(element, spec)
+		if err != nil {
+			return nil, err
+		}
+		if remove(topic.Topic) {
+			removed = append(removed, topic)
+		} else {
+			kept = append(kept, element)
+		}
+	}
+	if len(removed) == 0 {
+		return removed, nil
+	}
+	return removed, r.Body.Replace(spec.requestTopics, kept)
+}
+
+func requestTopicPartitions(element interface{}, spec topicsSpec) (TopicPartitions, error) {
+	s, ok := element.(*Struct)
+	if !ok {
+		return TopicPartitions{}, SchemaDecodingError{fmt.Sprintf("topic element %T is not a *Struct", element)}
+	}
+	name, err := topicName(s)
+	if err != nil {
+		return TopicPartitions{}, err
+	}
+	result := TopicPartitions{Topic: name}
+	if name == "" {
+		result.TopicID, _ = s.Get("topic_id").([16]byte)
+	}
+	if spec.requestPartitions == "" {
+		return result, nil
+	}
+	partitions, _ := s.Get(spec.requestPartitions).([]interface{})
+	for _, partition := range partitions {
+		if spec.requestPartition == "" {
+			if index, ok := partition.(int32); ok {
+				result.Partitions = append(result.Partitions, index)
+			}
+			continue
+		}
+		if ps, ok := partition.(*Struct); ok {
+			if index, ok := ps.Get(spec.requestPartition).(int32); ok {
+				result.Partitions = append(result.Partitions, index)
+			}
+		}
+	}
+	return result, nil
+}
+
+func topicName(s *Struct) (string, error) {
+	for _, key := range []string{"name", "topic"} {
+		switch value := s.Get(key).(type) {
+		case string:
+			return value, nil
+		case *string:
+			// null name of a topic referenced by topic id
+			if value == nil {
+				return "", nil
+			}
+			return *value, nil
+		}
+	}
+	return "", SchemaDecodingError{fmt.Sprintf("topic name not found in %s", s.GetSchema().GetName())}
+}
+
+// NewTopicsErrorResponse creates the response body in which all given topics and partitions have the error code set
+func NewTopicsErrorResponse(apiKey, apiVersion int16, topics []TopicPartitions, kerr KError) ([]byte, error) {
+	schema, err := getResponseSchema(apiKey, apiVersion, getResponseSchemaVersions(apiKey))
+	if err != nil {
+		return nil, err
+	}
+	body, err := newDefaultStruct(schema)
+	if err != nil {
+		return nil, err
+	}
+	if err = addResponseTopicErrors(apiKey, body, topics, kerr); err != nil {
+		return nil, err
+	}
+	return EncodeSchema(body, schema)
+}
+
+// NewTopicsResponseModifier creates the response modifier which removes topics for which the remove function returns true
+// and adds entries with the error code for the given topics. The remove function can be nil.
+func NewTopicsResponseModifier(apiKey, apiVersion int16, remove func(topic string) bool, errorTopics []TopicPartitions, kerr KError) (ResponseModifier, error) {
+	if _, ok := topicsSpecs[apiKey]; !ok {
+		return nil, fmt.Errorf("topics of api key %d are not supported", apiKey)
+	}
+	schema, err := getResponseSchema(apiKey, apiVersion, getResponseSchemaVersions(apiKey))
+	if err != nil {
+		return nil, err
+	}
+	return &structResponseModifier{
+		schema: schema,
+		modifyFunc: func(body *Struct) error {
+			if remove != nil {
+				if err := removeResponseTopics(apiKey, body, remove); err != nil {
+					return err
+				}
+			}
+			return addResponseTopicErrors(apiKey, body, errorTopics, kerr)
+		},
+	}, nil
+}
+
+type structResponseModifier struct {
+	schema     Schema
+	modifyFunc func(body *Struct) error
+}
+
+func (f *structResponseModifier) Apply(resp []byte) ([]byte, error) {
+	decodedStruct, err := DecodeSchema(resp, f.schema)
+	if err != nil {
+		return nil, err
+	}
+	if err = f.modifyFunc(decodedStruct); err != nil {
+		return nil, err
+	}
+	return EncodeSchema(decodedStruct, f.schema)
+}
+
+func removeResponseTopics(apiKey int16, body *Struct, remove func(topic string) bool) error {
+	spec := topicsSpecs[apiKey]
+	elements, ok := body.Get(spec.responseTopics).([]interface{})
+	if !ok {
+		return SchemaDecodingError{fmt.Sprintf("topics field %s is not an array", spec.responseTopics)}
+	}
+	kept := make([]interface{}, 0, len(elements))
+	for _, element := range elements {
+		s, ok := element.(*Struct)
+		if !ok {
+			return SchemaDecodingError{fmt.Sprintf("topic element %T is not a *Struct", element)}
+		}
+		name, err := topicName(s)
+		if err != nil {
+			return err
+		}
+		if !remove(name) {
+			kept = append(kept, element)
+		}
+	}
+	return body.Replace(spec.responseTopics, kept)
+}
+
+func addResponseTopicErrors(apiKey int16, body *Struct, topics []TopicPartitions, kerr KError) error {
+	if len(topics) == 0 {
+		return nil
+	}
+	spec := topicsSpecs[apiKey]
+	topicsField := body.GetSchema().GetFieldsByName()[spec.responseTopics]
+	if topicsField == nil {
+		return SchemaEncodingError{fmt.Sprintf("topics field %s not found", spec.responseTopics)}
+	}
+	elements, ok := body.Get(spec.responseTopics).([]interface{})
+	if !ok {
+		return SchemaDecodingError{fmt.Sprintf("topics field %s is not an array", spec.responseTopics)}
+	}
+	topicSchema := topicsField.def.GetSchema()
+	for _, topic := range topics {
+		element, err := newDefaultStruct(topicSchema)
+		if err != nil {
+			return err
+		}
+		if err = setTopicName(element, topic.Topic); err != nil {
+			return err
+		}
+		if topic.TopicID != ([16]byte{}) && topicSchema.GetFieldsByName()["topic_id"] != nil {
+			if err = element.Replace("topic_id", topic.TopicID); err != nil {
+				return err
+			}
+		}
+		if spec.responsePartition == "" {
+			if err = element.Replace(errorCodeKeyName, int16(kerr)); err != nil {
+				return err
+			}
+		} else {
+			partitionsField := topicSchema.GetFieldsByName()[spec.responsePartitions]
+			if partitionsField == nil {
+				return SchemaEncodingError{fmt.Sprintf("partitions field %s not found", spec.responsePartitions)}
+			}
+			partitions := make([]interface{}, 0, len(topic.Partitions))
+			for _, index := range topic.Partitions {
+				partition, err := newDefaultStruct(partitionsField.def.GetSchema())
+				if err != nil {
+					return err
+				}
+				if err = partition.Replace(spec.responsePartition, index); err != nil {
+					return err
+				}
+				if err = partition.Replace(errorCodeKeyName, int16(kerr)); err != nil {
+					return err
+				}
+				partitions = append(partitions, partition)
+			}
+			if err = element.Replace(spec.responsePartitions, partitions); err != nil {
+				return err
+			}
+		}
+		elements = append(elements, element)
+	}
+	return body.Replace(spec.responseTopics, elements)
+}
+
+func setTopicName(s *Struct, name string) error {
+	for _, key := range []string{"name", "topic"} {
+		if s.GetSchema().GetFieldsByName()[key] != nil {
+			if _, ok := s.Get(key).(*string); ok {
+				if name == "" {
+					// topic referenced by topic id
+					return s.Replace(key, (*string)(nil))
+				}
+				return s.Replace(key, &name)
+			}
+			return s.Replace(key, name)
+		}
+	}
+	return SchemaEncodingError{fmt.Sprintf("topic name not found in %s", s.GetSchema().GetName())}
+}
+
+// newDefaultStruct creates a struct with default values for all fields of the schema
+func newDefaultStruct(schema Schema) (*Struct, error) {
+	values := make([]interface{}, 0, len(schema.GetFields()))
+	for _, field := range schema.GetFields() {
+		value, err := defaultFieldValue(field.def)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return &Struct{Schema: schema, Values: values}, nil
+}
+
+func defaultFieldValue(field Field) (interface{}, error) {
+	if value, ok := defaultFieldValues[field.GetName()]; ok {
+		return value, nil
+	}
+	switch f := field.(type) {
+	case *Array, *CompactArray:
+		return make([]interface{}, 0), nil
+	case *NullableArray, *CompactNullableArray:
+		return nil, nil
+	case *SchemaTaggedFields, SchemaTaggedFields:
+		return make([]rawTaggedField, 0), nil
+	case *Mfield:
+		switch f.Ty.(type) {
+		case *Bool:
+			return false, nil
+		case *Int8:
+			return int8(0), nil
+		case *Int16:
+			return int16(0), nil
+		case *Int32:
+			return int32(0), nil
+		case *Int64:
+			return int64(0), nil
+		case *Str, *CompactStr:
+			return "", nil
+		case *NullableStr, *CompactNullableStr:
+			return (*string)(nil), nil
+		case *Bytes, *CompactBytes:
+			return make([]byte, 0), nil
+		case *Uuid:
+			return [16]byte{}, nil
+		case *NullableBytes, *CompactNullableBytes:
+			return []byte(nil), nil
+		case *schema:
+			return newDefaultStruct(f.Ty)
+		}
+	}
+	return nil, SchemaEncodingError{fmt.Sprintf("no default value for field %s", field.GetName())}
+}
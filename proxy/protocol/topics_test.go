@@ -0,0 +1,221 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encodeTestRequest(t *testing.T, apiKey, apiVersion int16, correlationID int32, body *Struct) []byte {
+	header := make([]byte, 0)
+	header = append(header, byte(apiKey>>8), byte(apiKey), byte(apiVersion>>8), byte(apiVersion))
+	header = append(header, 0, 0, 0, byte(correlationID))
+	clientID := "client"
+	header = append(header, 0, byte(len(clientID)))
+	header = append(header, clientID...)
+	requestKeyVersion := &RequestKeyVersion{ApiKey: apiKey, ApiVersion: apiVersion}
+	if requestKeyVersion.RequestHeaderVersion() >= 2 {
+		header = append(header, 0)
+	}
+	schema, err := GetRequestSchema(apiKey, apiVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bodyBuf, err := EncodeSchema(body, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(header, bodyBuf...)
+}
+
+func newTestStruct(t *testing.T, schema Schema, values map[string]interface{}) *Struct {
+	s, err := newDefaultStruct(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, value := range values {
+		bf := schema.GetFieldsByName()[name]
+		if bf == nil {
+			t.Fatalf("field %s not found", name)
+		}
+		s.Values[bf.index] = value
+	}
+	return s
+}
+
+func newTestMetadataRequest(t *testing.T, apiVersion int16, topics []string) *Struct {
+	schema, _ := GetRequestSchema(apiKeyMetadata, apiVersion)
+	topicSchema := schema.GetFieldsByName()["topics"].def.GetSchema()
+	var elements []interface{}
+	if topics != nil {
+		elements = make([]interface{}, 0)
+		for _, topic := range topics {
+			elements = append(elements, newTestStruct(t, topicSchema, map[string]interface{}{"name": topic}))
+		}
+	}
+	values := map[string]interface{}{"topics": elements}
+	if elements == nil {
+		values["topics"] = nil
+	}
+	return newTestStruct(t, schema, values)
+}
+
+func TestDecodeRequestRemoveTopics(t *testing.T) {
+	for _, apiVersion := range []int16{0, 1, 4, 8, 9} {
+		a := assert.New(t)
+
+		buf := encodeTestRequest(t, apiKeyMetadata, apiVersion, 7, newTestMetadataRequest(t, apiVersion, []string{"a", "b", "c"}))
+		request, err := DecodeRequest(buf)
+		a.Nil(err)
+		a.Equal(int32(7), request.CorrelationID)
+		a.Equal("client", *request.ClientID)
+
+		topics, allTopics, err := request.Topics()
+		a.Nil(err)
+		a.False(allTopics)
+		a.Equal([]TopicPartitions{{Topic: "a"}, {Topic: "b"}, {Topic: "c"}}, topics)
+
+		removed, err := request.RemoveTopics(func(topic string) bool { return topic != "b" })
+		a.Nil(err)
+		a.Equal([]TopicPartitions{{Topic: "a"}, {Topic: "c"}}, removed)
+
+		encoded, err := request.Encode()
+		a.Nil(err)
+		a.Equal(uint32(len(encoded)-4), binary.BigEndian.Uint32(encoded))
+
+		request, err = DecodeRequest(encoded[4:])
+		a.Nil(err)
+		topics, _, err = request.Topics()
+		a.Nil(err)
+		a.Equal([]TopicPartitions{{Topic: "b"}}, topics)
+	}
+}
+
+func TestDecodeRequestAllTopics(t *testing.T) {
+	a := assert.New(t)
+
+	request, err := DecodeRequest(encodeTestRequest(t, apiKeyMetadata, 0, 1, newTestMetadataRequest(t, 0, []string{})))
+	a.Nil(err)
+	_, allTopics, err := request.Topics()
+	a.Nil(err)
+	a.True(allTopics)
+
+	request, err = DecodeRequest(encodeTestRequest(t, apiKeyMetadata, 9, 1, newTestMetadataRequest(t, 9, nil)))
+	a.Nil(err)
+	_, allTopics, err = request.Topics()
+	a.Nil(err)
+	a.True(allTopics)
+}
+
+func TestDecodeProduceRequestPartitions(t *testing.T) {
+	for _, apiVersion := range []int16{3, 9} {
+		a := assert.New(t)
+
+		schema, _ := GetRequestSchema(apiKeyProduce, apiVersion)
+		topicSchema := schema.GetFieldsByName()["topic_data"].def.GetSchema()
+		partitionSchema := topicSchema.GetFieldsByName()["partition_data"].def.GetSchema()
+		partitions := []interface{}{
+			newTestStruct(t, partitionSchema, map[string]interface{}{"index": int32(0), "records": []byte{1, 2, 3}}),
+			newTestStruct(t, partitionSchema, map[string]interface{}{"index": int32(2)}),
+		}
+		body := newTestStruct(t, schema, map[string]interface{}{
+			"acks":       int16(-1),
+			"timeout_ms": int32(1000),
+			"topic_data": []interface{}{newTestStruct(t, topicSchema, map[string]interface{}{"name": "orders", "partition_data": partitions})},
+		})
+
+		request, err := DecodeRequest(encodeTestRequest(t, apiKeyProduce, apiVersion, 3, body))
+		a.Nil(err)
+		a.Equal(int16(-1), request.Body.Get("acks"))
+		topics, _, err := request.Topics()
+		a.Nil(err)
+		a.Equal([]TopicPartitions{{Topic: "orders", Partitions: []int32{0, 2}}}, topics)
+	}
+}
+
+func TestNewTopicsErrorResponse(t *testing.T) {
+	topics := []TopicPartitions{{Topic: "orders", Partitions: []int32{0, 1}}}
+	for apiKey, versions := range map[int16]int{apiKeyProduce: 10, apiKeyFetch: 13, apiKeyListOffsets: 8, apiKeyOffsetCommit: 9, apiKeyOffsetFetch: 8} {
+		for apiVersion := int16(0); int(apiVersion) < versions; apiVersion++ {
+			a := assert.New(t)
+
+			buf, err := NewTopicsErrorResponse(apiKey, apiVersion, topics, ErrTopicAuthorizationFailed)
+			a.Nil(err)
+
+			schema, err := getResponseSchema(apiKey, apiVersion, getResponseSchemaVersions(apiKey))
+			a.Nil(err)
+			response, err := DecodeSchema(buf, schema)
+			a.Nil(err)
+
+			spec := topicsSpecs[apiKey]
+			elements := response.Get(spec.responseTopics).([]interface{})
+			a.Len(elements, 1)
+			name, err := topicName(elements[0].(*Struct))
+			a.Nil(err)
+			a.Equal("orders", name)
+			partitions := elements[0].(*Struct).Get(spec.responsePartitions).([]interface{})
+			a.Len(partitions, 2)
+			for i, partition := range partitions {
+				a.Equal(int32(i), partition.(*Struct).Get(spec.responsePartition))
+				a.Equal(int16(ErrTopicAuthorizationFailed), partition.(*Struct).Get("error_code"))
+			}
+		}
+	}
+}
+
+func TestTopicsResponseModifierMetadata(t *testing.T) {
+	for apiVersion := int16(0); apiVersion < 10; apiVersion++ {
+		a := assert.New(t)
+
+		buf, err := NewTopicsErrorResponse(apiKeyMetadata, apiVersion, []TopicPartitions{{Topic: "a"}, {Topic: "b"}}, ErrNoError)
+		a.Nil(err)
+
+		modifier, err := NewTopicsResponseModifier(apiKeyMetadata, apiVersion, func(topic string) bool { return topic == "a" }, []TopicPartitions{{Topic: "c"}}, ErrTopicAuthorizationFailed)
+		a.Nil(err)
+		buf, err = modifier.Apply(buf)
+		a.Nil(err)
+
+		response, err := DecodeSchema(buf, metadataResponseSchemaVersions[apiVersion])
+		a.Nil(err)
+		elements := response.Get("topic_metadata").([]interface{})
+		a.Len(elements, 2)
+		name, _ := topicName(elements[0].(*Struct))
+		a.Equal("b", name)
+		a.Equal(int16(ErrNoError), elements[0].(*Struct).Get("error_code"))
+		name, _ = topicName(elements[1].(*Struct))
+		a.Equal("c", name)
+		a.Equal(int16(ErrTopicAuthorizationFailed), elements[1].(*Struct).Get("error_code"))
+	}
+}
+
+func TestTopicsResponseModifierMetadataTopicID(t *testing.T) {
+	a := assert.New(t)
+
+	topicID := [16]byte{1, 2, 3}
+	schema, _ := GetRequestSchema(apiKeyMetadata, 12)
+	topicSchema := schema.GetFieldsByName()["topics"].def.GetSchema()
+	body := newTestStruct(t, schema, map[string]interface{}{"topics": []interface{}{
+		newTestStruct(t, topicSchema, map[string]interface{}{"topic_id": topicID}),
+	}})
+	request, err := DecodeRequest(encodeTestRequest(t, apiKeyMetadata, 12, 1, body))
+	a.Nil(err)
+	topics, _, err := request.Topics()
+	a.Nil(err)
+	a.Equal([]TopicPartitions{{TopicID: topicID}}, topics)
+
+	buf, err := NewTopicsErrorResponse(apiKeyMetadata, 12, nil, ErrNoError)
+	a.Nil(err)
+	modifier, err := NewTopicsResponseModifier(apiKeyMetadata, 12, nil, topics, ErrTopicAuthorizationFailed)
+	a.Nil(err)
+	buf, err = modifier.Apply(buf)
+	a.Nil(err)
+
+	response, err := DecodeSchema(buf, metadataResponseSchemaVersions[12])
+	a.Nil(err)
+	elements := response.Get("topic_metadata").([]interface{})
+	a.Len(elements, 1)
+	a.Nil(elements[0].(*Struct).Get("name"))
+	a.Equal(topicID, elements[0].(*Struct).Get("topic_id"))
+	a.Equal(int16(ErrTopicAuthorizationFailed), elements[0].(*Struct).Get("error_code"))
+}
@@ -4,10 +4,13 @@ import (
 	"crypto/tls"
 	"fmt"
 	"net"
+	"sort"
 	"sync"
+	"sync/atomic"
 
 	"github.com/grepplabs/kafka-proxy/config"
 	"github.com/grepplabs/kafka-proxy/pkg/libs/util"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
@@ -29,7 +32,18 @@ type Listeners struct {
 	dynamicSequentialMinPort int
 
 	brokerToListenerConfig map[string]config.ListenerConfig
-	lock                   sync.RWMutex
+	// brokers of the bootstrap and external server mappings, the other brokers have dynamic listeners
+	staticBrokers map[string]struct{}
+	// listeners started by ListenInstances by listener address
+	staticListeners map[string]staticListener
+	lock            sync.RWMutex
+}
+
+type staticListener struct {
+	cfg      config.ListenerConfig
+	listener net.Listener
+	// broker address of the accepted connections, Reload changes it without reopening the listener
+	brokerAddress *atomic.Value
 }
 
 func NewListeners(cfg *config.Config) (*Listeners, error) {
@@ -45,11 +59,18 @@ func NewListeners(cfg *config.Config) (*Listeners, error) {
 
 	var tlsConfig *tls.Config
 	if cfg.Proxy.TLS.Enable {
-		var err error
-		tlsConfig, err = newTLSListenerConfig(cfg)
+		var (
+			certs *tlsCertificates
+			err   error
+		)
+		tlsConfig, certs, err = newReloadableTLSListenerConfig(cfg)
 		if err != nil {
 			return nil, err
 		}
+		// the certificates are reloaded on the next handshake after a rotation
+		if err = certs.watch(); err != nil {
+			return nil, err
+		}
 	}
 
 	listenFunc := func(cfg config.ListenerConfig) (net.Listener, error) {
@@ -69,6 +90,8 @@ func NewListeners(cfg *config.Config) (*Listeners, error) {
 		dynamicAdvertisedListener: dynamicAdvertisedListener,
 		connSrc:                   make(chan Conn, 1),
 		brokerToListenerConfig:    brokerToListenerConfig,
+		staticBrokers:             brokerKeys(brokerToListenerConfig),
+		staticListeners:           make(map[string]staticListener),
 		tcpConnOptions:            tcpConnOptions,
 		listenFunc:                listenFunc,
 		disableDynamicListeners:   cfg.Proxy.DisableDynamicListeners,
@@ -117,6 +140,37 @@ func getBrokerToListenerConfig(cfg *config.Config) (map[string]config.ListenerCo
 	return brokerToListenerConfig, nil
 }
 
+func brokerKeys(brokerToListenerConfig map[string]config.ListenerConfig) map[string]struct{} {
+	keys := make(map[string]struct{}, len(brokerToListenerConfig))
+	for k := range brokerToListenerConfig {
+		keys[k] = struct{}{}
+	}
+	return keys
+}
+
+// ListenerMapping is the broker address mapping, Dynamic is true for the listeners started for the brokers
+// from the metadata responses
+type ListenerMapping struct {
+	BrokerAddress     string `json:"brokerAddress"`
+	ListenerAddress   string `json:"listenerAddress"`
+	AdvertisedAddress string `json:"advertisedAddress"`
+	Dynamic           bool   `json:"dynamic"`
+}
+
+// Mappings returns the static and dynamic listener mappings ordered by the broker address
+func (p *Listeners) Mappings() []ListenerMapping {
+	p.lock.RLock()
+	mappings := make([]ListenerMapping, 0, len(p.brokerToListenerConfig))
+	for broker, v := range p.brokerToListenerConfig {
+		_, static := p.staticBrokers[broker]
+		mappings = append(mappings, ListenerMapping{BrokerAddress: v.BrokerAddress, ListenerAddress: v.ListenerAddress, AdvertisedAddress: v.AdvertisedAddress, Dynamic: !static})
+	}
+	p.lock.RUnlock()
+
+	sort.Slice(mappings, func(i, j int) bool { return mappings[i].BrokerAddress < mappings[j].BrokerAddress })
+	return mappings
+}
+
 func (p *Listeners) GetNetAddressMapping(brokerHost string, brokerPort int32) (listenerHost string, listenerPort int32, err error) {
 	if brokerHost == "" || brokerPort <= 0 {
 		return "", 0, fmt.Errorf("broker address '%s:%d' is invalid", brokerHost, brokerPort)
@@ -153,7 +207,7 @@ func (p *Listeners) ListenDynamicInstance(brokerAddress string) (string, int32,
 	}
 
 	cfg := config.ListenerConfig{ListenerAddress: defaultListenerAddress, BrokerAddress: brokerAddress}
-	l, err := listenInstance(p.connSrc, cfg, p.tcpConnOptions, p.listenFunc)
+	l, err := listenInstance(p.connSrc, cfg, func() string { return brokerAddress }, p.tcpConnOptions, p.listenFunc)
 	if err != nil {
 		return "", 0, err
 	}
@@ -179,15 +233,102 @@ func (p *Listeners) ListenInstances(cfgs []config.ListenerConfig) (<-chan Conn,
 
 	// allows multiple local addresses to point to the remote
 	for _, v := range cfgs {
-		_, err := listenInstance(p.connSrc, v, p.tcpConnOptions, p.listenFunc)
-		if err != nil {
+		if err := p.listenStaticInstance(v); err != nil {
 			return nil, err
 		}
 	}
 	return p.connSrc, nil
 }
 
-func listenInstance(dst chan<- Conn, cfg config.ListenerConfig, opts TCPConnOptions, listenFunc ListenFunc) (net.Listener, error) {
+func (p *Listeners) listenStaticInstance(cfg config.ListenerConfig) error {
+	if sl, ok := p.staticListeners[cfg.ListenerAddress]; ok && sl.cfg == cfg {
+		return nil
+	}
+	sl, err := p.newStaticListener(cfg)
+	if err != nil {
+		return err
+	}
+	p.staticListeners[cfg.ListenerAddress] = sl
+	return nil
+}
+
+func (p *Listeners) newStaticListener(cfg config.ListenerConfig) (staticListener, error) {
+	brokerAddress := new(atomic.Value)
+	brokerAddress.Store(cfg.BrokerAddress)
+	l, err := listenInstance(p.connSrc, cfg, func() string { return brokerAddress.Load().(string) }, p.tcpConnOptions, p.listenFunc)
+	if err != nil {
+		return staticListener{}, err
+	}
+	return staticListener{cfg: cfg, listener: l, brokerAddress: brokerAddress}, nil
+}
+
+// Reload applies the changed bootstrap and external server mappings. Listeners of the new listener addresses are
+// opened first, the error is returned for invalid mappings or if a listener cannot be opened, nothing is changed then.
+// Listeners of the removed bootstrap servers are closed, the connections accepted by them keep running. Listeners of
+// the changed bootstrap servers are kept and forward the new connections to the changed broker. Dynamic listeners are kept.
+func (p *Listeners) Reload(cfg *config.Config) error {
+	brokerToListenerConfig, err := getBrokerToListenerConfig(cfg)
+	if err != nil {
+		return err
+	}
+	staticBrokers := brokerKeys(brokerToListenerConfig)
+	wanted := make(map[string]config.ListenerConfig)
+	for _, v := range cfg.Proxy.BootstrapServers {
+		wanted[v.ListenerAddress] = v
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	opened := make(map[string]staticListener)
+	for address, v := range wanted {
+		if _, ok := p.staticListeners[address]; ok {
+			continue
+		}
+		sl, err := p.newStaticListener(v)
+		if err != nil {
+			for _, sl := range opened {
+				sl.listener.Close()
+			}
+			return errors.Wrapf(err, "listener on %s for remote %s", address, v.BrokerAddress)
+		}
+		opened[address] = sl
+	}
+
+	for broker, v := range p.brokerToListenerConfig {
+		if _, ok := p.staticBrokers[broker]; ok {
+			continue
+		}
+		if _, ok := brokerToListenerConfig[broker]; !ok {
+			brokerToListenerConfig[broker] = v
+		}
+	}
+	p.staticBrokers = staticBrokers
+	p.brokerToListenerConfig = brokerToListenerConfig
+
+	for address, sl := range p.staticListeners {
+		v, ok := wanted[address]
+		if !ok {
+			logrus.Infof("Closing listener on %s for remote %s", address, sl.cfg.BrokerAddress)
+			sl.listener.Close()
+			delete(p.staticListeners, address)
+			continue
+		}
+		if v != sl.cfg {
+			logrus.Infof("Listener on %s changed from remote %s to %s", address, sl.cfg.BrokerAddress, v.BrokerAddress)
+			sl.brokerAddress.Store(v.BrokerAddress)
+			sl.cfg = v
+			p.staticListeners[address] = sl
+		}
+	}
+	for address, sl := range opened {
+		p.staticListeners[address] = sl
+	}
+	return nil
+}
+
+// listenInstance accepts the connections for the broker address returned by brokerAddress
+func listenInstance(dst chan<- Conn, cfg config.ListenerConfig, brokerAddress func() string, opts TCPConnOptions, listenFunc ListenFunc) (net.Listener, error) {
 	l, err := listenFunc(cfg)
 	if err != nil {
 		return nil, err
@@ -205,8 +346,9 @@ func listenInstance(dst chan<- Conn, cfg config.ListenerConfig, opts TCPConnOpti
 					logrus.Infof("WARNING: Error while setting TCP options for accepted connection %q on %v: %v", cfg, l.Addr().String(), err)
 				}
 			}
-			logrus.Infof("New connection for %s", cfg.BrokerAddress)
-			dst <- Conn{BrokerAddress: cfg.BrokerAddress, LocalConnection: c}
+			broker := brokerAddress()
+			logrus.Infof("New connection for %s", broker)
+			dst <- Conn{BrokerAddress: broker, LocalConnection: c}
 		}
 	})
 
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/grepplabs/kafka-proxy/config"
 	"github.com/stretchr/testify/assert"
+	"net"
 	"testing"
 )
 
@@ -187,3 +188,124 @@ func TestGetBrokerToListenerConfig(t *testing.T) {
 		a.Equal(tt.mapping, mapping)
 	}
 }
+
+func TestListenersReload(t *testing.T) {
+	a := assert.New(t)
+
+	cfg := config.NewConfig()
+	cfg.Proxy.DisableDynamicListeners = true
+	cfg.Proxy.BootstrapServers = []config.ListenerConfig{
+		{BrokerAddress: "kafka-0:9092", ListenerAddress: freeListenerAddress(t), AdvertisedAddress: "proxy:32400"},
+		{BrokerAddress: "kafka-1:9092", ListenerAddress: freeListenerAddress(t), AdvertisedAddress: "proxy:32401"},
+	}
+	listeners, err := NewListeners(cfg)
+	a.Nil(err)
+	_, err = listeners.ListenInstances(cfg.Proxy.BootstrapServers)
+	a.Nil(err)
+	defer func() {
+		for _, sl := range listeners.staticListeners {
+			sl.listener.Close()
+		}
+	}()
+	listeners.brokerToListenerConfig["kafka-2:9092"] = config.ListenerConfig{BrokerAddress: "kafka-2:9092", ListenerAddress: "127.0.0.1:40000", AdvertisedAddress: "127.0.0.1:40000"}
+	kafka0Listener := listeners.staticListeners[cfg.Proxy.BootstrapServers[0].ListenerAddress].listener
+
+	reloaded := config.NewConfig()
+	reloaded.Proxy.BootstrapServers = []config.ListenerConfig{
+		cfg.Proxy.BootstrapServers[1],
+		{BrokerAddress: "kafka-3:9092", ListenerAddress: freeListenerAddress(t), AdvertisedAddress: "proxy:32403"},
+	}
+	a.Nil(listeners.Reload(reloaded))
+
+	_, err = kafka0Listener.Accept()
+	a.NotNil(err)
+	a.Len(listeners.staticListeners, 2)
+	_, _, err = listeners.GetNetAddressMapping("kafka-0", 9092)
+	a.NotNil(err)
+	host, port, err := listeners.GetNetAddressMapping("kafka-3", 9092)
+	a.Nil(err)
+	a.Equal("proxy", host)
+	a.Equal(int32(32403), port)
+	// dynamic listeners are kept
+	_, port, err = listeners.GetNetAddressMapping("kafka-2", 9092)
+	a.Nil(err)
+	a.Equal(int32(40000), port)
+
+	conn, err := net.Dial("tcp", reloaded.Proxy.BootstrapServers[1].ListenerAddress)
+	a.Nil(err)
+	defer conn.Close()
+	accepted := <-listeners.connSrc
+	defer accepted.LocalConnection.Close()
+	a.Equal("kafka-3:9092", accepted.BrokerAddress)
+
+	// invalid mappings are rejected without changes
+	invalid := config.NewConfig()
+	invalid.Proxy.BootstrapServers = []config.ListenerConfig{
+		{BrokerAddress: "kafka-4:9092", ListenerAddress: "127.0.0.1:40001", AdvertisedAddress: "127.0.0.1:40001"},
+		{BrokerAddress: "kafka-4:9092", ListenerAddress: "127.0.0.1:40002", AdvertisedAddress: "127.0.0.1:40002"},
+	}
+	a.NotNil(listeners.Reload(invalid))
+	a.Len(listeners.staticListeners, 2)
+	_, _, err = listeners.GetNetAddressMapping("kafka-3", 9092)
+	a.Nil(err)
+
+	// listeners which cannot be opened reject the reload, the previous listeners are kept
+	occupied, err := net.Listen("tcp", "127.0.0.1:0")
+	a.Nil(err)
+	defer occupied.Close()
+	unavailable := config.NewConfig()
+	unavailable.Proxy.BootstrapServers = []config.ListenerConfig{
+		{BrokerAddress: "kafka-5:9092", ListenerAddress: occupied.Addr().String(), AdvertisedAddress: "proxy:32405"},
+	}
+	a.NotNil(listeners.Reload(unavailable))
+	a.Len(listeners.staticListeners, 2)
+	_, _, err = listeners.GetNetAddressMapping("kafka-5", 9092)
+	a.NotNil(err)
+	_, _, err = listeners.GetNetAddressMapping("kafka-3", 9092)
+	a.Nil(err)
+
+	// changed bootstrap servers keep the listener
+	kafka3Listener := listeners.staticListeners[reloaded.Proxy.BootstrapServers[1].ListenerAddress].listener
+	changed := config.NewConfig()
+	changed.Proxy.BootstrapServers = []config.ListenerConfig{
+		{BrokerAddress: "kafka-6:9092", ListenerAddress: reloaded.Proxy.BootstrapServers[1].ListenerAddress, AdvertisedAddress: "proxy:32406"},
+	}
+	a.Nil(listeners.Reload(changed))
+	a.Len(listeners.staticListeners, 1)
+	a.Equal(kafka3Listener, listeners.staticListeners[reloaded.Proxy.BootstrapServers[1].ListenerAddress].listener)
+
+	conn, err = net.Dial("tcp", reloaded.Proxy.BootstrapServers[1].ListenerAddress)
+	a.Nil(err)
+	defer conn.Close()
+	accepted = <-listeners.connSrc
+	defer accepted.LocalConnection.Close()
+	a.Equal("kafka-6:9092", accepted.BrokerAddress)
+}
+
+func TestListenersMappings(t *testing.T) {
+	a := assert.New(t)
+
+	cfg := config.NewConfig()
+	cfg.Proxy.BootstrapServers = []config.ListenerConfig{
+		{BrokerAddress: "kafka-1:9092", ListenerAddress: "0.0.0.0:32401", AdvertisedAddress: "proxy:32401"},
+		{BrokerAddress: "kafka-0:9092", ListenerAddress: "0.0.0.0:32400", AdvertisedAddress: "proxy:32400"},
+	}
+	listeners, err := NewListeners(cfg)
+	a.Nil(err)
+	listeners.brokerToListenerConfig["kafka-2:9092"] = config.ListenerConfig{BrokerAddress: "kafka-2:9092", ListenerAddress: "127.0.0.1:40000", AdvertisedAddress: "127.0.0.1:40000"}
+
+	a.Equal([]ListenerMapping{
+		{BrokerAddress: "kafka-0:9092", ListenerAddress: "0.0.0.0:32400", AdvertisedAddress: "proxy:32400"},
+		{BrokerAddress: "kafka-1:9092", ListenerAddress: "0.0.0.0:32401", AdvertisedAddress: "proxy:32401"},
+		{BrokerAddress: "kafka-2:9092", ListenerAddress: "127.0.0.1:40000", AdvertisedAddress: "127.0.0.1:40000", Dynamic: true},
+	}, listeners.Mappings())
+}
+
+func freeListenerAddress(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
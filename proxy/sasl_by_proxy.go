@@ -36,6 +36,8 @@ type SASLOAuthBearerAuth struct {
 	readTimeout  time.Duration
 
 	tokenProvider apis.TokenProvider
+	// clientToken is the token of the locally authenticated client, the provider passes or exchanges it
+	clientToken string
 }
 
 type SASLPlainAuth struct {
@@ -172,7 +174,7 @@ func (b *SASLHandshake) sendAndReceiveHandshake(conn DeadlineReaderWriter) error
 }
 
 func (b *SASLOAuthBearerAuth) getOAuthBearerToken() (string, error) {
-	resp, err := b.tokenProvider.GetToken(context.Background(), apis.TokenRequest{})
+	resp, err := b.tokenProvider.GetToken(context.Background(), apis.TokenRequest{ClientToken: b.clientToken})
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,32 @@
+package proxy
+
+import (
+	"github.com/grepplabs/kafka-proxy/pkg/apis"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// clientTokenAuth authenticates the broker connection with the OAUTHBEARER token which the token provider returns
+// for the token of the client authenticated by local SASL e.g. the same token or a token exchanged for the broker audience
+type clientTokenAuth struct {
+	clientID string
+
+	writeTimeout time.Duration
+	readTimeout  time.Duration
+
+	tokenProvider apis.TokenProvider
+}
+
+// implements upstreamSaslAuth
+func (a *clientTokenAuth) saslAuthByProxy(result localSaslResult) (SASLAuthByProxy, error) {
+	if result.token == "" {
+		return nil, errors.Errorf("client token of principal %s is not available, local SASL mechanism must be %s", result.principal, SASLOAuthBearer)
+	}
+	return &SASLOAuthBearerAuth{
+		clientID:      a.clientID,
+		writeTimeout:  a.writeTimeout,
+		readTimeout:   a.readTimeout,
+		tokenProvider: a.tokenProvider,
+		clientToken:   result.token,
+	}, nil
+}
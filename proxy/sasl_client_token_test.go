@@ -0,0 +1,23 @@
+package proxy
+
+import (
+	"github.com/grepplabs/kafka-proxy/pkg/apis"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestClientTokenSaslAuthByProxy(t *testing.T) {
+	a := assert.New(t)
+
+	tokenProvider := &testTokenProvider{response: apis.TokenResponse{Success: true, Token: "broker-token"}}
+	auth := &clientTokenAuth{
+		clientID:      "proxy",
+		tokenProvider: tokenProvider,
+	}
+	saslAuthByProxy, err := auth.saslAuthByProxy(localSaslResult{principal: "alice", token: "client-token"})
+	a.Nil(err)
+	a.Equal(&SASLOAuthBearerAuth{clientID: "proxy", tokenProvider: tokenProvider, clientToken: "client-token"}, saslAuthByProxy)
+
+	_, err = auth.saslAuthByProxy(localSaslResult{principal: "alice"})
+	a.EqualError(err, "client token of principal alice is not available, local SASL mechanism must be OAUTHBEARER")
+}
@@ -0,0 +1,127 @@
+package proxy
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/grepplabs/kafka-proxy/proxy/protocol"
+	"github.com/pkg/errors"
+	"io"
+	"net"
+	"time"
+)
+
+// upstreamSaslAuth creates the SASL authentication of the broker connection for the client authenticated by local SASL
+type upstreamSaslAuth interface {
+	saslAuthByProxy(result localSaslResult) (SASLAuthByProxy, error)
+}
+
+// deferredSaslAuth delays the SASL authentication of the broker connection until the local SASL authentication is done
+type deferredSaslAuth struct {
+	writeTimeout time.Duration
+	readTimeout  time.Duration
+
+	localSasl        *LocalSasl
+	apiVersionLimits apiVersionLimits
+	upstream         upstreamSaslAuth
+}
+
+// receiveLocalAuth performs the local SASL authentication before the broker connection is authenticated.
+// ApiVersions requests, which clients send before SaslHandshake, are forwarded to the broker which is dialed on demand,
+// brokers answer ApiVersions before authentication. The returned server connection is nil, if it was not dialed.
+func (a *deferredSaslAuth) receiveLocalAuth(local DeadlineReaderWriter, dial func() (net.Conn, error)) (result localSaslResult, server net.Conn, err error) {
+	if err = local.SetDeadline(time.Now().Add(a.localSasl.timeout)); err != nil {
+		return localSaslResult{}, nil, err
+	}
+	for {
+		keyVersionBuf := make([]byte, 8) // Size => int32 + ApiKey => int16 + ApiVersion => int16
+		if _, err = io.ReadFull(local, keyVersionBuf); err != nil {
+			return localSaslResult{}, server, err
+		}
+		requestKeyVersion := &protocol.RequestKeyVersion{}
+		if err = protocol.Decode(keyVersionBuf, requestKeyVersion); err != nil {
+			return localSaslResult{}, server, err
+		}
+		switch requestKeyVersion.ApiKey {
+		case apiKeyApiApiVersions:
+			if server == nil {
+				if server, err = dial(); err != nil {
+					return localSaslResult{}, nil, err
+				}
+			}
+			if err = a.forwardApiVersions(local, server, requestKeyVersion, keyVersionBuf); err != nil {
+				return localSaslResult{}, server, err
+			}
+		case apiKeySaslHandshake:
+			switch requestKeyVersion.ApiVersion {
+			case 0:
+				result, err = a.localSasl.receiveAndSendSASLAuthV0(local, keyVersionBuf)
+			case 1:
+				result, err = a.localSasl.receiveAndSendSASLAuthV1(local, keyVersionBuf)
+			default:
+				err = fmt.Errorf("only saslHandshake version 0 and 1 are supported, got version %d", requestKeyVersion.ApiVersion)
+			}
+			if err != nil {
+				return localSaslResult{}, server, err
+			}
+			return result, server, local.SetDeadline(time.Time{})
+		default:
+			return localSaslResult{}, server, errors.New("SASL Auth is required. Only SaslHandshake or ApiVersions requests are allowed")
+		}
+	}
+}
+
+func (a *deferredSaslAuth) forwardApiVersions(local DeadlineReaderWriter, server net.Conn, requestKeyVersion *protocol.RequestKeyVersion, keyVersionBuf []byte) error {
+	if requestKeyVersion.Length > protocol.MaxRequestSize {
+		return protocol.PacketDecodingError{Info: fmt.Sprintf("api versions message of length %d too large", requestKeyVersion.Length)}
+	}
+	request := make([]byte, int(requestKeyVersion.Length+4))
+	copy(request, keyVersionBuf)
+	if _, err := io.ReadFull(local, request[len(keyVersionBuf):]); err != nil {
+		return err
+	}
+
+	if err := server.SetWriteDeadline(time.Now().Add(a.writeTimeout)); err != nil {
+		return err
+	}
+	if _, err := server.Write(request); err != nil {
+		return err
+	}
+	if err := server.SetReadDeadline(time.Now().Add(a.readTimeout)); err != nil {
+		return err
+	}
+	sizeBuf := make([]byte, 4) // Size => int32
+	if _, err := io.ReadFull(server, sizeBuf); err != nil {
+		return err
+	}
+	length := int32(binary.BigEndian.Uint32(sizeBuf))
+	if length < 4 || length > protocol.MaxResponseSize {
+		return protocol.PacketDecodingError{Info: fmt.Sprintf("api versions response of length %d is invalid", length)}
+	}
+	response := make([]byte, length)
+	if _, err := io.ReadFull(server, response); err != nil {
+		return err
+	}
+	if err := server.SetDeadline(time.Time{}); err != nil {
+		return err
+	}
+
+	// ApiVersions response header is always v0: CorrelationID => int32
+	body := response[4:]
+	if len(a.apiVersionLimits) != 0 {
+		modifier, err := protocol.NewApiVersionsResponseModifier(requestKeyVersion.ApiVersion, a.apiVersionLimits)
+		if err != nil {
+			return err
+		}
+		if modifier != nil {
+			if body, err = modifier.Apply(body); err != nil {
+				return err
+			}
+		}
+	}
+	newResponse := make([]byte, 8+len(body))
+	binary.BigEndian.PutUint32(newResponse, uint32(4+len(body)))
+	copy(newResponse[4:], response[:4])
+	copy(newResponse[8:], body)
+	_, err := local.Write(newResponse)
+	return err
+}
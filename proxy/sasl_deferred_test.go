@@ -0,0 +1,151 @@
+package proxy
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"github.com/grepplabs/kafka-proxy/pkg/apis"
+	"github.com/grepplabs/kafka-proxy/proxy/protocol"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestDeferredSaslAuthReceiveLocalAuth(t *testing.T) {
+	a := assert.New(t)
+
+	localSasl, err := NewLocalSasl(LocalSaslParams{
+		enabled:               true,
+		timeout:               5 * time.Second,
+		passwordAuthenticator: &fakePasswordAuthenticator{Username: "alice", Password: "alice-password"},
+	})
+	a.Nil(err)
+	auth := &deferredSaslAuth{
+		writeTimeout:     5 * time.Second,
+		readTimeout:      5 * time.Second,
+		localSasl:        localSasl,
+		apiVersionLimits: newApiVersionLimits(ProcessorConfig{LocalSasl: localSasl}),
+	}
+
+	clientConn, localConn := net.Pipe()
+	defer clientConn.Close()
+	defer localConn.Close()
+	serverConn, brokerConn := net.Pipe()
+	defer serverConn.Close()
+	defer brokerConn.Close()
+
+	// broker answers ApiVersions v0 with Produce versions 0-13
+	go func() {
+		sizeBuf := make([]byte, 4)
+		if _, err := io.ReadFull(brokerConn, sizeBuf); err != nil {
+			return
+		}
+		if _, err := io.ReadFull(brokerConn, make([]byte, binary.BigEndian.Uint32(sizeBuf))); err != nil {
+			return
+		}
+		response, _ := hex.DecodeString("000000100000000700000000000100000000000d")
+		_, _ = brokerConn.Write(response)
+	}()
+
+	apiVersionsResponse := make(chan string, 1)
+	clientErr := make(chan error, 1)
+	go func() {
+		request, err := protocol.Encode(&protocol.Request{CorrelationID: 7, ClientID: "client", Body: &protocol.ApiVersionsRequestV0{}})
+		if err != nil {
+			clientErr <- err
+			return
+		}
+		sizeBuf := make([]byte, 4)
+		binary.BigEndian.PutUint32(sizeBuf, uint32(len(request)))
+		if _, err = clientConn.Write(append(sizeBuf, request...)); err != nil {
+			clientErr <- err
+			return
+		}
+		response := make([]byte, 20)
+		if _, err = io.ReadFull(clientConn, response); err != nil {
+			clientErr <- err
+			return
+		}
+		apiVersionsResponse <- hex.EncodeToString(response)
+
+		client := &SASLPlainAuth{writeTimeout: 5 * time.Second, readTimeout: 5 * time.Second, username: "alice", password: "alice-password"}
+		clientErr <- client.sendAndReceiveSASLAuth(clientConn)
+	}()
+
+	dials := 0
+	result, server, err := auth.receiveLocalAuth(localConn, func() (net.Conn, error) {
+		dials++
+		return serverConn, nil
+	})
+	a.Nil(err)
+	a.Equal(localSaslResult{principal: "alice"}, result)
+	a.Equal(serverConn, server)
+	a.Equal(1, dials)
+	// Produce max version is clamped
+	a.Equal("0000001000000007000000000001000000000009", <-apiVersionsResponse)
+	a.Nil(<-clientErr)
+}
+
+func TestDeferredSaslAuthReceiveLocalAuthRequired(t *testing.T) {
+	a := assert.New(t)
+
+	localSasl, err := NewLocalSasl(LocalSaslParams{enabled: true, timeout: 5 * time.Second})
+	a.Nil(err)
+	auth := &deferredSaslAuth{
+		localSasl: localSasl,
+	}
+	clientConn, localConn := net.Pipe()
+	defer clientConn.Close()
+	defer localConn.Close()
+
+	go func() {
+		// Metadata v0 request
+		request, _ := hex.DecodeString("0000000f00030000000000010005616c69636500000000")
+		_, _ = clientConn.Write(request)
+	}()
+	_, server, err := auth.receiveLocalAuth(localConn, func() (net.Conn, error) {
+		a.Fail("unexpected dial")
+		return nil, nil
+	})
+	a.Nil(server)
+	a.EqualError(err, "SASL Auth is required. Only SaslHandshake or ApiVersions requests are allowed")
+}
+
+func TestDeferredSaslAuthReceiveLocalOauth(t *testing.T) {
+	a := assert.New(t)
+
+	// {"alg":"none"}.{"sub":"alice"}
+	token := "eyJhbGciOiJub25lIn0.eyJzdWIiOiJhbGljZSJ9.c2ln"
+	localSasl, err := NewLocalSasl(LocalSaslParams{
+		enabled:            true,
+		timeout:            5 * time.Second,
+		tokenAuthenticator: &testTokenInfo{token: token},
+	})
+	a.Nil(err)
+	auth := &deferredSaslAuth{
+		localSasl: localSasl,
+	}
+	clientConn, localConn := net.Pipe()
+	defer clientConn.Close()
+	defer localConn.Close()
+
+	clientErr := make(chan error, 1)
+	go func() {
+		client := &SASLOAuthBearerAuth{
+			writeTimeout:  5 * time.Second,
+			readTimeout:   5 * time.Second,
+			tokenProvider: &testTokenProvider{response: apis.TokenResponse{Success: true, Token: token}},
+		}
+		clientErr <- client.sendAndReceiveSASLAuth(clientConn)
+	}()
+
+	result, server, err := auth.receiveLocalAuth(localConn, func() (net.Conn, error) {
+		a.Fail("unexpected dial")
+		return nil, nil
+	})
+	a.Nil(err)
+	a.Nil(server)
+	a.Equal(localSaslResult{principal: "alice", token: token}, result)
+	a.Nil(<-clientErr)
+}
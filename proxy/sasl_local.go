@@ -8,6 +8,7 @@ import (
 	"github.com/grepplabs/kafka-proxy/proxy/protocol"
 	"github.com/pkg/errors"
 	"io"
+	"sort"
 	"time"
 )
 
@@ -15,6 +16,7 @@ type LocalSasl struct {
 	enabled             bool
 	timeout             time.Duration
 	localAuthenticators map[string]LocalSaslAuth
+	bruteForceGuard     *BruteForceGuard
 }
 
 type LocalSaslParams struct {
@@ -22,9 +24,12 @@ type LocalSaslParams struct {
 	timeout               time.Duration
 	passwordAuthenticator apis.PasswordAuthenticator
 	tokenAuthenticator    apis.TokenInfo
+	scramMechanism        string
+	scramCredentialStore  apis.ScramCredentialStore
+	bruteForceGuard       *BruteForceGuard
 }
 
-func NewLocalSasl(params LocalSaslParams) *LocalSasl {
+func NewLocalSasl(params LocalSaslParams) (*LocalSasl, error) {
 	localAuthenticators := make(map[string]LocalSaslAuth)
 	if params.passwordAuthenticator != nil {
 		localAuthenticators[SASLPlain] = NewLocalSaslPlain(params.passwordAuthenticator)
@@ -33,33 +38,60 @@ func NewLocalSasl(params LocalSaslParams) *LocalSasl {
 	if params.tokenAuthenticator != nil {
 		localAuthenticators[SASLOAuthBearer] = NewLocalSaslOauth(params.tokenAuthenticator)
 	}
+
+	if params.scramCredentialStore != nil {
+		localSaslScram, err := NewLocalSaslScram(params.scramMechanism, params.scramCredentialStore)
+		if err != nil {
+			return nil, err
+		}
+		localAuthenticators[params.scramMechanism] = localSaslScram
+	}
 	return &LocalSasl{
 		enabled:             params.enabled,
 		timeout:             params.timeout,
 		localAuthenticators: localAuthenticators,
+		bruteForceGuard:     params.bruteForceGuard,
+	}, nil
+}
+
+// newConversation starts the SASL exchange, attempts are rejected without calling the authenticator when locked out
+func (p *LocalSasl) newConversation(conn DeadlineReaderWriter, localSaslAuth LocalSaslAuth) localSaslConversation {
+	conversation := newLocalSaslConversation(localSaslAuth)
+	if p.bruteForceGuard == nil {
+		return conversation
+	}
+	return &guardedConversation{
+		guard:        p.bruteForceGuard,
+		ip:           remoteIP(conn),
+		localSasl:    localSaslAuth,
+		conversation: conversation,
 	}
 }
 
-func (p *LocalSasl) receiveAndSendSASLAuthV1(conn DeadlineReaderWriter, readKeyVersionBuf []byte) (err error) {
+func (p *LocalSasl) receiveAndSendSASLAuthV1(conn DeadlineReaderWriter, readKeyVersionBuf []byte) (result localSaslResult, err error) {
 	var localSaslAuth LocalSaslAuth
 	if localSaslAuth, err = p.receiveAndSendSaslV0orV1(conn, readKeyVersionBuf, 1); err != nil {
-		return err
+		return localSaslResult{}, err
 	}
-	if err = p.receiveAndSendAuthV1(conn, localSaslAuth); err != nil {
-		return err
-	}
-	return nil
+	return p.receiveAndSendAuthV1(conn, localSaslAuth)
 }
 
-func (p *LocalSasl) receiveAndSendSASLAuthV0(conn DeadlineReaderWriter, readKeyVersionBuf []byte) (err error) {
+func (p *LocalSasl) receiveAndSendSASLAuthV0(conn DeadlineReaderWriter, readKeyVersionBuf []byte) (result localSaslResult, err error) {
 	var localSaslAuth LocalSaslAuth
 	if localSaslAuth, err = p.receiveAndSendSaslV0orV1(conn, readKeyVersionBuf, 0); err != nil {
-		return err
+		return localSaslResult{}, err
 	}
-	if err = p.receiveAndSendAuthV0(conn, localSaslAuth); err != nil {
-		return err
+	return p.receiveAndSendAuthV0(conn, localSaslAuth)
+}
+
+// enabledMechanisms returns the sorted mechanisms, which are listed in the SaslHandshake response
+func (p *LocalSasl) enabledMechanisms() []string {
+	mechanisms := make([]string, 0, len(p.localAuthenticators))
+	for mechanism := range p.localAuthenticators {
+		mechanisms = append(mechanisms, mechanism)
 	}
-	return nil
+	sort.Strings(mechanisms)
+	return mechanisms
 }
 
 func (p *LocalSasl) receiveAndSendSaslV0orV1(conn DeadlineReaderWriter, keyVersionBuf []byte, version int16) (localSaslAuth LocalSaslAuth, err error) {
@@ -99,17 +131,14 @@ func (p *LocalSasl) receiveAndSendSaslV0orV1(conn DeadlineReaderWriter, keyVersi
 
 	var saslResult error
 	saslErr := protocol.ErrNoError
+	mechanisms := p.enabledMechanisms()
 	localSaslAuth = p.localAuthenticators[saslReqV0orV1.Mechanism]
 	if localSaslAuth == nil {
-		mechanisms := make([]string, 0)
-		for mechanism := range p.localAuthenticators {
-			mechanisms = append(mechanisms, mechanism)
-		}
-		saslResult = fmt.Errorf("PLAIN or OAUTHBEARER mechanism expected, %v are configured, but got %s", mechanisms, saslReqV0orV1.Mechanism)
+		saslResult = fmt.Errorf("one of %v mechanisms expected, but got %s", mechanisms, saslReqV0orV1.Mechanism)
 		saslErr = protocol.ErrUnsupportedSASLMechanism
 	}
 
-	saslResV0 := &protocol.SaslHandshakeResponseV0orV1{Err: saslErr, EnabledMechanisms: []string{saslReqV0orV1.Mechanism}}
+	saslResV0 := &protocol.SaslHandshakeResponseV0orV1{Err: saslErr, EnabledMechanisms: mechanisms}
 	newResponseBuf, err := protocol.Encode(saslResV0)
 	if err != nil {
 		return nil, err
@@ -127,32 +156,45 @@ func (p *LocalSasl) receiveAndSendSaslV0orV1(conn DeadlineReaderWriter, keyVersi
 	return localSaslAuth, saslResult
 }
 
-func (p *LocalSasl) receiveAndSendAuthV1(conn DeadlineReaderWriter, localSaslAuth LocalSaslAuth) (err error) {
+func (p *LocalSasl) receiveAndSendAuthV1(conn DeadlineReaderWriter, localSaslAuth LocalSaslAuth) (result localSaslResult, err error) {
 	requestDeadline := time.Now().Add(p.timeout)
 	err = conn.SetDeadline(requestDeadline)
 	if err != nil {
-		return err
+		return localSaslResult{}, err
+	}
+	if localSaslAuth == nil {
+		return localSaslResult{}, errors.New("localSaslAuth is nil")
 	}
+	conversation := p.newConversation(conn, localSaslAuth)
+	for {
+		var done bool
+		if result, done, err = p.receiveAndSendAuthStepV1(conn, conversation); err != nil || done {
+			return result, err
+		}
+	}
+}
 
+// receiveAndSendAuthStepV1 handles a single SaslAuthenticate request, done is false when the mechanism expects a next one
+func (p *LocalSasl) receiveAndSendAuthStepV1(conn DeadlineReaderWriter, conversation localSaslConversation) (result localSaslResult, done bool, err error) {
 	keyVersionBuf := make([]byte, 8) // Size => int32 + ApiKey => int16 + ApiVersion => int16
 	if _, err = io.ReadFull(conn, keyVersionBuf); err != nil {
-		return err
+		return localSaslResult{}, false, err
 	}
 	requestKeyVersion := &protocol.RequestKeyVersion{}
 	if err = protocol.Decode(keyVersionBuf, requestKeyVersion); err != nil {
-		return err
+		return localSaslResult{}, false, err
 	}
 	if requestKeyVersion.ApiKey != 36 {
-		return errors.Errorf("SaslAuthenticate is expected, but got apiKey %d", requestKeyVersion.ApiKey)
+		return localSaslResult{}, false, errors.Errorf("SaslAuthenticate is expected, but got apiKey %d", requestKeyVersion.ApiKey)
 	}
 
 	if requestKeyVersion.Length > protocol.MaxRequestSize {
-		return protocol.PacketDecodingError{Info: fmt.Sprintf("sasl authenticate message of length %d too large", requestKeyVersion.Length)}
+		return localSaslResult{}, false, protocol.PacketDecodingError{Info: fmt.Sprintf("sasl authenticate message of length %d too large", requestKeyVersion.Length)}
 	}
 
 	resp := make([]byte, int(requestKeyVersion.Length-4))
 	if _, err = io.ReadFull(conn, resp); err != nil {
-		return err
+		return localSaslResult{}, false, err
 	}
 	payload := bytes.Join([][]byte{keyVersionBuf[4:], resp}, nil)
 
@@ -161,141 +203,144 @@ func (p *LocalSasl) receiveAndSendAuthV1(conn DeadlineReaderWriter, localSaslAut
 		saslAuthReqV0 := &protocol.SaslAuthenticateRequestV0{}
 		req := &protocol.Request{Body: saslAuthReqV0}
 		if err = protocol.Decode(payload, req); err != nil {
-			return err
+			return localSaslResult{}, false, err
 		}
 
-		authErr := localSaslAuth.doLocalAuth(saslAuthReqV0.SaslAuthBytes)
+		challenge, done, result, authErr := conversation.step(saslAuthReqV0.SaslAuthBytes)
 
 		var saslAuthResV0 *protocol.SaslAuthenticateResponseV0
 		if authErr == nil {
-			// Length of SaslAuthBytes !=0 for OAUTHBEARER causes that java SaslClientAuthenticator in INTERMEDIATE state will sent SaslAuthenticate(36) second time
-			saslAuthResV0 = &protocol.SaslAuthenticateResponseV0{Err: protocol.ErrNoError, SaslAuthBytes: make([]byte, 0)}
+			saslAuthResV0 = &protocol.SaslAuthenticateResponseV0{Err: protocol.ErrNoError, SaslAuthBytes: challenge}
 		} else {
 			errMsg := authErr.Error()
 			saslAuthResV0 = &protocol.SaslAuthenticateResponseV0{Err: protocol.ErrSASLAuthenticationFailed, ErrMsg: &errMsg, SaslAuthBytes: make([]byte, 0)}
 		}
 		newResponseBuf, err := protocol.Encode(saslAuthResV0)
 		if err != nil {
-			return err
+			return localSaslResult{}, false, err
 		}
 
 		newHeaderBuf, err := protocol.Encode(&protocol.ResponseHeader{Length: int32(len(newResponseBuf) + 4), CorrelationID: req.CorrelationID})
 		if err != nil {
-			return err
+			return localSaslResult{}, false, err
 		}
 		if _, err := conn.Write(newHeaderBuf); err != nil {
-			return err
+			return localSaslResult{}, false, err
 		}
 		if _, err := conn.Write(newResponseBuf); err != nil {
-			return err
+			return localSaslResult{}, false, err
 		}
-		return authErr
+		return result, done, authErr
 	case 1:
 		saslAuthReqV1 := &protocol.SaslAuthenticateRequestV1{}
 		req := &protocol.Request{Body: saslAuthReqV1}
 		if err = protocol.Decode(payload, req); err != nil {
-			return err
+			return localSaslResult{}, false, err
 		}
 
-		authErr := localSaslAuth.doLocalAuth(saslAuthReqV1.SaslAuthBytes)
+		challenge, done, result, authErr := conversation.step(saslAuthReqV1.SaslAuthBytes)
 
 		var saslAuthResV1 *protocol.SaslAuthenticateResponseV1
 		if authErr == nil {
-			// Length of SaslAuthBytes !=0 for OAUTHBEARER causes that java SaslClientAuthenticator in INTERMEDIATE state will sent SaslAuthenticate(36) second time
-			saslAuthResV1 = &protocol.SaslAuthenticateResponseV1{Err: protocol.ErrNoError, SaslAuthBytes: make([]byte, 0), SessionLifetimeMs: 0}
+			saslAuthResV1 = &protocol.SaslAuthenticateResponseV1{Err: protocol.ErrNoError, SaslAuthBytes: challenge, SessionLifetimeMs: 0}
 		} else {
 			errMsg := authErr.Error()
 			saslAuthResV1 = &protocol.SaslAuthenticateResponseV1{Err: protocol.ErrSASLAuthenticationFailed, ErrMsg: &errMsg, SaslAuthBytes: make([]byte, 0), SessionLifetimeMs: 0}
 		}
 		newResponseBuf, err := protocol.Encode(saslAuthResV1)
 		if err != nil {
-			return err
+			return localSaslResult{}, false, err
 		}
 
 		newHeaderBuf, err := protocol.Encode(&protocol.ResponseHeader{Length: int32(len(newResponseBuf) + 4), CorrelationID: req.CorrelationID})
 		if err != nil {
-			return err
+			return localSaslResult{}, false, err
 		}
 		if _, err := conn.Write(newHeaderBuf); err != nil {
-			return err
+			return localSaslResult{}, false, err
 		}
 		if _, err := conn.Write(newResponseBuf); err != nil {
-			return err
+			return localSaslResult{}, false, err
 		}
-		return authErr
+		return result, done, authErr
 	case 2:
 		saslAuthReqV2 := &protocol.SaslAuthenticateRequestV2{}
 		req := &protocol.RequestV2{Body: saslAuthReqV2}
 		if err = protocol.Decode(payload, req); err != nil {
-			return err
+			return localSaslResult{}, false, err
 		}
 
-		authErr := localSaslAuth.doLocalAuth(saslAuthReqV2.SaslAuthBytes)
+		challenge, done, result, authErr := conversation.step(saslAuthReqV2.SaslAuthBytes)
 
 		var saslAuthResV2 *protocol.SaslAuthenticateResponseV2
 		if authErr == nil {
-			// Length of SaslAuthBytes !=0 for OAUTHBEARER causes that java SaslClientAuthenticator in INTERMEDIATE state will sent SaslAuthenticate(36) second time
-			saslAuthResV2 = &protocol.SaslAuthenticateResponseV2{Err: protocol.ErrNoError, SaslAuthBytes: make([]byte, 0), SessionLifetimeMs: 0}
+			saslAuthResV2 = &protocol.SaslAuthenticateResponseV2{Err: protocol.ErrNoError, SaslAuthBytes: challenge, SessionLifetimeMs: 0}
 		} else {
 			errMsg := authErr.Error()
 			saslAuthResV2 = &protocol.SaslAuthenticateResponseV2{Err: protocol.ErrSASLAuthenticationFailed, ErrMsg: &errMsg, SaslAuthBytes: make([]byte, 0), SessionLifetimeMs: 0}
 		}
 		newResponseBuf, err := protocol.Encode(saslAuthResV2)
 		if err != nil {
-			return err
+			return localSaslResult{}, false, err
 		}
 		// 2 (Length) + 2 (CorrelationID) + 1 (empty TaggedFields)
 		newHeaderBuf, err := protocol.Encode(&protocol.ResponseHeaderV1{Length: int32(len(newResponseBuf) + 5), CorrelationID: req.CorrelationID})
 		if err != nil {
-			return err
+			return localSaslResult{}, false, err
 		}
 		if _, err := conn.Write(newHeaderBuf); err != nil {
-			return err
+			return localSaslResult{}, false, err
 		}
 		if _, err := conn.Write(newResponseBuf); err != nil {
-			return err
+			return localSaslResult{}, false, err
 		}
-		return authErr
+		return result, done, authErr
 	default:
-		return errors.Errorf("SaslAuthenticate version 0,1 or 2 is expected, apiVersion %d", requestKeyVersion.ApiVersion)
+		return localSaslResult{}, false, errors.Errorf("SaslAuthenticate version 0,1 or 2 is expected, apiVersion %d", requestKeyVersion.ApiVersion)
 	}
 }
 
-func (p *LocalSasl) receiveAndSendAuthV0(conn DeadlineReaderWriter, localSaslAuth LocalSaslAuth) (err error) {
+func (p *LocalSasl) receiveAndSendAuthV0(conn DeadlineReaderWriter, localSaslAuth LocalSaslAuth) (result localSaslResult, err error) {
 	requestDeadline := time.Now().Add(p.timeout)
 	err = conn.SetDeadline(requestDeadline)
 	if err != nil {
-		return err
+		return localSaslResult{}, err
 	}
-
-	sizeBuf := make([]byte, 4) // Size => int32
-	if _, err = io.ReadFull(conn, sizeBuf); err != nil {
-		return err
-	}
-
-	length := binary.BigEndian.Uint32(sizeBuf)
-	if int32(length) > protocol.MaxRequestSize {
-		return protocol.PacketDecodingError{Info: fmt.Sprintf("auth message of length %d too large", length)}
+	if localSaslAuth == nil {
+		return localSaslResult{}, errors.New("localSaslAuth is nil")
 	}
+	conversation := p.newConversation(conn, localSaslAuth)
+	for {
+		sizeBuf := make([]byte, 4) // Size => int32
+		if _, err = io.ReadFull(conn, sizeBuf); err != nil {
+			return localSaslResult{}, err
+		}
 
-	saslAuthBytes := make([]byte, length)
-	_, err = io.ReadFull(conn, saslAuthBytes)
-	if err != nil {
-		return err
-	}
+		length := binary.BigEndian.Uint32(sizeBuf)
+		if int32(length) > protocol.MaxRequestSize {
+			return localSaslResult{}, protocol.PacketDecodingError{Info: fmt.Sprintf("auth message of length %d too large", length)}
+		}
 
-	if localSaslAuth == nil {
-		return errors.New("localSaslAuth is nil")
-	}
+		saslAuthBytes := make([]byte, length)
+		_, err = io.ReadFull(conn, saslAuthBytes)
+		if err != nil {
+			return localSaslResult{}, err
+		}
 
-	if err = localSaslAuth.doLocalAuth(saslAuthBytes); err != nil {
-		return err
-	}
-	// If the credentials are valid, we would write a 4 byte response filled with null characters.
-	// Otherwise, the closes the connection i.e. return error
-	header := make([]byte, 4)
-	if _, err := conn.Write(header); err != nil {
-		return err
+		challenge, done, result, err := conversation.step(saslAuthBytes)
+		if err != nil {
+			return localSaslResult{}, err
+		}
+		// If the credentials are valid, we would write the size prefixed challenge, which is a 4 byte response filled with null characters
+		// for single step mechanisms. Otherwise, the proxy closes the connection i.e. returns the error
+		response := make([]byte, 4+len(challenge))
+		binary.BigEndian.PutUint32(response, uint32(len(challenge)))
+		copy(response[4:], challenge)
+		if _, err := conn.Write(response); err != nil {
+			return localSaslResult{}, err
+		}
+		if done {
+			return result, nil
+		}
 	}
-	return nil
 }
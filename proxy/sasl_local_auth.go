@@ -2,11 +2,19 @@ package proxy
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"github.com/grepplabs/kafka-proxy/pkg/apis"
 	"github.com/grepplabs/kafka-proxy/proxy/protocol"
+	"github.com/pkg/errors"
+	"github.com/xdg/scram"
 	"strconv"
 	"strings"
+	"sync/atomic"
 )
 
 type errLocalAuthFailed struct {
@@ -17,8 +25,52 @@ func (e errLocalAuthFailed) Error() string {
 	return fmt.Sprintf("user %s authentication failed", e.user)
 }
 
+type errLocalTokenRejected struct {
+	status int32
+}
+
+func (e errLocalTokenRejected) Error() string {
+	return fmt.Sprintf("local oauth verify token failed with status: %d", e.status)
+}
+
+// localSaslResult is the outcome of the successful local SASL authentication
+type localSaslResult struct {
+	principal string
+	// token is the OAUTHBEARER token of the client, it is empty for other mechanisms
+	token string
+}
+
 type LocalSaslAuth interface {
-	doLocalAuth(saslAuthBytes []byte) (err error)
+	// doLocalAuth authenticates the client and returns the authenticated principal
+	doLocalAuth(saslAuthBytes []byte) (result localSaslResult, err error)
+}
+
+// localSaslConversation is the server side of a SASL exchange which can take more than one SaslAuthenticate round trip
+type localSaslConversation interface {
+	// step processes the client message and returns the server challenge. The result is returned when done
+	step(saslAuthBytes []byte) (challenge []byte, done bool, result localSaslResult, err error)
+}
+
+// localSaslMultiStepAuth is implemented by the mechanisms which require more than one round trip e.g. SCRAM
+type localSaslMultiStepAuth interface {
+	newConversation() localSaslConversation
+}
+
+func newLocalSaslConversation(localSaslAuth LocalSaslAuth) localSaslConversation {
+	if multiStepAuth, ok := localSaslAuth.(localSaslMultiStepAuth); ok {
+		return multiStepAuth.newConversation()
+	}
+	return singleStepConversation{localSaslAuth: localSaslAuth}
+}
+
+type singleStepConversation struct {
+	localSaslAuth LocalSaslAuth
+}
+
+func (c singleStepConversation) step(saslAuthBytes []byte) (challenge []byte, done bool, result localSaslResult, err error) {
+	result, err = c.localSaslAuth.doLocalAuth(saslAuthBytes)
+	// Length of SaslAuthBytes !=0 for OAUTHBEARER causes that java SaslClientAuthenticator in INTERMEDIATE state will sent SaslAuthenticate(36) second time
+	return make([]byte, 0), true, result, err
 }
 
 type LocalSaslPlain struct {
@@ -32,29 +84,38 @@ func NewLocalSaslPlain(localAuthenticator apis.PasswordAuthenticator) *LocalSasl
 }
 
 // implements LocalSaslAuth
-func (p *LocalSaslPlain) doLocalAuth(saslAuthBytes []byte) (err error) {
+func (p *LocalSaslPlain) doLocalAuth(saslAuthBytes []byte) (result localSaslResult, err error) {
 	tokens := strings.Split(string(saslAuthBytes), "\x00")
 	if len(tokens) != 3 {
-		return fmt.Errorf("invalid SASL/PLAIN request: expected 3 tokens, got %d", len(tokens))
+		return localSaslResult{}, fmt.Errorf("invalid SASL/PLAIN request: expected 3 tokens, got %d", len(tokens))
 	}
 	if p.localAuthenticator == nil {
-		return protocol.PacketDecodingError{Info: "Listener authenticator is not set"}
+		return localSaslResult{}, protocol.PacketDecodingError{Info: "Listener authenticator is not set"}
 	}
 
 	// logrus.Infof("user: %s , password: %s", tokens[1], tokens[2])
 	ok, status, err := p.localAuthenticator.Authenticate(tokens[1], tokens[2])
 	if err != nil {
 		proxyLocalAuthTotal.WithLabelValues("error", "1").Inc()
-		return err
+		return localSaslResult{}, err
 	}
 	proxyLocalAuthTotal.WithLabelValues(strconv.FormatBool(ok), strconv.Itoa(int(status))).Inc()
 
 	if !ok {
-		return errLocalAuthFailed{
+		return localSaslResult{}, errLocalAuthFailed{
 			user: tokens[1],
 		}
 	}
-	return nil
+	return localSaslResult{principal: tokens[1]}, nil
+}
+
+// implements localSaslUsernameAuth
+func (p *LocalSaslPlain) username(saslAuthBytes []byte) string {
+	tokens := strings.Split(string(saslAuthBytes), "\x00")
+	if len(tokens) != 3 {
+		return ""
+	}
+	return tokens[1]
 }
 
 type LocalSaslOauth struct {
@@ -70,17 +131,160 @@ func NewLocalSaslOauth(tokenAuthenticator apis.TokenInfo) *LocalSaslOauth {
 }
 
 // implements LocalSaslAuth
-func (p *LocalSaslOauth) doLocalAuth(saslAuthBytes []byte) (err error) {
-	token, _, _, err := p.saslOAuthBearer.GetClientInitialResponse(saslAuthBytes)
+func (p *LocalSaslOauth) doLocalAuth(saslAuthBytes []byte) (result localSaslResult, err error) {
+	token, authzid, _, err := p.saslOAuthBearer.GetClientInitialResponse(saslAuthBytes)
 	if err != nil {
-		return err
+		return localSaslResult{}, err
 	}
 	resp, err := p.tokenAuthenticator.VerifyToken(context.Background(), apis.VerifyRequest{Token: token})
 	if err != nil {
-		return err
+		return localSaslResult{}, err
 	}
 	if !resp.Success {
-		return fmt.Errorf("local oauth verify token failed with status: %d", resp.Status)
+		return localSaslResult{}, errLocalTokenRejected{status: resp.Status}
+	}
+	subject := tokenSubject(token)
+	// without the subject the principal would fall back to the client certificate or the anonymous principal
+	if subject == "" {
+		return localSaslResult{}, errLocalAuthFailed{user: authzid}
+	}
+	// the authorization id is not verified by the token info, it must be the token subject as for SCRAM
+	if authzid != "" && authzid != subject {
+		return localSaslResult{}, errLocalAuthFailed{user: authzid}
+	}
+	return localSaslResult{principal: subject, token: token}, nil
+}
+
+// tokenSubject returns the sub claim of a JWT token or an empty string. The token must be already verified.
+func tokenSubject(token string) string {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return ""
+	}
+	claims := struct {
+		Subject string `json:"sub"`
+	}{}
+	if err = json.Unmarshal(payload, &claims); err != nil {
+		return ""
+	}
+	return claims.Subject
+}
+
+// minimal iteration count required by Kafka, it is used for mock credentials until the store returns credentials
+const defaultScramMockIterations = 4096
+
+type LocalSaslScram struct {
+	mechanism       string
+	hashGenerator   scram.HashGeneratorFcn
+	credentialStore apis.ScramCredentialStore
+	// mock credentials of unknown users are derived from the secret, so the same salt is sent on every attempt
+	mockSecret     []byte
+	mockIterations int32
+}
+
+func NewLocalSaslScram(mechanism string, credentialStore apis.ScramCredentialStore) (*LocalSaslScram, error) {
+	hashGenerator := SHA256
+	if mechanism == SASLSCRAM512 {
+		hashGenerator = SHA512
+	}
+	mockSecret := make([]byte, 32)
+	if _, err := rand.Read(mockSecret); err != nil {
+		return nil, errors.Wrap(err, "generating SCRAM mock credentials secret")
+	}
+	return &LocalSaslScram{
+		mechanism:       mechanism,
+		hashGenerator:   hashGenerator,
+		credentialStore: credentialStore,
+		mockSecret:      mockSecret,
+		mockIterations:  defaultScramMockIterations,
+	}, nil
+}
+
+// implements LocalSaslAuth
+func (p *LocalSaslScram) doLocalAuth(saslAuthBytes []byte) (result localSaslResult, err error) {
+	return localSaslResult{}, errors.Errorf("%s requires a multi step conversation", p.mechanism)
+}
+
+// implements localSaslUsernameAuth
+func (p *LocalSaslScram) username(saslAuthBytes []byte) string {
+	return scramUsername(saslAuthBytes)
+}
+
+// implements localSaslMultiStepAuth
+func (p *LocalSaslScram) newConversation() localSaslConversation {
+	conversation := &localSaslScramConversation{}
+	// error is always nil
+	server, _ := p.hashGenerator.NewServer(func(username string) (scram.StoredCredentials, error) {
+		credentials, found, err := p.credentialStore.GetCredentials(p.mechanism, username)
+		if err != nil {
+			conversation.storeErr = err
+			return scram.StoredCredentials{}, err
+		}
+		if !found {
+			// unknown users fail at client-final-message as for a wrong password, the server-first-message does not reveal them
+			return p.mockCredentials(username), nil
+		}
+		atomic.StoreInt32(&p.mockIterations, credentials.Iterations)
+		return scram.StoredCredentials{
+			KeyFactors: scram.KeyFactors{Salt: string(credentials.Salt), Iters: int(credentials.Iterations)},
+			StoredKey:  credentials.StoredKey,
+			ServerKey:  credentials.ServerKey,
+		}, nil
+	})
+	conversation.serverConversation = server.NewConversation()
+	return conversation
+}
+
+// mockCredentials returns the credentials of an unknown user which no client proof matches.
+// The salt is the HMAC of the username and the iteration count is the one of the stored credentials.
+func (p *LocalSaslScram) mockCredentials(username string) scram.StoredCredentials {
+	mockKey := func(label string) []byte {
+		mac := hmac.New(sha256.New, p.mockSecret)
+		mac.Write([]byte(label))
+		mac.Write([]byte{0})
+		mac.Write([]byte(username))
+		return mac.Sum(nil)
+	}
+	return scram.StoredCredentials{
+		KeyFactors: scram.KeyFactors{Salt: string(mockKey("salt")), Iters: int(atomic.LoadInt32(&p.mockIterations))},
+		StoredKey:  mockKey("stored-key"),
+		ServerKey:  mockKey("server-key"),
+	}
+}
+
+type localSaslScramConversation struct {
+	serverConversation *scram.ServerConversation
+	storeErr           error
+}
+
+func (c *localSaslScramConversation) step(saslAuthBytes []byte) (challenge []byte, done bool, result localSaslResult, err error) {
+	response, err := c.serverConversation.Step(string(saslAuthBytes))
+	if err != nil {
+		if c.storeErr != nil {
+			proxyLocalAuthTotal.WithLabelValues("error", "1").Inc()
+			return nil, true, localSaslResult{}, c.storeErr
+		}
+		username := c.serverConversation.Username()
+		if username == "" {
+			// malformed client-first-message
+			return nil, true, localSaslResult{}, err
+		}
+		// the SCRAM server-error (e.g. e=invalid-proof) is not sent, SaslAuthenticate fails as for other mechanisms
+		proxyLocalAuthTotal.WithLabelValues("false", "0").Inc()
+		return nil, true, localSaslResult{}, errLocalAuthFailed{user: username}
+	}
+	if !c.serverConversation.Done() {
+		return []byte(response), false, localSaslResult{}, nil
+	}
+	username := c.serverConversation.Username()
+	if authzid := c.serverConversation.AuthzID(); authzid != "" && authzid != username {
+		proxyLocalAuthTotal.WithLabelValues("false", "0").Inc()
+		return nil, true, localSaslResult{}, errors.Errorf("authorization id %s is different from user %s", authzid, username)
 	}
-	return nil
+	proxyLocalAuthTotal.WithLabelValues("true", "0").Inc()
+	return []byte(response), true, localSaslResult{principal: username}, nil
 }
@@ -0,0 +1,425 @@
+package proxy
+
+import (
+	"container/list"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	lockoutTypeUsername = "username"
+	lockoutTypeIP       = "ip"
+	lockoutTypeGlobal   = "global"
+
+	// the least recently used counters are evicted when the number of tracked usernames or IPs exceeds the limit
+	bruteForceMaxEntries = 100000
+	// lockout is doubled for every failure after the threshold up to the maximum lockout
+	bruteForceMaxDoublings = 30
+)
+
+type errLocalAuthLocked struct{}
+
+func (e errLocalAuthLocked) Error() string {
+	return "too many failed authentication attempts, try again later"
+}
+
+type BruteForceGuardOptions struct {
+	// failures of a username until the lockout, 0 disables the username lockout
+	UsernameMaxFailures int
+	// failures from a source IP until the lockout, 0 disables the IP lockout
+	IPMaxFailures int
+	// failures are forgotten when there was no failure and lockout within the window
+	FailureWindow time.Duration
+	// first lockout, the lockout is doubled for every further failure
+	Lockout    time.Duration
+	MaxLockout time.Duration
+	// attempts from source IPs without a successful authentication within the failure window are rejected when the
+	// failures exceed the rate, 0 disables the global limit
+	GlobalMaxFailuresPerSecond int
+}
+
+type failureCounter struct {
+	key      string
+	failures int
+	// attempts in progress, they are counted as failures until the authenticator returns
+	pending     int
+	lastFailure time.Time
+	lastSuccess time.Time
+	lockedUntil time.Time
+}
+
+// failureCounters are the counters of the usernames or source IPs in the least recently used order
+type failureCounters struct {
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+func newFailureCounters() *failureCounters {
+	return &failureCounters{
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+func (c *failureCounters) get(key string) *failureCounter {
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*failureCounter)
+}
+
+func (c *failureCounters) add(key string) *failureCounter {
+	counter := &failureCounter{key: key}
+	c.entries[key] = c.lru.PushFront(counter)
+	return counter
+}
+
+func (c *failureCounters) oldest() *failureCounter {
+	if elem := c.lru.Back(); elem != nil {
+		return elem.Value.(*failureCounter)
+	}
+	return nil
+}
+
+func (c *failureCounters) remove(counter *failureCounter) {
+	if elem, ok := c.entries[counter.key]; ok {
+		c.lru.Remove(elem)
+		delete(c.entries, counter.key)
+	}
+}
+
+func (c *failureCounters) len() int {
+	return c.lru.Len()
+}
+
+// BruteForceGuard tracks failed local authentications per username and per source IP. Locked out attempts are rejected
+// before the authenticator is called.
+type BruteForceGuard struct {
+	options    BruteForceGuardOptions
+	nowFn      func() time.Time
+	maxEntries int
+
+	usernames *failureCounters
+	ips       *failureCounters
+
+	globalTokens     float64
+	globalLastRefill time.Time
+	globalExhausted  bool
+
+	l sync.Mutex
+}
+
+func NewBruteForceGuard(options BruteForceGuardOptions) *BruteForceGuard {
+	return &BruteForceGuard{
+		options:          options,
+		nowFn:            time.Now,
+		maxEntries:       bruteForceMaxEntries,
+		usernames:        newFailureCounters(),
+		ips:              newFailureCounters(),
+		globalTokens:     float64(options.GlobalMaxFailuresPerSecond),
+		globalLastRefill: time.Now(),
+	}
+}
+
+// guardAttempt is an authentication attempt which was not rejected, it is counted as a failure until it is finished
+type guardAttempt struct {
+	ip               string
+	username         string
+	ipReserved       bool
+	usernameReserved bool
+	globalReserved   bool
+}
+
+// begin returns an error if the authentication attempt is rejected. The check and the reservation of the attempt are
+// done under one lock, so concurrent attempts cannot exceed the limits. The attempt must be finished by success,
+// failure or release.
+func (g *BruteForceGuard) begin(ip, username string) (*guardAttempt, error) {
+	g.l.Lock()
+	defer g.l.Unlock()
+
+	now := g.nowFn()
+	var ipCounter, usernameCounter *failureCounter
+	if ip != "" {
+		ipCounter = g.ips.get(ip)
+	}
+	if username != "" {
+		usernameCounter = g.usernames.get(username)
+	}
+	if ipCounter != nil && g.locked(ipCounter, g.options.IPMaxFailures, now) {
+		proxyLocalAuthRejectedTotal.WithLabelValues(lockoutTypeIP).Inc()
+		return nil, errLocalAuthLocked{}
+	}
+	if usernameCounter != nil && g.locked(usernameCounter, g.options.UsernameMaxFailures, now) {
+		proxyLocalAuthRejectedTotal.WithLabelValues(lockoutTypeUsername).Inc()
+		return nil, errLocalAuthLocked{}
+	}
+	attempt := &guardAttempt{ip: ip, username: username}
+	if g.options.GlobalMaxFailuresPerSecond > 0 {
+		g.refillGlobal(now)
+		if g.globalTokens >= 1 {
+			g.globalTokens--
+			attempt.globalReserved = true
+		} else if !g.knownGood(ipCounter, now) {
+			proxyLocalAuthRejectedTotal.WithLabelValues(lockoutTypeGlobal).Inc()
+			return nil, errLocalAuthLocked{}
+		}
+	}
+	if ip != "" && g.options.IPMaxFailures > 0 {
+		g.counter(g.ips, ip, now).pending++
+		attempt.ipReserved = true
+	}
+	if username != "" && g.options.UsernameMaxFailures > 0 {
+		g.counter(g.usernames, username, now).pending++
+		attempt.usernameReserved = true
+	}
+	return attempt, nil
+}
+
+// failure records the failed authentication, the reserved failure of the global budget is consumed
+func (g *BruteForceGuard) failure(attempt *guardAttempt) {
+	g.l.Lock()
+	defer g.l.Unlock()
+
+	now := g.nowFn()
+	g.releasePending(attempt)
+	if g.options.GlobalMaxFailuresPerSecond > 0 {
+		g.refillGlobal(now)
+		if !attempt.globalReserved && g.globalTokens >= 1 {
+			g.globalTokens--
+		}
+		if g.globalTokens < 1 && !g.globalExhausted {
+			g.globalExhausted = true
+			logrus.Warnf("Local authentication failures exceeded %d per second, authentication attempts of unknown source IPs are rejected", g.options.GlobalMaxFailuresPerSecond)
+			proxyLocalAuthLockoutsTotal.WithLabelValues(lockoutTypeGlobal).Inc()
+		}
+	}
+	if attempt.ipReserved {
+		g.recordFailure(g.ips, lockoutTypeIP, attempt.ip, g.options.IPMaxFailures, now)
+	}
+	if attempt.usernameReserved {
+		g.recordFailure(g.usernames, lockoutTypeUsername, attempt.username, g.options.UsernameMaxFailures, now)
+	}
+}
+
+// success resets the failures of the username and marks the source IP as known good. The failures of the source IP are
+// kept, a valid account does not allow guessing the passwords of other users.
+func (g *BruteForceGuard) success(attempt *guardAttempt) {
+	g.l.Lock()
+	defer g.l.Unlock()
+
+	now := g.nowFn()
+	g.releasePending(attempt)
+	g.releaseGlobal(attempt, now)
+	if attempt.ip != "" && g.options.GlobalMaxFailuresPerSecond > 0 {
+		g.counter(g.ips, attempt.ip, now).lastSuccess = now
+	}
+	if counter := g.usernames.get(attempt.username); counter != nil {
+		counter.failures = 0
+		counter.lastFailure = time.Time{}
+		counter.lockedUntil = time.Time{}
+		if counter.pending == 0 {
+			g.usernames.remove(counter)
+		}
+	}
+}
+
+// release finishes the attempt which was neither a success nor a failure e.g. an intermediate step or an authenticator error
+func (g *BruteForceGuard) release(attempt *guardAttempt) {
+	g.l.Lock()
+	defer g.l.Unlock()
+
+	g.releasePending(attempt)
+	g.releaseGlobal(attempt, g.nowFn())
+}
+
+func (g *BruteForceGuard) releasePending(attempt *guardAttempt) {
+	if attempt.ipReserved {
+		if counter := g.ips.get(attempt.ip); counter != nil && counter.pending > 0 {
+			counter.pending--
+		}
+	}
+	if attempt.usernameReserved {
+		if counter := g.usernames.get(attempt.username); counter != nil && counter.pending > 0 {
+			counter.pending--
+		}
+	}
+}
+
+func (g *BruteForceGuard) releaseGlobal(attempt *guardAttempt, now time.Time) {
+	if !attempt.globalReserved {
+		return
+	}
+	g.refillGlobal(now)
+	g.globalTokens++
+	if rate := float64(g.options.GlobalMaxFailuresPerSecond); g.globalTokens > rate {
+		g.globalTokens = rate
+	}
+}
+
+func (g *BruteForceGuard) refillGlobal(now time.Time) {
+	rate := float64(g.options.GlobalMaxFailuresPerSecond)
+	g.globalTokens += now.Sub(g.globalLastRefill).Seconds() * rate
+	if g.globalTokens > rate {
+		g.globalTokens = rate
+	}
+	g.globalLastRefill = now
+	if g.globalTokens >= 1 {
+		g.globalExhausted = false
+	}
+}
+
+// locked returns true during the lockout or when the attempts in progress would exceed the remaining failures. At least
+// one attempt at a time is allowed after the lockout.
+func (g *BruteForceGuard) locked(counter *failureCounter, maxFailures int, now time.Time) bool {
+	if now.Before(counter.lockedUntil) {
+		return true
+	}
+	if maxFailures <= 0 {
+		return false
+	}
+	failures := counter.failures
+	if g.expired(counter, now) {
+		failures = 0
+	}
+	remaining := maxFailures - failures
+	if remaining < 1 {
+		remaining = 1
+	}
+	return counter.pending >= remaining
+}
+
+// knownGood returns true if there was a successful authentication from the source IP within the failure window
+func (g *BruteForceGuard) knownGood(ipCounter *failureCounter, now time.Time) bool {
+	return ipCounter != nil && !ipCounter.lastSuccess.IsZero() && now.Sub(ipCounter.lastSuccess) <= g.options.FailureWindow
+}
+
+// counter returns the counter of the key. Unused counters are pruned and the least recently used counter is evicted
+// when the number of counters exceeds the limit.
+func (g *BruteForceGuard) counter(counters *failureCounters, key string, now time.Time) *failureCounter {
+	if counter := counters.get(key); counter != nil {
+		return counter
+	}
+	for oldest := counters.oldest(); oldest != nil && g.unused(oldest, now); oldest = counters.oldest() {
+		counters.remove(oldest)
+	}
+	for counters.len() >= g.maxEntries {
+		counters.remove(counters.oldest())
+	}
+	return counters.add(key)
+}
+
+func (g *BruteForceGuard) recordFailure(counters *failureCounters, lockoutType string, key string, maxFailures int, now time.Time) {
+	counter := g.counter(counters, key, now)
+	if g.expired(counter, now) {
+		counter.failures = 0
+		counter.lockedUntil = time.Time{}
+	}
+	counter.failures++
+	counter.lastFailure = now
+	if counter.failures < maxFailures {
+		return
+	}
+	doublings := counter.failures - maxFailures
+	if doublings > bruteForceMaxDoublings {
+		doublings = bruteForceMaxDoublings
+	}
+	lockout := g.options.Lockout * time.Duration(1<<uint(doublings))
+	if lockout > g.options.MaxLockout || lockout <= 0 {
+		lockout = g.options.MaxLockout
+	}
+	counter.lockedUntil = now.Add(lockout)
+	logrus.Warnf("Local authentication of %s %s locked out for %v after %d failures", lockoutType, key, lockout, counter.failures)
+	proxyLocalAuthLockoutsTotal.WithLabelValues(lockoutType).Inc()
+}
+
+func (g *BruteForceGuard) expired(counter *failureCounter, now time.Time) bool {
+	last := counter.lastFailure
+	if counter.lockedUntil.After(last) {
+		last = counter.lockedUntil
+	}
+	return now.Sub(last) > g.options.FailureWindow
+}
+
+// unused returns true if the counter has no attempt in progress, no failures and no success within the failure window
+func (g *BruteForceGuard) unused(counter *failureCounter, now time.Time) bool {
+	return counter.pending == 0 && g.expired(counter, now) && now.Sub(counter.lastSuccess) > g.options.FailureWindow
+}
+
+// guardedConversation rejects locked out attempts without calling the authenticator and records the results
+type guardedConversation struct {
+	guard        *BruteForceGuard
+	ip           string
+	localSasl    LocalSaslAuth
+	conversation localSaslConversation
+	username     string
+	started      bool
+}
+
+func (c *guardedConversation) step(saslAuthBytes []byte) (challenge []byte, done bool, result localSaslResult, err error) {
+	if !c.started {
+		c.started = true
+		if usernameAuth, ok := c.localSasl.(localSaslUsernameAuth); ok {
+			c.username = usernameAuth.username(saslAuthBytes)
+		}
+	}
+	attempt, err := c.guard.begin(c.ip, c.username)
+	if err != nil {
+		return nil, true, localSaslResult{}, err
+	}
+	challenge, done, result, err = c.conversation.step(saslAuthBytes)
+	switch {
+	case err != nil && isLocalAuthFailure(err):
+		c.guard.failure(attempt)
+	case err == nil && done:
+		c.guard.success(attempt)
+	default:
+		c.guard.release(attempt)
+	}
+	return challenge, done, result, err
+}
+
+// localSaslUsernameAuth is implemented by the mechanisms which send the username before the credentials are verified
+type localSaslUsernameAuth interface {
+	username(saslAuthBytes []byte) string
+}
+
+// isLocalAuthFailure returns true for rejected credentials, but not for malformed requests or authenticator errors
+func isLocalAuthFailure(err error) bool {
+	switch err.(type) {
+	case errLocalAuthFailed, errLocalTokenRejected:
+		return true
+	}
+	return false
+}
+
+func remoteIP(conn interface{}) string {
+	addrConn, ok := conn.(interface{ RemoteAddr() net.Addr })
+	if !ok || addrConn.RemoteAddr() == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(addrConn.RemoteAddr().String())
+	if err != nil {
+		return ""
+	}
+	return host
+}
+
+// scramUsername returns the username of the SCRAM client-first-message e.g. n,,n=user,r=nonce
+func scramUsername(saslAuthBytes []byte) string {
+	fields := strings.Split(string(saslAuthBytes), ",")
+	if len(fields) < 3 {
+		return ""
+	}
+	for _, field := range fields[2:] {
+		if strings.HasPrefix(field, "n=") {
+			return strings.NewReplacer("=2C", ",", "=3D", "=").Replace(field[2:])
+		}
+	}
+	return ""
+}
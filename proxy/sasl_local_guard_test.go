@@ -0,0 +1,247 @@
+package proxy
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestBruteForceGuard(options BruteForceGuardOptions) (*BruteForceGuard, *fakeClock) {
+	guard := NewBruteForceGuard(options)
+	clock := &fakeClock{now: time.Now()}
+	guard.nowFn = clock.Now
+	guard.globalLastRefill = clock.now
+	return guard, clock
+}
+
+// checkGuard returns the error of the attempt without recording a result
+func checkGuard(guard *BruteForceGuard, ip, username string) error {
+	attempt, err := guard.begin(ip, username)
+	if err == nil {
+		guard.release(attempt)
+	}
+	return err
+}
+
+func failGuard(a *assert.Assertions, guard *BruteForceGuard, ip, username string) {
+	attempt, err := guard.begin(ip, username)
+	if a.Nil(err) {
+		guard.failure(attempt)
+	}
+}
+
+func succeedGuard(a *assert.Assertions, guard *BruteForceGuard, ip, username string) {
+	attempt, err := guard.begin(ip, username)
+	if a.Nil(err) {
+		guard.success(attempt)
+	}
+}
+
+func TestBruteForceGuardUsernameLockout(t *testing.T) {
+	a := assert.New(t)
+
+	guard, clock := newTestBruteForceGuard(BruteForceGuardOptions{UsernameMaxFailures: 3, FailureWindow: 10 * time.Minute, Lockout: time.Minute, MaxLockout: 5 * time.Minute})
+
+	for i := 0; i < 2; i++ {
+		a.Nil(checkGuard(guard, "10.0.0.1", "alice"))
+		failGuard(a, guard, "10.0.0.1", "alice")
+	}
+	a.Nil(checkGuard(guard, "10.0.0.1", "alice"))
+	failGuard(a, guard, "10.0.0.1", "alice")
+	a.Equal(errLocalAuthLocked{}, checkGuard(guard, "10.0.0.2", "alice"))
+	a.Nil(checkGuard(guard, "10.0.0.1", "bob"))
+
+	// lockout is doubled for every further failure and capped
+	for _, lockout := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute} {
+		clock.now = clock.now.Add(lockout - time.Second)
+		a.NotNil(checkGuard(guard, "", "alice"), "%v", lockout)
+		clock.now = clock.now.Add(time.Second)
+		a.Nil(checkGuard(guard, "", "alice"), "%v", lockout)
+		failGuard(a, guard, "", "alice")
+	}
+
+	// success resets the username
+	clock.now = clock.now.Add(5 * time.Minute)
+	succeedGuard(a, guard, "", "alice")
+	failGuard(a, guard, "", "alice")
+	a.Nil(checkGuard(guard, "", "alice"))
+}
+
+func TestBruteForceGuardFailureWindow(t *testing.T) {
+	a := assert.New(t)
+
+	guard, clock := newTestBruteForceGuard(BruteForceGuardOptions{UsernameMaxFailures: 2, FailureWindow: time.Minute, Lockout: time.Minute, MaxLockout: time.Hour})
+
+	failGuard(a, guard, "", "alice")
+	clock.now = clock.now.Add(2 * time.Minute)
+	failGuard(a, guard, "", "alice")
+	a.Nil(checkGuard(guard, "", "alice"))
+	failGuard(a, guard, "", "alice")
+	a.NotNil(checkGuard(guard, "", "alice"))
+
+	// window starts after the lockout
+	clock.now = clock.now.Add(time.Minute + 30*time.Second)
+	a.Nil(checkGuard(guard, "", "alice"))
+	failGuard(a, guard, "", "alice")
+	a.NotNil(checkGuard(guard, "", "alice"))
+	a.Equal(3, guard.usernames.get("alice").failures)
+}
+
+func TestBruteForceGuardIPLockout(t *testing.T) {
+	a := assert.New(t)
+
+	guard, _ := newTestBruteForceGuard(BruteForceGuardOptions{UsernameMaxFailures: 100, IPMaxFailures: 3, FailureWindow: time.Minute, Lockout: time.Minute, MaxLockout: time.Hour})
+
+	failGuard(a, guard, "10.0.0.1", "alice")
+	failGuard(a, guard, "10.0.0.1", "bob")
+	succeedGuard(a, guard, "10.0.0.1", "bob")
+	failGuard(a, guard, "10.0.0.1", "carol")
+	a.NotNil(checkGuard(guard, "10.0.0.1", "dave"))
+	a.NotNil(checkGuard(guard, "10.0.0.1", ""))
+	a.Nil(checkGuard(guard, "10.0.0.2", "dave"))
+}
+
+func TestBruteForceGuardGlobalLimit(t *testing.T) {
+	a := assert.New(t)
+
+	guard, clock := newTestBruteForceGuard(BruteForceGuardOptions{GlobalMaxFailuresPerSecond: 2, FailureWindow: time.Minute, Lockout: time.Minute, MaxLockout: time.Hour})
+
+	// successes do not consume the budget
+	for i := 0; i < 3; i++ {
+		succeedGuard(a, guard, "10.0.0.1", "alice")
+	}
+	failGuard(a, guard, "10.0.0.2", "bob")
+	a.Nil(checkGuard(guard, "10.0.0.3", "carol"))
+	failGuard(a, guard, "10.0.0.3", "carol")
+	a.NotNil(checkGuard(guard, "10.0.0.4", "dave"))
+
+	// source IPs with a recent success are not rejected
+	succeedGuard(a, guard, "10.0.0.1", "alice")
+	failGuard(a, guard, "10.0.0.1", "alice")
+	a.Nil(checkGuard(guard, "10.0.0.1", "alice"))
+
+	clock.now = clock.now.Add(500 * time.Millisecond)
+	a.Nil(checkGuard(guard, "10.0.0.4", "dave"))
+
+	// known good source IPs are forgotten after the failure window
+	clock.now = clock.now.Add(2 * time.Minute)
+	for i := 0; i < 2; i++ {
+		failGuard(a, guard, "10.0.0.4", "dave")
+	}
+	a.NotNil(checkGuard(guard, "10.0.0.1", "alice"))
+}
+
+func TestBruteForceGuardReservesAttempts(t *testing.T) {
+	a := assert.New(t)
+
+	guard, _ := newTestBruteForceGuard(BruteForceGuardOptions{UsernameMaxFailures: 2, GlobalMaxFailuresPerSecond: 3, FailureWindow: time.Minute, Lockout: time.Minute, MaxLockout: time.Hour})
+
+	// concurrent attempts are counted as failures until they are finished
+	first, err := guard.begin("10.0.0.1", "alice")
+	a.Nil(err)
+	second, err := guard.begin("10.0.0.2", "alice")
+	a.Nil(err)
+	_, err = guard.begin("10.0.0.3", "alice")
+	a.Equal(errLocalAuthLocked{}, err)
+	third, err := guard.begin("10.0.0.3", "bob")
+	a.Nil(err)
+	_, err = guard.begin("10.0.0.4", "carol")
+	a.Equal(errLocalAuthLocked{}, err)
+
+	guard.release(first)
+	a.Nil(checkGuard(guard, "10.0.0.3", "alice"))
+	guard.failure(second)
+	guard.success(third)
+	a.Equal(1, guard.usernames.get("alice").failures)
+	a.Equal(0, guard.usernames.get("alice").pending)
+	a.Nil(checkGuard(guard, "10.0.0.4", "carol"))
+}
+
+func TestBruteForceGuardMaxEntries(t *testing.T) {
+	a := assert.New(t)
+
+	guard, clock := newTestBruteForceGuard(BruteForceGuardOptions{UsernameMaxFailures: 1, FailureWindow: time.Minute, Lockout: time.Minute, MaxLockout: time.Hour})
+	guard.maxEntries = 2
+
+	failGuard(a, guard, "", "alice")
+	failGuard(a, guard, "", "bob")
+	a.NotNil(checkGuard(guard, "", "alice"))
+	failGuard(a, guard, "", "carol")
+	a.Equal(2, guard.usernames.len())
+	a.NotNil(guard.usernames.get("alice"))
+	a.Nil(guard.usernames.get("bob"))
+
+	// unused counters are pruned
+	clock.now = clock.now.Add(3 * time.Minute)
+	failGuard(a, guard, "", "dave")
+	a.Equal(1, guard.usernames.len())
+}
+
+func TestGuardedConversationDoesNotCallAuthenticatorWhenLocked(t *testing.T) {
+	a := assert.New(t)
+
+	guard, _ := newTestBruteForceGuard(BruteForceGuardOptions{UsernameMaxFailures: 2, FailureWindow: time.Minute, Lockout: time.Minute, MaxLockout: time.Hour})
+	authenticator := &countingPasswordAuthenticator{}
+	localSasl, err := NewLocalSasl(LocalSaslParams{enabled: true, timeout: 5 * time.Second, passwordAuthenticator: authenticator, bruteForceGuard: guard})
+	a.Nil(err)
+	localSaslAuth := localSasl.localAuthenticators[SASLPlain]
+
+	for i := 0; i < 2; i++ {
+		_, _, _, err := localSasl.newConversation(nil, localSaslAuth).step([]byte("\x00alice\x00wrong"))
+		a.Equal(errLocalAuthFailed{user: "alice"}, err)
+	}
+	_, done, _, err := localSasl.newConversation(nil, localSaslAuth).step([]byte("\x00alice\x00alice-secret"))
+	a.True(done)
+	a.Equal(errLocalAuthLocked{}, err)
+	a.Equal(2, authenticator.calls)
+
+	_, _, result, err := localSasl.newConversation(nil, localSaslAuth).step([]byte("\x00bob\x00bob-secret"))
+	a.Nil(err)
+	a.Equal("bob", result.principal)
+}
+
+func TestGuardedConversationIgnoresAuthenticatorErrors(t *testing.T) {
+	a := assert.New(t)
+
+	guard, _ := newTestBruteForceGuard(BruteForceGuardOptions{UsernameMaxFailures: 1, FailureWindow: time.Minute, Lockout: time.Minute, MaxLockout: time.Hour})
+	authenticator := &countingPasswordAuthenticator{err: errors.New("rpc error")}
+	localSasl, err := NewLocalSasl(LocalSaslParams{enabled: true, timeout: 5 * time.Second, passwordAuthenticator: authenticator, bruteForceGuard: guard})
+	a.Nil(err)
+	localSaslAuth := localSasl.localAuthenticators[SASLPlain]
+
+	for i := 0; i < 2; i++ {
+		_, _, _, err := localSasl.newConversation(nil, localSaslAuth).step([]byte("\x00alice\x00alice-secret"))
+		a.EqualError(err, "rpc error")
+	}
+	a.Equal(2, authenticator.calls)
+}
+
+func TestRemoteIP(t *testing.T) {
+	a := assert.New(t)
+
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+	a.Equal("", remoteIP(serverConn))
+	a.Equal("", remoteIP(nil))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	a.Nil(err)
+	defer listener.Close()
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	a.Nil(err)
+	defer conn.Close()
+	a.Equal("127.0.0.1", remoteIP(conn))
+}
+
+func TestScramUsername(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal("alice", scramUsername([]byte("n,,n=alice,r=fyko+d2lbbFgONRv9qkxdawL")))
+	a.Equal("a,b=c", scramUsername([]byte("n,a=admin,n=a=2Cb=3Dc,r=nonce")))
+	a.Equal("", scramUsername([]byte("c=biws,r=nonce,p=proof")))
+	a.Equal("", scramUsername([]byte("invalid")))
+}
@@ -2,8 +2,16 @@ package proxy
 
 import (
 	"bytes"
+	"encoding/base64"
+	"encoding/binary"
 	"encoding/hex"
+	"github.com/grepplabs/kafka-proxy/pkg/apis"
+	"github.com/grepplabs/kafka-proxy/pkg/libs/scram-file-store"
+	"github.com/grepplabs/kafka-proxy/proxy/protocol"
 	"github.com/stretchr/testify/assert"
+	"io"
+	"net"
+	"strings"
 	"testing"
 	"time"
 )
@@ -82,8 +90,11 @@ func TestLocalSaslReceiveAndSendAuthV1(t *testing.T) {
 				Password: tc.password,
 			})
 			localSasl := &LocalSasl{}
-			err = localSasl.receiveAndSendAuthV1(conn, localSaslAuth)
+			result, err := localSasl.receiveAndSendAuthV1(conn, localSaslAuth)
 			a.Equal(tc.authError, err)
+			if tc.authError == nil {
+				a.Equal(tc.username, result.principal)
+			}
 
 			written := conn.writer.Bytes()
 			a.Equal(tc.resHex, hex.EncodeToString(written))
@@ -124,3 +135,217 @@ func (r *fakeDeadlineReaderWriter) SetReadDeadline(t time.Time) error {
 func (r *fakeDeadlineReaderWriter) SetWriteDeadline(t time.Time) error {
 	return nil
 }
+
+func TestLocalSaslScram(t *testing.T) {
+	tests := []struct {
+		name      string
+		mechanism string
+		username  string
+		password  string
+		authError error
+	}{
+		{
+			name:      "SCRAM-SHA-256 success",
+			mechanism: SASLSCRAM256,
+			username:  "my-test-user",
+			password:  "my-test-password",
+		},
+		{
+			name:      "SCRAM-SHA-512 success",
+			mechanism: SASLSCRAM512,
+			username:  "my-test-user",
+			password:  "my-test-password",
+		},
+		{
+			name:      "SCRAM-SHA-512 bad password",
+			mechanism: SASLSCRAM512,
+			username:  "my-test-user",
+			password:  "bad-password",
+			authError: errLocalAuthFailed{user: "my-test-user"},
+		},
+		{
+			name:      "SCRAM-SHA-256 unknown user",
+			mechanism: SASLSCRAM256,
+			username:  "unknown-user",
+			password:  "my-test-password",
+			authError: errLocalAuthFailed{user: "unknown-user"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			a := assert.New(t)
+
+			credentials, err := scramfilestore.NewCredentials(tc.mechanism, "my-test-user", "my-test-password", nil, scramfilestore.MinIterations)
+			a.Nil(err)
+			localSasl, err := NewLocalSasl(LocalSaslParams{
+				enabled:        true,
+				timeout:        5 * time.Second,
+				scramMechanism: tc.mechanism,
+				scramCredentialStore: &fakeScramCredentialStore{
+					mechanism:   tc.mechanism,
+					username:    "my-test-user",
+					credentials: credentials,
+				},
+			})
+			a.Nil(err)
+
+			clientConn, serverConn := net.Pipe()
+			defer clientConn.Close()
+			defer serverConn.Close()
+
+			clientErr := make(chan error, 1)
+			go func() {
+				client := &SASLSCRAMAuth{
+					clientID:     "test-client",
+					writeTimeout: 5 * time.Second,
+					readTimeout:  5 * time.Second,
+					username:     tc.username,
+					password:     tc.password,
+					mechanism:    tc.mechanism,
+				}
+				clientErr <- client.sendAndReceiveSASLAuth(clientConn)
+			}()
+
+			keyVersionBuf := make([]byte, 8)
+			_, err = io.ReadFull(serverConn, keyVersionBuf)
+			a.Nil(err)
+			result, err := localSasl.receiveAndSendSASLAuthV1(serverConn, keyVersionBuf)
+			a.Equal(tc.authError, err)
+			if tc.authError == nil {
+				a.Equal(tc.username, result.principal)
+				a.Nil(<-clientErr)
+			} else {
+				a.Equal(protocol.ErrSASLAuthenticationFailed, <-clientErr)
+			}
+		})
+	}
+}
+
+func TestLocalSaslScramUnknownUserServerFirstMessage(t *testing.T) {
+	a := assert.New(t)
+
+	credentials, err := scramfilestore.NewCredentials(SASLSCRAM256, "my-test-user", "my-test-password", nil, 8192)
+	a.Nil(err)
+	localSaslScram, err := NewLocalSaslScram(SASLSCRAM256, &fakeScramCredentialStore{
+		mechanism:   SASLSCRAM256,
+		username:    "my-test-user",
+		credentials: credentials,
+	})
+	a.Nil(err)
+
+	serverFirstMessage := func(username string) (salt string, iterations string) {
+		challenge, done, _, err := localSaslScram.newConversation().step([]byte("n,,n=" + username + ",r=client-nonce"))
+		a.Nil(err)
+		a.False(done)
+		attrs := strings.Split(string(challenge), ",")
+		a.Len(attrs, 3)
+		a.True(strings.HasPrefix(attrs[0], "r=client-nonce"))
+		a.True(strings.HasPrefix(attrs[1], "s="))
+		a.True(strings.HasPrefix(attrs[2], "i="))
+		return attrs[1], attrs[2]
+	}
+	knownSalt, knownIterations := serverFirstMessage("my-test-user")
+	unknownSalt, unknownIterations := serverFirstMessage("unknown-user")
+	a.Equal(len(knownSalt), len(unknownSalt))
+	a.NotEqual(knownSalt, unknownSalt)
+	a.Equal("i=8192", knownIterations)
+	a.Equal(knownIterations, unknownIterations)
+
+	// the salt of an unknown user does not change between attempts
+	salt, _ := serverFirstMessage("unknown-user")
+	a.Equal(unknownSalt, salt)
+	salt, _ = serverFirstMessage("other-unknown-user")
+	a.NotEqual(unknownSalt, salt)
+}
+
+type fakeScramCredentialStore struct {
+	mechanism   string
+	username    string
+	credentials apis.ScramCredentials
+}
+
+func (s *fakeScramCredentialStore) GetCredentials(mechanism, username string) (apis.ScramCredentials, bool, error) {
+	if mechanism != s.mechanism || username != s.username {
+		return apis.ScramCredentials{}, false, nil
+	}
+	return s.credentials, true, nil
+}
+
+func TestLocalSaslHandshakeListsEnabledMechanisms(t *testing.T) {
+	localSasl, err := NewLocalSasl(LocalSaslParams{
+		enabled:               true,
+		timeout:               5 * time.Second,
+		passwordAuthenticator: &fakePasswordAuthenticator{},
+		tokenAuthenticator:    &countingTokenInfo{},
+	})
+	assert.Nil(t, err)
+	tests := []struct {
+		mechanism string
+		err       protocol.KError
+	}{
+		{mechanism: SASLPlain, err: protocol.ErrNoError},
+		{mechanism: SASLOAuthBearer, err: protocol.ErrNoError},
+		{mechanism: SASLSCRAM256, err: protocol.ErrUnsupportedSASLMechanism},
+	}
+	for _, tc := range tests {
+		t.Run(tc.mechanism, func(t *testing.T) {
+			a := assert.New(t)
+
+			reqBuf, err := protocol.Encode(&protocol.Request{
+				ClientID: "test-client",
+				Body:     &protocol.SaslHandshakeRequestV0orV1{Version: 1, Mechanism: tc.mechanism},
+			})
+			a.Nil(err)
+			sizeBuf := make([]byte, 4)
+			binary.BigEndian.PutUint32(sizeBuf, uint32(len(reqBuf)))
+			conn := &fakeDeadlineReaderWriter{
+				reader: bytes.NewBuffer(bytes.Join([][]byte{sizeBuf, reqBuf}, nil)),
+				writer: new(bytes.Buffer),
+			}
+			keyVersionBuf := make([]byte, 8)
+			_, err = io.ReadFull(conn, keyVersionBuf)
+			a.Nil(err)
+
+			localSaslAuth, err := localSasl.receiveAndSendSaslV0orV1(conn, keyVersionBuf, 1)
+			if tc.err == protocol.ErrNoError {
+				a.Nil(err)
+				a.Equal(localSasl.localAuthenticators[tc.mechanism], localSaslAuth)
+			} else {
+				a.NotNil(err)
+			}
+
+			res := &protocol.SaslHandshakeResponseV0orV1{}
+			a.Nil(protocol.Decode(conn.writer.Bytes()[8:], res))
+			a.Equal(tc.err, res.Err)
+			a.Equal([]string{SASLOAuthBearer, SASLPlain}, res.EnabledMechanisms)
+		})
+	}
+}
+
+func TestLocalSaslOauthAuthorizationID(t *testing.T) {
+	a := assert.New(t)
+
+	token := "eyJhbGciOiJub25lIn0." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"alice"}`)) + ".c2ln"
+	localAuth := NewLocalSaslOauth(&testTokenInfo{token: token})
+
+	result, err := localAuth.doLocalAuth(SaslOAuthBearer{}.ToBytes(token, "", nil))
+	a.Nil(err)
+	a.Equal("alice", result.principal)
+
+	result, err = localAuth.doLocalAuth(SaslOAuthBearer{}.ToBytes(token, "alice", nil))
+	a.Nil(err)
+	a.Equal("alice", result.principal)
+
+	_, err = localAuth.doLocalAuth(SaslOAuthBearer{}.ToBytes(token, "admin", nil))
+	a.Equal(errLocalAuthFailed{user: "admin"}, err)
+
+	// verified tokens without the subject are rejected
+	for _, noSubject := range []string{
+		"eyJhbGciOiJub25lIn0." + base64.RawURLEncoding.EncodeToString([]byte(`{"aud":"kafka"}`)) + ".c2ln",
+		"opaque-token",
+	} {
+		localAuth = NewLocalSaslOauth(&testTokenInfo{token: noSubject})
+		_, err = localAuth.doLocalAuth(SaslOAuthBearer{}.ToBytes(noSubject, "", nil))
+		a.Equal(errLocalAuthFailed{}, err)
+	}
+}
@@ -0,0 +1,50 @@
+package proxy
+
+import (
+	"github.com/grepplabs/kafka-proxy/pkg/apis"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// userCredentialsAuth authenticates the broker connection with the SASL credentials of the principal authenticated by local SASL
+type userCredentialsAuth struct {
+	clientID string
+	method   string
+
+	writeTimeout time.Duration
+	readTimeout  time.Duration
+
+	provider apis.UserCredentialsProvider
+}
+
+// implements upstreamSaslAuth
+func (a *userCredentialsAuth) saslAuthByProxy(result localSaslResult) (SASLAuthByProxy, error) {
+	credentials, found, err := a.provider.GetUserCredentials(result.principal)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errors.Errorf("upstream credentials of principal %s not found", result.principal)
+	}
+	switch a.method {
+	case SASLPlain:
+		return &SASLPlainAuth{
+			clientID:     a.clientID,
+			writeTimeout: a.writeTimeout,
+			readTimeout:  a.readTimeout,
+			username:     credentials.Username,
+			password:     credentials.Password,
+		}, nil
+	case SASLSCRAM256, SASLSCRAM512:
+		return &SASLSCRAMAuth{
+			clientID:     a.clientID,
+			writeTimeout: a.writeTimeout,
+			readTimeout:  a.readTimeout,
+			username:     credentials.Username,
+			password:     credentials.Password,
+			mechanism:    a.method,
+		}, nil
+	default:
+		return nil, errors.Errorf("SASL Mechanism not valid '%s'", a.method)
+	}
+}
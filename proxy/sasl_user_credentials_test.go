@@ -0,0 +1,35 @@
+package proxy
+
+import (
+	"github.com/grepplabs/kafka-proxy/pkg/apis"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestUserCredentialsSaslAuthByProxy(t *testing.T) {
+	a := assert.New(t)
+
+	auth := &userCredentialsAuth{
+		clientID: "proxy",
+		method:   SASLPlain,
+		provider: &fakeUserCredentialsProvider{"alice": {Username: "alice-upstream", Password: "alice-secret"}},
+	}
+	saslAuthByProxy, err := auth.saslAuthByProxy(localSaslResult{principal: "alice"})
+	a.Nil(err)
+	a.Equal(&SASLPlainAuth{clientID: "proxy", username: "alice-upstream", password: "alice-secret"}, saslAuthByProxy)
+
+	auth.method = SASLSCRAM512
+	saslAuthByProxy, err = auth.saslAuthByProxy(localSaslResult{principal: "alice"})
+	a.Nil(err)
+	a.Equal(&SASLSCRAMAuth{clientID: "proxy", username: "alice-upstream", password: "alice-secret", mechanism: SASLSCRAM512}, saslAuthByProxy)
+
+	_, err = auth.saslAuthByProxy(localSaslResult{principal: "bob"})
+	a.EqualError(err, "upstream credentials of principal bob not found")
+}
+
+type fakeUserCredentialsProvider map[string]apis.UserCredentials
+
+func (p fakeUserCredentialsProvider) GetUserCredentials(principal string) (apis.UserCredentials, bool, error) {
+	credentials, ok := p[principal]
+	return credentials, ok, nil
+}
@@ -76,31 +76,16 @@ var (
 	zeroTime = time.Time{}
 )
 
-func newTLSListenerConfig(conf *config.Config) (*tls.Config, error) {
+// newReloadableTLSListenerConfig returns the listener config which serves the reloaded certificates
+func newReloadableTLSListenerConfig(conf *config.Config) (*tls.Config, *tlsCertificates, error) {
 	opts := conf.Proxy.TLS
 
 	if opts.ListenerKeyFile == "" || opts.ListenerCertFile == "" {
-		return nil, errors.New("Listener key and cert files must not be empty")
-	}
-	certPEMBlock, err := ioutil.ReadFile(opts.ListenerCertFile)
-	if err != nil {
-		return nil, err
-	}
-	keyPEMBlock, err := ioutil.ReadFile(opts.ListenerKeyFile)
-	if err != nil {
-		return nil, err
-	}
-	keyPEMBlock, err = decryptPEM(keyPEMBlock, opts.ListenerKeyPassword)
-	if err != nil {
-		return nil, err
-	}
-	cert, err := tls.X509KeyPair(certPEMBlock, keyPEMBlock)
-	if err != nil {
-		return nil, err
+		return nil, nil, errors.New("Listener key and cert files must not be empty")
 	}
 	cipherSuites, err := getCipherSuites(opts.ListenerCipherSuites)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	// for security, ensure TLS_FALLBACK_SCSV is always included first
 	if len(cipherSuites) == 0 || cipherSuites[0] != tls.TLS_FALLBACK_SCSV {
@@ -108,11 +93,10 @@ func newTLSListenerConfig(conf *config.Config) (*tls.Config, error) {
 	}
 	curvePreferences, err := getCurvePreferences(opts.ListenerCurvePreferences)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	cfg := &tls.Config{
-		Certificates:             []tls.Certificate{cert},
 		ClientAuth:               tls.NoClientCert,
 		PreferServerCipherSuites: true,
 		MinVersion:               tls.VersionTLS12,
@@ -120,25 +104,22 @@ func newTLSListenerConfig(conf *config.Config) (*tls.Config, error) {
 		CipherSuites:             cipherSuites,
 	}
 	if opts.CAChainCertFile != "" {
-		caCertPEMBlock, err := ioutil.ReadFile(opts.CAChainCertFile)
-		if err != nil {
-			return nil, err
-		}
-		clientCAs := x509.NewCertPool()
-		if ok := clientCAs.AppendCertsFromPEM(caCertPEMBlock); !ok {
-			return nil, errors.New("Failed to parse listener root certificate")
-		}
-		cfg.ClientCAs = clientCAs
 		cfg.ClientAuth = tls.RequireAndVerifyClientCert
 	}
 
 	tlsValidateFunc, err := tlsClientCertVerificationFunc(conf)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	cfg.VerifyPeerCertificate = tlsValidateFunc
 
-	return cfg, nil
+	certs, err := newTLSCertificates(tlsReloadListener, cfg, opts.ListenerCertFile, opts.ListenerKeyFile, opts.ListenerKeyPassword, opts.CAChainCertFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg.GetCertificate = certs.getCertificate
+	cfg.GetConfigForClient = certs.getConfigForClient
+	return cfg, certs, nil
 }
 
 func removeEmptyStrings(input []string) []string {
@@ -195,45 +176,21 @@ func getCurvePreferences(enabledCurvePreferences []string) ([]tls.CurveID, error
 	return curvePreferences, nil
 }
 
-func newTLSClientConfig(conf *config.Config) (*tls.Config, error) {
+// newTLSClientCertificates returns the client certificates for the connections to the brokers
+func newTLSClientCertificates(conf *config.Config) (*tlsCertificates, error) {
 	// https://blog.cloudflare.com/exposing-go-on-the-internet/
 	opts := conf.Kafka.TLS
 
 	cfg := &tls.Config{InsecureSkipVerify: opts.InsecureSkipVerify}
 
+	var certFile, keyFile string
 	if opts.ClientCertFile != "" && opts.ClientKeyFile != "" {
-		certPEMBlock, err := ioutil.ReadFile(opts.ClientCertFile)
-		if err != nil {
-			return nil, err
-		}
-		keyPEMBlock, err := ioutil.ReadFile(opts.ClientKeyFile)
-		if err != nil {
-			return nil, err
-		}
-		keyPEMBlock, err = decryptPEM(keyPEMBlock, opts.ClientKeyPassword)
-		if err != nil {
-			return nil, err
-		}
-		cert, err := tls.X509KeyPair(certPEMBlock, keyPEMBlock)
-		if err != nil {
-			return nil, err
-		}
-		cfg.Certificates = []tls.Certificate{cert}
-	}
-
-	if opts.CAChainCertFile != "" {
-		caCertPEMBlock, err := ioutil.ReadFile(opts.CAChainCertFile)
-		if err != nil {
-			return nil, err
-		}
-		rootCAs := x509.NewCertPool()
-		if ok := rootCAs.AppendCertsFromPEM(caCertPEMBlock); !ok {
-			return nil, errors.New("Failed to parse client root certificate")
-		}
-
-		cfg.RootCAs = rootCAs
+		certFile, keyFile = opts.ClientCertFile, opts.ClientKeyFile
+	} else if opts.SameClientCertEnable {
+		// only compared with the certificate of the proxy client
+		certFile = opts.ClientCertFile
 	}
-	return cfg, nil
+	return newTLSCertificates(tlsReloadClient, cfg, certFile, keyFile, opts.ClientKeyPassword, opts.CAChainCertFile)
 }
 
 func decryptPEM(pemData []byte, password string) ([]byte, error) {
@@ -268,6 +225,9 @@ func parseCertificate(certFile string) (*x509.Certificate, error) {
 	}
 
 	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, errors.Errorf("Failed to parse certificate file from location '%s'", certFile)
+	}
 
 	cert, parseErr := x509.ParseCertificate(block.Bytes)
 
@@ -0,0 +1,200 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	principalMappingDefault   = "DEFAULT"
+	principalMappingRule      = "RULE:"
+	principalMappingSANPrefix = "SAN:"
+
+	sanTypeDNS   = "DNS"
+	sanTypeURI   = "URI"
+	sanTypeEmail = "EMAIL"
+	sanTypeIP    = "IP"
+)
+
+type principalMappingRuleEntry struct {
+	isDefault   bool
+	sanType     string // empty for the subject DN
+	pattern     *regexp.Regexp
+	replacement string
+	toLower     bool
+	toUpper     bool
+}
+
+// PrincipalMapper maps the client certificate to the principal with rules similar to the Kafka ssl.principal.mapping.rules.
+// The rules are evaluated in order, the first matching rule wins:
+//
+//	DEFAULT                                   the subject DN in RFC 2253 format e.g. CN=alice,OU=eng,O=Example,C=US
+//	RULE:pattern/replacement/[L|U]            the pattern must match the whole subject DN
+//	RULE:SAN:<type>:pattern/replacement/[L|U] the pattern must match the whole SAN of the type DNS, URI, EMAIL or IP
+//
+// The replacement may reference the groups of the pattern e.g. $1, slashes in the pattern and the replacement are escaped as \/.
+// L or U transform the result to lower or upper case.
+type PrincipalMapper struct {
+	rules []principalMappingRuleEntry
+}
+
+func NewPrincipalMapper(rules []string) (*PrincipalMapper, error) {
+	if len(rules) == 0 {
+		return nil, errors.New("at least one principal mapping rule is required")
+	}
+	mapper := &PrincipalMapper{}
+	for _, rule := range rules {
+		entry, err := parsePrincipalMappingRule(strings.TrimSpace(rule))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid principal mapping rule '%s'", rule)
+		}
+		mapper.rules = append(mapper.rules, entry)
+	}
+	return mapper, nil
+}
+
+func parsePrincipalMappingRule(rule string) (principalMappingRuleEntry, error) {
+	if rule == principalMappingDefault {
+		return principalMappingRuleEntry{isDefault: true}, nil
+	}
+	if !strings.HasPrefix(rule, principalMappingRule) {
+		return principalMappingRuleEntry{}, errors.Errorf("rule must be %s or start with %s", principalMappingDefault, principalMappingRule)
+	}
+	rule = rule[len(principalMappingRule):]
+
+	entry := principalMappingRuleEntry{}
+	if strings.HasPrefix(rule, principalMappingSANPrefix) {
+		rule = rule[len(principalMappingSANPrefix):]
+		end := strings.IndexByte(rule, ':')
+		if end == -1 {
+			return principalMappingRuleEntry{}, errors.New("SAN type is missing")
+		}
+		entry.sanType = rule[:end]
+		switch entry.sanType {
+		case sanTypeDNS, sanTypeURI, sanTypeEmail, sanTypeIP:
+		default:
+			return principalMappingRuleEntry{}, errors.Errorf("unsupported SAN type %s", entry.sanType)
+		}
+		rule = rule[end+1:]
+	}
+	parts := splitEscaped(rule, '/')
+	if len(parts) != 3 {
+		return principalMappingRuleEntry{}, errors.New("expected pattern/replacement/[L|U]")
+	}
+	switch parts[2] {
+	case "":
+	case "L":
+		entry.toLower = true
+	case "U":
+		entry.toUpper = true
+	default:
+		return principalMappingRuleEntry{}, errors.Errorf("unsupported case transformation %s", parts[2])
+	}
+	if parts[0] == "" {
+		return principalMappingRuleEntry{}, errors.New("pattern must not be empty")
+	}
+	pattern, err := regexp.Compile("^(?:" + parts[0] + ")$")
+	if err != nil {
+		return principalMappingRuleEntry{}, err
+	}
+	entry.pattern = pattern
+	entry.replacement = strings.ReplaceAll(parts[1], `\/`, "/")
+	return entry, nil
+}
+
+// splitEscaped splits the value by the separator, which is not preceded by a backslash
+func splitEscaped(value string, sep byte) []string {
+	result := make([]string, 0)
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case sep:
+			result = append(result, value[start:i])
+			start = i + 1
+		}
+	}
+	return append(result, value[start:])
+}
+
+// Principal returns the principal of the first matching rule
+func (m *PrincipalMapper) Principal(cert *x509.Certificate) (string, bool) {
+	for _, rule := range m.rules {
+		if principal, ok := rule.apply(cert); ok {
+			return principal, true
+		}
+	}
+	return "", false
+}
+
+func (r principalMappingRuleEntry) apply(cert *x509.Certificate) (string, bool) {
+	if r.isDefault {
+		return cert.Subject.String(), true
+	}
+	for _, value := range r.values(cert) {
+		if !r.pattern.MatchString(value) {
+			continue
+		}
+		principal := r.pattern.ReplaceAllString(value, r.replacement)
+		if r.toLower {
+			principal = strings.ToLower(principal)
+		} else if r.toUpper {
+			principal = strings.ToUpper(principal)
+		}
+		if principal != "" {
+			return principal, true
+		}
+	}
+	return "", false
+}
+
+func (r principalMappingRuleEntry) values(cert *x509.Certificate) []string {
+	switch r.sanType {
+	case "":
+		return []string{cert.Subject.String()}
+	case sanTypeDNS:
+		return cert.DNSNames
+	case sanTypeEmail:
+		return cert.EmailAddresses
+	case sanTypeURI:
+		result := make([]string, 0, len(cert.URIs))
+		for _, uri := range cert.URIs {
+			result = append(result, uri.String())
+		}
+		return result
+	case sanTypeIP:
+		result := make([]string, 0, len(cert.IPAddresses))
+		for _, ip := range cert.IPAddresses {
+			result = append(result, ip.String())
+		}
+		return result
+	}
+	return nil
+}
+
+// handshakeAndMapPrincipal performs the TLS handshake and maps the client certificate to the principal
+func handshakeAndMapPrincipal(conn net.Conn, mapper *PrincipalMapper, handshakeTimeout time.Duration) (string, error) {
+	tlsConn, ok := conn.(*tls.Conn)
+	if !ok {
+		return "", errors.New("Unable to cast connection to TLS when mapping client cert principal")
+	}
+	if err := handshakeTLSConn(tlsConn, handshakeTimeout); err != nil {
+		return "", err
+	}
+	peerCertificates := tlsConn.ConnectionState().PeerCertificates
+	if len(peerCertificates) == 0 {
+		return "", errors.New("client certificate is required to map the principal")
+	}
+	principal, ok := mapper.Principal(peerCertificates[0])
+	if !ok {
+		return "", errors.Errorf("no principal mapping rule matches the client certificate %s", peerCertificates[0].Subject.String())
+	}
+	return principal, nil
+}
@@ -0,0 +1,90 @@
+package proxy
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func principalTestCertificate() *x509.Certificate {
+	uri, _ := url.Parse("spiffe://example.org/ns/payments/sa/billing")
+	return &x509.Certificate{
+		Subject: pkix.Name{
+			CommonName:         "Alice",
+			OrganizationalUnit: []string{"ServiceUsers"},
+			Organization:       []string{"Example"},
+			Country:            []string{"US"},
+		},
+		DNSNames:       []string{"alice.example.org", "alice.internal"},
+		EmailAddresses: []string{"alice@example.org"},
+		IPAddresses:    []net.IP{net.ParseIP("10.0.0.1")},
+		URIs:           []*url.URL{uri},
+	}
+}
+
+func TestPrincipalMapper(t *testing.T) {
+	cert := principalTestCertificate()
+	tests := []struct {
+		rules     []string
+		principal string
+		ok        bool
+	}{
+		{rules: []string{"DEFAULT"}, principal: "CN=Alice,OU=ServiceUsers,O=Example,C=US", ok: true},
+		{rules: []string{`RULE:^CN=(.*?),OU=ServiceUsers.*$/$1/`}, principal: "Alice", ok: true},
+		{rules: []string{`RULE:^CN=(.*?),OU=ServiceUsers.*$/$1/L`}, principal: "alice", ok: true},
+		{rules: []string{`RULE:^CN=(.*?),OU=(.*?),O=(.*?),C=(.*?)$/$1@$3/U`}, principal: "ALICE@EXAMPLE", ok: true},
+		{rules: []string{`RULE:CN=(.*?),OU=Admins.*/$1/`}, ok: false},
+		{rules: []string{`RULE:CN=(.*?),OU=Admins.*/$1/`, "DEFAULT"}, principal: "CN=Alice,OU=ServiceUsers,O=Example,C=US", ok: true},
+		// pattern must match the whole value
+		{rules: []string{`RULE:CN=Alice/alice/`}, ok: false},
+		{rules: []string{`RULE:SAN:DNS:(.*)\.internal/$1/`}, principal: "alice", ok: true},
+		{rules: []string{`RULE:SAN:EMAIL:(.*)@example\.org/$1/U`}, principal: "ALICE", ok: true},
+		{rules: []string{`RULE:SAN:IP:10\.0\.0\.(\d+)/host-$1/`}, principal: "host-1", ok: true},
+		{rules: []string{`RULE:SAN:URI:spiffe:\/\/example.org\/ns\/([^\/]+)\/sa\/([^\/]+)/$1.$2/`}, principal: "payments.billing", ok: true},
+		{rules: []string{`RULE:SAN:URI:(.*)/spiffe:\/\/$1/`}, principal: "spiffe://spiffe://example.org/ns/payments/sa/billing", ok: true},
+		{rules: []string{`RULE:SAN:DNS:.*\.other\.org/dns/`, `RULE:SAN:EMAIL:.*/email/`}, principal: "email", ok: true},
+		// empty result is not a match
+		{rules: []string{`RULE:CN=.*//`, `RULE:SAN:DNS:alice\..*/dns/`}, principal: "dns", ok: true},
+	}
+	for _, tt := range tests {
+		mapper, err := NewPrincipalMapper(tt.rules)
+		assert.Nil(t, err, "%v", tt.rules)
+		principal, ok := mapper.Principal(cert)
+		assert.Equal(t, tt.ok, ok, "%v", tt.rules)
+		assert.Equal(t, tt.principal, principal, "%v", tt.rules)
+	}
+}
+
+func TestPrincipalMapperErrors(t *testing.T) {
+	tests := []struct {
+		rules []string
+		err   string
+	}{
+		{rules: []string{}, err: "at least one principal mapping rule is required"},
+		{rules: []string{"CN=(.*)/$1/"}, err: "invalid principal mapping rule 'CN=(.*)/$1/': rule must be DEFAULT or start with RULE:"},
+		{rules: []string{"RULE:CN=(.*)/$1"}, err: "invalid principal mapping rule 'RULE:CN=(.*)/$1': expected pattern/replacement/[L|U]"},
+		{rules: []string{"RULE:CN=(.*)/$1/X"}, err: "invalid principal mapping rule 'RULE:CN=(.*)/$1/X': unsupported case transformation X"},
+		{rules: []string{"RULE:/x/"}, err: "invalid principal mapping rule 'RULE:/x/': pattern must not be empty"},
+		{rules: []string{"RULE:SAN:OID:.*/x/"}, err: "invalid principal mapping rule 'RULE:SAN:OID:.*/x/': unsupported SAN type OID"},
+		{rules: []string{"RULE:SAN:DNS"}, err: "invalid principal mapping rule 'RULE:SAN:DNS': SAN type is missing"},
+		{rules: []string{"RULE:CN=(.*/$1/"}, err: "invalid principal mapping rule 'RULE:CN=(.*/$1/': error parsing regexp: missing closing ): `^(?:CN=(.*)$`"},
+	}
+	for _, tt := range tests {
+		_, err := NewPrincipalMapper(tt.rules)
+		assert.NotNil(t, err, "%v", tt.rules)
+		if err != nil {
+			assert.Equal(t, tt.err, err.Error())
+		}
+	}
+}
+
+func TestSplitEscaped(t *testing.T) {
+	assert.Equal(t, []string{"a", "b", ""}, splitEscaped("a/b/", '/'))
+	assert.Equal(t, []string{`a\/b`, "c", "L"}, splitEscaped(`a\/b/c/L`, '/'))
+	assert.Equal(t, []string{`a\\`, "b"}, splitEscaped(`a\\/b`, '/'))
+	assert.Equal(t, []string{""}, splitEscaped("", '/'))
+}
@@ -0,0 +1,216 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/grepplabs/kafka-proxy/pkg/libs/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	tlsReloadListener = "listener"
+	tlsReloadClient   = "client"
+
+	tlsCertificateTypeCert = "cert"
+	tlsCertificateTypeCA   = "ca"
+)
+
+// tlsCertificates serves the certificate and the CAs which are reloaded when the files change. The handshakes started
+// after the reload use the new files, the established connections are not affected.
+type tlsCertificates struct {
+	// listener or client, the CAs of the listener verify the client certificates
+	name            string
+	certFile        string
+	keyFile         string
+	keyPassword     string
+	caChainCertFile string
+
+	// settings of the listener or the client, the certificates are set by the load
+	baseConfig *tls.Config
+	config     *tls.Config
+	// certificate of the cert file, it is parsed also without the key file
+	leaf *x509.Certificate
+	lock sync.RWMutex
+}
+
+func newTLSCertificates(name string, baseConfig *tls.Config, certFile, keyFile, keyPassword, caChainCertFile string) (*tlsCertificates, error) {
+	certs := &tlsCertificates{
+		name:            name,
+		certFile:        certFile,
+		keyFile:         keyFile,
+		keyPassword:     keyPassword,
+		caChainCertFile: caChainCertFile,
+		baseConfig:      baseConfig,
+	}
+	if err := certs.load(); err != nil {
+		return nil, err
+	}
+	return certs, nil
+}
+
+func (l *tlsCertificates) load() error {
+	cfg := l.baseConfig.Clone()
+	cfg.GetCertificate = nil
+	cfg.GetConfigForClient = nil
+
+	var (
+		leaf *x509.Certificate
+		err  error
+	)
+	if l.keyFile != "" {
+		var cert tls.Certificate
+		if cert, leaf, err = loadX509KeyPair(l.certFile, l.keyFile, l.keyPassword); err != nil {
+			return err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	} else if l.certFile != "" {
+		if leaf, err = parseCertificate(l.certFile); err != nil {
+			return err
+		}
+	}
+
+	var caExpiry time.Time
+	if l.caChainCertFile != "" {
+		var pool *x509.CertPool
+		if pool, caExpiry, err = loadCertPool(l.caChainCertFile); err != nil {
+			return errors.Wrapf(err, "Failed to parse %s root certificate", l.name)
+		}
+		if l.name == tlsReloadListener {
+			cfg.ClientCAs = pool
+		} else {
+			cfg.RootCAs = pool
+		}
+	}
+
+	l.lock.Lock()
+	l.config = cfg
+	l.leaf = leaf
+	l.lock.Unlock()
+
+	if leaf != nil {
+		proxyTLSCertificateExpiry.WithLabelValues(l.name, tlsCertificateTypeCert).Set(float64(leaf.NotAfter.Unix()))
+	}
+	if l.caChainCertFile != "" {
+		proxyTLSCertificateExpiry.WithLabelValues(l.name, tlsCertificateTypeCA).Set(float64(caExpiry.Unix()))
+	}
+	return nil
+}
+
+// reload keeps the previous certificates if the files are invalid e.g. the key was not written yet
+func (l *tlsCertificates) reload() {
+	err := l.load()
+	recordTLSReload(l.name, err)
+	if err != nil {
+		logrus.Errorf("Reload of TLS certificates of the %s failed, previous certificates are kept: %v", l.name, err)
+		return
+	}
+	logrus.Infof("TLS certificates of the %s reloaded", l.name)
+}
+
+func (l *tlsCertificates) watch() error {
+	for _, filename := range []string{l.certFile, l.keyFile, l.caChainCertFile} {
+		if filename == "" {
+			continue
+		}
+		if err := util.WatchForUpdates(filename, make(chan bool, 1), l.reload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *tlsCertificates) getConfig() *tls.Config {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	return l.config
+}
+
+func (l *tlsCertificates) getLeaf() *x509.Certificate {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	return l.leaf
+}
+
+func (l *tlsCertificates) getConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	return l.getConfig(), nil
+}
+
+func (l *tlsCertificates) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return &l.getConfig().Certificates[0], nil
+}
+
+func recordTLSReload(name string, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	proxyTLSReloadsTotal.WithLabelValues(name, result).Inc()
+	proxyTLSLastReloadTimestamp.WithLabelValues(name).Set(float64(time.Now().Unix()))
+	if err != nil {
+		proxyTLSLastReloadSuccess.WithLabelValues(name).Set(0)
+	} else {
+		proxyTLSLastReloadSuccess.WithLabelValues(name).Set(1)
+	}
+}
+
+// loadX509KeyPair returns the certificate and its parsed leaf
+func loadX509KeyPair(certFile, keyFile, keyPassword string) (tls.Certificate, *x509.Certificate, error) {
+	certPEMBlock, err := ioutil.ReadFile(certFile)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	keyPEMBlock, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	keyPEMBlock, err = decryptPEM(keyPEMBlock, keyPassword)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	cert, err := tls.X509KeyPair(certPEMBlock, keyPEMBlock)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	return cert, leaf, nil
+}
+
+// loadCertPool returns the pool of the PEM certificates and the earliest expiry
+func loadCertPool(caChainCertFile string) (*x509.CertPool, time.Time, error) {
+	caCertPEMBlock, err := ioutil.ReadFile(caChainCertFile)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	pool := x509.NewCertPool()
+	var expiry time.Time
+	for rest := caCertPEMBlock; ; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		pool.AddCert(cert)
+		if expiry.IsZero() || cert.NotAfter.Before(expiry) {
+			expiry = cert.NotAfter
+		}
+	}
+	if expiry.IsZero() {
+		return nil, time.Time{}, errors.Errorf("no certificates found in %s", caChainCertFile)
+	}
+	return pool, expiry, nil
+}
@@ -0,0 +1,204 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/grepplabs/kafka-proxy/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListenerCertificatesReload(t *testing.T) {
+	a := assert.New(t)
+
+	bundle := NewCertsBundle()
+	defer bundle.Close()
+	rotated := NewCertsBundle()
+	defer rotated.Close()
+
+	c := new(config.Config)
+	c.Proxy.TLS.ListenerCertFile = bundle.ServerCert.Name()
+	c.Proxy.TLS.ListenerKeyFile = bundle.ServerKey.Name()
+	c.Proxy.TLS.CAChainCertFile = bundle.CACert.Name()
+
+	serverConfig, certs, err := newReloadableTLSListenerConfig(c)
+	a.Nil(err)
+
+	serverCert, err := listenerHandshake(serverConfig, bundle.ClientCert.Name(), bundle.ClientKey.Name())
+	a.Nil(err)
+	a.True(serverCert.Equal(mustParseCertificate(t, bundle.ServerCert.Name())))
+
+	copyFile(t, rotated.ServerCert.Name(), bundle.ServerCert.Name())
+	copyFile(t, rotated.ServerKey.Name(), bundle.ServerKey.Name())
+	copyFile(t, rotated.CACert.Name(), bundle.CACert.Name())
+	certs.reload()
+
+	serverCert, err = listenerHandshake(serverConfig, rotated.ClientCert.Name(), rotated.ClientKey.Name())
+	a.Nil(err)
+	a.True(serverCert.Equal(mustParseCertificate(t, rotated.ServerCert.Name())))
+	// client certificates signed by the previous CA are rejected
+	_, err = listenerHandshake(serverConfig, bundle.ClientCert.Name(), bundle.ClientKey.Name())
+	a.NotNil(err)
+
+	// invalid files keep the previous certificates
+	a.Nil(ioutil.WriteFile(bundle.ServerKey.Name(), []byte("invalid"), 0600))
+	certs.reload()
+	serverCert, err = listenerHandshake(serverConfig, rotated.ClientCert.Name(), rotated.ClientKey.Name())
+	a.Nil(err)
+	a.True(serverCert.Equal(mustParseCertificate(t, rotated.ServerCert.Name())))
+}
+
+func TestLoadCertPool(t *testing.T) {
+	a := assert.New(t)
+
+	bundle := NewCertsBundle()
+	defer bundle.Close()
+
+	pool, expiry, err := loadCertPool(bundle.CACert.Name())
+	a.Nil(err)
+	a.NotNil(pool)
+	a.Equal(mustParseCertificate(t, bundle.CACert.Name()).NotAfter, expiry)
+
+	_, _, err = loadCertPool(bundle.CAKey.Name())
+	a.EqualError(err, "no certificates found in "+bundle.CAKey.Name())
+}
+
+// listenerHandshake returns the certificate of the server
+func listenerHandshake(serverConfig *tls.Config, clientCertFile, clientKeyFile string) (*x509.Certificate, error) {
+	clientCert, err := tls.LoadX509KeyPair(clientCertFile, clientKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	defer listener.Close()
+	clientConn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		return nil, err
+	}
+	defer clientConn.Close()
+	serverConn, err := listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	defer serverConn.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	_ = clientConn.SetDeadline(deadline)
+	_ = serverConn.SetDeadline(deadline)
+
+	server := tls.Server(serverConn, serverConfig)
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.Handshake()
+		server.Close()
+	}()
+	client := tls.Client(clientConn, &tls.Config{InsecureSkipVerify: true, Certificates: []tls.Certificate{clientCert}})
+	if err = client.Handshake(); err != nil {
+		return nil, err
+	}
+	// TLS 1.3 client finishes the handshake before the server verifies the client certificate
+	if err = <-serverErr; err != nil {
+		return nil, err
+	}
+	return client.ConnectionState().PeerCertificates[0], nil
+}
+
+func mustParseCertificate(t *testing.T, certFile string) *x509.Certificate {
+	cert, err := parseCertificate(certFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func copyFile(t *testing.T, src, dst string) {
+	content, err := ioutil.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(dst, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClientCertificatesReload(t *testing.T) {
+	a := assert.New(t)
+
+	bundle := NewCertsBundle()
+	defer bundle.Close()
+	rotated := NewCertsBundle()
+	defer rotated.Close()
+
+	c := new(config.Config)
+	c.Kafka.TLS.Enable = true
+	c.Kafka.TLS.ClientCertFile = bundle.ClientCert.Name()
+	c.Kafka.TLS.ClientKeyFile = bundle.ClientKey.Name()
+	c.Kafka.TLS.CAChainCertFile = bundle.CACert.Name()
+
+	certs, err := newTLSClientCertificates(c)
+	a.Nil(err)
+	a.True(certs.getLeaf().Equal(mustParseCertificate(t, bundle.ClientCert.Name())))
+
+	// broker with the rotated certificates
+	serverCert, err := tls.LoadX509KeyPair(rotated.ServerCert.Name(), rotated.ServerKey.Name())
+	a.Nil(err)
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{serverCert}})
+	a.Nil(err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+	dialer := tlsDialer{timeout: 3 * time.Second, rawDialer: directDialer{dialTimeout: 3 * time.Second}, certs: certs}
+
+	_, err = dialer.Dial("tcp", listener.Addr().String())
+	a.NotNil(err)
+
+	copyFile(t, rotated.ClientCert.Name(), bundle.ClientCert.Name())
+	copyFile(t, rotated.ClientKey.Name(), bundle.ClientKey.Name())
+	copyFile(t, rotated.CACert.Name(), bundle.CACert.Name())
+	certs.reload()
+
+	a.True(certs.getLeaf().Equal(mustParseCertificate(t, rotated.ClientCert.Name())))
+	conn, err := dialer.Dial("tcp", listener.Addr().String())
+	a.Nil(err)
+	if conn != nil {
+		conn.Close()
+	}
+}
+
+func TestClientCertificatesWithoutKeyFile(t *testing.T) {
+	a := assert.New(t)
+
+	bundle := NewCertsBundle()
+	defer bundle.Close()
+
+	c := new(config.Config)
+	c.Kafka.TLS.ClientCertFile = bundle.ClientCert.Name()
+	certs, err := newTLSClientCertificates(c)
+	a.Nil(err)
+	a.Nil(certs.getLeaf())
+
+	// the certificate is compared with the certificate of the proxy client
+	c.Kafka.TLS.SameClientCertEnable = true
+	certs, err = newTLSClientCertificates(c)
+	a.Nil(err)
+	a.True(certs.getLeaf().Equal(mustParseCertificate(t, bundle.ClientCert.Name())))
+	a.Empty(certs.getConfig().Certificates)
+
+	a.Nil(ioutil.WriteFile(bundle.ClientCert.Name(), []byte("invalid"), 0600))
+	_, err = newTLSClientCertificates(c)
+	a.EqualError(err, "Failed to parse certificate file from location '"+bundle.ClientCert.Name()+"'")
+}
@@ -26,7 +26,7 @@ func TestDefaultCipherSuites(t *testing.T) {
 	c.Proxy.TLS.ListenerCertFile = bundle.ServerCert.Name()
 	c.Proxy.TLS.ListenerKeyFile = bundle.ServerKey.Name()
 
-	serverConfig, err := newTLSListenerConfig(c)
+	serverConfig, _, err := newReloadableTLSListenerConfig(c)
 	a.Nil(err)
 	// TLS_FALLBACK_SCSV is added as first
 	a.Equal(len(getPreferredDefaultCiphers())+1, len(serverConfig.CipherSuites))
@@ -45,7 +45,7 @@ func TestEnabledCipherSuites(t *testing.T) {
 	c.Proxy.TLS.ListenerCipherSuites = []string{"ECDHE-ECDSA-AES256-GCM-SHA384", "ECDHE-RSA-AES256-GCM-SHA384"}
 	c.Proxy.TLS.ListenerCurvePreferences = []string{"P521"}
 
-	serverConfig, err := newTLSListenerConfig(c)
+	serverConfig, _, err := newReloadableTLSListenerConfig(c)
 	a.Nil(err)
 	// TLS_FALLBACK_SCSV is added as first
 	a.Equal(3, len(serverConfig.CipherSuites))
@@ -0,0 +1,111 @@
+package proxy
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/grepplabs/kafka-proxy/config"
+	"github.com/grepplabs/kafka-proxy/proxy/protocol"
+)
+
+type topicFilterRule struct {
+	allow         bool
+	principal     string // empty matches all principals
+	brokerAddress string // broker address of the listener, empty matches all listeners
+	glob          string
+	regex         *regexp.Regexp
+}
+
+func (r topicFilterRule) appliesTo(principal, brokerAddress string) bool {
+	return (r.principal == "" || r.principal == principal) && (r.brokerAddress == "" || r.brokerAddress == brokerAddress)
+}
+
+func (r topicFilterRule) matches(topic string) bool {
+	if r.regex != nil {
+		return r.regex.MatchString(topic)
+	}
+	ok, _ := path.Match(r.glob, topic)
+	return ok
+}
+
+// MetadataTopicFilter removes topics from Metadata responses. A topic matching a deny rule is removed,
+// if any allow rule applies to the connection only topics matching an allow rule are kept.
+type MetadataTopicFilter struct {
+	rules []topicFilterRule
+}
+
+// NewMetadataTopicFilter creates the filter from the configured rules. It returns nil if no rule is configured.
+func NewMetadataTopicFilter(cfg *config.Config) (*MetadataTopicFilter, error) {
+	if len(cfg.MetadataTopicFilter.Rules) == 0 {
+		return nil, nil
+	}
+	rules := make([]topicFilterRule, 0, len(cfg.MetadataTopicFilter.Rules))
+	for _, v := range cfg.MetadataTopicFilter.Rules {
+		rule := topicFilterRule{allow: v.Allow, principal: v.Principal}
+		if v.Regex {
+			regex, err := regexp.Compile(v.Pattern)
+			if err != nil {
+				return nil, err
+			}
+			rule.regex = regex
+		} else {
+			rule.glob = v.Pattern
+		}
+		if v.Listener == "" {
+			rules = append(rules, rule)
+			continue
+		}
+		for _, servers := range [][]config.ListenerConfig{cfg.Proxy.BootstrapServers, cfg.Proxy.ExternalServers} {
+			for _, server := range servers {
+				if server.ListenerAddress == v.Listener {
+					rule.brokerAddress = server.BrokerAddress
+					rules = append(rules, rule)
+				}
+			}
+		}
+	}
+	return &MetadataTopicFilter{rules: rules}, nil
+}
+
+// topicFilterFunc returns the filter of the principal connected to the broker or nil if no rule applies.
+// The rules match the client visible names, in a namespace the prefix is stripped before the rules are evaluated
+// and the topics outside of the namespace are removed.
+func (f *MetadataTopicFilter) topicFilterFunc(principal, brokerAddress, namespacePrefix string) protocol.TopicFilterFunc {
+	var allowRules, denyRules []topicFilterRule
+	for _, rule := range f.rules {
+		if !rule.appliesTo(principal, brokerAddress) {
+			continue
+		}
+		if rule.allow {
+			allowRules = append(allowRules, rule)
+		} else {
+			denyRules = append(denyRules, rule)
+		}
+	}
+	if len(allowRules) == 0 && len(denyRules) == 0 {
+		return nil
+	}
+	return func(topic string) bool {
+		if namespacePrefix != "" {
+			if !strings.HasPrefix(topic, namespacePrefix) {
+				return false
+			}
+			topic = strings.TrimPrefix(topic, namespacePrefix)
+		}
+		for _, rule := range denyRules {
+			if rule.matches(topic) {
+				return false
+			}
+		}
+		if len(allowRules) == 0 {
+			return true
+		}
+		for _, rule := range allowRules {
+			if rule.matches(topic) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,55 @@
+package proxy
+
+import (
+	"testing"
+
+	"github.com/grepplabs/kafka-proxy/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadataTopicFilter(t *testing.T) {
+	a := assert.New(t)
+
+	cfg := config.NewConfig()
+	a.Nil(cfg.InitBootstrapServers([]string{"kafka-0:9092,0.0.0.0:32400", "kafka-1:9092,0.0.0.0:32401"}))
+	a.Nil(cfg.InitMetadataTopicFilter([]string{
+		"deny,*,__*",
+		"allow,principal:alice,re:^(orders|payments)-[a-z]+$",
+		"allow,listener:0.0.0.0:32401,public.*",
+	}))
+	a.Nil(cfg.Validate())
+
+	filter, err := NewMetadataTopicFilter(cfg)
+	a.Nil(err)
+
+	alice := filter.topicFilterFunc("alice", "kafka-0:9092", "")
+	a.True(alice("orders-eu"))
+	a.False(alice("orders-eu-1"))
+	a.False(alice("public.events"))
+	a.False(alice("__consumer_offsets"))
+
+	bob := filter.topicFilterFunc("bob", "kafka-0:9092", "")
+	a.True(bob("orders-eu"))
+	a.False(bob("__transaction_state"))
+
+	bobPublic := filter.topicFilterFunc("bob", "kafka-1:9092", "")
+	a.True(bobPublic("public.events"))
+	a.False(bobPublic("orders-eu"))
+}
+
+func TestMetadataTopicFilterInvalidRules(t *testing.T) {
+	tests := []struct {
+		rule string
+		err  string
+	}{
+		{rule: "allow,*", err: "metadata-topic-filter must be in form '<allow|deny>,<*|principal:name|listener:host:port>,<glob|re:regex>'"},
+		{rule: "permit,*,orders", err: "metadata-topic-filter permit,*,orders: permission must be allow or deny"},
+		{rule: "allow,user:alice,orders", err: "metadata-topic-filter allow,user:alice,orders: scope must be *, principal:name or listener:host:port"},
+		{rule: "allow,*,re:orders(", err: "metadata-topic-filter allow,*,re:orders(: error parsing regexp: missing closing ): `orders(`"},
+		{rule: "allow,*,orders[", err: "metadata-topic-filter allow,*,orders[: invalid glob pattern: syntax error in pattern"},
+	}
+	for _, tc := range tests {
+		cfg := config.NewConfig()
+		assert.EqualError(t, cfg.InitMetadataTopicFilter([]string{tc.rule}), tc.err)
+	}
+}
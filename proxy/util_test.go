@@ -88,14 +88,15 @@ func localPipe(listener net.Listener, dialer proxy.Dialer, timeout time.Duration
 func makeTLSPipe(conf *config.Config, expectedClientCert *x509.Certificate) (net.Conn, net.Conn, func(), error) {
 	stop := func() {}
 
-	serverConfig, err := newTLSListenerConfig(conf)
+	serverConfig, _, err := newReloadableTLSListenerConfig(conf)
 	if err != nil {
 		return nil, nil, stop, err
 	}
-	clientConfig, err := newTLSClientConfig(conf)
+	clientCertificates, err := newTLSClientCertificates(conf)
 	if err != nil {
 		return nil, nil, stop, err
 	}
+	clientConfig := clientCertificates.getConfig()
 	var clientCertToCheck *x509.Certificate = nil
 	if conf.Kafka.TLS.SameClientCertEnable {
 		clientCertToCheck = expectedClientCert
@@ -139,11 +140,12 @@ func makeTLSSocks5ProxyPipe(conf *config.Config, authenticator socks5.Authentica
 	if authenticator != nil {
 		socks5Conf.AuthMethods = []socks5.Authenticator{authenticator}
 	}
-	clientConfig, err := newTLSClientConfig(conf)
+	clientCertificates, err := newTLSClientCertificates(conf)
 	if err != nil {
 		return nil, nil, stop, err
 	}
-	serverConfig, err := newTLSListenerConfig(conf)
+	clientConfig := clientCertificates.getConfig()
+	serverConfig, _, err := newReloadableTLSListenerConfig(conf)
 	if err != nil {
 		return nil, nil, stop, err
 	}
@@ -212,11 +214,12 @@ func makeTLSHttpProxyPipe(conf *config.Config, proxyusername, proxypassword stri
 	if err != nil {
 		return nil, nil, stop, err
 	}
-	clientConfig, err := newTLSClientConfig(conf)
+	clientCertificates, err := newTLSClientCertificates(conf)
 	if err != nil {
 		return nil, nil, stop, err
 	}
-	serverConfig, err := newTLSListenerConfig(conf)
+	clientConfig := clientCertificates.getConfig()
+	serverConfig, _, err := newReloadableTLSListenerConfig(conf)
 	if err != nil {
 		return nil, nil, stop, err
 	}